@@ -1,6 +1,14 @@
 // Code generated by go-bindata.
 // sources:
 // 1640111208_dummy.up.sql
+// 1642666031_add_settings_last_updated.up.sql
+// 1642666032_add_fiat_display_decimals.up.sql
+// 1642666033_add_browser_trusted_origins.up.sql
+// 1642666034_profile_pictures_show_to_from_bool.up.sql
+// 1642666035_add_notifications_settings.up.sql
+// 1642666036_add_settings_resets.up.sql
+// 1642666037_add_message_archiving_settings.up.sql
+// 1642666038_add_push_notifications_server_url.up.sql
 // doc.go
 // DO NOT EDIT!
 
@@ -89,6 +97,166 @@ func _1640111208_dummyUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1642666031_add_settings_last_updatedUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x35\xcc\x41\x0a\xc2\x30\x10\x46\xe1\x7d\x4e\xf1\x2f\x15\xbc\x81\xab\xb1\x46\x3a\x18\x13\x99\x4e\xad\x5d\x85\x40\x4b\x11\x44\x84\x8c\xf7\x57\x44\xd7\xdf\xe3\x35\xe2\x49\x3d\x94\x76\xc1\x83\x0f\x88\x49\xe1\xaf\xdc\x69\x87\x3a\x9b\xdd\x1e\x4b\xcd\xf7\x52\x2d\xbf\x9e\x53\xb1\x79\xc2\xca\xe1\x2f\xb8\x90\x34\x2d\x09\xce\xc2\x27\x92\x11\x47\x3f\x6e\x3e\xfc\x4b\x73\x31\x70\xd4\xef\x32\xf6\x21\xb8\x35\x06\xd6\x36\xf5\x0a\x49\x03\xef\xb7\xee\x0d\xd2\x2d\x1e\x51\x7d\x00\x00\x00")
+
+func _1642666031_add_settings_last_updatedUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1642666031_add_settings_last_updatedUpSql,
+		"1642666031_add_settings_last_updated.up.sql",
+	)
+}
+
+func _1642666031_add_settings_last_updatedUpSql() (*asset, error) {
+	bytes, err := _1642666031_add_settings_last_updatedUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1642666031_add_settings_last_updated.up.sql", size: 125, mode: os.FileMode(436), modTime: time.Unix(1642666031, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1642666032_add_fiat_display_decimalsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x29\xc9\xcc\x4b\x2f\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xcb\x4c\x2c\x89\x4f\xc9\x2c\x2e\xc8\x49\xac\x8c\x4f\x49\x4d\xce\xcc\x4d\xcc\x29\x56\xf0\xf4\x0b\x51\xf0\xf3\x07\xe2\x50\x1f\x1f\x05\x17\x57\x37\xc7\x50\x9f\x10\x05\x23\x6b\x2e\x00\x2a\x6a\x5b\x68\x4e\x00\x00\x00")
+
+func _1642666032_add_fiat_display_decimalsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1642666032_add_fiat_display_decimalsUpSql,
+		"1642666032_add_fiat_display_decimals.up.sql",
+	)
+}
+
+func _1642666032_add_fiat_display_decimalsUpSql() (*asset, error) {
+	bytes, err := _1642666032_add_fiat_display_decimalsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1642666032_add_fiat_display_decimals.up.sql", size: 78, mode: os.FileMode(436), modTime: time.Unix(1642666032, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1642666033_add_browser_trusted_originsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x29\xc9\xcc\x4b\x2f\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\x2a\xca\x2f\x2f\x4e\x2d\x8a\x2f\x29\x2a\x2d\x2e\x49\x4d\x89\xcf\x2f\xca\x4c\xcf\xcc\x2b\x56\x70\xf2\xf1\x77\xb2\xe6\x02\x00\x3b\xbd\x99\x2f\x3e\x00\x00\x00")
+
+func _1642666033_add_browser_trusted_originsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1642666033_add_browser_trusted_originsUpSql,
+		"1642666033_add_browser_trusted_origins.up.sql",
+	)
+}
+
+func _1642666033_add_browser_trusted_originsUpSql() (*asset, error) {
+	bytes, err := _1642666033_add_browser_trusted_originsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1642666033_add_browser_trusted_origins.up.sql", size: 62, mode: os.FileMode(436), modTime: time.Unix(1642666033, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1642666034_profile_pictures_show_to_from_boolUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x74\x90\x3d\x4f\x33\x31\x10\x84\xfb\xfc\x8a\xe9\xde\x44\xba\x3b\xbd\x07\x1d\x88\x02\x89\x93\xa0\x09\x08\x02\x94\xd1\x72\xde\x4b\x2c\x1c\xef\xc9\xbb\x4e\xc8\xbf\x47\xce\x47\x83\x94\xda\xe3\xd9\x79\x9e\xba\xc6\x98\x64\xf0\x81\x97\xa3\xef\x2d\x27\xd6\xa5\xae\x65\xb7\x34\xc1\x8e\x14\x7d\x62\x32\x76\x20\x05\x45\x70\xcc\x1b\x38\x1e\x28\x07\xf3\x71\x05\x13\xf4\x12\x8d\x7a\x53\x48\x0c\xfb\x49\x5d\x63\xda\xce\x2a\xa8\xc0\xd6\x8c\x2d\x85\xcc\x0a\x1f\x91\x28\xae\x18\x94\x18\xdf\x3c\xda\x0d\x08\x6a\x92\xd8\xa1\x45\x4f\xf1\x9f\xe1\x8b\x61\x12\x1c\x68\xa4\x64\x18\x92\x6c\x40\xa5\x2e\xf0\x8a\xfa\x3d\x2c\x65\xae\x40\xd1\x21\x31\xb9\x72\xdb\x5b\x19\xc5\x5b\x4e\x7b\x89\x8c\x9d\xe4\xe0\xc0\x3f\xa3\x28\x1f\x6e\x9f\x71\x20\xc3\x31\x55\xda\xb2\x72\x42\xe0\xc1\x40\x76\x48\x9d\x60\x1a\x7c\x1c\xa7\x4a\x36\xf5\x8e\xcb\xa7\xf2\x5c\x80\x2b\xf8\x86\x1b\xd0\x79\xca\x40\x41\xb9\x94\x9d\x08\x48\xf1\xbf\x3a\xa0\xf5\x12\xb7\x9c\x8a\xae\xbf\x62\x9a\xc9\xfb\xcb\xc3\xfd\xa2\x83\xb2\x15\x73\x8a\xb7\x6e\x71\xd9\xfc\x1d\x5a\x7c\x3e\x76\xaf\xdd\xe5\xc8\xfc\x79\x81\xa7\x39\xa6\x6d\x85\xab\x0a\xd7\xb3\xdb\xc9\xef\x00\x17\x5f\xd9\xa9\xcb\x01\x00\x00")
+
+func _1642666034_profile_pictures_show_to_from_boolUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1642666034_profile_pictures_show_to_from_boolUpSql,
+		"1642666034_profile_pictures_show_to_from_bool.up.sql",
+	)
+}
+
+func _1642666034_profile_pictures_show_to_from_boolUpSql() (*asset, error) {
+	bytes, err := _1642666034_profile_pictures_show_to_from_boolUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1642666034_profile_pictures_show_to_from_bool.up.sql", size: 459, mode: os.FileMode(436), modTime: time.Unix(1642666034, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1642666035_add_notifications_settingsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x29\xc9\xcc\x4b\x2f\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\xc8\xcb\x2f\xc9\x4c\xcb\x4c\x4e\x2c\xc9\xcc\xcf\x2b\x8e\x87\xab\x70\xf2\xf1\x77\xb2\xe6\x02\x00\x8c\x4c\x08\xe3\x3d\x00\x00\x00")
+
+func _1642666035_add_notifications_settingsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1642666035_add_notifications_settingsUpSql,
+		"1642666035_add_notifications_settings.up.sql",
+	)
+}
+
+func _1642666035_add_notifications_settingsUpSql() (*asset, error) {
+	bytes, err := _1642666035_add_notifications_settingsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1642666035_add_notifications_settings.up.sql", size: 61, mode: os.FileMode(436), modTime: time.Unix(1642666035, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1642666036_add_settings_resetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6d\x8d\xc1\x0a\xc2\x30\x10\x44\xef\xf9\x8a\x39\x5a\xf0\x0f\x3c\xad\x61\xd5\x60\x9a\xca\x76\x2b\xf6\x54\x44\x83\xf4\xa2\xd0\xa4\xff\x6f\x0d\xe2\xc9\xdb\xc0\x9b\x99\x67\x85\x49\x19\x4a\x5b\xcf\x70\x3b\x84\x46\xc1\x17\xd7\x6a\x8b\x14\x73\x1e\x9f\x8f\x34\x4c\x71\x89\x09\x2b\x03\x8c\x77\xb8\xa0\xbc\x67\xc1\x49\x5c\x4d\xd2\xe3\xc8\x3d\xa8\xd3\xc6\x05\x2b\x5c\x73\xd0\xf5\xd2\xfb\x6e\x71\x26\xb1\x07\x92\x72\x1b\x3a\xef\x0b\x7b\xcd\xd3\x2d\xfe\x45\xc5\x34\x5c\xf3\x47\xf2\x03\xa6\xda\x98\x37\x6c\xc4\x51\x82\xa7\x00\x00\x00")
+
+func _1642666036_add_settings_resetsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1642666036_add_settings_resetsUpSql,
+		"1642666036_add_settings_resets.up.sql",
+	)
+}
+
+func _1642666036_add_settings_resetsUpSql() (*asset, error) {
+	bytes, err := _1642666036_add_settings_resetsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1642666036_add_settings_resets.up.sql", size: 167, mode: os.FileMode(436), modTime: time.Unix(1642666036, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1642666037_add_message_archiving_settingsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x85\xcc\x41\x0e\xc2\x20\x10\x00\xc0\xbb\xaf\xd8\x7f\x78\xda\x0a\x3d\xad\x90\x54\x38\x13\xac\x6b\x21\x51\xda\xb0\xab\x89\xbf\xb7\x1f\x30\x9e\x27\x19\xa4\x60\x27\x08\x38\x90\x05\x61\xd5\xda\x16\x01\x34\x06\x4e\x9e\xe2\xd9\xc1\x93\x45\xf2\xc2\x29\xf7\xb9\xd4\xf7\xae\x89\x5b\xbe\x3e\xf8\x06\x83\xf7\x64\xd1\x81\xf3\x01\x5c\x24\x02\x63\x47\x8c\x14\x20\x4c\xd1\x1e\x0f\xf8\x27\xbe\xb3\xce\x25\x95\x2a\xba\xf6\x4f\x5a\x5b\x12\xcd\x5d\x5f\xdb\xef\x77\x44\xba\xec\xf1\x17\xfa\x7c\x66\x78\xb2\x00\x00\x00")
+
+func _1642666037_add_message_archiving_settingsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1642666037_add_message_archiving_settingsUpSql,
+		"1642666037_add_message_archiving_settings.up.sql",
+	)
+}
+
+func _1642666037_add_message_archiving_settingsUpSql() (*asset, error) {
+	bytes, err := _1642666037_add_message_archiving_settingsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1642666037_add_message_archiving_settings.up.sql", size: 178, mode: os.FileMode(436), modTime: time.Unix(1642666037, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1642666038_add_push_notifications_server_urlUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x05\xc1\x41\x0e\x83\x20\x10\x05\xd0\xbd\xa7\xf8\xe1\x1a\xae\x46\xa1\x71\x31\x62\x42\xa0\x5b\x62\x1a\x6c\x49\x0c\x18\x06\x3d\x7f\xdf\x23\xf6\xc6\xc1\xd3\xc4\x06\x92\x7a\xcf\xe5\x2b\x20\xad\x31\x6f\x1c\x56\x8b\xeb\x96\x5f\x2c\xb5\xe7\x23\x7f\xf6\x9e\x6b\x91\x28\xa9\x3d\xa9\xc5\xbb\x9d\x78\x93\x9b\x17\x72\xb0\x9b\x87\x0d\xcc\xd0\xe6\x45\x81\x3d\x94\x1a\x87\x3f\x6f\xe5\x00\xaf\x5b\x00\x00\x00")
+
+func _1642666038_add_push_notifications_server_urlUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1642666038_add_push_notifications_server_urlUpSql,
+		"1642666038_add_push_notifications_server_url.up.sql",
+	)
+}
+
+func _1642666038_add_push_notifications_server_urlUpSql() (*asset, error) {
+	bytes, err := _1642666038_add_push_notifications_server_urlUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1642666038_add_push_notifications_server_url.up.sql", size: 91, mode: os.FileMode(436), modTime: time.Unix(1642666038, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _docGo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x2c\xc9\xb1\x0d\xc4\x20\x0c\x05\xd0\x9e\x29\xfe\x02\xd8\xfd\x6d\xe3\x4b\xac\x2f\x44\x82\x09\x78\x7f\xa5\x49\xfd\xa6\x1d\xdd\xe8\xd8\xcf\x55\x8a\x2a\xe3\x47\x1f\xbe\x2c\x1d\x8c\xfa\x6f\xe3\xb4\x34\xd4\xd9\x89\xbb\x71\x59\xb6\x18\x1b\x35\x20\xa2\x9f\x0a\x03\xa2\xe5\x0d\x00\x00\xff\xff\x60\xcd\x06\xbe\x4a\x00\x00\x00")
 
 func docGoBytes() ([]byte, error) {
@@ -162,6 +330,14 @@ func AssetNames() []string {
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
 	"1640111208_dummy.up.sql": _1640111208_dummyUpSql,
+	"1642666031_add_settings_last_updated.up.sql": _1642666031_add_settings_last_updatedUpSql,
+	"1642666032_add_fiat_display_decimals.up.sql": _1642666032_add_fiat_display_decimalsUpSql,
+	"1642666033_add_browser_trusted_origins.up.sql": _1642666033_add_browser_trusted_originsUpSql,
+	"1642666034_profile_pictures_show_to_from_bool.up.sql": _1642666034_profile_pictures_show_to_from_boolUpSql,
+	"1642666035_add_notifications_settings.up.sql": _1642666035_add_notifications_settingsUpSql,
+	"1642666036_add_settings_resets.up.sql": _1642666036_add_settings_resetsUpSql,
+	"1642666037_add_message_archiving_settings.up.sql": _1642666037_add_message_archiving_settingsUpSql,
+	"1642666038_add_push_notifications_server_url.up.sql": _1642666038_add_push_notifications_server_urlUpSql,
 	"doc.go": docGo,
 }
 
@@ -206,6 +382,14 @@ type bintree struct {
 }
 var _bintree = &bintree{nil, map[string]*bintree{
 	"1640111208_dummy.up.sql": &bintree{_1640111208_dummyUpSql, map[string]*bintree{}},
+	"1642666031_add_settings_last_updated.up.sql": &bintree{_1642666031_add_settings_last_updatedUpSql, map[string]*bintree{}},
+	"1642666032_add_fiat_display_decimals.up.sql": &bintree{_1642666032_add_fiat_display_decimalsUpSql, map[string]*bintree{}},
+	"1642666033_add_browser_trusted_origins.up.sql": &bintree{_1642666033_add_browser_trusted_originsUpSql, map[string]*bintree{}},
+	"1642666034_profile_pictures_show_to_from_bool.up.sql": &bintree{_1642666034_profile_pictures_show_to_from_boolUpSql, map[string]*bintree{}},
+	"1642666035_add_notifications_settings.up.sql": &bintree{_1642666035_add_notifications_settingsUpSql, map[string]*bintree{}},
+	"1642666036_add_settings_resets.up.sql": &bintree{_1642666036_add_settings_resetsUpSql, map[string]*bintree{}},
+	"1642666037_add_message_archiving_settings.up.sql": &bintree{_1642666037_add_message_archiving_settingsUpSql, map[string]*bintree{}},
+	"1642666038_add_push_notifications_server_url.up.sql": &bintree{_1642666038_add_push_notifications_server_urlUpSql, map[string]*bintree{}},
 	"doc.go": &bintree{docGo, map[string]*bintree{}},
 }}
 
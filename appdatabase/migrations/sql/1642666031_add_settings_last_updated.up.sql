@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS settings_last_updated (
+  setting VARCHAR PRIMARY KEY,
+  updated_at INT NOT NULL
+) WITHOUT ROWID;
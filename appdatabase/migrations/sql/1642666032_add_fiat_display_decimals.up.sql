@@ -0,0 +1 @@
+ALTER TABLE settings ADD COLUMN fiat_display_decimals INT NOT NULL DEFAULT 2;
@@ -0,0 +1 @@
+ALTER TABLE settings ADD COLUMN browser_trusted_origins BLOB;
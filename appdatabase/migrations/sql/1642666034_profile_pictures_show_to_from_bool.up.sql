@@ -0,0 +1,6 @@
+-- profile_pictures_show_to was created as an enum defaulting to contacts only
+-- (1), so the values in range are kept: a stored 1 can't be told apart from a
+-- legacy true, and reading it as everyone would expose the pictures of every
+-- user left at the default. Values outside of the enum, i.e. a legacy false
+-- stored as 0, are converted to contacts only.
+UPDATE settings SET profile_pictures_show_to = 1 WHERE profile_pictures_show_to NOT IN (1, 2, 3);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS settings_resets (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  setting VARCHAR NOT NULL,
+  source VARCHAR NOT NULL,
+  reset_at INT NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE settings ADD COLUMN message_archiving_enabled BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE settings ADD COLUMN fetch_history_on_startup BOOLEAN NOT NULL DEFAULT FALSE;
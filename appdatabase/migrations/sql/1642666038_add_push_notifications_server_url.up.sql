@@ -0,0 +1 @@
+ALTER TABLE settings ADD COLUMN push_notifications_server_url VARCHAR NOT NULL DEFAULT "";
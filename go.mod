@@ -10,6 +10,10 @@ replace github.com/docker/docker => github.com/docker/engine v1.4.2-0.2019071716
 
 replace github.com/nfnt/resize => github.com/status-im/resize v0.0.0-20201215164250-7c6d9f0d3088
 
+replace github.com/status-im/go-waku => ./third_party/go-waku
+
+replace github.com/status-im/go-waku-rendezvous => ./third_party/go-waku-rendezvous
+
 require (
 	github.com/beevik/ntp v0.2.0
 	github.com/cenkalti/backoff/v3 v3.2.2
@@ -21,6 +25,7 @@ require (
 	github.com/golang/mock v1.6.0
 	github.com/golang/protobuf v1.5.2
 	github.com/google/uuid v1.3.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/imdario/mergo v0.3.12
 	github.com/ipfs/go-cid v0.0.7
 	github.com/ipfs/go-ds-sql v0.2.0
@@ -69,6 +74,7 @@ require (
 	go.uber.org/zap v1.19.0
 	golang.org/x/crypto v0.0.0-20210813211128-0a44fdfbc16e
 	golang.org/x/image v0.0.0-20210220032944-ac19c3e999fb
+	golang.org/x/net v0.0.0-20210813160813-60bc85c4be6d
 	golang.org/x/tools v0.1.2 // indirect
 	google.golang.org/protobuf v1.27.1
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
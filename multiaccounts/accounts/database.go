@@ -5,6 +5,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/status-im/status-go/eth-node/types"
 	"github.com/status-im/status-go/nodecfg"
@@ -12,6 +16,13 @@ import (
 	"github.com/status-im/status-go/sqlite"
 )
 
+const (
+	// StickerPacksInstalled is the setting holding the ids of the installed sticker packs.
+	StickerPacksInstalled = "stickers/packs-installed"
+	// StickerPacksPending is the setting holding the ids of the sticker packs being installed.
+	StickerPacksPending = "stickers/packs-pending"
+)
+
 const (
 	uniqueChatConstraint   = "UNIQUE constraint failed: accounts.chat"
 	uniqueWalletConstraint = "UNIQUE constraint failed: accounts.wallet"
@@ -40,6 +51,10 @@ var (
 	ErrChatNotUnique = errors.New("another account is set to be default chat. disable it before using new")
 	// ErrInvalidConfig returned if config isn't allowed
 	ErrInvalidConfig = errors.New("configuration value not allowed")
+	// ErrSettingNotNullable returned when unsetting a setting that must always have a value
+	ErrSettingNotNullable = errors.New("setting can't be unset")
+	// ErrSettingNotResettable returned when resetting a setting without a default, immutable or sensitive
+	ErrSettingNotResettable = errors.New("setting can't be reset")
 )
 
 type Account struct {
@@ -139,18 +154,50 @@ type Settings struct {
 	GifFavorites                   *json.RawMessage              `json:"gifs/favorite-gifs"`
 	OpenseaEnabled                 bool                          `json:"opensea-enabled?,omitempty"`
 	TelemetryServerURL             string                        `json:"telemetry-server-url,omitempty"`
+	PushNotificationsServerURL     string                        `json:"push-notifications-server-url,omitempty"`
 	LastBackup                     uint64                        `json:"last-backup,omitempty"`
 	BackupEnabled                  bool                          `json:"backup-enabled?,omitempty"`
 	AutoMessageEnabled             bool                          `json:"auto-message-enabled?,omitempty"`
+	FiatDisplayDecimals            uint                          `json:"fiat-display-decimals"`
+	BrowserTrustedOrigins          *json.RawMessage              `json:"browser/trusted-origins,omitempty"`
+	NotificationsSettings          *json.RawMessage              `json:"notifications-settings,omitempty"`
+	MessageArchivingEnabled        bool                          `json:"message-archiving-enabled?"`
+	FetchHistoryOnStartup          bool                          `json:"fetch-history-on-startup?"`
+}
+
+// sharedSettings is the settings state shared by every Database of a connection,
+// so that writes are serialized and published whichever instance they go through.
+type sharedSettings struct {
+	notifier  *settingsNotifier
+	writeMu   *sync.Mutex
+	conflicts *settingConflicts
 }
 
+var (
+	sharedSettingsMu sync.Mutex
+	sharedSettingsOf = map[*sql.DB]*sharedSettings{}
+)
+
 func NewDB(db *sql.DB) *Database {
-	return &Database{db: db}
+	sharedSettingsMu.Lock()
+	defer sharedSettingsMu.Unlock()
+	shared, ok := sharedSettingsOf[db]
+	if !ok {
+		shared = &sharedSettings{notifier: &settingsNotifier{}, writeMu: &sync.Mutex{}, conflicts: &settingConflicts{}}
+		sharedSettingsOf[db] = shared
+	}
+	return &Database{db: db, notifier: shared.notifier, writeMu: shared.writeMu, conflicts: shared.conflicts}
 }
 
 // Database sql wrapper for operations with browser objects.
 type Database struct {
-	db *sql.DB
+	db       *sql.DB
+	notifier *settingsNotifier
+	// writeMu serializes settings writes, so that change notifications
+	// are published in the same order the writes are committed.
+	writeMu *sync.Mutex
+	// conflicts holds the rule violations accepted from backups.
+	conflicts *settingConflicts
 }
 
 // Get database
@@ -160,6 +207,9 @@ func (db Database) DB() *sql.DB {
 
 // Close closes database.
 func (db Database) Close() error {
+	sharedSettingsMu.Lock()
+	delete(sharedSettingsOf, db.db)
+	sharedSettingsMu.Unlock()
 	return db.db.Close()
 }
 
@@ -232,271 +282,404 @@ INSERT INTO settings (
 }
 
 func (db *Database) SaveSetting(setting string, value interface{}) error {
-	var (
-		update *sql.Stmt
-		err    error
-	)
+	return db.SaveSettings(map[string]interface{}{setting: value})
+}
 
-	switch setting {
-	case "chaos-mode?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
-		}
-		update, err = db.db.Prepare("UPDATE settings SET chaos_mode = ? WHERE synthetic_id = 'id'")
-	case "currency":
-		update, err = db.db.Prepare("UPDATE settings SET currency = ? WHERE synthetic_id = 'id'")
-	case "custom-bootnodes":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET custom_bootnodes = ? WHERE synthetic_id = 'id'")
-	case "custom-bootnodes-enabled?":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET custom_bootnodes_enabled = ? WHERE synthetic_id = 'id'")
-	case "dapps-address":
-		str, ok := value.(string)
-		if ok {
-			value = types.HexToAddress(str)
-		} else {
-			return ErrInvalidConfig
-		}
-		update, err = db.db.Prepare("UPDATE settings SET dapps_address = ? WHERE synthetic_id = 'id'")
-	case "eip1581-address":
-		str, ok := value.(string)
-		if ok {
-			value = types.HexToAddress(str)
-		} else {
-			return ErrInvalidConfig
-		}
-		update, err = db.db.Prepare("UPDATE settings SET eip1581_address = ? WHERE synthetic_id = 'id'")
-	case "fleet":
-		update, err = db.db.Prepare("UPDATE settings SET fleet = ? WHERE synthetic_id = 'id'")
-	case "hide-home-tooltip?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
-		}
-		update, err = db.db.Prepare("UPDATE settings SET hide_home_tooltip = ? WHERE synthetic_id = 'id'")
-	case "messages-from-contacts-only":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
-		}
-		update, err = db.db.Prepare("UPDATE settings SET messages_from_contacts_only = ? WHERE synthetic_id = 'id'")
-	case "keycard-instance_uid":
-		update, err = db.db.Prepare("UPDATE settings SET keycard_instance_uid = ? WHERE synthetic_id = 'id'")
-	case "keycard-paired_on":
-		update, err = db.db.Prepare("UPDATE settings SET keycard_paired_on = ? WHERE synthetic_id = 'id'")
-	case "keycard-pairing":
-		update, err = db.db.Prepare("UPDATE settings SET keycard_pairing = ? WHERE synthetic_id = 'id'")
-	case "last-updated":
-		update, err = db.db.Prepare("UPDATE settings SET last_updated = ? WHERE synthetic_id = 'id'")
-	case "latest-derived-path":
-		update, err = db.db.Prepare("UPDATE settings SET latest_derived_path = ? WHERE synthetic_id = 'id'")
-	case "link-preview-request-enabled":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
-		}
-		update, err = db.db.Prepare("UPDATE settings SET link_preview_request_enabled = ? WHERE synthetic_id = 'id'")
-	case "link-previews-enabled-sites":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET link_previews_enabled_sites = ? WHERE synthetic_id = 'id'")
-	case "log-level":
-		update, err = db.db.Prepare("UPDATE settings SET log_level = ? WHERE synthetic_id = 'id'")
-	case "mnemonic":
-		update, err = db.db.Prepare("UPDATE settings SET mnemonic = ? WHERE synthetic_id = 'id'")
-	case "name":
-		update, err = db.db.Prepare("UPDATE settings SET name = ? WHERE synthetic_id = 'id'")
-	case "networks/current-network":
-		update, err = db.db.Prepare("UPDATE settings SET current_network = ? WHERE synthetic_id = 'id'")
-	case "networks/networks":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET networks = ? WHERE synthetic_id = 'id'")
-	case "node-config":
-		var jsonString []byte
-		jsonString, err = json.Marshal(value)
+// SaveSettings stores multiple settings in a single transaction.
+// Settings are validated before anything is written.
+func (db *Database) SaveSettings(values map[string]interface{}) error {
+	return db.saveSettings(values, SettingSourceClient)
+}
+
+func (db *Database) saveSettings(values map[string]interface{}, source string) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+	return db.saveSettingsLocked(values, source)
+}
+
+// saveSettingsLocked must be called with writeMu held.
+func (db *Database) saveSettingsLocked(values map[string]interface{}, source string) error {
+	changes, err := db.commitSettings(values, source)
+	if err != nil {
+		return err
+	}
+	db.notifier.notify(changes)
+	return nil
+}
+
+// commitSettings writes the settings and returns the changes to publish.
+// It must be called with writeMu held, and the changes published before releasing it.
+func (db *Database) commitSettings(values map[string]interface{}, source string) ([]SettingChange, error) {
+	prepared := make(map[string]interface{}, len(values))
+	for setting, value := range values {
+		value, err := handleSetting(setting, value)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		var nodeConfig params.NodeConfig
-		err = json.Unmarshal(jsonString, &nodeConfig)
-		if err != nil {
-			return err
+		prepared[setting] = value
+	}
+
+	tx, err := db.db.BeginTx(context.Background(), &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts, err := checkSettingRules(tx, prepared)
+	if err == nil && len(conflicts) > 0 && !softConflicts(source) {
+		err = &conflicts[0]
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	changes := make([]SettingChange, 0, len(values))
+	for setting, value := range prepared {
+		err = saveSetting(tx, setting, value)
+		if err == nil {
+			_, err = tx.Exec("INSERT OR REPLACE INTO settings_last_updated (setting, updated_at) VALUES (?, ?)", setting, now)
 		}
-		if err = nodecfg.SaveNodeConfig(db.db, &nodeConfig); err != nil {
-			return err
+		if err == nil && source == SettingSourceReset {
+			_, err = tx.Exec("INSERT INTO settings_resets (setting, source, reset_at) VALUES (?, ?, ?)", setting, source, now)
 		}
-		value = nil
-		update, err = db.db.Prepare("UPDATE settings SET node_config = ? WHERE synthetic_id = 'id'")
-	case "notifications-enabled?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
 		}
-		update, err = db.db.Prepare("UPDATE settings SET notifications_enabled = ? WHERE synthetic_id = 'id'")
-	case "photo-path":
-		update, err = db.db.Prepare("UPDATE settings SET photo_path = ? WHERE synthetic_id = 'id'")
-	case "pinned-mailservers":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET pinned_mailservers = ? WHERE synthetic_id = 'id'")
-	case "preferred-name":
-		update, err = db.db.Prepare("UPDATE settings SET preferred_name = ? WHERE synthetic_id = 'id'")
-	case "preview-privacy?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		changes = append(changes, SettingChange{Setting: setting, Value: values[setting], Source: source})
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	db.recordConflicts(conflicts)
+	return changes, nil
+}
+
+// handleSetting validates the value and converts it to the form stored in the database.
+func handleSetting(setting string, value interface{}) (interface{}, error) {
+	if setting == "node-config" {
+		return decodeNodeConfig(value)
+	}
+
+	field, ok := settingFields[setting]
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	// A nil value unsets nullable fields instead of going through the handler,
+	// otherwise it would be stored as the zero value or rejected.
+	if field.Handler == nil || value == nil && field.Nullable {
+		return value, nil
+	}
+	return field.Handler(value)
+}
+
+// checkSettingRules runs the rules of every setting about to be written. Rules see
+// the values of other settings as they will be once the whole batch is applied.
+// Conflicts are returned separately from errors so the caller can decide whether to reject the write.
+func checkSettingRules(tx *sql.Tx, values map[string]interface{}) ([]SettingConflictError, error) {
+	current := func(setting string) (interface{}, error) {
+		if value, ok := values[setting]; ok {
+			return value, nil
 		}
-		update, err = db.db.Prepare("UPDATE settings SET preview_privacy = ? WHERE synthetic_id = 'id'")
-	case "public-key":
-		update, err = db.db.Prepare("UPDATE settings SET public_key = ? WHERE synthetic_id = 'id'")
-	case "remember-syncing-choice?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		return querySettingWithTx(tx, setting)
+	}
+	var conflicts []SettingConflictError
+	for setting, value := range values {
+		for _, rule := range settingRules[setting] {
+			err := rule(value, current)
+			var conflict *SettingConflictError
+			if errors.As(err, &conflict) {
+				conflicts = append(conflicts, *conflict)
+			} else if err != nil {
+				return nil, err
+			}
 		}
-		update, err = db.db.Prepare("UPDATE settings SET remember_syncing_choice = ? WHERE synthetic_id = 'id'")
-	case "remote-push-notifications-enabled?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Error() < conflicts[j].Error()
+	})
+	return conflicts, nil
+}
+
+func querySettingWithTx(tx *sql.Tx, setting string) (interface{}, error) {
+	if setting == "node-config" {
+		nodeConfig, err := nodecfg.GetNodeConfigWithTx(tx)
+		if err == sql.ErrNoRows {
+			return nil, nil
 		}
-		update, err = db.db.Prepare("UPDATE settings SET remote_push_notifications_enabled = ? WHERE synthetic_id = 'id'")
-	case "push-notifications-server-enabled?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		return nodeConfig, err
+	}
+	field, ok := settingFields[setting]
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+	value, err := readSetting(tx, field)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return value, err
+}
+
+// decodeNodeConfig accepts the node config as a struct or as a JSON object.
+func decodeNodeConfig(value interface{}) (*params.NodeConfig, error) {
+	jsonString, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var nodeConfig params.NodeConfig
+	err = json.Unmarshal(jsonString, &nodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &nodeConfig, nil
+}
+
+func saveSetting(tx *sql.Tx, setting string, value interface{}) error {
+	if setting == "node-config" {
+		if err := nodecfg.SaveConfigWithTx(tx, value.(*params.NodeConfig)); err != nil {
+			return err
 		}
-		update, err = db.db.Prepare("UPDATE settings SET push_notifications_server_enabled = ? WHERE synthetic_id = 'id'")
-	case "push-notifications-from-contacts-only?":
-		_, ok := value.(bool)
+		_, err := tx.Exec("UPDATE settings SET node_config = NULL WHERE synthetic_id = 'id'")
+		return err
+	}
+
+	_, err := tx.Exec("UPDATE settings SET "+settingFields[setting].Column+" = ? WHERE synthetic_id = 'id'", value)
+	return err
+}
+
+// UnsetSetting stores NULL for the given setting. Only nullable settings can be unset.
+func (db *Database) UnsetSetting(setting string) error {
+	field, ok := settingFields[setting]
+	if !ok {
+		return ErrInvalidConfig
+	}
+	if !field.Nullable {
+		return ErrSettingNotNullable
+	}
+	return db.SaveSetting(setting, nil)
+}
+
+// ResetSettings restores the given settings to their default value.
+// Nothing is written if any of the settings can't be reset.
+// Each setting restored is recorded, see SettingResets.
+func (db *Database) ResetSettings(settings []string) error {
+	errs := make(SettingsErrors)
+	values := make(map[string]interface{}, len(settings))
+	for _, setting := range settings {
+		field, ok := settingFields[setting]
 		if !ok {
-			return ErrInvalidConfig
+			errs[setting] = ErrInvalidConfig
+			continue
 		}
-		update, err = db.db.Prepare("UPDATE settings SET push_notifications_from_contacts_only = ? WHERE synthetic_id = 'id'")
-	case "push-notifications-block-mentions?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		if !field.resettable() {
+			errs[setting] = ErrSettingNotResettable
+			continue
 		}
-		update, err = db.db.Prepare("UPDATE settings SET push_notifications_block_mentions = ? WHERE synthetic_id = 'id'")
-	case "send-push-notifications?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		values[setting] = field.Default
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	return db.saveSettings(values, SettingSourceReset)
+}
+
+// ResetAllNonEssential restores every setting that has a default value.
+func (db *Database) ResetAllNonEssential() error {
+	var settings []string
+	for setting, field := range settingFields {
+		if field.resettable() {
+			settings = append(settings, setting)
 		}
-		update, err = db.db.Prepare("UPDATE settings SET send_push_notifications = ? WHERE synthetic_id = 'id'")
-	case "stickers/packs-installed":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET stickers_packs_installed = ? WHERE synthetic_id = 'id'")
-	case "stickers/packs-pending":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET stickers_packs_pending = ? WHERE synthetic_id = 'id'")
-	case "stickers/recent-stickers":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET stickers_recent_stickers = ? WHERE synthetic_id = 'id'")
-	case "syncing-on-mobile-network?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+	}
+	return db.ResetSettings(settings)
+}
+
+// SettingReset is an entry of the audit trail of the settings restored to their default.
+type SettingReset struct {
+	Setting string    `json:"setting"`
+	Source  string    `json:"source"`
+	ResetAt time.Time `json:"resetAt"`
+}
+
+// SettingResets returns every setting reset, oldest first.
+func (db *Database) SettingResets() ([]SettingReset, error) {
+	rows, err := db.db.Query("SELECT setting, source, reset_at FROM settings_resets ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resets []SettingReset
+	for rows.Next() {
+		var (
+			reset   SettingReset
+			resetAt int64
+		)
+		if err := rows.Scan(&reset.Setting, &reset.Source, &resetAt); err != nil {
+			return nil, err
 		}
-		update, err = db.db.Prepare("UPDATE settings SET syncing_on_mobile_network = ? WHERE synthetic_id = 'id'")
-	case "use-mailservers?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		reset.ResetAt = time.Unix(0, resetAt*int64(time.Millisecond))
+		resets = append(resets, reset)
+	}
+	return resets, rows.Err()
+}
+
+// BackupEligibleSettings returns the current value of every setting included in backups.
+func (db *Database) BackupEligibleSettings() (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for setting, field := range settingFields {
+		if !field.backedUp() {
+			continue
 		}
-		update, err = db.db.Prepare("UPDATE settings SET use_mailservers = ? WHERE synthetic_id = 'id'")
-	case "default-sync-period":
-		update, err = db.db.Prepare("UPDATE settings SET default_sync_period = ? WHERE synthetic_id = 'id'")
-	case "usernames":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET usernames = ? WHERE synthetic_id = 'id'")
-	case "wallet-set-up-passed?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		value, err := readSetting(db.db, field)
+		if err == sql.ErrNoRows {
+			return result, nil
 		}
-		update, err = db.db.Prepare("UPDATE settings SET wallet_set_up_passed = ? WHERE synthetic_id = 'id'")
-	case "wallet/visible-tokens":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET wallet_visible_tokens = ? WHERE synthetic_id = 'id'")
-	case "appearance":
-		update, err = db.db.Prepare("UPDATE settings SET appearance = ? WHERE synthetic_id = 'id'")
-	case "profile-pictures-show-to":
-		update, err = db.db.Prepare("UPDATE settings SET profile_pictures_show_to = ? WHERE synthetic_id = 'id'")
-	case "profile-pictures-visibility":
-		update, err = db.db.Prepare("UPDATE settings SET profile_pictures_visibility = ? WHERE synthetic_id = 'id'")
-	case "waku-bloom-filter-mode":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		if err != nil {
+			return nil, err
 		}
-		update, err = db.db.Prepare("UPDATE settings SET waku_bloom_filter_mode = ? WHERE synthetic_id = 'id'")
-	case "webview-allow-permission-requests?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		result[setting] = value
+	}
+	return result, nil
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// readSetting returns the value of a setting as its kind, or nil if it's unset.
+func readSetting(db queryRower, field SettingField) (interface{}, error) {
+	row := db.QueryRow("SELECT " + field.Column + " FROM settings WHERE synthetic_id = 'id'")
+	switch field.Kind {
+	case SettingKindBool:
+		var value sql.NullBool
+		if err := row.Scan(&value); err != nil || !value.Valid {
+			return nil, err
 		}
-		update, err = db.db.Prepare("UPDATE settings SET webview_allow_permission_requests = ? WHERE synthetic_id = 'id'")
-	case "anon-metrics/should-send?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		return value.Bool, nil
+	case SettingKindInt:
+		var value sql.NullInt64
+		if err := row.Scan(&value); err != nil || !value.Valid {
+			return nil, err
 		}
-		update, err = db.db.Prepare("UPDATE settings SET anon_metrics_should_send = ? WHERE synthetic_id = 'id'")
-	case "current-user-status":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET current_user_status = ? WHERE synthetic_id = 'id'")
-	case "send-status-updates?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		return value.Int64, nil
+	case SettingKindJSON:
+		var value interface{}
+		err := row.Scan(&sqlite.JSONBlob{Data: &value})
+		return value, err
+	case SettingKindAddress:
+		var value types.Address
+		err := row.Scan(&value)
+		return value, err
+	default:
+		var value sql.NullString
+		if err := row.Scan(&value); err != nil || !value.Valid {
+			return nil, err
 		}
-		update, err = db.db.Prepare("UPDATE settings SET send_status_updates = ? WHERE synthetic_id = 'id'")
-	case "gifs/recent-gifs":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET gif_recents = ? WHERE synthetic_id = 'id'")
-	case "gifs/favorite-gifs":
-		value = &sqlite.JSONBlob{Data: value}
-		update, err = db.db.Prepare("UPDATE settings SET gif_favorites = ? WHERE synthetic_id = 'id'")
-	case "opensea-enabled?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		return value.String, nil
+	}
+}
+
+// ApplyBackedUpSettings stores the settings of a backup taken at the given clock.
+// Settings not included in backups, or written locally after the clock, are skipped.
+func (db *Database) ApplyBackedUpSettings(values map[string]interface{}, clock uint64) error {
+	return db.applyRemoteSettings(values, clock, SettingSourceBackup)
+}
+
+// ApplySyncedSettings stores the settings synced from a paired device at the given clock.
+// Like backups, only the settings which aren't specific to a device are applied, unless
+// they were written locally after the clock.
+func (db *Database) ApplySyncedSettings(values map[string]interface{}, clock uint64) error {
+	return db.applyRemoteSettings(values, clock, SettingSourceSync)
+}
+
+func (db *Database) applyRemoteSettings(values map[string]interface{}, clock uint64, source string) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	toApply := make(map[string]interface{}, len(values))
+	for setting, value := range values {
+		field, ok := settingFields[setting]
+		if !ok || !field.backedUp() {
+			continue
 		}
-		update, err = db.db.Prepare("UPDATE settings SET opensea_enabled = ? WHERE synthetic_id = 'id'")
-	case "telemetry-server-url":
-		update, err = db.db.Prepare("UPDATE settings SET telemetry_server_url = ? WHERE synthetic_id = 'id'")
-	case "backup-enabled?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		lastUpdated, err := db.SettingLastUpdated(setting)
+		if err != nil {
+			return err
 		}
-		update, err = db.db.Prepare("UPDATE settings SET backup_enabled = ? WHERE synthetic_id = 'id'")
-	case "auto-message-enabled?":
-		_, ok := value.(bool)
-		if !ok {
-			return ErrInvalidConfig
+		if !lastUpdated.IsZero() && uint64(lastUpdated.UnixNano()/int64(time.Millisecond)) >= clock {
+			continue
 		}
+		toApply[setting] = value
+	}
+	if len(toApply) == 0 {
+		return nil
+	}
+	return db.saveSettingsLocked(toApply, source)
+}
 
-		update, err = db.db.Prepare("UPDATE settings SET auto_message_enabled = ? WHERE synthetic_id = 'id'")
-
-	default:
-		return ErrInvalidConfig
+// SettingLastUpdated returns when the setting was last written.
+// The zero time is returned if it was never written.
+func (db *Database) SettingLastUpdated(setting string) (time.Time, error) {
+	if _, ok := settingFields[setting]; !ok && setting != "node-config" {
+		return time.Time{}, ErrInvalidConfig
+	}
+	var updatedAt int64
+	err := db.db.QueryRow("SELECT updated_at FROM settings_last_updated WHERE setting = ?", setting).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
 	}
 	if err != nil {
-		return err
+		return time.Time{}, err
 	}
+	return time.Unix(0, updatedAt*int64(time.Millisecond)), nil
+}
 
-	_, err = update.Exec(value)
+func (db *Database) querySetting(setting string, dest interface{}) error {
+	field, ok := settingFields[setting]
+	if !ok {
+		return ErrInvalidConfig
+	}
+	err := db.db.QueryRow("SELECT " + field.Column + " FROM settings WHERE synthetic_id = 'id'").Scan(dest)
+	if err == sql.ErrNoRows {
+		return nil
+	}
 	return err
 }
 
+// GetNullableString returns the value of a setting and whether it has been set.
+func (db *Database) GetNullableString(setting string) (string, bool, error) {
+	var result sql.NullString
+	err := db.querySetting(setting, &result)
+	return result.String, result.Valid, err
+}
+
+// GetNullableBool returns the value of a setting and whether it has been set.
+func (db *Database) GetNullableBool(setting string) (bool, bool, error) {
+	var result sql.NullBool
+	err := db.querySetting(setting, &result)
+	return result.Bool, result.Valid, err
+}
+
+// GetNullableInt64 returns the value of a setting and whether it has been set.
+func (db *Database) GetNullableInt64(setting string) (int64, bool, error) {
+	var result sql.NullInt64
+	err := db.querySetting(setting, &result)
+	return result.Int64, result.Valid, err
+}
+
 func (db *Database) GetSettings() (Settings, error) {
-	var s Settings
-	err := db.db.QueryRow("SELECT address, anon_metrics_should_send, chaos_mode, currency, current_network, custom_bootnodes, custom_bootnodes_enabled, dapps_address, eip1581_address, fleet, hide_home_tooltip, installation_id, key_uid, keycard_instance_uid, keycard_paired_on, keycard_pairing, last_updated, latest_derived_path, link_preview_request_enabled, link_previews_enabled_sites, log_level, mnemonic, name, networks, notifications_enabled, push_notifications_server_enabled, push_notifications_from_contacts_only, remote_push_notifications_enabled, send_push_notifications, push_notifications_block_mentions, photo_path, pinned_mailservers, preferred_name, preview_privacy, public_key, remember_syncing_choice, signing_phrase, stickers_packs_installed, stickers_packs_pending, stickers_recent_stickers, syncing_on_mobile_network, default_sync_period, use_mailservers, messages_from_contacts_only, usernames, appearance, profile_pictures_show_to, profile_pictures_visibility, wallet_root_address, wallet_set_up_passed, wallet_visible_tokens, waku_bloom_filter_mode, webview_allow_permission_requests, current_user_status, send_status_updates, gif_recents, gif_favorites, opensea_enabled, last_backup, backup_enabled, telemetry_server_url, auto_message_enabled FROM settings WHERE synthetic_id = 'id'").Scan(
+	var (
+		s                     Settings
+		anonMetricsShouldSend sql.NullBool
+	)
+	err := db.db.QueryRow("SELECT address, anon_metrics_should_send, chaos_mode, currency, current_network, custom_bootnodes, custom_bootnodes_enabled, dapps_address, eip1581_address, fleet, hide_home_tooltip, installation_id, key_uid, keycard_instance_uid, keycard_paired_on, keycard_pairing, last_updated, latest_derived_path, link_preview_request_enabled, link_previews_enabled_sites, log_level, mnemonic, name, networks, notifications_enabled, push_notifications_server_enabled, push_notifications_from_contacts_only, remote_push_notifications_enabled, send_push_notifications, push_notifications_block_mentions, photo_path, pinned_mailservers, preferred_name, preview_privacy, public_key, remember_syncing_choice, signing_phrase, stickers_packs_installed, stickers_packs_pending, stickers_recent_stickers, syncing_on_mobile_network, default_sync_period, use_mailservers, messages_from_contacts_only, usernames, appearance, profile_pictures_show_to, profile_pictures_visibility, wallet_root_address, wallet_set_up_passed, wallet_visible_tokens, waku_bloom_filter_mode, webview_allow_permission_requests, current_user_status, send_status_updates, gif_recents, gif_favorites, opensea_enabled, last_backup, backup_enabled, telemetry_server_url, auto_message_enabled, fiat_display_decimals, browser_trusted_origins, notifications_settings, message_archiving_enabled, fetch_history_on_startup, push_notifications_server_url FROM settings WHERE synthetic_id = 'id'").Scan(
 		&s.Address,
-		&s.AnonMetricsShouldSend,
+		&anonMetricsShouldSend,
 		&s.ChaosMode,
 		&s.Currency,
 		&s.CurrentNetwork,
@@ -557,7 +740,14 @@ func (db *Database) GetSettings() (Settings, error) {
 		&s.BackupEnabled,
 		&s.TelemetryServerURL,
 		&s.AutoMessageEnabled,
+		&s.FiatDisplayDecimals,
+		&s.BrowserTrustedOrigins,
+		&s.NotificationsSettings,
+		&s.MessageArchivingEnabled,
+		&s.FetchHistoryOnStartup,
+		&s.PushNotificationsServerURL,
 	)
+	s.AnonMetricsShouldSend = anonMetricsShouldSend.Bool
 
 	return s, err
 }
@@ -678,6 +868,145 @@ func (db *Database) GetProfilePicturesVisibility() (int, error) {
 	return result, err
 }
 
+// GetTrustedOrigins returns the normalized origins trusted by the browser.
+func (db *Database) GetTrustedOrigins() ([]string, error) {
+	var origins []string
+	err := db.db.QueryRow("SELECT browser_trusted_origins FROM settings WHERE synthetic_id = 'id'").Scan(&sqlite.JSONBlob{Data: &origins})
+	if err == sql.ErrNoRows {
+		return origins, nil
+	}
+	return origins, err
+}
+
+// AddTrustedOrigin adds an origin to the ones trusted by the browser.
+func (db *Database) AddTrustedOrigin(origin string) error {
+	return db.updateTrustedOrigins(func(origins []string) ([]string, error) {
+		return append(origins, origin), nil
+	})
+}
+
+// RemoveTrustedOrigin removes an origin from the ones trusted by the browser.
+func (db *Database) RemoveTrustedOrigin(origin string) error {
+	normalized, err := OriginHandler(origin)
+	if err != nil {
+		return err
+	}
+	return db.updateTrustedOrigins(func(origins []string) ([]string, error) {
+		result := make([]string, 0, len(origins))
+		for _, o := range origins {
+			if o != normalized {
+				result = append(result, o)
+			}
+		}
+		return result, nil
+	})
+}
+
+func (db *Database) updateTrustedOrigins(update func([]string) ([]string, error)) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	origins, err := db.GetTrustedOrigins()
+	if err != nil {
+		return err
+	}
+	origins, err = update(origins)
+	if err != nil {
+		return err
+	}
+	normalized, err := trustedOriginsList(origins)
+	if err != nil {
+		return err
+	}
+	return db.saveSettingsLocked(map[string]interface{}{"browser/trusted-origins": normalized}, SettingSourceClient)
+}
+
+// GetStickerPacks returns the ids of the installed or pending sticker packs.
+func (db *Database) GetStickerPacks(setting string) ([]uint64, error) {
+	if setting != StickerPacksInstalled && setting != StickerPacksPending {
+		return nil, ErrInvalidConfig
+	}
+	var ids []uint64
+	err := db.db.QueryRow("SELECT " + settingFields[setting].Column + " FROM settings WHERE synthetic_id = 'id'").Scan(&sqlite.JSONBlob{Data: &ids})
+	if err == sql.ErrNoRows {
+		return ids, nil
+	}
+	return ids, err
+}
+
+// AddStickerPack adds a sticker pack to the installed or pending ones.
+func (db *Database) AddStickerPack(setting string, id uint64) error {
+	return db.MoveStickerPack(id, "", setting)
+}
+
+// RemoveStickerPack removes a sticker pack from the installed or pending ones.
+func (db *Database) RemoveStickerPack(setting string, id uint64) error {
+	return db.MoveStickerPack(id, setting, "")
+}
+
+// MoveStickerPack atomically removes a sticker pack from one of the sticker pack
+// settings and adds it to the other. Either of them can be empty.
+func (db *Database) MoveStickerPack(id uint64, from string, to string) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	values := make(map[string]interface{}, 2)
+	if from != "" {
+		ids, err := db.GetStickerPacks(from)
+		if err != nil {
+			return err
+		}
+		result := make([]uint64, 0, len(ids))
+		for _, i := range ids {
+			if i != id {
+				result = append(result, i)
+			}
+		}
+		values[from] = result
+	}
+	if to != "" {
+		if to == from {
+			return ErrInvalidConfig
+		}
+		ids, err := db.GetStickerPacks(to)
+		if err != nil {
+			return err
+		}
+		set, err := stickerPacksSet(append(ids, id))
+		if err != nil {
+			return err
+		}
+		values[to] = set
+	}
+	return db.saveSettingsLocked(values, SettingSourceClient)
+}
+
+// GetFiatDisplayDecimals returns how many decimals fiat amounts are displayed with.
+// Currencies without a minor unit are always displayed without decimals.
+func (db *Database) GetFiatDisplayDecimals() (uint, error) {
+	var (
+		result   uint
+		currency sql.NullString
+	)
+	err := db.db.QueryRow("SELECT fiat_display_decimals, currency FROM settings WHERE synthetic_id = 'id'").Scan(&result, &currency)
+	if err == sql.ErrNoRows {
+		return 2, nil
+	}
+	if zeroDecimalCurrencies[strings.ToLower(currency.String)] {
+		return 0, err
+	}
+	return result, err
+}
+
+func (db *Database) GetProfilePicturesShowTo() (ProfilePicturesShowToType, error) {
+	var result ProfilePicturesShowToType
+	err := db.db.QueryRow("SELECT profile_pictures_show_to FROM settings WHERE synthetic_id = 'id'").Scan(&result)
+	if err == sql.ErrNoRows {
+		return ProfilePicturesShowToContactsOnly, nil
+	}
+	return result, err
+}
+
 func (db *Database) GetPublicKey() (rst string, err error) {
 	err = db.db.QueryRow("SELECT public_key FROM settings WHERE synthetic_id = 'id'").Scan(&rst)
 	if err == sql.ErrNoRows {
@@ -835,11 +1164,15 @@ func (db *Database) LastBackup() (uint64, error) {
 }
 
 func (db *Database) SetLastBackup(time uint64) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
 	_, err := db.db.Exec("UPDATE settings SET last_backup = ?", time)
 	return err
 }
 
 func (db *Database) SetBackupFetched(fetched bool) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
 	_, err := db.db.Exec("UPDATE settings SET backup_fetched = ?", fetched)
 	return err
 }
@@ -3,15 +3,27 @@ package accounts
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	bindata "github.com/status-im/migrate/v4/source/go_bindata"
+
 	"github.com/status-im/status-go/appdatabase"
+	"github.com/status-im/status-go/appdatabase/migrations"
+	migrationsprevnodecfg "github.com/status-im/status-go/appdatabase/migrationsprevnodecfg"
 	"github.com/status-im/status-go/eth-node/types"
+	"github.com/status-im/status-go/nodecfg"
 	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/sqlite"
 )
 
 var (
@@ -40,6 +52,8 @@ var (
 		ProfilePicturesShowTo:     ProfilePicturesShowToContactsOnly,
 		ProfilePicturesVisibility: ProfilePicturesVisibilityContactsOnly,
 		DefaultSyncPeriod:         86400,
+		FiatDisplayDecimals:       2,
+		MessageArchivingEnabled:   true,
 		UseMailservers:            true,
 		LinkPreviewRequestEnabled: true,
 		SendStatusUpdates:         true,
@@ -80,6 +94,846 @@ func TestSaveSetting(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestNullableSettingRoundTrip(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	_, set, err := db.GetNullableString("fleet")
+	require.NoError(t, err)
+	require.False(t, set)
+
+	require.NoError(t, db.SaveSetting("fleet", ""))
+	fleet, set, err := db.GetNullableString("fleet")
+	require.NoError(t, err)
+	require.True(t, set)
+	require.Equal(t, "", fleet)
+
+	require.NoError(t, db.SaveSetting("anon-metrics/should-send?", false))
+	shouldSend, set, err := db.GetNullableBool("anon-metrics/should-send?")
+	require.NoError(t, err)
+	require.True(t, set)
+	require.False(t, shouldSend)
+
+	require.NoError(t, db.UnsetSetting("anon-metrics/should-send?"))
+	_, set, err = db.GetNullableBool("anon-metrics/should-send?")
+	require.NoError(t, err)
+	require.False(t, set)
+
+	// GetSettings must still work with unset values
+	_, err = db.GetSettings()
+	require.NoError(t, err)
+
+	require.NoError(t, db.SaveSetting("last-updated", 0))
+	lastUpdated, set, err := db.GetNullableInt64("last-updated")
+	require.NoError(t, err)
+	require.True(t, set)
+	require.Equal(t, int64(0), lastUpdated)
+}
+
+func TestUnsetNonNullableSetting(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.Equal(t, ErrSettingNotNullable, db.UnsetSetting("currency"))
+	require.Equal(t, ErrInvalidConfig, db.UnsetSetting("not-a-setting"))
+}
+
+func TestSaveSettingsPreservesUnset(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSetting("log-level", "INFO"))
+
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"currency":                  "eur",
+		"log-level":                 nil,
+		"anon-metrics/should-send?": nil,
+	}))
+
+	_, set, err := db.GetNullableString("log-level")
+	require.NoError(t, err)
+	require.False(t, set)
+
+	_, set, err = db.GetNullableBool("anon-metrics/should-send?")
+	require.NoError(t, err)
+	require.False(t, set)
+
+	currency, set, err := db.GetNullableString("currency")
+	require.NoError(t, err)
+	require.True(t, set)
+	require.Equal(t, "eur", currency)
+
+	// A nil value is still rejected for settings that can't be unset
+	require.Equal(t, ErrInvalidConfig, db.SaveSettings(map[string]interface{}{
+		"currency":    "usd",
+		"chaos-mode?": nil,
+	}))
+	currency, _, err = db.GetNullableString("currency")
+	require.NoError(t, err)
+	require.Equal(t, "eur", currency)
+}
+
+func TestResetSettings(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSetting("currency", "eur"))
+	require.NoError(t, db.SaveSetting("use-mailservers?", false))
+
+	changes, unsubscribe := db.SubscribeToSettingChanges()
+	defer unsubscribe()
+	require.NoError(t, db.ResetSettings([]string{"currency", "use-mailservers?"}))
+
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, "usd", s.Currency)
+	require.True(t, s.UseMailservers)
+
+	reset := map[string]interface{}{}
+	for i := 0; i < 2; i++ {
+		change := <-changes
+		require.Equal(t, SettingSourceReset, change.Source)
+		reset[change.Setting] = change.Value
+	}
+	require.Equal(t, map[string]interface{}{"currency": "usd", "use-mailservers?": true}, reset)
+}
+
+func TestResetSettingsAuditTrail(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSetting("currency", "eur"))
+
+	// Regular writes aren't recorded
+	resets, err := db.SettingResets()
+	require.NoError(t, err)
+	require.Empty(t, resets)
+
+	before := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, db.ResetSettings([]string{"currency", "use-mailservers?"}))
+	require.Error(t, db.ResetSettings([]string{"fleet", "mnemonic"}))
+
+	resets, err = db.SettingResets()
+	require.NoError(t, err)
+	require.Len(t, resets, 2)
+	var reset []string
+	for _, r := range resets {
+		reset = append(reset, r.Setting)
+		require.Equal(t, SettingSourceReset, r.Source)
+		require.False(t, r.ResetAt.Before(before))
+	}
+	require.ElementsMatch(t, []string{"currency", "use-mailservers?"}, reset)
+
+	// The trail is persisted, and kept once settings are reset again
+	require.NoError(t, NewDB(db.DB()).ResetAllNonEssential())
+	resets, err = db.SettingResets()
+	require.NoError(t, err)
+	require.Greater(t, len(resets), 2)
+	require.Contains(t, reset, resets[0].Setting)
+	require.False(t, resets[len(resets)-1].ResetAt.Before(resets[0].ResetAt))
+}
+
+func TestResetSettingsRejectsProtected(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSetting("currency", "eur"))
+
+	err := db.ResetSettings([]string{"currency", "mnemonic", "public-key", "not-a-setting"})
+	require.Equal(t, SettingsErrors{
+		"mnemonic":      ErrSettingNotResettable,
+		"public-key":    ErrSettingNotResettable,
+		"not-a-setting": ErrInvalidConfig,
+	}, err)
+
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, "eur", s.Currency)
+}
+
+func TestResetAllNonEssential(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSetting("currency", "eur"))
+	require.NoError(t, db.SaveSetting("mnemonic", "yurt joey vibe"))
+	require.NoError(t, db.ResetAllNonEssential())
+
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, "usd", s.Currency)
+	require.Equal(t, "yurt joey vibe", *s.Mnemonic)
+	require.Equal(t, settings.PublicKey, s.PublicKey)
+}
+
+func TestSettingLastUpdated(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	lastUpdated, err := db.SettingLastUpdated("currency")
+	require.NoError(t, err)
+	require.True(t, lastUpdated.IsZero())
+
+	before := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, db.SaveSettings(map[string]interface{}{"currency": "eur", "fleet": "eth.prod"}))
+
+	for _, setting := range []string{"currency", "fleet"} {
+		lastUpdated, err = db.SettingLastUpdated(setting)
+		require.NoError(t, err)
+		require.False(t, lastUpdated.Before(before))
+	}
+
+	_, err = db.SettingLastUpdated("not-a-setting")
+	require.Equal(t, ErrInvalidConfig, err)
+}
+
+func TestConcurrentSaveSettingNotificationOrder(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	changes, unsubscribe := db.SubscribeToSettingChanges()
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			require.NoError(t, db.SaveSetting("currency", fmt.Sprintf("c%d", i)))
+		}(i)
+	}
+	wg.Wait()
+
+	var last SettingChange
+	for i := 0; i < 20; i++ {
+		last = <-changes
+	}
+
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, s.Currency, last.Value)
+}
+
+func TestSettingChangesSlowSubscriber(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	timeout := settingChangeTimeout
+	settingChangeTimeout = 50 * time.Millisecond
+	defer func() { settingChangeTimeout = timeout }()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	slow, unsubscribeSlow := db.SubscribeToSettingChanges()
+	fast, unsubscribeFast := db.SubscribeToSettingChanges()
+	defer unsubscribeFast()
+
+	received := make(chan []interface{})
+	go func() {
+		var values []interface{}
+		for change := range fast {
+			values = append(values, change.Value)
+		}
+		received <- values
+	}()
+
+	// The slow subscriber never reads, so the last change overflows its buffer
+	var expected []interface{}
+	for i := 0; i <= settingChangeBuffer; i++ {
+		currency := fmt.Sprintf("c%d", i)
+		expected = append(expected, currency)
+		require.NoError(t, db.SaveSetting("currency", currency))
+	}
+	require.Equal(t, uint64(1), db.DroppedSettingChanges())
+
+	unsubscribeFast()
+	require.Equal(t, expected, <-received)
+
+	unsubscribeSlow()
+	unsubscribeSlow()
+	var buffered []interface{}
+	for change := range slow {
+		buffered = append(buffered, change.Value)
+	}
+	require.Equal(t, expected[:settingChangeBuffer], buffered)
+
+	// Nobody is subscribed anymore
+	require.NoError(t, db.SaveSetting("currency", "eur"))
+	require.Equal(t, uint64(1), db.DroppedSettingChanges())
+}
+
+func TestSettingChangesSharedByConnection(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	changes, unsubscribe := db.SubscribeToSettingChanges()
+	defer unsubscribe()
+
+	require.NoError(t, NewDB(db.DB()).SaveSetting("currency", "eur"))
+	change := <-changes
+	require.Equal(t, "currency", change.Setting)
+	require.Equal(t, "eur", change.Value)
+}
+
+func TestFiatDisplayDecimals(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	decimals, err := db.GetFiatDisplayDecimals()
+	require.NoError(t, err)
+	require.Equal(t, uint(2), decimals)
+
+	// Values coming from JSON are decoded as float64
+	require.NoError(t, db.SaveSetting("fiat-display-decimals", float64(4)))
+	decimals, err = db.GetFiatDisplayDecimals()
+	require.NoError(t, err)
+	require.Equal(t, uint(4), decimals)
+
+	require.Equal(t, ErrValueOutOfRange, db.SaveSetting("fiat-display-decimals", 5))
+	require.Equal(t, ErrInvalidConfig, db.SaveSetting("fiat-display-decimals", 1.5))
+
+	// Currencies without minor unit can't be selected while decimals are displayed
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"currency":              "eur",
+		"fiat-display-decimals": 2,
+	}))
+	for _, currency := range []string{"jpy", "KRW"} {
+		err = db.SaveSetting("currency", currency)
+		require.IsType(t, &SettingConflictError{}, err)
+		require.Contains(t, err.Error(), "fiat-display-decimals")
+	}
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, "eur", s.Currency)
+
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"currency":              "JPY",
+		"fiat-display-decimals": 0,
+	}))
+	decimals, err = db.GetFiatDisplayDecimals()
+	require.NoError(t, err)
+	require.Equal(t, uint(0), decimals)
+
+	err = db.SaveSetting("fiat-display-decimals", 2)
+	require.IsType(t, &SettingConflictError{}, err)
+	require.Contains(t, err.Error(), "currency")
+	require.NoError(t, db.SaveSetting("currency", "krw"))
+
+	// No decimals is a value of its own and is kept in the JSON
+	s, err = db.GetSettings()
+	require.NoError(t, err)
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"fiat-display-decimals":0`)
+
+	// Both settings can be changed together
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"currency":              "eur",
+		"fiat-display-decimals": 3,
+	}))
+	decimals, err = db.GetFiatDisplayDecimals()
+	require.NoError(t, err)
+	require.Equal(t, uint(3), decimals)
+}
+
+func TestTrustedOrigins(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	origins, err := db.GetTrustedOrigins()
+	require.NoError(t, err)
+	require.Empty(t, origins)
+
+	require.NoError(t, db.AddTrustedOrigin("https://Status.im/"))
+	require.NoError(t, db.AddTrustedOrigin("https://status.im"))
+	require.NoError(t, db.AddTrustedOrigin("wss://relay.status.im"))
+	require.Equal(t, ErrInvalidOrigin, db.AddTrustedOrigin("http://status.im"))
+
+	origins, err = db.GetTrustedOrigins()
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://status.im", "wss://relay.status.im"}, origins)
+
+	require.NoError(t, db.RemoveTrustedOrigin("HTTPS://status.im/"))
+	origins, err = db.GetTrustedOrigins()
+	require.NoError(t, err)
+	require.Equal(t, []string{"wss://relay.status.im"}, origins)
+
+	// Whole list writes are validated too
+	require.Equal(t, ErrInvalidOrigin, db.SaveSetting("browser/trusted-origins", []interface{}{"https://status.im/path"}))
+	require.NoError(t, db.SaveSetting("browser/trusted-origins", []interface{}{"https://a.com/", "https://A.com"}))
+	origins, err = db.GetTrustedOrigins()
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://a.com"}, origins)
+}
+
+func TestProfilePicturesShowTo(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	require.NoError(t, db.SaveSetting("profile-pictures-show-to", ProfilePicturesShowToNone))
+	showTo, err := db.GetProfilePicturesShowTo()
+	require.NoError(t, err)
+	require.Equal(t, ProfilePicturesShowToNone, showTo)
+
+	require.Equal(t, ErrValueOutOfRange, db.SaveSetting("profile-pictures-show-to", 4))
+	require.Equal(t, ErrInvalidConfig, db.SaveSetting("profile-pictures-show-to", "everyone"))
+}
+
+func TestProfilePicturesShowToLegacyBool(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	// Older clients send a boolean meaning "show to everyone"
+	require.NoError(t, db.SaveSetting("profile-pictures-show-to", true))
+	showTo, err := db.GetProfilePicturesShowTo()
+	require.NoError(t, err)
+	require.Equal(t, ProfilePicturesShowToEveryone, showTo)
+
+	require.NoError(t, db.SaveSettings(map[string]interface{}{"profile-pictures-show-to": false}))
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, ProfilePicturesShowToContactsOnly, s.ProfilePicturesShowTo)
+}
+
+// setupLegacyShowToDB returns a database with the settings of an older client,
+// which stored showTo in profile_pictures_show_to, and the function applying
+// the remaining migrations.
+func setupLegacyShowToDB(t *testing.T, showTo interface{}) (*Database, func() error, func()) {
+	tmpfile, err := ioutil.TempFile("", "settings-tests-")
+	require.NoError(t, err)
+	db, err := sqlite.OpenDB(tmpfile.Name(), "settings-tests")
+	require.NoError(t, err)
+	stop := func() {
+		require.NoError(t, db.Close())
+		require.NoError(t, os.Remove(tmpfile.Name()))
+	}
+
+	require.NoError(t, migrationsprevnodecfg.Migrate(db))
+	require.NoError(t, nodecfg.MigrateNodeConfig(db))
+
+	var names []string
+	for _, name := range migrations.AssetNames() {
+		if name < "1642666034_profile_pictures_show_to_from_bool.up.sql" {
+			names = append(names, name)
+		}
+	}
+	require.NoError(t, sqlite.Migrate(db, bindata.Resource(names, migrations.Asset)))
+
+	accounts := NewDB(db)
+	require.NoError(t, accounts.CreateSettings(settings, config))
+	_, err = db.Exec("UPDATE settings SET profile_pictures_show_to = ? WHERE synthetic_id = 'id'", showTo)
+	require.NoError(t, err)
+
+	return accounts, func() error { return migrations.Migrate(db) }, stop
+}
+
+func TestProfilePicturesShowToMigration(t *testing.T) {
+	cases := []struct {
+		name     string
+		stored   interface{}
+		expected ProfilePicturesShowToType
+	}{
+		{"contacts only", int64(ProfilePicturesShowToContactsOnly), ProfilePicturesShowToContactsOnly},
+		// A legacy true is stored as 1 and reads as the contacts only default
+		{"legacy true", true, ProfilePicturesShowToContactsOnly},
+		{"legacy false", false, ProfilePicturesShowToContactsOnly},
+		{"everyone", int64(ProfilePicturesShowToEveryone), ProfilePicturesShowToEveryone},
+		{"nobody", int64(ProfilePicturesShowToNone), ProfilePicturesShowToNone},
+		{"invalid", 7, ProfilePicturesShowToContactsOnly},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, migrate, stop := setupLegacyShowToDB(t, c.stored)
+			defer stop()
+
+			require.NoError(t, migrate())
+			showTo, err := db.GetProfilePicturesShowTo()
+			require.NoError(t, err)
+			require.Equal(t, c.expected, showTo)
+		})
+	}
+}
+
+func TestProfilePicturesShowToLegacyPayload(t *testing.T) {
+	db, migrate, stop := setupLegacyShowToDB(t, false)
+	defer stop()
+
+	require.NoError(t, migrate())
+	showTo, err := db.GetProfilePicturesShowTo()
+	require.NoError(t, err)
+	require.Equal(t, ProfilePicturesShowToContactsOnly, showTo)
+
+	// A payload sent by an older client carries the boolean
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"profile-pictures-show-to": true}`), &payload))
+	require.NoError(t, db.SaveSettings(payload))
+
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, ProfilePicturesShowToEveryone, s.ProfilePicturesShowTo)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"profile-pictures-show-to": false}`), &payload))
+	require.NoError(t, db.SaveSettings(payload))
+	showTo, err = db.GetProfilePicturesShowTo()
+	require.NoError(t, err)
+	require.Equal(t, ProfilePicturesShowToContactsOnly, showTo)
+}
+
+func TestEverySettingHasBackupDecision(t *testing.T) {
+	for setting, field := range settingFields {
+		require.Contains(t, []BackupPolicy{BackupInclude, BackupExclude}, field.Backup, setting)
+	}
+}
+
+func TestBackupEligibleSettings(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSetting("currency", "eur"))
+	require.NoError(t, db.SaveSetting("use-mailservers?", false))
+	require.NoError(t, db.AddTrustedOrigin("https://status.im"))
+	require.NoError(t, db.SaveSetting("mnemonic", "yurt joey vibe"))
+
+	backup, err := db.BackupEligibleSettings()
+	require.NoError(t, err)
+
+	for setting := range backup {
+		require.True(t, settingFields[setting].backedUp(), setting)
+	}
+	require.NotContains(t, backup, "mnemonic")
+	require.NotContains(t, backup, "public-key")
+	require.Equal(t, "eur", backup["currency"])
+	require.Equal(t, false, backup["use-mailservers?"])
+
+	restored, stopRestored := setupTestDB(t)
+	defer stopRestored()
+
+	require.NoError(t, restored.CreateSettings(settings, config))
+	clock := uint64(time.Now().UnixNano()/int64(time.Millisecond)) + 1
+	require.NoError(t, restored.ApplyBackedUpSettings(backup, clock))
+
+	s, err := restored.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, "eur", s.Currency)
+	require.False(t, s.UseMailservers)
+	origins, err := restored.GetTrustedOrigins()
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://status.im"}, origins)
+}
+
+func TestWakuLightClientRequiresStoreNode(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	lightClient := config
+	lightClient.WakuV2Config.Enabled = true
+	lightClient.WakuV2Config.LightClient = true
+
+	err := db.SaveSetting("node-config", lightClient)
+	conflict := &SettingConflictError{}
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, "node-config", conflict.Setting)
+	require.Equal(t, "fleet", conflict.Other)
+
+	// Selecting a fleet in the same batch resolves the conflict
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"node-config": lightClient,
+		"fleet":       "eth.prod",
+	}))
+
+	// The fleet can't be cleared while the light client has no store node
+	err = db.SaveSetting("fleet", nil)
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, "fleet", conflict.Setting)
+	require.Equal(t, "node-config", conflict.Other)
+
+	err = db.SaveSettings(map[string]interface{}{"fleet": ""})
+	require.True(t, errors.As(err, &conflict))
+
+	lightClient.ClusterConfig.StoreNodes = []string{"/ip4/127.0.0.1/tcp/60000/p2p/16Uiu2HAmPLe7Mzm8TsYUubgCAW1aJoeFScxrLj8ppHFivPo97bUZ"}
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"node-config": lightClient,
+		"fleet":       nil,
+	}))
+	require.Empty(t, db.SettingConflicts())
+}
+
+func TestFetchHistoryOnStartupRequiresArchiving(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.True(t, s.MessageArchivingEnabled)
+	require.False(t, s.FetchHistoryOnStartup)
+
+	require.NoError(t, db.SaveSetting("fetch-history-on-startup?", true))
+
+	// Archiving can't be disabled while the history is fetched on startup
+	err = db.SaveSetting("message-archiving-enabled?", false)
+	conflict := &SettingConflictError{}
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, "message-archiving-enabled?", conflict.Setting)
+	require.Equal(t, "fetch-history-on-startup?", conflict.Other)
+
+	err = db.SaveSettings(map[string]interface{}{"message-archiving-enabled?": false, "currency": "eur"})
+	require.True(t, errors.As(err, &conflict))
+	s, err = db.GetSettings()
+	require.NoError(t, err)
+	require.True(t, s.MessageArchivingEnabled)
+	require.Equal(t, settings.Currency, s.Currency)
+
+	// Both can be disabled together
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"message-archiving-enabled?": false,
+		"fetch-history-on-startup?":  false,
+	}))
+
+	// And the history can't be fetched on startup without archiving
+	err = db.SaveSetting("fetch-history-on-startup?", true)
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, "fetch-history-on-startup?", conflict.Setting)
+	require.Equal(t, "message-archiving-enabled?", conflict.Other)
+
+	err = db.SaveSettings(map[string]interface{}{"fetch-history-on-startup?": true})
+	require.True(t, errors.As(err, &conflict))
+
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"message-archiving-enabled?": true,
+		"fetch-history-on-startup?":  true,
+	}))
+	require.Empty(t, db.SettingConflicts())
+}
+
+func TestApplySyncedSettingsRecordsConflicts(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSetting("fetch-history-on-startup?", true))
+
+	changes, unsubscribe := db.SubscribeToSettingChanges()
+	defer unsubscribe()
+
+	// The paired device disabled archiving, which is applied despite the conflict
+	clock := uint64(time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond))
+	require.NoError(t, db.ApplySyncedSettings(map[string]interface{}{
+		"message-archiving-enabled?": false,
+		"chaos-mode?":                true,
+	}, clock))
+
+	change := <-changes
+	require.Equal(t, SettingChange{Setting: "message-archiving-enabled?", Value: false, Source: SettingSourceSync}, change)
+
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.False(t, s.MessageArchivingEnabled)
+	require.True(t, s.FetchHistoryOnStartup)
+	require.False(t, s.ChaosMode)
+
+	conflicts := db.SettingConflicts()
+	require.Len(t, conflicts, 1)
+	require.Equal(t, "message-archiving-enabled?", conflicts[0].Setting)
+	require.Equal(t, "fetch-history-on-startup?", conflicts[0].Other)
+
+	// Older syncs are skipped
+	require.NoError(t, db.ApplySyncedSettings(map[string]interface{}{"message-archiving-enabled?": true}, 1))
+	s, err = db.GetSettings()
+	require.NoError(t, err)
+	require.False(t, s.MessageArchivingEnabled)
+}
+
+func TestApplyBackedUpSettingsRecordsConflicts(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSettings(map[string]interface{}{"currency": "jpy", "fiat-display-decimals": 0}))
+
+	// The same value is rejected when saved by the client
+	require.IsType(t, &SettingConflictError{}, db.SaveSetting("fiat-display-decimals", 2))
+
+	clock := uint64(time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond))
+	require.NoError(t, db.ApplyBackedUpSettings(map[string]interface{}{"fiat-display-decimals": 2}, clock))
+
+	decimals, err := db.GetFiatDisplayDecimals()
+	require.NoError(t, err)
+	require.Equal(t, uint(0), decimals)
+	stored, _, err := db.GetNullableInt64("fiat-display-decimals")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stored)
+
+	conflicts := db.SettingConflicts()
+	require.Len(t, conflicts, 1)
+	require.Equal(t, "fiat-display-decimals", conflicts[0].Setting)
+	require.Equal(t, "currency", conflicts[0].Other)
+}
+
+func TestApplyBackedUpSettingsRespectsClock(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	require.NoError(t, db.SaveSetting("currency", "eur"))
+
+	// The backup is older than the local write
+	require.NoError(t, db.ApplyBackedUpSettings(map[string]interface{}{
+		"currency":    "chf",
+		"chaos-mode?": true,
+		"mnemonic":    "yurt joey vibe",
+	}, 1))
+
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, "eur", s.Currency)
+	require.False(t, s.ChaosMode)
+	require.Nil(t, s.Mnemonic)
+
+	require.Equal(t, ErrInvalidConfig, db.ApplyBackedUpSettings(map[string]interface{}{"use-mailservers?": "yes"}, 1))
+}
+
+func TestProbeSettings(t *testing.T) {
+	var probed []string
+	unreachable := errors.New("unreachable")
+	probe := func(url string) error {
+		probed = append(probed, url)
+		if url == "https://push.status.im" {
+			return nil
+		}
+		return unreachable
+	}
+
+	err := ProbeSettings(map[string]interface{}{"telemetry-server-url": "https://Telemetry.Status.im"}, probe)
+	var probeErr *ProbeError
+	require.True(t, errors.As(err, &probeErr))
+	require.True(t, errors.Is(err, unreachable))
+	require.Equal(t, "telemetry-server-url", probeErr.Setting)
+	require.Equal(t, []string{"https://telemetry.status.im"}, probed)
+
+	// Empty URLs and other settings aren't probed
+	require.NoError(t, ProbeSettings(map[string]interface{}{
+		"push-notifications-server-url": " https://Push.Status.im ",
+		"telemetry-server-url":          "",
+		"currency":                      "eur",
+	}, probe))
+	require.Equal(t, []string{"https://telemetry.status.im", "https://push.status.im"}, probed)
+
+	require.Equal(t, ErrInvalidURL, ProbeSettings(map[string]interface{}{"push-notifications-server-url": "http://status.im"}, probe))
+	require.Len(t, probed, 2)
+}
+
+func TestProbeURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodHead, r.Method)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	require.NoError(t, ProbeURL(server.URL))
+	server.Close()
+	require.Error(t, ProbeURL(server.URL))
+}
+
+func TestServerURLSettings(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	// Saves of the database itself never probe, the URLs are only normalized
+	require.NoError(t, db.SaveSettings(map[string]interface{}{
+		"telemetry-server-url":          "https://Telemetry.Status.im",
+		"push-notifications-server-url": "https://Push.Status.im/",
+	}))
+	s, err := db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, "https://telemetry.status.im", s.TelemetryServerURL)
+	require.Equal(t, "https://push.status.im/", s.PushNotificationsServerURL)
+
+	require.NoError(t, db.ResetSettings([]string{"push-notifications-server-url"}))
+	s, err = db.GetSettings()
+	require.NoError(t, err)
+	require.Equal(t, "", s.PushNotificationsServerURL)
+
+	// Plain http is never allowed
+	require.Equal(t, ErrInvalidURL, db.SaveSetting("telemetry-server-url", "http://status.im"))
+	require.Equal(t, ErrInvalidURL, db.SaveSetting("push-notifications-server-url", "http://status.im"))
+}
+
+func TestStickerPacks(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	changes, unsubscribe := db.SubscribeToSettingChanges()
+	defer unsubscribe()
+
+	require.NoError(t, db.AddStickerPack(StickerPacksPending, 4))
+	require.NoError(t, db.AddStickerPack(StickerPacksPending, 2))
+	require.NoError(t, db.AddStickerPack(StickerPacksPending, 4))
+	require.Equal(t, []uint64{4}, (<-changes).Value)
+	require.Equal(t, []uint64{2, 4}, (<-changes).Value)
+	require.Equal(t, []uint64{2, 4}, (<-changes).Value)
+
+	require.NoError(t, db.MoveStickerPack(4, StickerPacksPending, StickerPacksInstalled))
+	pending, err := db.GetStickerPacks(StickerPacksPending)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, pending)
+	installed, err := db.GetStickerPacks(StickerPacksInstalled)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4}, installed)
+
+	require.NoError(t, db.RemoveStickerPack(StickerPacksPending, 2))
+	pending, err = db.GetStickerPacks(StickerPacksPending)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	require.Equal(t, ErrInvalidConfig, db.AddStickerPack("currency", 1))
+	require.Equal(t, ErrValueOutOfRange, db.SaveSetting(StickerPacksInstalled, []interface{}{float64(-1)}))
+}
+
+func TestConcurrentAddStickerPack(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(id uint64) {
+			defer wg.Done()
+			require.NoError(t, db.AddStickerPack(StickerPacksInstalled, id))
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	installed, err := db.GetStickerPacks(StickerPacksInstalled)
+	require.NoError(t, err)
+	require.Len(t, installed, 10)
+}
+
 func TestSaveAccounts(t *testing.T) {
 	type testCase struct {
 		description string
@@ -0,0 +1,198 @@
+package accounts
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/status-im/status-go/sqlite"
+)
+
+const (
+	NotificationSendAlerts     = "send-alerts"
+	NotificationDeliverQuietly = "deliver-quietly"
+	NotificationTurnOff        = "turn-off"
+)
+
+const (
+	MessagePreviewNone = iota
+	MessagePreviewName
+	MessagePreviewNameAndMessage
+)
+
+// ErrUnknownNotificationSetting returned if a notification settings key doesn't exist.
+var ErrUnknownNotificationSetting = errors.New("unknown notification setting")
+
+// NotificationSettings holds the notification preferences of the account.
+type NotificationSettings struct {
+	OneToOneChats                string `json:"one-to-one-chats"`
+	GroupChats                   string `json:"group-chats"`
+	PersonalMentions             string `json:"personal-mentions"`
+	GlobalMentions               string `json:"global-mentions"`
+	AllMessages                  string `json:"all-messages"`
+	ContactRequests              string `json:"contact-requests"`
+	IdentityVerificationRequests string `json:"identity-verification-requests"`
+	SoundEnabled                 bool   `json:"sound-enabled"`
+	Volume                       int    `json:"volume"`
+	MessagePreview               int    `json:"message-preview"`
+}
+
+// DefaultNotificationSettings returns the notification settings of a new account.
+func DefaultNotificationSettings() NotificationSettings {
+	return NotificationSettings{
+		OneToOneChats:                NotificationSendAlerts,
+		GroupChats:                   NotificationSendAlerts,
+		PersonalMentions:             NotificationSendAlerts,
+		GlobalMentions:               NotificationSendAlerts,
+		AllMessages:                  NotificationTurnOff,
+		ContactRequests:              NotificationSendAlerts,
+		IdentityVerificationRequests: NotificationSendAlerts,
+		SoundEnabled:                 true,
+		Volume:                       50,
+		MessagePreview:               MessagePreviewNameAndMessage,
+	}
+}
+
+// Validate returns an error if any of the notification settings has an invalid value.
+func (s NotificationSettings) Validate() error {
+	for key, value := range map[string]string{
+		"one-to-one-chats":               s.OneToOneChats,
+		"group-chats":                    s.GroupChats,
+		"personal-mentions":              s.PersonalMentions,
+		"global-mentions":                s.GlobalMentions,
+		"all-messages":                   s.AllMessages,
+		"contact-requests":               s.ContactRequests,
+		"identity-verification-requests": s.IdentityVerificationRequests,
+	} {
+		switch value {
+		case NotificationSendAlerts, NotificationDeliverQuietly, NotificationTurnOff:
+		default:
+			return fmt.Errorf("%s: invalid value %q", key, value)
+		}
+	}
+	if s.Volume < 0 || s.Volume > 100 {
+		return fmt.Errorf("volume: %w", ErrValueOutOfRange)
+	}
+	if s.MessagePreview < MessagePreviewNone || s.MessagePreview > MessagePreviewNameAndMessage {
+		return fmt.Errorf("message-preview: %w", ErrValueOutOfRange)
+	}
+	return nil
+}
+
+// decodeNotificationSettings decodes a JSON object, missing keys keep their default.
+func decodeNotificationSettings(data []byte) (NotificationSettings, error) {
+	settings := DefaultNotificationSettings()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		return settings, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return settings, settings.Validate()
+}
+
+// NotificationSettingsHandler accepts the notification settings as a struct or as a JSON object.
+func NotificationSettingsHandler(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value, ErrInvalidConfig
+	}
+	settings, err := decodeNotificationSettings(data)
+	if err != nil {
+		return value, err
+	}
+	return &sqlite.JSONBlob{Data: settings}, nil
+}
+
+// GetNotificationSettings returns the notification settings, or their default if never set.
+func (db *Database) GetNotificationSettings() (NotificationSettings, error) {
+	var data []byte
+	err := db.db.QueryRow("SELECT notifications_settings FROM settings WHERE synthetic_id = 'id'").Scan(&data)
+	if err == sql.ErrNoRows || err == nil && len(data) == 0 {
+		return DefaultNotificationSettings(), nil
+	}
+	if err != nil {
+		return NotificationSettings{}, err
+	}
+	return decodeNotificationSettings(data)
+}
+
+// UpdateNotificationSettings applies a partial update to the notification settings.
+// Keys set to nil are restored to their default. Only the keys that changed
+// are published to the settings subscribers.
+func (db *Database) UpdateNotificationSettings(patch map[string]interface{}) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	current, err := db.GetNotificationSettings()
+	if err != nil {
+		return err
+	}
+	currentValues, err := notificationSettingsToMap(current)
+	if err != nil {
+		return err
+	}
+	defaults, err := notificationSettingsToMap(DefaultNotificationSettings())
+	if err != nil {
+		return err
+	}
+
+	updated := make(map[string]interface{}, len(currentValues))
+	for key, value := range currentValues {
+		updated[key] = value
+	}
+	for key, value := range patch {
+		if _, ok := defaults[key]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownNotificationSetting, key)
+		}
+		if value == nil {
+			value = defaults[key]
+		}
+		updated[key] = value
+	}
+
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	settings, err := decodeNotificationSettings(data)
+	if err != nil {
+		return err
+	}
+	updatedValues, err := notificationSettingsToMap(settings)
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[string]interface{})
+	for key, value := range updatedValues {
+		if !reflect.DeepEqual(currentValues[key], value) {
+			changed[key] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	changes, err := db.commitSettings(map[string]interface{}{"notifications-settings": settings}, SettingSourceClient)
+	if err != nil {
+		return err
+	}
+	for i := range changes {
+		changes[i].Value = changed
+	}
+	db.notifier.notify(changes)
+	return nil
+}
+
+func notificationSettingsToMap(settings NotificationSettings) (map[string]interface{}, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	err = json.Unmarshal(data, &values)
+	return values, err
+}
@@ -0,0 +1,87 @@
+package accounts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationSettingsDefault(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	s, err := db.GetNotificationSettings()
+	require.NoError(t, err)
+	require.Equal(t, DefaultNotificationSettings(), s)
+}
+
+func TestUpdateNotificationSettings(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+	changes, unsubscribe := db.SubscribeToSettingChanges()
+	defer unsubscribe()
+
+	require.NoError(t, db.UpdateNotificationSettings(map[string]interface{}{
+		"group-chats": NotificationDeliverQuietly,
+		"volume":      float64(80),
+		// unchanged keys are not published
+		"sound-enabled": true,
+	}))
+
+	s, err := db.GetNotificationSettings()
+	require.NoError(t, err)
+	expected := DefaultNotificationSettings()
+	expected.GroupChats = NotificationDeliverQuietly
+	expected.Volume = 80
+	require.Equal(t, expected, s)
+
+	change := <-changes
+	require.Equal(t, "notifications-settings", change.Setting)
+	require.Equal(t, map[string]interface{}{"group-chats": NotificationDeliverQuietly, "volume": float64(80)}, change.Value)
+
+	// Removing a key resets it to its default
+	require.NoError(t, db.UpdateNotificationSettings(map[string]interface{}{"volume": nil}))
+	s, err = db.GetNotificationSettings()
+	require.NoError(t, err)
+	require.Equal(t, DefaultNotificationSettings().Volume, s.Volume)
+	require.Equal(t, NotificationDeliverQuietly, s.GroupChats)
+}
+
+func TestUpdateNotificationSettingsRejectsInvalid(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	err := db.UpdateNotificationSettings(map[string]interface{}{"group-chats": NotificationTurnOff, "not-a-key": true})
+	require.True(t, errors.Is(err, ErrUnknownNotificationSetting))
+
+	err = db.UpdateNotificationSettings(map[string]interface{}{"volume": 101})
+	require.True(t, errors.Is(err, ErrValueOutOfRange))
+
+	require.Error(t, db.UpdateNotificationSettings(map[string]interface{}{"group-chats": "loud"}))
+
+	s, err := db.GetNotificationSettings()
+	require.NoError(t, err)
+	require.Equal(t, DefaultNotificationSettings(), s)
+}
+
+func TestSaveNotificationSettingsBlob(t *testing.T) {
+	db, stop := setupTestDB(t)
+	defer stop()
+
+	require.NoError(t, db.CreateSettings(settings, config))
+
+	require.Error(t, db.SaveSetting("notifications-settings", map[string]interface{}{"unknown": 1}))
+	require.NoError(t, db.SaveSetting("notifications-settings", map[string]interface{}{"all-messages": NotificationSendAlerts}))
+
+	s, err := db.GetNotificationSettings()
+	require.NoError(t, err)
+	require.Equal(t, NotificationSendAlerts, s.AllMessages)
+	require.Equal(t, NotificationSendAlerts, s.GroupChats)
+}
@@ -0,0 +1,119 @@
+package accounts
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// SettingSourceClient is the source of settings saved through the API.
+	SettingSourceClient = "client"
+	// SettingSourceReset is the source of settings restored to their default.
+	SettingSourceReset = "reset"
+	// SettingSourceBackup is the source of settings restored from a backup.
+	SettingSourceBackup = "backup"
+	// SettingSourceSync is the source of settings synced from a paired device.
+	SettingSourceSync = "sync"
+)
+
+// SettingChange is published each time a setting is written.
+type SettingChange struct {
+	Setting string      `json:"setting"`
+	Value   interface{} `json:"value"`
+	Source  string      `json:"source"`
+}
+
+// settingChangeBuffer is the number of changes a subscription buffers until it is read.
+const settingChangeBuffer = 100
+
+// settingChangeTimeout bounds how long a write waits for slow subscribers
+// once their buffer is full. Changes still not delivered are dropped.
+var settingChangeTimeout = time.Second
+
+type settingsNotifier struct {
+	mu            sync.Mutex
+	subscriptions []chan SettingChange
+	dropped       uint64
+}
+
+func (n *settingsNotifier) subscribe() (<-chan SettingChange, func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c := make(chan SettingChange, settingChangeBuffer)
+	n.subscriptions = append(n.subscriptions, c)
+
+	var once sync.Once
+	return c, func() {
+		once.Do(func() { n.unsubscribe(c) })
+	}
+}
+
+func (n *settingsNotifier) unsubscribe(c chan SettingChange) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i, subscription := range n.subscriptions {
+		if subscription == c {
+			n.subscriptions = append(n.subscriptions[:i], n.subscriptions[i+1:]...)
+			close(c)
+			return
+		}
+	}
+}
+
+// notify publishes the changes, in order, to every subscription. Subscribers with
+// a full buffer are waited for up to settingChangeTimeout for the whole batch,
+// the changes they still can't receive are logged and counted as dropped.
+func (n *settingsNotifier) notify(changes []SettingChange) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.subscriptions) == 0 {
+		return
+	}
+
+	timeout := time.NewTimer(settingChangeTimeout)
+	defer timeout.Stop()
+	expired := false
+	var dropped uint64
+	for _, change := range changes {
+		for _, c := range n.subscriptions {
+			select {
+			case c <- change:
+				continue
+			default:
+			}
+			if !expired {
+				select {
+				case c <- change:
+					continue
+				case <-timeout.C:
+					expired = true
+				}
+			}
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		n.dropped += dropped
+		log.Warn("dropped setting changes, subscriber too slow", "dropped", dropped, "total", n.dropped)
+	}
+}
+
+func (n *settingsNotifier) droppedChanges() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dropped
+}
+
+// SubscribeToSettingChanges returns a channel receiving every setting that is written,
+// and a function to unsubscribe which closes the channel. Writes wait a bounded time
+// for subscribers which don't keep up, so the channel should be read continuously.
+func (db *Database) SubscribeToSettingChanges() (<-chan SettingChange, func()) {
+	return db.notifier.subscribe()
+}
+
+// DroppedSettingChanges returns how many changes were not delivered to slow subscribers.
+func (db *Database) DroppedSettingChanges() uint64 {
+	return db.notifier.droppedChanges()
+}
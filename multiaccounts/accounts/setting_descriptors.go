@@ -0,0 +1,51 @@
+package accounts
+
+import (
+	"sort"
+)
+
+// SettingValidation summarizes the values a setting accepts, when its handler restricts them.
+type SettingValidation struct {
+	Min      *int64        `json:"min,omitempty"`
+	Max      *int64        `json:"max,omitempty"`
+	Enum     []interface{} `json:"enum,omitempty"`
+	MaxItems int           `json:"maxItems,omitempty"`
+	Schemes  []string      `json:"schemes,omitempty"`
+}
+
+// SettingDescriptor describes a setting supported by this build.
+type SettingDescriptor struct {
+	Name       string             `json:"name"`
+	Kind       SettingKind        `json:"kind"`
+	Default    interface{}        `json:"default,omitempty"`
+	Nullable   bool               `json:"nullable"`
+	Immutable  bool               `json:"immutable"`
+	Sensitive  bool               `json:"sensitive"`
+	Backup     bool               `json:"backup"`
+	Validation *SettingValidation `json:"validation,omitempty"`
+}
+
+// DescribeSettings returns a description of every setting, sorted by name.
+func DescribeSettings() []SettingDescriptor {
+	descriptors := make([]SettingDescriptor, 0, len(settingFields))
+	for name, field := range settingFields {
+		descriptors = append(descriptors, SettingDescriptor{
+			Name:       name,
+			Kind:       field.Kind,
+			Default:    field.Default,
+			Nullable:   field.Nullable,
+			Immutable:  field.Immutable,
+			Sensitive:  field.Sensitive,
+			Backup:     field.backedUp(),
+			Validation: field.Validation,
+		})
+	}
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Name < descriptors[j].Name
+	})
+	return descriptors
+}
+
+func intRange(min, max int64) *SettingValidation {
+	return &SettingValidation{Min: &min, Max: &max}
+}
@@ -0,0 +1,39 @@
+package accounts
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func TestDescribeSettingsGolden(t *testing.T) {
+	data, err := json.MarshalIndent(DescribeSettings(), "", "  ")
+	require.NoError(t, err)
+	data = append(data, '\n')
+
+	golden := filepath.Join("testdata", "settings_descriptors.golden.json")
+	if *updateGolden {
+		require.NoError(t, ioutil.WriteFile(golden, data, 0644))
+	}
+	expected, err := ioutil.ReadFile(golden)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(data), "run with -update to regenerate %s", golden)
+}
+
+func TestDescribeSettingsCoversRegistry(t *testing.T) {
+	descriptors := DescribeSettings()
+	require.Len(t, descriptors, len(settingFields))
+	for i, descriptor := range descriptors {
+		if i > 0 {
+			require.Less(t, descriptors[i-1].Name, descriptor.Name)
+		}
+		field := settingFields[descriptor.Name]
+		require.Equal(t, field.Kind, descriptor.Kind)
+	}
+}
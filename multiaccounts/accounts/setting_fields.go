@@ -0,0 +1,196 @@
+package accounts
+
+import (
+	"sort"
+	"strings"
+)
+
+// ValueHandler validates a setting value and converts it to the form
+// that is stored in the database.
+type ValueHandler func(interface{}) (interface{}, error)
+
+// SettingField describes a setting that can be written through SaveSetting.
+type SettingField struct {
+	// Column is the name of the column in the settings table.
+	Column string
+	// Kind is the type of the stored value.
+	Kind SettingKind
+	// Handler, if set, is applied to the value before it's persisted.
+	Handler ValueHandler
+	// Nullable fields can be unset, which is distinct from their zero value.
+	Nullable bool
+	// Default is the value the setting is restored to on reset.
+	Default interface{}
+	// Immutable fields are set when the account is created and never reset.
+	Immutable bool
+	// Sensitive fields hold credentials and are never reset or backed up.
+	Sensitive bool
+	// Backup tells whether the setting is included in account backups.
+	// Every setting must make an explicit decision.
+	Backup BackupPolicy
+	// Probe settings hold an URL that is checked to be reachable before
+	// interactive saves through the settings API, when probing is enabled.
+	Probe bool
+	// Validation summarizes the values accepted by Handler, if it restricts them.
+	Validation *SettingValidation
+}
+
+// SettingKind is the type of the value stored for a setting.
+type SettingKind string
+
+const (
+	SettingKindBool    SettingKind = "bool"
+	SettingKindInt     SettingKind = "int"
+	SettingKindString  SettingKind = "string"
+	SettingKindJSON    SettingKind = "json"
+	SettingKindAddress SettingKind = "address"
+)
+
+// BackupPolicy tells whether a setting is included in account backups.
+type BackupPolicy int
+
+const (
+	// BackupExclude settings are specific to this device or account creation.
+	BackupExclude BackupPolicy = iota + 1
+	// BackupInclude settings are restored on other devices from a backup.
+	BackupInclude
+)
+
+func (f SettingField) backedUp() bool {
+	return f.Backup == BackupInclude && !f.Sensitive
+}
+
+func (f SettingField) resettable() bool {
+	return f.Default != nil && !f.Immutable && !f.Sensitive
+}
+
+// SettingsErrors maps a setting to the reason it was rejected.
+type SettingsErrors map[string]error
+
+func (e SettingsErrors) Error() string {
+	settings := make([]string, 0, len(e))
+	for setting := range e {
+		settings = append(settings, setting)
+	}
+	sort.Strings(settings)
+
+	msgs := make([]string, len(settings))
+	for i, setting := range settings {
+		msgs[i] = setting + ": " + e[setting].Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+const (
+	// maxTrustedOrigins is the maximum number of origins the browser can trust.
+	maxTrustedOrigins = 100
+	// maxStickerPacks is the maximum number of installed or pending sticker packs.
+	maxStickerPacks = 1000
+)
+
+var (
+	addressHandler        = ChainHandlers(TrimSpace, NotEmpty, AddressHandler)
+	currencyHandler       = ChainHandlers(TrimSpace, Lowercase, NotEmpty)
+	trustedOriginsList    = StringListHandler(OriginHandler, maxTrustedOrigins)
+	trustedOriginsHandler = ChainHandlers(trustedOriginsList, JSONBlobHandler)
+	serverURLHandler      = ChainHandlers(TrimSpace, EmptyOr(URLHandler("https")))
+	stickerPacksSet       = IntSetHandler(maxStickerPacks)
+	stickerPacksHandler   = ChainHandlers(stickerPacksSet, JSONBlobHandler)
+
+	profilePicturesShowToHandler = ChainHandlers(
+		profilePicturesShowToBoolHandler,
+		IntEnumHandler(
+			int64(ProfilePicturesShowToContactsOnly),
+			int64(ProfilePicturesShowToEveryone),
+			int64(ProfilePicturesShowToNone),
+		),
+	)
+	profilePicturesVisibilityHandler = IntEnumHandler(
+		int64(ProfilePicturesVisibilityContactsOnly),
+		int64(ProfilePicturesVisibilityEveryone),
+		int64(ProfilePicturesVisibilityNone),
+	)
+)
+
+var (
+	profilePicturesShowToValidation = &SettingValidation{Enum: []interface{}{
+		ProfilePicturesShowToContactsOnly,
+		ProfilePicturesShowToEveryone,
+		ProfilePicturesShowToNone,
+	}}
+	profilePicturesVisibilityValidation = &SettingValidation{Enum: []interface{}{
+		ProfilePicturesVisibilityContactsOnly,
+		ProfilePicturesVisibilityEveryone,
+		ProfilePicturesVisibilityNone,
+	}}
+	trustedOriginsValidation = &SettingValidation{MaxItems: maxTrustedOrigins, Schemes: []string{"https", "wss"}}
+	serverURLValidation      = &SettingValidation{Schemes: []string{"https"}}
+	stickerPacksValidation   = &SettingValidation{MaxItems: maxStickerPacks}
+)
+
+// settingFields maps the client facing name of a setting to its column.
+// node-config is not listed, it is stored in a separate set of tables.
+var settingFields = map[string]SettingField{
+	"anon-metrics/should-send?":              {Column: "anon_metrics_should_send", Kind: SettingKindBool, Handler: BoolHandler, Nullable: true, Default: false, Backup: BackupInclude},
+	"appearance":                             {Column: "appearance", Kind: SettingKindInt, Default: uint(0), Backup: BackupInclude},
+	"auto-message-enabled?":                  {Column: "auto_message_enabled", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"backup-enabled?":                        {Column: "backup_enabled", Kind: SettingKindBool, Handler: BoolHandler, Default: true, Backup: BackupInclude},
+	"browser/trusted-origins":                {Column: "browser_trusted_origins", Kind: SettingKindJSON, Handler: trustedOriginsHandler, Validation: trustedOriginsValidation, Nullable: true, Backup: BackupInclude},
+	"chaos-mode?":                            {Column: "chaos_mode", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupExclude},
+	"currency":                               {Column: "currency", Kind: SettingKindString, Handler: currencyHandler, Default: "usd", Backup: BackupInclude},
+	"current-user-status":                    {Column: "current_user_status", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupExclude},
+	"custom-bootnodes":                       {Column: "custom_bootnodes", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupExclude},
+	"custom-bootnodes-enabled?":              {Column: "custom_bootnodes_enabled", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupExclude},
+	"dapps-address":                          {Column: "dapps_address", Kind: SettingKindAddress, Handler: addressHandler, Immutable: true, Backup: BackupExclude},
+	"default-sync-period":                    {Column: "default_sync_period", Kind: SettingKindInt, Default: uint(86400), Backup: BackupInclude},
+	"eip1581-address":                        {Column: "eip1581_address", Kind: SettingKindAddress, Handler: addressHandler, Immutable: true, Backup: BackupExclude},
+	"fetch-history-on-startup?":              {Column: "fetch_history_on_startup", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"fiat-display-decimals":                  {Column: "fiat_display_decimals", Kind: SettingKindInt, Handler: IntRangeHandler(0, 4), Validation: intRange(0, 4), Default: int64(2), Backup: BackupInclude},
+	"fleet":                                  {Column: "fleet", Kind: SettingKindString, Nullable: true, Backup: BackupExclude},
+	"gifs/favorite-gifs":                     {Column: "gif_favorites", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupInclude},
+	"gifs/recent-gifs":                       {Column: "gif_recents", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupInclude},
+	"hide-home-tooltip?":                     {Column: "hide_home_tooltip", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"keycard-instance_uid":                   {Column: "keycard_instance_uid", Kind: SettingKindString, Sensitive: true, Backup: BackupExclude},
+	"keycard-paired_on":                      {Column: "keycard_paired_on", Kind: SettingKindInt, Sensitive: true, Backup: BackupExclude},
+	"keycard-pairing":                        {Column: "keycard_pairing", Kind: SettingKindString, Sensitive: true, Backup: BackupExclude},
+	"last-updated":                           {Column: "last_updated", Kind: SettingKindInt, Nullable: true, Backup: BackupExclude},
+	"latest-derived-path":                    {Column: "latest_derived_path", Kind: SettingKindInt, Backup: BackupExclude},
+	"link-preview-request-enabled":           {Column: "link_preview_request_enabled", Kind: SettingKindBool, Handler: BoolHandler, Default: true, Backup: BackupInclude},
+	"link-previews-enabled-sites":            {Column: "link_previews_enabled_sites", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupInclude},
+	"log-level":                              {Column: "log_level", Kind: SettingKindString, Nullable: true, Backup: BackupExclude},
+	"message-archiving-enabled?":             {Column: "message_archiving_enabled", Kind: SettingKindBool, Handler: BoolHandler, Default: true, Backup: BackupInclude},
+	"messages-from-contacts-only":            {Column: "messages_from_contacts_only", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"mnemonic":                               {Column: "mnemonic", Kind: SettingKindString, Nullable: true, Sensitive: true, Backup: BackupExclude},
+	"name":                                   {Column: "name", Kind: SettingKindString, Backup: BackupExclude},
+	"networks/current-network":               {Column: "current_network", Kind: SettingKindString, Backup: BackupExclude},
+	"networks/networks":                      {Column: "networks", Kind: SettingKindJSON, Handler: JSONBlobHandler, Backup: BackupExclude},
+	"notifications-enabled?":                 {Column: "notifications_enabled", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"notifications-settings":                 {Column: "notifications_settings", Kind: SettingKindJSON, Handler: NotificationSettingsHandler, Nullable: true, Default: DefaultNotificationSettings(), Backup: BackupInclude},
+	"opensea-enabled?":                       {Column: "opensea_enabled", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"photo-path":                             {Column: "photo_path", Kind: SettingKindString, Backup: BackupExclude},
+	"pinned-mailservers":                     {Column: "pinned_mailservers", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupExclude},
+	"preferred-name":                         {Column: "preferred_name", Kind: SettingKindString, Nullable: true, Backup: BackupInclude},
+	"preview-privacy?":                       {Column: "preview_privacy", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"profile-pictures-show-to":               {Column: "profile_pictures_show_to", Kind: SettingKindInt, Handler: profilePicturesShowToHandler, Validation: profilePicturesShowToValidation, Default: ProfilePicturesShowToContactsOnly, Backup: BackupInclude},
+	"profile-pictures-visibility":            {Column: "profile_pictures_visibility", Kind: SettingKindInt, Handler: profilePicturesVisibilityHandler, Validation: profilePicturesVisibilityValidation, Default: ProfilePicturesVisibilityContactsOnly, Backup: BackupInclude},
+	"public-key":                             {Column: "public_key", Kind: SettingKindString, Immutable: true, Backup: BackupExclude},
+	"push-notifications-block-mentions?":     {Column: "push_notifications_block_mentions", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"push-notifications-from-contacts-only?": {Column: "push_notifications_from_contacts_only", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+	"push-notifications-server-enabled?":     {Column: "push_notifications_server_enabled", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupExclude},
+	"push-notifications-server-url":          {Column: "push_notifications_server_url", Kind: SettingKindString, Handler: serverURLHandler, Validation: serverURLValidation, Probe: true, Default: "", Backup: BackupInclude},
+	"remember-syncing-choice?":               {Column: "remember_syncing_choice", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupExclude},
+	"remote-push-notifications-enabled?":     {Column: "remote_push_notifications_enabled", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupExclude},
+	"send-push-notifications?":               {Column: "send_push_notifications", Kind: SettingKindBool, Handler: BoolHandler, Default: true, Backup: BackupInclude},
+	"send-status-updates?":                   {Column: "send_status_updates", Kind: SettingKindBool, Handler: BoolHandler, Default: true, Backup: BackupInclude},
+	"stickers/packs-installed":               {Column: "stickers_packs_installed", Kind: SettingKindJSON, Handler: stickerPacksHandler, Validation: stickerPacksValidation, Nullable: true, Backup: BackupInclude},
+	"stickers/packs-pending":                 {Column: "stickers_packs_pending", Kind: SettingKindJSON, Handler: stickerPacksHandler, Validation: stickerPacksValidation, Nullable: true, Backup: BackupExclude},
+	"stickers/recent-stickers":               {Column: "stickers_recent_stickers", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupInclude},
+	"syncing-on-mobile-network?":             {Column: "syncing_on_mobile_network", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupExclude},
+	"telemetry-server-url":                   {Column: "telemetry_server_url", Kind: SettingKindString, Handler: serverURLHandler, Validation: serverURLValidation, Probe: true, Default: "", Backup: BackupInclude},
+	"use-mailservers?":                       {Column: "use_mailservers", Kind: SettingKindBool, Handler: BoolHandler, Default: true, Backup: BackupInclude},
+	"usernames":                              {Column: "usernames", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupInclude},
+	"waku-bloom-filter-mode":                 {Column: "waku_bloom_filter_mode", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupExclude},
+	"wallet-set-up-passed?":                  {Column: "wallet_set_up_passed", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupExclude},
+	"wallet/visible-tokens":                  {Column: "wallet_visible_tokens", Kind: SettingKindJSON, Handler: JSONBlobHandler, Nullable: true, Backup: BackupInclude},
+	"webview-allow-permission-requests?":     {Column: "webview_allow_permission_requests", Kind: SettingKindBool, Handler: BoolHandler, Default: false, Backup: BackupInclude},
+}
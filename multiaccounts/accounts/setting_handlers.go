@@ -0,0 +1,339 @@
+package accounts
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+
+	"github.com/status-im/status-go/eth-node/types"
+	"github.com/status-im/status-go/sqlite"
+)
+
+var (
+	// ErrEmptyValue returned if a setting requires a non empty value
+	ErrEmptyValue = errors.New("value can't be empty")
+	// ErrValueTooLong returned if a value exceeds the maximum length of a setting
+	ErrValueTooLong = errors.New("value is too long")
+	// ErrValueOutOfRange returned if a value is outside of the range allowed for a setting
+	ErrValueOutOfRange = errors.New("value out of range")
+	// ErrTooManyItems returned if a list exceeds the number of entries allowed for a setting
+	ErrTooManyItems = errors.New("too many items")
+	// ErrInvalidOrigin returned if a value is not a https or wss origin
+	ErrInvalidOrigin = errors.New("invalid origin")
+	// ErrInvalidURL returned if a value is not an URL with an allowed scheme
+	ErrInvalidURL = errors.New("invalid url")
+)
+
+// ChainHandlers returns a handler passing the value through each of the handlers in order.
+// Each handler receives the value returned by the previous one, the first error is returned.
+func ChainHandlers(handlers ...ValueHandler) ValueHandler {
+	return func(value interface{}) (interface{}, error) {
+		var err error
+		for _, handler := range handlers {
+			value, err = handler(value)
+			if err != nil {
+				return value, err
+			}
+		}
+		return value, nil
+	}
+}
+
+// BoolHandler accepts only bool values.
+func BoolHandler(value interface{}) (interface{}, error) {
+	_, ok := value.(bool)
+	if !ok {
+		return value, ErrInvalidConfig
+	}
+	return value, nil
+}
+
+// JSONBlobHandler stores the value as JSON.
+func JSONBlobHandler(value interface{}) (interface{}, error) {
+	return &sqlite.JSONBlob{Data: value}, nil
+}
+
+// AddressHandler converts a hex string to an address.
+func AddressHandler(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return value, ErrInvalidConfig
+	}
+	return types.HexToAddress(str), nil
+}
+
+// TrimSpace removes leading and trailing white space from string values.
+func TrimSpace(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return value, ErrInvalidConfig
+	}
+	return strings.TrimSpace(str), nil
+}
+
+// Lowercase converts string values to lower case.
+func Lowercase(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return value, ErrInvalidConfig
+	}
+	return strings.ToLower(str), nil
+}
+
+// NotEmpty rejects empty strings.
+func NotEmpty(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return value, ErrInvalidConfig
+	}
+	if str == "" {
+		return value, ErrEmptyValue
+	}
+	return value, nil
+}
+
+// MaxLen rejects strings longer than n bytes.
+func MaxLen(n int) ValueHandler {
+	return func(value interface{}) (interface{}, error) {
+		str, ok := value.(string)
+		if !ok {
+			return value, ErrInvalidConfig
+		}
+		if len(str) > n {
+			return value, ErrValueTooLong
+		}
+		return value, nil
+	}
+}
+
+// toInt64 converts integer values of any type to int64.
+// Values decoded from JSON are float64 and are accepted when integral.
+func toInt64(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f != float64(int64(f)) {
+			return 0, false
+		}
+		return int64(f), true
+	}
+	return 0, false
+}
+
+// IntRangeHandler accepts integers between min and max, inclusive.
+func IntRangeHandler(min, max int64) ValueHandler {
+	return func(value interface{}) (interface{}, error) {
+		n, ok := toInt64(value)
+		if !ok {
+			return value, ErrInvalidConfig
+		}
+		if n < min || n > max {
+			return value, ErrValueOutOfRange
+		}
+		return n, nil
+	}
+}
+
+// IntEnumHandler accepts only the given integers.
+func IntEnumHandler(values ...int64) ValueHandler {
+	return func(value interface{}) (interface{}, error) {
+		n, ok := toInt64(value)
+		if !ok {
+			return value, ErrInvalidConfig
+		}
+		for _, v := range values {
+			if n == v {
+				return n, nil
+			}
+		}
+		return value, ErrValueOutOfRange
+	}
+}
+
+// IntSetHandler accepts a list of at most maxItems non negative integers.
+// The result is sorted and free of duplicates.
+func IntSetHandler(maxItems int) ValueHandler {
+	return func(value interface{}) (interface{}, error) {
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice {
+			return value, ErrInvalidConfig
+		}
+
+		seen := make(map[uint64]bool, v.Len())
+		result := make([]uint64, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			n, ok := toInt64(v.Index(i).Interface())
+			if !ok {
+				return value, ErrInvalidConfig
+			}
+			if n < 0 {
+				return value, ErrValueOutOfRange
+			}
+			if seen[uint64(n)] {
+				continue
+			}
+			seen[uint64(n)] = true
+			result = append(result, uint64(n))
+		}
+		if len(result) > maxItems {
+			return value, ErrTooManyItems
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+		return result, nil
+	}
+}
+
+// profilePicturesShowToBoolHandler maps the boolean written by older clients,
+// "show to everyone", to the corresponding ProfilePicturesShowToType.
+func profilePicturesShowToBoolHandler(value interface{}) (interface{}, error) {
+	everyone, ok := value.(bool)
+	if !ok {
+		return value, nil
+	}
+	if everyone {
+		return ProfilePicturesShowToEveryone, nil
+	}
+	return ProfilePicturesShowToContactsOnly, nil
+}
+
+// StringListHandler validates each entry of a list of strings with the given handler.
+// The result is sorted and free of duplicates, so it can be compared exactly.
+func StringListHandler(item ValueHandler, maxItems int) ValueHandler {
+	return func(value interface{}) (interface{}, error) {
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case []string:
+			for _, str := range v {
+				items = append(items, str)
+			}
+		default:
+			return value, ErrInvalidConfig
+		}
+
+		seen := make(map[string]bool, len(items))
+		result := make([]string, 0, len(items))
+		for _, i := range items {
+			handled, err := item(i)
+			if err != nil {
+				return value, err
+			}
+			str, ok := handled.(string)
+			if !ok {
+				return value, ErrInvalidConfig
+			}
+			if seen[str] {
+				continue
+			}
+			seen[str] = true
+			result = append(result, str)
+		}
+		if len(result) > maxItems {
+			return value, ErrTooManyItems
+		}
+		sort.Strings(result)
+		return result, nil
+	}
+}
+
+// OriginHandler normalizes a https or wss origin. The scheme and host are
+// lower cased, international hosts are punycode encoded, default ports and
+// trailing slashes are dropped. URLs with a path, query or credentials are rejected.
+func OriginHandler(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return value, ErrInvalidConfig
+	}
+	u, err := url.Parse(strings.TrimSpace(str))
+	if err != nil {
+		return value, ErrInvalidOrigin
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "https" && scheme != "wss" {
+		return value, ErrInvalidOrigin
+	}
+	if u.Opaque != "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" || u.Path != "" && u.Path != "/" {
+		return value, ErrInvalidOrigin
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return value, ErrInvalidOrigin
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+		if ip.To4() == nil {
+			host = "[" + host + "]"
+		}
+	} else {
+		host, err = idna.Lookup.ToASCII(host)
+		if err != nil {
+			return value, ErrInvalidOrigin
+		}
+	}
+
+	if port := u.Port(); port != "" && port != "443" {
+		host += ":" + port
+	}
+	return scheme + "://" + host, nil
+}
+
+// EmptyOr accepts empty strings and passes any other value to the handler.
+func EmptyOr(handler ValueHandler) ValueHandler {
+	return func(value interface{}) (interface{}, error) {
+		if value == "" {
+			return value, nil
+		}
+		return handler(value)
+	}
+}
+
+// URLHandler accepts absolute URLs with one of the given schemes.
+// The scheme and host are lower cased and international hosts punycode encoded.
+func URLHandler(schemes ...string) ValueHandler {
+	return func(value interface{}) (interface{}, error) {
+		str, ok := value.(string)
+		if !ok {
+			return value, ErrInvalidConfig
+		}
+		u, err := url.Parse(str)
+		if err != nil || u.Opaque != "" || u.User != nil || u.Hostname() == "" {
+			return value, ErrInvalidURL
+		}
+		u.Scheme = strings.ToLower(u.Scheme)
+		allowed := false
+		for _, scheme := range schemes {
+			if u.Scheme == scheme {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return value, ErrInvalidURL
+		}
+
+		host := u.Hostname()
+		if net.ParseIP(host) == nil {
+			host, err = idna.Lookup.ToASCII(host)
+			if err != nil {
+				return value, ErrInvalidURL
+			}
+			if port := u.Port(); port != "" {
+				host += ":" + port
+			}
+			u.Host = host
+		}
+		return u.String(), nil
+	}
+}
@@ -0,0 +1,137 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/eth-node/types"
+)
+
+func TestChainHandlersThreadsValue(t *testing.T) {
+	var seen []interface{}
+	record := func(value interface{}) (interface{}, error) {
+		seen = append(seen, value)
+		return value, nil
+	}
+
+	handler := ChainHandlers(TrimSpace, record, Lowercase, record, MaxLen(3))
+	value, err := handler("  USD ")
+	require.NoError(t, err)
+	require.Equal(t, "usd", value)
+	require.Equal(t, []interface{}{"USD", "usd"}, seen)
+}
+
+func TestChainHandlersShortCircuits(t *testing.T) {
+	called := false
+	handler := ChainHandlers(TrimSpace, NotEmpty, func(value interface{}) (interface{}, error) {
+		called = true
+		return value, nil
+	})
+
+	_, err := handler("   ")
+	require.Equal(t, ErrEmptyValue, err)
+	require.False(t, called)
+
+	_, err = handler(1)
+	require.Equal(t, ErrInvalidConfig, err)
+}
+
+func TestMaxLen(t *testing.T) {
+	_, err := MaxLen(3)("abcd")
+	require.Equal(t, ErrValueTooLong, err)
+
+	value, err := MaxLen(3)("abc")
+	require.NoError(t, err)
+	require.Equal(t, "abc", value)
+}
+
+func TestAddressHandlerChain(t *testing.T) {
+	value, err := addressHandler(" 0xD1300f99fDF7346986CbC766903245087394ecd0\n")
+	require.NoError(t, err)
+	require.Equal(t, types.HexToAddress("0xD1300f99fDF7346986CbC766903245087394ecd0"), value)
+
+	_, err = addressHandler("")
+	require.Equal(t, ErrEmptyValue, err)
+}
+
+func TestOriginHandler(t *testing.T) {
+	for origin, expected := range map[string]string{
+		"https://Example.com":         "https://example.com",
+		"https://example.com/":        "https://example.com",
+		"HTTPS://example.com:443":     "https://example.com",
+		"wss://example.com:8443":      "wss://example.com:8443",
+		"https://bücher.example":      "https://xn--bcher-kva.example",
+		"https://[2001:db8::1]:8080/": "https://[2001:db8::1]:8080",
+	} {
+		value, err := OriginHandler(origin)
+		require.NoError(t, err, origin)
+		require.Equal(t, expected, value)
+	}
+
+	for _, origin := range []string{
+		"http://example.com",
+		"https://example.com/path",
+		"https://example.com?q=1",
+		"https://user@example.com",
+		"example.com",
+		"https://",
+	} {
+		_, err := OriginHandler(origin)
+		require.Equal(t, ErrInvalidOrigin, err, origin)
+	}
+}
+
+func TestStringListHandler(t *testing.T) {
+	handler := StringListHandler(OriginHandler, 2)
+
+	value, err := handler([]interface{}{"https://b.com/", "https://A.com", "https://b.com"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://a.com", "https://b.com"}, value)
+
+	_, err = handler([]string{"https://a.com", "https://b.com", "https://c.com"})
+	require.Equal(t, ErrTooManyItems, err)
+
+	_, err = handler([]interface{}{"https://a.com", 1})
+	require.Equal(t, ErrInvalidConfig, err)
+
+	_, err = handler("https://a.com")
+	require.Equal(t, ErrInvalidConfig, err)
+}
+
+func TestServerURLHandler(t *testing.T) {
+	for url, expected := range map[string]string{
+		"":                                "",
+		" https://Telemetry.Status.im/v1": "https://telemetry.status.im/v1",
+		"HTTPS://bücher.example:8443/":    "https://xn--bcher-kva.example:8443/",
+	} {
+		value, err := serverURLHandler(url)
+		require.NoError(t, err, url)
+		require.Equal(t, expected, value)
+	}
+
+	for _, url := range []string{"http://telemetry.status.im", "telemetry.status.im", "https://", "wss://status.im"} {
+		_, err := serverURLHandler(url)
+		require.Equal(t, ErrInvalidURL, err, url)
+	}
+}
+
+func TestIntSetHandler(t *testing.T) {
+	handler := IntSetHandler(3)
+
+	value, err := handler([]interface{}{float64(3), float64(1), float64(3)})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 3}, value)
+
+	_, err = handler([]interface{}{float64(-1)})
+	require.Equal(t, ErrValueOutOfRange, err)
+
+	_, err = handler([]interface{}{1.5})
+	require.Equal(t, ErrInvalidConfig, err)
+
+	_, err = handler([]int{1, 2, 3, 4})
+	require.Equal(t, ErrTooManyItems, err)
+
+	_, err = handler(map[string]interface{}{})
+	require.Equal(t, ErrInvalidConfig, err)
+}
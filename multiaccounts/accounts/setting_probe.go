@@ -0,0 +1,66 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const probeTimeout = 5 * time.Second
+
+// ProbeError is returned by interactive saves when a server URL can't be reached.
+// The client can still store the URL with the settings_saveSettingForce API.
+type ProbeError struct {
+	Setting string
+	URL     string
+	Err     error
+}
+
+func (e *ProbeError) Error() string {
+	return fmt.Sprintf("%s: %s is not reachable: %v", e.Setting, e.URL, e.Err)
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// ProbeURL sends a HEAD request to the URL, any response means it's reachable.
+func ProbeURL(url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// ProbeSettings checks with probe the URLs of the settings about to be saved
+// interactively. Writes made by the node itself, such as migrations, resets,
+// backups and syncs, are never probed, so it's up to the client API to call it.
+func ProbeSettings(values map[string]interface{}, probe func(url string) error) error {
+	for setting, value := range values {
+		field, ok := settingFields[setting]
+		if !ok || !field.Probe {
+			continue
+		}
+		handled, err := handleSetting(setting, value)
+		if err != nil {
+			return err
+		}
+		url, _ := handled.(string)
+		if url == "" {
+			continue
+		}
+		if err := probe(url); err != nil {
+			return &ProbeError{Setting: setting, URL: url, Err: err}
+		}
+	}
+	return nil
+}
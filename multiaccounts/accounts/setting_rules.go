@@ -0,0 +1,214 @@
+package accounts
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/status-im/status-go/params"
+)
+
+// maxSettingConflicts is the number of recorded conflicts kept in memory.
+const maxSettingConflicts = 100
+
+// SettingRule validates the proposed value of a setting against other settings.
+// current returns the value another setting will have once the write is applied.
+type SettingRule func(value interface{}, current func(setting string) (interface{}, error)) error
+
+// SettingConflictError is returned when a setting value is not allowed
+// in combination with the value of another setting.
+type SettingConflictError struct {
+	Setting string
+	Other   string
+	Reason  string
+}
+
+func (e *SettingConflictError) Error() string {
+	return fmt.Sprintf("%s conflicts with %s: %s", e.Setting, e.Other, e.Reason)
+}
+
+// settingRules lists the rules that must hold when a setting is written.
+var settingRules = map[string][]SettingRule{
+	"currency":                   {currencyDecimalsRule},
+	"fetch-history-on-startup?":  {fetchHistoryOnStartupRule},
+	"fiat-display-decimals":      {fiatDisplayDecimalsRule},
+	"fleet":                      {fleetRule},
+	"message-archiving-enabled?": {messageArchivingRule},
+	"node-config":                {nodeConfigLightClientRule},
+}
+
+// softConflicts returns true if writes from the source are applied despite conflicts.
+// Backups and syncs come from another device that already accepted the values,
+// rejecting them would leave the settings diverged, so conflicts are only recorded.
+func softConflicts(source string) bool {
+	return source == SettingSourceBackup || source == SettingSourceSync
+}
+
+type settingConflicts struct {
+	mu        sync.Mutex
+	conflicts []SettingConflictError
+}
+
+func (db *Database) recordConflicts(conflicts []SettingConflictError) {
+	if len(conflicts) == 0 {
+		return
+	}
+	db.conflicts.mu.Lock()
+	defer db.conflicts.mu.Unlock()
+	for _, conflict := range conflicts {
+		log.Warn("applied conflicting setting", "setting", conflict.Setting, "other", conflict.Other, "reason", conflict.Reason)
+	}
+	db.conflicts.conflicts = append(db.conflicts.conflicts, conflicts...)
+	if len(db.conflicts.conflicts) > maxSettingConflicts {
+		db.conflicts.conflicts = db.conflicts.conflicts[len(db.conflicts.conflicts)-maxSettingConflicts:]
+	}
+}
+
+// SettingConflicts returns the conflicts accepted when applying backed up settings, oldest first.
+func (db *Database) SettingConflicts() []SettingConflictError {
+	db.conflicts.mu.Lock()
+	defer db.conflicts.mu.Unlock()
+	return append([]SettingConflictError(nil), db.conflicts.conflicts...)
+}
+
+// zeroDecimalCurrencies are the ISO 4217 currencies without a minor unit.
+var zeroDecimalCurrencies = map[string]bool{
+	"bif": true,
+	"clp": true,
+	"djf": true,
+	"gnf": true,
+	"isk": true,
+	"jpy": true,
+	"kmf": true,
+	"krw": true,
+	"pyg": true,
+	"rwf": true,
+	"ugx": true,
+	"uyi": true,
+	"vnd": true,
+	"vuv": true,
+	"xaf": true,
+	"xof": true,
+	"xpf": true,
+}
+
+// decimalsNotAllowed returns the lower cased currency if it has no minor unit
+// but would be displayed with decimals.
+func decimalsNotAllowed(currency interface{}, decimals interface{}) (string, bool) {
+	str, _ := currency.(string)
+	str = strings.ToLower(str)
+	return str, zeroDecimalCurrencies[str] && decimals != nil && decimals != int64(0)
+}
+
+func fiatDisplayDecimalsRule(value interface{}, current func(string) (interface{}, error)) error {
+	currency, err := current("currency")
+	if err != nil {
+		return err
+	}
+	if str, ok := decimalsNotAllowed(currency, value); ok {
+		return &SettingConflictError{
+			Setting: "fiat-display-decimals",
+			Other:   "currency",
+			Reason:  fmt.Sprintf("%s has no decimals", str),
+		}
+	}
+	return nil
+}
+
+func currencyDecimalsRule(value interface{}, current func(string) (interface{}, error)) error {
+	decimals, err := current("fiat-display-decimals")
+	if err != nil {
+		return err
+	}
+	if str, ok := decimalsNotAllowed(value, decimals); ok {
+		return &SettingConflictError{
+			Setting: "currency",
+			Other:   "fiat-display-decimals",
+			Reason:  fmt.Sprintf("%s has no decimals", str),
+		}
+	}
+	return nil
+}
+
+// historyWithoutArchiving returns true if the history would be fetched on startup
+// while messages aren't archived, which leaves nowhere to store it.
+func historyWithoutArchiving(archiving interface{}, fetchHistory interface{}) bool {
+	return archiving == false && fetchHistory == true
+}
+
+func messageArchivingRule(value interface{}, current func(string) (interface{}, error)) error {
+	fetchHistory, err := current("fetch-history-on-startup?")
+	if err != nil {
+		return err
+	}
+	if historyWithoutArchiving(value, fetchHistory) {
+		return &SettingConflictError{
+			Setting: "message-archiving-enabled?",
+			Other:   "fetch-history-on-startup?",
+			Reason:  "history fetched on startup requires message archiving",
+		}
+	}
+	return nil
+}
+
+func fetchHistoryOnStartupRule(value interface{}, current func(string) (interface{}, error)) error {
+	archiving, err := current("message-archiving-enabled?")
+	if err != nil {
+		return err
+	}
+	if historyWithoutArchiving(archiving, value) {
+		return &SettingConflictError{
+			Setting: "fetch-history-on-startup?",
+			Other:   "message-archiving-enabled?",
+			Reason:  "history fetched on startup requires message archiving",
+		}
+	}
+	return nil
+}
+
+// lightClientWithoutStoreNode returns true if waku v2 runs as a light client
+// without a way to find a store node.
+func lightClientWithoutStoreNode(nodeConfig *params.NodeConfig, fleet interface{}) bool {
+	if nodeConfig == nil || !nodeConfig.WakuV2Config.Enabled || !nodeConfig.WakuV2Config.LightClient {
+		return false
+	}
+	if len(nodeConfig.ClusterConfig.StoreNodes) > 0 || nodeConfig.ClusterConfig.Fleet != "" {
+		return false
+	}
+	str, _ := fleet.(string)
+	return str == ""
+}
+
+func nodeConfigLightClientRule(value interface{}, current func(string) (interface{}, error)) error {
+	fleet, err := current("fleet")
+	if err != nil {
+		return err
+	}
+	nodeConfig, _ := value.(*params.NodeConfig)
+	if lightClientWithoutStoreNode(nodeConfig, fleet) {
+		return &SettingConflictError{
+			Setting: "node-config",
+			Other:   "fleet",
+			Reason:  "waku light client requires a store node or a fleet",
+		}
+	}
+	return nil
+}
+
+func fleetRule(value interface{}, current func(string) (interface{}, error)) error {
+	nodeConfig, err := current("node-config")
+	if err != nil {
+		return err
+	}
+	config, _ := nodeConfig.(*params.NodeConfig)
+	if lightClientWithoutStoreNode(config, value) {
+		return &SettingConflictError{
+			Setting: "fleet",
+			Other:   "node-config",
+			Reason:  "waku light client requires a store node or a fleet",
+		}
+	}
+	return nil
+}
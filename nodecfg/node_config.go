@@ -713,6 +713,11 @@ func MigrateNodeConfig(db *sql.DB) error {
 	return nil
 }
 
+// GetNodeConfigWithTx loads the node config within an existing transaction.
+func GetNodeConfigWithTx(tx *sql.Tx) (*params.NodeConfig, error) {
+	return loadNodeConfig(tx)
+}
+
 func GetNodeConfig(db *sql.DB) (*params.NodeConfig, error) {
 	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{})
 	if err != nil {
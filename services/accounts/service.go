@@ -12,15 +12,22 @@ import (
 
 // NewService initializes service instance.
 func NewService(db *accounts.Database, mdb *multiaccounts.Database, manager *account.Manager, feed *event.Feed) *Service {
-	return &Service{db, mdb, manager, feed}
+	return &Service{db: db, mdb: mdb, manager: manager, feed: feed}
 }
 
 // Service is a browsers service.
 type Service struct {
-	db      *accounts.Database
-	mdb     *multiaccounts.Database
-	manager *account.Manager
-	feed    *event.Feed
+	db        *accounts.Database
+	mdb       *multiaccounts.Database
+	manager   *account.Manager
+	feed      *event.Feed
+	urlProbes bool
+}
+
+// EnableURLProbes makes the settings API check that the server URLs saved by
+// the client are reachable. It must be called before the APIs are registered.
+func (s *Service) EnableURLProbes(enabled bool) {
+	s.urlProbes = enabled
 }
 
 // Start a service.
@@ -39,7 +46,7 @@ func (s *Service) APIs() []rpc.API {
 		{
 			Namespace: "settings",
 			Version:   "0.1.0",
-			Service:   NewSettingsAPI(s.db),
+			Service:   NewSettingsAPI(s.db, s.urlProbes),
 		},
 		{
 			Namespace: "accounts",
@@ -8,13 +8,21 @@ import (
 	"github.com/status-im/status-go/params"
 )
 
-func NewSettingsAPI(db *accounts.Database) *SettingsAPI {
-	return &SettingsAPI{db}
+// NewSettingsAPI returns the settings API. With probeURLs, the server URLs saved
+// by the client are checked to be reachable first.
+func NewSettingsAPI(db *accounts.Database, probeURLs bool) *SettingsAPI {
+	api := &SettingsAPI{db: db}
+	if probeURLs {
+		api.probe = accounts.ProbeURL
+	}
+	return api
 }
 
 // SettingsAPI is class with methods available over RPC.
 type SettingsAPI struct {
 	db *accounts.Database
+	// probe checks the server URLs saved by the client, nil when disabled.
+	probe func(url string) error
 }
 
 func (api *SettingsAPI) SaveSetting(ctx context.Context, typ string, val interface{}) error {
@@ -23,13 +31,36 @@ func (api *SettingsAPI) SaveSetting(ctx context.Context, typ string, val interfa
 		return nil
 	}
 
+	if api.probe != nil {
+		if err := accounts.ProbeSettings(map[string]interface{}{typ: val}, api.probe); err != nil {
+			return err
+		}
+	}
+	return api.db.SaveSetting(typ, val)
+}
+
+// SaveSettingForce stores the setting even if its server URL isn't reachable.
+func (api *SettingsAPI) SaveSettingForce(ctx context.Context, typ string, val interface{}) error {
 	return api.db.SaveSetting(typ, val)
 }
 
+// DescribeSettings lists the settings supported by this build.
+func (api *SettingsAPI) DescribeSettings(ctx context.Context) []accounts.SettingDescriptor {
+	return accounts.DescribeSettings()
+}
+
 func (api *SettingsAPI) GetSettings(ctx context.Context) (accounts.Settings, error) {
 	return api.db.GetSettings()
 }
 
+func (api *SettingsAPI) GetNotificationSettings(ctx context.Context) (accounts.NotificationSettings, error) {
+	return api.db.GetNotificationSettings()
+}
+
+func (api *SettingsAPI) UpdateNotificationSettings(ctx context.Context, patch map[string]interface{}) error {
+	return api.db.UpdateNotificationSettings(patch)
+}
+
 func (api *SettingsAPI) NodeConfig(ctx context.Context) (*params.NodeConfig, error) {
 	return nodecfg.GetNodeConfig(api.db.DB())
 }
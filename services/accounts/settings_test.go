@@ -0,0 +1,87 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/appdatabase"
+	"github.com/status-im/status-go/multiaccounts/accounts"
+	"github.com/status-im/status-go/params"
+)
+
+func setupTestSettingsAPI(t *testing.T) (*SettingsAPI, func()) {
+	tmpfile, err := ioutil.TempFile("", "settings-api-tests-")
+	require.NoError(t, err)
+	db, err := appdatabase.InitializeDB(tmpfile.Name(), "settings-api-tests")
+	require.NoError(t, err)
+	accountsDB := accounts.NewDB(db)
+	networks := json.RawMessage("{}")
+	require.NoError(t, accountsDB.CreateSettings(accounts.Settings{Networks: &networks}, params.NodeConfig{NetworkID: 10, DataDir: "test"}))
+
+	return NewSettingsAPI(accountsDB, false), func() {
+		require.NoError(t, db.Close())
+		require.NoError(t, os.Remove(tmpfile.Name()))
+	}
+}
+
+func TestSettingsAPIProbesServerURLs(t *testing.T) {
+	api, stop := setupTestSettingsAPI(t)
+	defer stop()
+
+	var probed []string
+	unreachable := errors.New("unreachable")
+	api.probe = func(url string) error {
+		probed = append(probed, url)
+		return unreachable
+	}
+
+	ctx := context.Background()
+	for _, setting := range []string{"telemetry-server-url", "push-notifications-server-url"} {
+		err := api.SaveSetting(ctx, setting, "https://Status.im")
+		var probeErr *accounts.ProbeError
+		require.True(t, errors.As(err, &probeErr))
+		require.True(t, errors.Is(err, unreachable))
+		require.Equal(t, setting, probeErr.Setting)
+	}
+	require.Equal(t, []string{"https://status.im", "https://status.im"}, probed)
+
+	s, err := api.GetSettings(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "", s.TelemetryServerURL)
+	require.Equal(t, "", s.PushNotificationsServerURL)
+
+	// Forced saves, resets and syncs don't probe
+	require.NoError(t, api.SaveSettingForce(ctx, "push-notifications-server-url", "https://Status.im"))
+	require.NoError(t, api.db.ResetSettings([]string{"telemetry-server-url"}))
+	clock := uint64(time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond))
+	require.NoError(t, api.db.ApplySyncedSettings(map[string]interface{}{"telemetry-server-url": "https://status.im"}, clock))
+	require.Len(t, probed, 2)
+
+	s, err = api.GetSettings(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "https://status.im", s.TelemetryServerURL)
+	require.Equal(t, "https://status.im", s.PushNotificationsServerURL)
+
+	// Other settings are saved without probe
+	require.NoError(t, api.SaveSetting(ctx, "currency", "eur"))
+	require.Len(t, probed, 2)
+}
+
+func TestSettingsAPIWithoutProbes(t *testing.T) {
+	api, stop := setupTestSettingsAPI(t)
+	defer stop()
+
+	require.Nil(t, api.probe)
+	require.NotNil(t, NewSettingsAPI(api.db, true).probe)
+
+	ctx := context.Background()
+	require.NoError(t, api.SaveSetting(ctx, "push-notifications-server-url", "https://unreachable.invalid"))
+	require.Equal(t, accounts.ErrInvalidURL, api.SaveSetting(ctx, "push-notifications-server-url", "http://status.im"))
+}
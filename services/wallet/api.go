@@ -62,6 +62,11 @@ func (api *API) GetCachedBalancesbyChainID(ctx context.Context, chainID uint64,
 	return api.s.transferController.GetCachedBalances(ctx, chainID, addresses)
 }
 
+// FormatFiat returns a fiat amount formatted with the currency and display decimals of the settings.
+func (api *API) FormatFiat(ctx context.Context, amount float64) (string, error) {
+	return api.s.fiatFormatter.Format(amount)
+}
+
 // GetTokensBalances return mapping of token balances for every account.
 func (api *API) GetTokensBalances(ctx context.Context, accounts, addresses []common.Address) (map[common.Address]map[common.Address]*hexutil.Big, error) {
 	chainClient, err := chain.NewLegacyClient(api.s.rpcClient)
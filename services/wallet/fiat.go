@@ -0,0 +1,118 @@
+package wallet
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/status-im/status-go/multiaccounts/accounts"
+)
+
+// maxFormattedFiatAmounts bounds the cache of formatted amounts, which is
+// cleared once full.
+const maxFormattedFiatAmounts = 1000
+
+// fiatSettings are the settings fiat amounts are formatted with.
+var fiatSettings = map[string]bool{
+	"currency":              true,
+	"fiat-display-decimals": true,
+}
+
+// FiatFormatter formats fiat amounts in the currency and with the decimals of
+// the settings. Once started, formatted amounts are cached until one of these
+// settings changes.
+type FiatFormatter struct {
+	accountsDB *accounts.Database
+
+	mu        sync.Mutex
+	formatted map[float64]string
+	// generation is incremented on each invalidation, so that amounts formatted
+	// with the previous settings are not cached
+	generation  uint64
+	unsubscribe func()
+	done        chan struct{}
+}
+
+func NewFiatFormatter(accountsDB *accounts.Database) *FiatFormatter {
+	return &FiatFormatter{
+		accountsDB: accountsDB,
+		formatted:  make(map[float64]string),
+	}
+}
+
+// Start subscribes to the setting changes invalidating the formatted amounts.
+func (f *FiatFormatter) Start() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.unsubscribe != nil {
+		return
+	}
+
+	changes, unsubscribe := f.accountsDB.SubscribeToSettingChanges()
+	f.unsubscribe = unsubscribe
+	f.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		for change := range changes {
+			if fiatSettings[change.Setting] {
+				f.invalidate()
+			}
+		}
+	}(f.done)
+}
+
+// Stop unsubscribes from the setting changes and clears the formatted amounts.
+func (f *FiatFormatter) Stop() {
+	f.mu.Lock()
+	unsubscribe, done := f.unsubscribe, f.done
+	f.unsubscribe, f.done = nil, nil
+	f.mu.Unlock()
+	if unsubscribe == nil {
+		return
+	}
+
+	unsubscribe()
+	<-done
+	f.invalidate()
+}
+
+func (f *FiatFormatter) invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.generation++
+	f.formatted = make(map[float64]string)
+}
+
+// Format returns the amount with the display decimals, followed by the currency code.
+func (f *FiatFormatter) Format(amount float64) (string, error) {
+	f.mu.Lock()
+	if formatted, ok := f.formatted[amount]; ok {
+		f.mu.Unlock()
+		return formatted, nil
+	}
+	generation := f.generation
+	f.mu.Unlock()
+
+	decimals, err := f.accountsDB.GetFiatDisplayDecimals()
+	if err != nil {
+		return "", err
+	}
+	currency, ok, err := f.accountsDB.GetNullableString("currency")
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		currency = "usd"
+	}
+	formatted := strconv.FormatFloat(amount, 'f', int(decimals), 64) + " " + strings.ToUpper(currency)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.unsubscribe != nil && f.generation == generation {
+		if len(f.formatted) >= maxFormattedFiatAmounts {
+			f.formatted = make(map[float64]string)
+		}
+		f.formatted[amount] = formatted
+	}
+	return formatted, nil
+}
@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/appdatabase"
+	"github.com/status-im/status-go/multiaccounts/accounts"
+	"github.com/status-im/status-go/params"
+)
+
+func setupTestFiatDB(t *testing.T) (*accounts.Database, func()) {
+	tmpfile, err := ioutil.TempFile("", "wallet-fiat-tests-")
+	require.NoError(t, err)
+	db, err := appdatabase.InitializeDB(tmpfile.Name(), "wallet-fiat-tests")
+	require.NoError(t, err)
+	accountsDB := accounts.NewDB(db)
+	networks := json.RawMessage("{}")
+	require.NoError(t, accountsDB.CreateSettings(accounts.Settings{
+		Currency:            "usd",
+		Networks:            &networks,
+		FiatDisplayDecimals: 2,
+	}, params.NodeConfig{NetworkID: 10, DataDir: "test"}))
+	return accountsDB, func() {
+		require.NoError(t, db.Close())
+		require.NoError(t, os.Remove(tmpfile.Name()))
+	}
+}
+
+func TestFiatFormatter(t *testing.T) {
+	accountsDB, stop := setupTestFiatDB(t)
+	defer stop()
+
+	f := NewFiatFormatter(accountsDB)
+	f.Start()
+	defer f.Stop()
+
+	formatted, err := f.Format(12.345)
+	require.NoError(t, err)
+	require.Equal(t, "12.35 USD", formatted)
+
+	// Settings saved through another instance invalidate the cache
+	other := accounts.NewDB(accountsDB.DB())
+	require.NoError(t, other.SaveSetting("fiat-display-decimals", 3))
+	require.Eventually(t, func() bool {
+		formatted, err := f.Format(12.345)
+		return err == nil && formatted == "12.345 USD"
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, other.SaveSettings(map[string]interface{}{
+		"currency":              "jpy",
+		"fiat-display-decimals": 0,
+	}))
+	require.Eventually(t, func() bool {
+		formatted, err := f.Format(12.345)
+		return err == nil && formatted == "12 JPY"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestFiatFormatterStopped(t *testing.T) {
+	accountsDB, stop := setupTestFiatDB(t)
+	defer stop()
+
+	f := NewFiatFormatter(accountsDB)
+	f.Start()
+	f.Stop()
+	f.Stop()
+
+	// Nothing is cached without subscription, so changes apply right away
+	formatted, err := f.Format(1)
+	require.NoError(t, err)
+	require.Equal(t, "1.00 USD", formatted)
+	require.NoError(t, accountsDB.SaveSetting("currency", "eur"))
+	formatted, err = f.Format(1)
+	require.NoError(t, err)
+	require.Equal(t, "1.00 EUR", formatted)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
 
+	"github.com/status-im/status-go/multiaccounts/accounts"
 	"github.com/status-im/status-go/rpc"
 	"github.com/status-im/status-go/services/wallet/transfer"
 )
@@ -22,6 +23,7 @@ func NewService(db *sql.DB, rpcClient *rpc.Client, accountFeed *event.Feed) *Ser
 	transactionManager := &TransactionManager{db: db}
 	favouriteManager := &FavouriteManager{db: db}
 	transferController := transfer.NewTransferController(db, rpcClient, accountFeed)
+	fiatFormatter := NewFiatFormatter(accounts.NewDB(db))
 
 	return &Service{
 		rpcClient:             rpcClient,
@@ -31,6 +33,7 @@ func NewService(db *sql.DB, rpcClient *rpc.Client, accountFeed *event.Feed) *Ser
 		transactionManager:    transactionManager,
 		transferController:    transferController,
 		cryptoOnRampManager:   cryptoOnRampManager,
+		fiatFormatter:         fiatFormatter,
 	}
 }
 
@@ -43,12 +46,14 @@ type Service struct {
 	favouriteManager      *FavouriteManager
 	cryptoOnRampManager   *CryptoOnRampManager
 	transferController    *transfer.Controller
+	fiatFormatter         *FiatFormatter
 	started               bool
 }
 
 // Start signals transmitter.
 func (s *Service) Start() error {
 	err := s.transferController.Start()
+	s.fiatFormatter.Start()
 	s.started = true
 	return err
 }
@@ -62,6 +67,7 @@ func (s *Service) GetFeed() *event.Feed {
 func (s *Service) Stop() error {
 	log.Info("wallet will be stopped")
 	s.transferController.Stop()
+	s.fiatFormatter.Stop()
 	s.started = false
 	log.Info("wallet stopped")
 	return nil
@@ -0,0 +1,16 @@
+module github.com/status-im/go-waku-rendezvous
+
+go 1.15
+
+require (
+	github.com/gogo/protobuf v1.3.2
+	github.com/golang/protobuf v1.3.3
+	github.com/ipfs/go-log/v2 v2.0.5
+	github.com/kr/pretty v0.2.0 // indirect
+	github.com/libp2p/go-libp2p-core v0.8.5
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/multiformats/go-multiaddr v0.3.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/stretchr/testify v1.6.1
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+)
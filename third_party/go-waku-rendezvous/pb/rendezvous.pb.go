@@ -0,0 +1,1659 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: rendezvous.proto
+
+package rendezvous_pb
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Message_MessageType int32
+
+const (
+	Message_REGISTER          Message_MessageType = 0
+	Message_REGISTER_RESPONSE Message_MessageType = 1
+	Message_DISCOVER          Message_MessageType = 2
+	Message_DISCOVER_RESPONSE Message_MessageType = 3
+)
+
+var Message_MessageType_name = map[int32]string{
+	0: "REGISTER",
+	1: "REGISTER_RESPONSE",
+	2: "DISCOVER",
+	3: "DISCOVER_RESPONSE",
+}
+
+var Message_MessageType_value = map[string]int32{
+	"REGISTER":          0,
+	"REGISTER_RESPONSE": 1,
+	"DISCOVER":          2,
+	"DISCOVER_RESPONSE": 3,
+}
+
+func (x Message_MessageType) String() string {
+	return proto.EnumName(Message_MessageType_name, int32(x))
+}
+
+func (Message_MessageType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ef0a1d5737df1c36, []int{0, 0}
+}
+
+type Message_ResponseStatus int32
+
+const (
+	Message_OK                  Message_ResponseStatus = 0
+	Message_E_INVALID_NAMESPACE Message_ResponseStatus = 100
+	Message_E_INVALID_PEER_INFO Message_ResponseStatus = 101
+	Message_E_INVALID_TTL       Message_ResponseStatus = 102
+	Message_E_NOT_AUTHORIZED    Message_ResponseStatus = 200
+	Message_E_INTERNAL_ERROR    Message_ResponseStatus = 300
+	Message_E_UNAVAILABLE       Message_ResponseStatus = 400
+)
+
+var Message_ResponseStatus_name = map[int32]string{
+	0:   "OK",
+	100: "E_INVALID_NAMESPACE",
+	101: "E_INVALID_PEER_INFO",
+	102: "E_INVALID_TTL",
+	200: "E_NOT_AUTHORIZED",
+	300: "E_INTERNAL_ERROR",
+	400: "E_UNAVAILABLE",
+}
+
+var Message_ResponseStatus_value = map[string]int32{
+	"OK":                  0,
+	"E_INVALID_NAMESPACE": 100,
+	"E_INVALID_PEER_INFO": 101,
+	"E_INVALID_TTL":       102,
+	"E_NOT_AUTHORIZED":    200,
+	"E_INTERNAL_ERROR":    300,
+	"E_UNAVAILABLE":       400,
+}
+
+func (x Message_ResponseStatus) String() string {
+	return proto.EnumName(Message_ResponseStatus_name, int32(x))
+}
+
+func (Message_ResponseStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ef0a1d5737df1c36, []int{0, 1}
+}
+
+type Message struct {
+	Type                 Message_MessageType       `protobuf:"varint,1,opt,name=type,proto3,enum=rendezvous.pb.Message_MessageType" json:"type,omitempty"`
+	Register             *Message_Register         `protobuf:"bytes,2,opt,name=register,proto3" json:"register,omitempty"`
+	RegisterResponse     *Message_RegisterResponse `protobuf:"bytes,3,opt,name=registerResponse,proto3" json:"registerResponse,omitempty"`
+	Discover             *Message_Discover         `protobuf:"bytes,4,opt,name=discover,proto3" json:"discover,omitempty"`
+	DiscoverResponse     *Message_DiscoverResponse `protobuf:"bytes,5,opt,name=discoverResponse,proto3" json:"discoverResponse,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *Message) Reset()         { *m = Message{} }
+func (m *Message) String() string { return proto.CompactTextString(m) }
+func (*Message) ProtoMessage()    {}
+func (*Message) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ef0a1d5737df1c36, []int{0}
+}
+func (m *Message) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Message) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Message.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Message) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Message.Merge(m, src)
+}
+func (m *Message) XXX_Size() int {
+	return m.Size()
+}
+func (m *Message) XXX_DiscardUnknown() {
+	xxx_messageInfo_Message.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Message proto.InternalMessageInfo
+
+func (m *Message) GetType() Message_MessageType {
+	if m != nil {
+		return m.Type
+	}
+	return Message_REGISTER
+}
+
+func (m *Message) GetRegister() *Message_Register {
+	if m != nil {
+		return m.Register
+	}
+	return nil
+}
+
+func (m *Message) GetRegisterResponse() *Message_RegisterResponse {
+	if m != nil {
+		return m.RegisterResponse
+	}
+	return nil
+}
+
+func (m *Message) GetDiscover() *Message_Discover {
+	if m != nil {
+		return m.Discover
+	}
+	return nil
+}
+
+func (m *Message) GetDiscoverResponse() *Message_DiscoverResponse {
+	if m != nil {
+		return m.DiscoverResponse
+	}
+	return nil
+}
+
+type Message_Register struct {
+	Ns                   string   `protobuf:"bytes,1,opt,name=ns,proto3" json:"ns,omitempty"`
+	SignedPeerRecord     []byte   `protobuf:"bytes,2,opt,name=signedPeerRecord,proto3" json:"signedPeerRecord,omitempty"`
+	Ttl                  int64    `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Message_Register) Reset()         { *m = Message_Register{} }
+func (m *Message_Register) String() string { return proto.CompactTextString(m) }
+func (*Message_Register) ProtoMessage()    {}
+func (*Message_Register) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ef0a1d5737df1c36, []int{0, 0}
+}
+func (m *Message_Register) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Message_Register) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Message_Register.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Message_Register) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Message_Register.Merge(m, src)
+}
+func (m *Message_Register) XXX_Size() int {
+	return m.Size()
+}
+func (m *Message_Register) XXX_DiscardUnknown() {
+	xxx_messageInfo_Message_Register.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Message_Register proto.InternalMessageInfo
+
+func (m *Message_Register) GetNs() string {
+	if m != nil {
+		return m.Ns
+	}
+	return ""
+}
+
+func (m *Message_Register) GetSignedPeerRecord() []byte {
+	if m != nil {
+		return m.SignedPeerRecord
+	}
+	return nil
+}
+
+func (m *Message_Register) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type Message_RegisterResponse struct {
+	Status               Message_ResponseStatus `protobuf:"varint,1,opt,name=status,proto3,enum=rendezvous.pb.Message_ResponseStatus" json:"status,omitempty"`
+	StatusText           string                 `protobuf:"bytes,2,opt,name=statusText,proto3" json:"statusText,omitempty"`
+	Ttl                  int64                  `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *Message_RegisterResponse) Reset()         { *m = Message_RegisterResponse{} }
+func (m *Message_RegisterResponse) String() string { return proto.CompactTextString(m) }
+func (*Message_RegisterResponse) ProtoMessage()    {}
+func (*Message_RegisterResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ef0a1d5737df1c36, []int{0, 1}
+}
+func (m *Message_RegisterResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Message_RegisterResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Message_RegisterResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Message_RegisterResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Message_RegisterResponse.Merge(m, src)
+}
+func (m *Message_RegisterResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *Message_RegisterResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_Message_RegisterResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Message_RegisterResponse proto.InternalMessageInfo
+
+func (m *Message_RegisterResponse) GetStatus() Message_ResponseStatus {
+	if m != nil {
+		return m.Status
+	}
+	return Message_OK
+}
+
+func (m *Message_RegisterResponse) GetStatusText() string {
+	if m != nil {
+		return m.StatusText
+	}
+	return ""
+}
+
+func (m *Message_RegisterResponse) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type Message_Discover struct {
+	Ns                   string   `protobuf:"bytes,1,opt,name=ns,proto3" json:"ns,omitempty"`
+	Limit                int64    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Message_Discover) Reset()         { *m = Message_Discover{} }
+func (m *Message_Discover) String() string { return proto.CompactTextString(m) }
+func (*Message_Discover) ProtoMessage()    {}
+func (*Message_Discover) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ef0a1d5737df1c36, []int{0, 2}
+}
+func (m *Message_Discover) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Message_Discover) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Message_Discover.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Message_Discover) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Message_Discover.Merge(m, src)
+}
+func (m *Message_Discover) XXX_Size() int {
+	return m.Size()
+}
+func (m *Message_Discover) XXX_DiscardUnknown() {
+	xxx_messageInfo_Message_Discover.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Message_Discover proto.InternalMessageInfo
+
+func (m *Message_Discover) GetNs() string {
+	if m != nil {
+		return m.Ns
+	}
+	return ""
+}
+
+func (m *Message_Discover) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type Message_DiscoverResponse struct {
+	Registrations        []*Message_Register    `protobuf:"bytes,1,rep,name=registrations,proto3" json:"registrations,omitempty"`
+	Status               Message_ResponseStatus `protobuf:"varint,3,opt,name=status,proto3,enum=rendezvous.pb.Message_ResponseStatus" json:"status,omitempty"`
+	StatusText           string                 `protobuf:"bytes,4,opt,name=statusText,proto3" json:"statusText,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *Message_DiscoverResponse) Reset()         { *m = Message_DiscoverResponse{} }
+func (m *Message_DiscoverResponse) String() string { return proto.CompactTextString(m) }
+func (*Message_DiscoverResponse) ProtoMessage()    {}
+func (*Message_DiscoverResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ef0a1d5737df1c36, []int{0, 3}
+}
+func (m *Message_DiscoverResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Message_DiscoverResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Message_DiscoverResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Message_DiscoverResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Message_DiscoverResponse.Merge(m, src)
+}
+func (m *Message_DiscoverResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *Message_DiscoverResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_Message_DiscoverResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Message_DiscoverResponse proto.InternalMessageInfo
+
+func (m *Message_DiscoverResponse) GetRegistrations() []*Message_Register {
+	if m != nil {
+		return m.Registrations
+	}
+	return nil
+}
+
+func (m *Message_DiscoverResponse) GetStatus() Message_ResponseStatus {
+	if m != nil {
+		return m.Status
+	}
+	return Message_OK
+}
+
+func (m *Message_DiscoverResponse) GetStatusText() string {
+	if m != nil {
+		return m.StatusText
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterEnum("rendezvous.pb.Message_MessageType", Message_MessageType_name, Message_MessageType_value)
+	proto.RegisterEnum("rendezvous.pb.Message_ResponseStatus", Message_ResponseStatus_name, Message_ResponseStatus_value)
+	proto.RegisterType((*Message)(nil), "rendezvous.pb.Message")
+	proto.RegisterType((*Message_Register)(nil), "rendezvous.pb.Message.Register")
+	proto.RegisterType((*Message_RegisterResponse)(nil), "rendezvous.pb.Message.RegisterResponse")
+	proto.RegisterType((*Message_Discover)(nil), "rendezvous.pb.Message.Discover")
+	proto.RegisterType((*Message_DiscoverResponse)(nil), "rendezvous.pb.Message.DiscoverResponse")
+}
+
+func init() { proto.RegisterFile("rendezvous.proto", fileDescriptor_ef0a1d5737df1c36) }
+
+var fileDescriptor_ef0a1d5737df1c36 = []byte{
+	// 528 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xcd, 0x6e, 0xd3, 0x4c,
+	0x14, 0xcd, 0xd8, 0x69, 0xbe, 0xf4, 0xb6, 0x89, 0xa6, 0xf3, 0x51, 0x11, 0x65, 0x61, 0xaa, 0x48,
+	0x88, 0x8a, 0x45, 0x84, 0x8a, 0xc4, 0x06, 0xb1, 0x70, 0xeb, 0x01, 0x2c, 0x5c, 0x3b, 0xba, 0x76,
+	0x03, 0x62, 0x63, 0xa5, 0xf5, 0x10, 0x59, 0x2a, 0x76, 0xe4, 0x71, 0x2b, 0xca, 0x96, 0x17, 0x60,
+	0xc9, 0x8e, 0x17, 0x80, 0x3d, 0x8f, 0xd0, 0x25, 0x8f, 0x80, 0xc2, 0x8b, 0x20, 0xff, 0xa5, 0xf9,
+	0xa1, 0x05, 0x89, 0x95, 0xef, 0xbd, 0x73, 0xce, 0xd1, 0xb9, 0x67, 0x6c, 0x03, 0x4d, 0x44, 0x14,
+	0x88, 0xf7, 0xe7, 0xf1, 0x99, 0xec, 0x4f, 0x92, 0x38, 0x8d, 0x59, 0x6b, 0x7e, 0x72, 0xdc, 0xfb,
+	0xda, 0x84, 0xff, 0x0e, 0x85, 0x94, 0xa3, 0xb1, 0x60, 0x8f, 0xa0, 0x9e, 0x5e, 0x4c, 0x44, 0x87,
+	0xec, 0x90, 0xdd, 0xf6, 0x5e, 0xaf, 0xbf, 0x80, 0xec, 0x97, 0xa8, 0xea, 0xe9, 0x5d, 0x4c, 0x04,
+	0xe6, 0x78, 0xf6, 0x18, 0x9a, 0x89, 0x18, 0x87, 0x32, 0x15, 0x49, 0x47, 0xd9, 0x21, 0xbb, 0x1b,
+	0x7b, 0x77, 0xae, 0xe1, 0x62, 0x09, 0xc3, 0x19, 0x81, 0xb9, 0x99, 0xc7, 0x72, 0x2a, 0xe4, 0x24,
+	0x8e, 0xa4, 0xe8, 0xa8, 0xb9, 0xc8, 0xbd, 0x3f, 0x89, 0x94, 0x70, 0x5c, 0x11, 0xc8, 0x1c, 0x05,
+	0xa1, 0x3c, 0x89, 0xcf, 0x45, 0xd2, 0xa9, 0xdf, 0xe8, 0xc8, 0x28, 0x61, 0x38, 0x23, 0x64, 0x8e,
+	0xaa, 0x7a, 0xe6, 0x68, 0xed, 0x46, 0x47, 0xc6, 0x12, 0x1c, 0x57, 0x04, 0xba, 0xaf, 0xa0, 0x59,
+	0xf9, 0x66, 0x6d, 0x50, 0x22, 0x99, 0xa7, 0xbc, 0x8e, 0x4a, 0x24, 0xd9, 0x7d, 0xa0, 0x32, 0x1c,
+	0x47, 0x22, 0x18, 0x88, 0x8c, 0x71, 0x12, 0x27, 0x41, 0x9e, 0xe3, 0x26, 0xae, 0xcc, 0x19, 0x05,
+	0x35, 0x4d, 0x4f, 0xf3, 0x84, 0x54, 0xcc, 0xca, 0xee, 0x07, 0x02, 0x74, 0x39, 0x12, 0xf6, 0x04,
+	0x1a, 0x32, 0x1d, 0xa5, 0x67, 0xb2, 0xbc, 0xcc, 0xbb, 0xd7, 0x66, 0x59, 0x10, 0xdc, 0x1c, 0x8c,
+	0x25, 0x89, 0x69, 0x00, 0x45, 0xe5, 0x89, 0x77, 0x69, 0xee, 0x65, 0x1d, 0xe7, 0x26, 0xbf, 0x71,
+	0xf1, 0x00, 0x9a, 0x55, 0x0a, 0x2b, 0xfb, 0xdd, 0x82, 0xb5, 0xd3, 0xf0, 0x6d, 0x58, 0x08, 0xa9,
+	0x58, 0x34, 0xdd, 0x6f, 0x04, 0xe8, 0x72, 0x70, 0x8c, 0x43, 0xab, 0xb8, 0xcc, 0x64, 0x94, 0x86,
+	0x71, 0xae, 0xa2, 0xfe, 0xcd, 0xfb, 0xb4, 0xc8, 0x9a, 0x5b, 0x5f, 0xfd, 0xf7, 0xf5, 0xeb, 0xcb,
+	0xeb, 0xf7, 0x5e, 0xc2, 0xc6, 0xdc, 0x57, 0xc0, 0x36, 0xa1, 0x89, 0xfc, 0x99, 0xe9, 0x7a, 0x1c,
+	0x69, 0x8d, 0x6d, 0xc3, 0x56, 0xd5, 0xf9, 0xc8, 0xdd, 0x81, 0x63, 0xbb, 0x9c, 0x92, 0x0c, 0x64,
+	0x98, 0xee, 0x81, 0x33, 0xe4, 0x48, 0x95, 0x0c, 0x54, 0x75, 0x57, 0x20, 0xb5, 0xf7, 0x99, 0x40,
+	0x7b, 0xd1, 0x13, 0x6b, 0x80, 0xe2, 0xbc, 0xa0, 0x35, 0x76, 0x1b, 0xfe, 0xe7, 0xbe, 0x69, 0x0f,
+	0x75, 0xcb, 0x34, 0x7c, 0x5b, 0x3f, 0xe4, 0xee, 0x40, 0x3f, 0xe0, 0x34, 0x58, 0x3c, 0x18, 0x70,
+	0x8e, 0xbe, 0x69, 0x3f, 0x75, 0xa8, 0x60, 0x5b, 0xd0, 0xba, 0x3a, 0xf0, 0x3c, 0x8b, 0xbe, 0x61,
+	0xdb, 0x40, 0xb9, 0x6f, 0x3b, 0x9e, 0xaf, 0x1f, 0x79, 0xcf, 0x1d, 0x34, 0x5f, 0x73, 0x83, 0x5e,
+	0x92, 0x62, 0x6c, 0xda, 0x1e, 0x47, 0x5b, 0xb7, 0x7c, 0x8e, 0xe8, 0x20, 0xfd, 0xa2, 0x30, 0x96,
+	0x09, 0x1c, 0xd9, 0xfa, 0x50, 0x37, 0x2d, 0x7d, 0xdf, 0xe2, 0xf4, 0xa3, 0xba, 0x4f, 0x2f, 0xa7,
+	0x1a, 0xf9, 0x3e, 0xd5, 0xc8, 0x8f, 0xa9, 0x46, 0x3e, 0xfd, 0xd4, 0x6a, 0xc7, 0x8d, 0xfc, 0xbf,
+	0xf2, 0xf0, 0x57, 0x00, 0x00, 0x00, 0xff, 0xff, 0x4c, 0x11, 0x6e, 0xa0, 0x6b, 0x04, 0x00, 0x00,
+}
+
+func (m *Message) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Message) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Message) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.DiscoverResponse != nil {
+		{
+			size, err := m.DiscoverResponse.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRendezvous(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Discover != nil {
+		{
+			size, err := m.Discover.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRendezvous(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.RegisterResponse != nil {
+		{
+			size, err := m.RegisterResponse.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRendezvous(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Register != nil {
+		{
+			size, err := m.Register.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRendezvous(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Type != 0 {
+		i = encodeVarintRendezvous(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Message_Register) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Message_Register) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Message_Register) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Ttl != 0 {
+		i = encodeVarintRendezvous(dAtA, i, uint64(m.Ttl))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.SignedPeerRecord) > 0 {
+		i -= len(m.SignedPeerRecord)
+		copy(dAtA[i:], m.SignedPeerRecord)
+		i = encodeVarintRendezvous(dAtA, i, uint64(len(m.SignedPeerRecord)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Ns) > 0 {
+		i -= len(m.Ns)
+		copy(dAtA[i:], m.Ns)
+		i = encodeVarintRendezvous(dAtA, i, uint64(len(m.Ns)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Message_RegisterResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Message_RegisterResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Message_RegisterResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Ttl != 0 {
+		i = encodeVarintRendezvous(dAtA, i, uint64(m.Ttl))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.StatusText) > 0 {
+		i -= len(m.StatusText)
+		copy(dAtA[i:], m.StatusText)
+		i = encodeVarintRendezvous(dAtA, i, uint64(len(m.StatusText)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Status != 0 {
+		i = encodeVarintRendezvous(dAtA, i, uint64(m.Status))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Message_Discover) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Message_Discover) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Message_Discover) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Limit != 0 {
+		i = encodeVarintRendezvous(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Ns) > 0 {
+		i -= len(m.Ns)
+		copy(dAtA[i:], m.Ns)
+		i = encodeVarintRendezvous(dAtA, i, uint64(len(m.Ns)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Message_DiscoverResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Message_DiscoverResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Message_DiscoverResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.StatusText) > 0 {
+		i -= len(m.StatusText)
+		copy(dAtA[i:], m.StatusText)
+		i = encodeVarintRendezvous(dAtA, i, uint64(len(m.StatusText)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Status != 0 {
+		i = encodeVarintRendezvous(dAtA, i, uint64(m.Status))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Registrations) > 0 {
+		for iNdEx := len(m.Registrations) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Registrations[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRendezvous(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintRendezvous(dAtA []byte, offset int, v uint64) int {
+	offset -= sovRendezvous(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *Message) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Type != 0 {
+		n += 1 + sovRendezvous(uint64(m.Type))
+	}
+	if m.Register != nil {
+		l = m.Register.Size()
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	if m.RegisterResponse != nil {
+		l = m.RegisterResponse.Size()
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	if m.Discover != nil {
+		l = m.Discover.Size()
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	if m.DiscoverResponse != nil {
+		l = m.DiscoverResponse.Size()
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Message_Register) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Ns)
+	if l > 0 {
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	l = len(m.SignedPeerRecord)
+	if l > 0 {
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	if m.Ttl != 0 {
+		n += 1 + sovRendezvous(uint64(m.Ttl))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Message_RegisterResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Status != 0 {
+		n += 1 + sovRendezvous(uint64(m.Status))
+	}
+	l = len(m.StatusText)
+	if l > 0 {
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	if m.Ttl != 0 {
+		n += 1 + sovRendezvous(uint64(m.Ttl))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Message_Discover) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Ns)
+	if l > 0 {
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	if m.Limit != 0 {
+		n += 1 + sovRendezvous(uint64(m.Limit))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Message_DiscoverResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Registrations) > 0 {
+		for _, e := range m.Registrations {
+			l = e.Size()
+			n += 1 + l + sovRendezvous(uint64(l))
+		}
+	}
+	if m.Status != 0 {
+		n += 1 + sovRendezvous(uint64(m.Status))
+	}
+	l = len(m.StatusText)
+	if l > 0 {
+		n += 1 + l + sovRendezvous(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovRendezvous(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozRendezvous(x uint64) (n int) {
+	return sovRendezvous(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Message) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRendezvous
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Message: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Message: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= Message_MessageType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Register", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Register == nil {
+				m.Register = &Message_Register{}
+			}
+			if err := m.Register.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RegisterResponse", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RegisterResponse == nil {
+				m.RegisterResponse = &Message_RegisterResponse{}
+			}
+			if err := m.RegisterResponse.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Discover", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Discover == nil {
+				m.Discover = &Message_Discover{}
+			}
+			if err := m.Discover.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DiscoverResponse", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.DiscoverResponse == nil {
+				m.DiscoverResponse = &Message_DiscoverResponse{}
+			}
+			if err := m.DiscoverResponse.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRendezvous(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Message_Register) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRendezvous
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Register: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Register: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ns", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Ns = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SignedPeerRecord", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SignedPeerRecord = append(m.SignedPeerRecord[:0], dAtA[iNdEx:postIndex]...)
+			if m.SignedPeerRecord == nil {
+				m.SignedPeerRecord = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ttl", wireType)
+			}
+			m.Ttl = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Ttl |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRendezvous(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Message_RegisterResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRendezvous
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RegisterResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RegisterResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			m.Status = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Status |= Message_ResponseStatus(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StatusText", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.StatusText = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ttl", wireType)
+			}
+			m.Ttl = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Ttl |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRendezvous(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Message_Discover) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRendezvous
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Discover: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Discover: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ns", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Ns = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRendezvous(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Message_DiscoverResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRendezvous
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DiscoverResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DiscoverResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Registrations", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Registrations = append(m.Registrations, &Message_Register{})
+			if err := m.Registrations[len(m.Registrations)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			m.Status = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Status |= Message_ResponseStatus(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StatusText", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.StatusText = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRendezvous(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRendezvous
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipRendezvous(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowRendezvous
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowRendezvous
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthRendezvous
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupRendezvous
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthRendezvous
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthRendezvous        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowRendezvous          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupRendezvous = fmt.Errorf("proto: unexpected end of group")
+)
@@ -0,0 +1,109 @@
+package rendezvous
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// NewMemoryStorage creates a storage that keeps the registrations in memory.
+// They are lost when the rendezvous point is restarted
+func NewMemoryStorage() Storage {
+	return NewStorage(&memoryDB{records: make(map[string][]byte)})
+}
+
+type memoryDB struct {
+	sync.RWMutex
+	records map[string][]byte
+}
+
+func (m *memoryDB) Put(key []byte, value []byte) error {
+	m.Lock()
+	defer m.Unlock()
+	m.records[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+func (m *memoryDB) Delete(key []byte) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.records, string(key))
+	return nil
+}
+
+func (m *memoryDB) NewIterator(prefix []byte) Iterator {
+	m.RLock()
+	defer m.RUnlock()
+
+	var records []dbRecord
+	for k, v := range m.records {
+		if bytes.HasPrefix([]byte(k), prefix) {
+			records = append(records, dbRecord{key: []byte(k), value: v})
+		}
+	}
+
+	return newSliceIterator(records)
+}
+
+type dbRecord struct {
+	key   []byte
+	value []byte
+}
+
+// sliceIterator iterates over a snapshot of the records, sorted by key
+type sliceIterator struct {
+	records []dbRecord
+	pos     int
+}
+
+func newSliceIterator(records []dbRecord) *sliceIterator {
+	sort.Slice(records, func(i, j int) bool {
+		return bytes.Compare(records[i].key, records[j].key) < 0
+	})
+	return &sliceIterator{records: records, pos: -1}
+}
+
+func (it *sliceIterator) valid() bool {
+	return it.pos >= 0 && it.pos < len(it.records)
+}
+
+func (it *sliceIterator) Release() {
+	it.records = nil
+	it.pos = -1
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.pos < len(it.records) {
+		it.pos++
+	}
+	return it.valid()
+}
+
+func (it *sliceIterator) Prev() bool {
+	if it.pos >= 0 {
+		it.pos--
+	}
+	return it.valid()
+}
+
+// Seek moves to the first record with a key greater or equal than the one received
+func (it *sliceIterator) Seek(key []byte) bool {
+	it.pos = sort.Search(len(it.records), func(i int) bool {
+		return bytes.Compare(it.records[i].key, key) >= 0
+	})
+	return it.valid()
+}
+
+func (it *sliceIterator) Key() []byte {
+	if !it.valid() {
+		return nil
+	}
+	return it.records[it.pos].key
+}
+
+func (it *sliceIterator) Value() []byte {
+	if !it.valid() {
+		return nil
+	}
+	return it.records[it.pos].value
+}
@@ -0,0 +1,78 @@
+package rendezvous
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/gob"
+	"time"
+)
+
+// sqlStorageSchema creates the table of the registrations. Expired rows are
+// removed by the rendezvous service cleaner
+const sqlStorageSchema = `CREATE TABLE IF NOT EXISTS rendezvous_registrations (
+	key BLOB PRIMARY KEY,
+	namespace TEXT NOT NULL,
+	value BLOB NOT NULL,
+	expires INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rendezvous_registrations_expires ON rendezvous_registrations(expires);`
+
+// NewPersistentStorage creates a storage that keeps the registrations in a
+// SQLite database, so they are recovered when the rendezvous point is restarted.
+// The table is created if it does not exist
+func NewPersistentStorage(db *sql.DB) (Storage, error) {
+	if _, err := db.Exec(sqlStorageSchema); err != nil {
+		return Storage{}, err
+	}
+	return NewStorage(&sqlDB{db: db}), nil
+}
+
+type sqlDB struct {
+	db *sql.DB
+}
+
+func (s *sqlDB) Put(key []byte, value []byte) error {
+	var stored RegistrationRecord
+	if err := gob.NewDecoder(bytes.NewBuffer(value)).Decode(&stored); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec("INSERT OR REPLACE INTO rendezvous_registrations (key, namespace, value, expires) VALUES (?, ?, ?, ?)", key, stored.Ns, value, stored.Deadline.UnixNano())
+	return err
+}
+
+func (s *sqlDB) Delete(key []byte) error {
+	_, err := s.db.Exec("DELETE FROM rendezvous_registrations WHERE key = ?", key)
+	return err
+}
+
+// NewIterator returns the registrations that have not expired yet
+func (s *sqlDB) NewIterator(prefix []byte) Iterator {
+	rows, err := s.db.Query("SELECT key, value FROM rendezvous_registrations WHERE substr(key, 1, ?) = ? AND expires > ?", len(prefix), prefix, time.Now().UnixNano())
+	if err != nil {
+		log.Error("could not read rendezvous registrations", err)
+		return newSliceIterator(nil)
+	}
+	defer rows.Close()
+
+	var records []dbRecord
+	for rows.Next() {
+		var r dbRecord
+		if err := rows.Scan(&r.key, &r.value); err != nil {
+			log.Error("could not read rendezvous registration", err)
+			continue
+		}
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("could not read rendezvous registrations", err)
+	}
+
+	return newSliceIterator(records)
+}
+
+func (s *sqlDB) DeleteExpired(now time.Time) error {
+	_, err := s.db.Exec("DELETE FROM rendezvous_registrations WHERE expires <= ?", now.UnixNano())
+	return err
+}
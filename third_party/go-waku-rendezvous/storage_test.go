@@ -0,0 +1,81 @@
+package rendezvous
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // Blank import to register the sqlite3 driver
+	"github.com/stretchr/testify/require"
+)
+
+func newPersistentStorage(t *testing.T) (Storage, *sql.DB) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Each connection to :memory: opens a different database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	s, err := NewPersistentStorage(db)
+	require.NoError(t, err)
+	return s, db
+}
+
+func TestStorage(t *testing.T) {
+	persistent, _ := newPersistentStorage(t)
+
+	for name, s := range map[string]Storage{"memory": NewMemoryStorage(), "sql": persistent} {
+		t.Run(name, func(t *testing.T) {
+			deadline := time.Now().Add(time.Hour)
+			key, err := s.Add("ns1", "peer1", []byte{1}, 3600, deadline)
+			require.NoError(t, err)
+			_, err = s.Add("ns2", "peer2", []byte{2}, 3600, deadline)
+			require.NoError(t, err)
+
+			records, err := s.GetRandom("ns1", 10)
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+			require.Equal(t, []byte{1}, records[0].PeerEnvelope)
+			require.Equal(t, "ns1", records[0].Ns)
+
+			var keys []RecordsKey
+			require.NoError(t, s.IterateAllKeys(func(key RecordsKey, _ time.Time) error {
+				keys = append(keys, key)
+				return nil
+			}))
+			require.Len(t, keys, 2)
+
+			require.NoError(t, s.RemoveByKey(key))
+			records, err = s.GetRandom("ns1", 10)
+			require.NoError(t, err)
+			require.Empty(t, records)
+		})
+	}
+}
+
+func TestPersistentStorageRecovery(t *testing.T) {
+	s, db := newPersistentStorage(t)
+
+	_, err := s.Add("ns", "peer1", []byte{1}, 3600, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, err = s.Add("ns", "peer2", []byte{2}, 1, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+
+	// Expired registrations are not returned, even before they are deleted
+	records, err := s.GetRandom("ns", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	require.NoError(t, s.DeleteExpired(time.Now()))
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM rendezvous_registrations").Scan(&count))
+	require.Equal(t, 1, count)
+
+	// The registrations are recovered by a new storage on the same database
+	s, err = NewPersistentStorage(db)
+	require.NoError(t, err)
+	records, err = s.GetRandom("ns", 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, []byte{1}, records[0].PeerEnvelope)
+}
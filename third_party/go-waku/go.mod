@@ -0,0 +1,41 @@
+module github.com/status-im/go-waku
+
+go 1.15
+
+replace github.com/ethereum/go-ethereum v1.10.4 => github.com/status-im/go-ethereum v1.10.4-status.3
+
+replace github.com/status-im/go-waku-rendezvous => ../go-waku-rendezvous
+
+require (
+	contrib.go.opencensus.io/exporter/prometheus v0.4.0
+	github.com/cruxic/go-hmac-drbg v0.0.0-20170206035330-84c46983886d
+	github.com/ethereum/go-ethereum v1.10.4
+	github.com/gogo/protobuf v1.3.2
+	github.com/golang/protobuf v1.5.2
+	github.com/gorilla/rpc v1.2.0
+	github.com/gorilla/websocket v1.4.2
+	github.com/hashicorp/golang-lru v0.5.5-0.20210104140557-80c98217689d
+	github.com/ipfs/go-ds-sql v0.2.0
+	github.com/ipfs/go-log v1.0.5
+	github.com/jessevdk/go-flags v1.4.0
+	github.com/libp2p/go-libp2p v0.15.1
+	github.com/libp2p/go-libp2p-circuit v0.4.0
+	github.com/libp2p/go-libp2p-connmgr v0.2.4
+	github.com/libp2p/go-libp2p-core v0.9.0
+	github.com/libp2p/go-libp2p-peerstore v0.3.0
+	github.com/libp2p/go-libp2p-pubsub v0.5.5
+	github.com/libp2p/go-libp2p-swarm v0.5.3
+	github.com/libp2p/go-libp2p-transport-upgrader v0.4.6
+	github.com/libp2p/go-msgio v0.0.6
+	github.com/libp2p/go-ws-transport v0.5.0
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/minio/sha256-simd v1.0.0
+	github.com/multiformats/go-multiaddr v0.4.0
+	github.com/multiformats/go-multiaddr-dns v0.3.1
+	github.com/multiformats/go-multiaddr-fmt v0.1.0
+	github.com/status-im/go-waku-rendezvous v0.0.0-20211018070416-a93f3b70c432
+	github.com/stretchr/testify v1.7.0
+	github.com/syndtr/goleveldb v1.0.1-0.20210305035536-64b5b1c73954
+	go.opencensus.io v0.23.0
+	golang.org/x/time v0.0.0-20201208040808-7e3f01d25324
+)
@@ -0,0 +1,155 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// StoredPeer is a peer of the peerstore kept in a database, so it's known
+// again once the node restarts
+type StoredPeer struct {
+	ID        peer.ID
+	Addrs     []ma.Multiaddr
+	Protocols []string
+	// Zero if unknown
+	Latency time.Duration
+	// The peer is deleted once it expires
+	Expiry time.Time
+}
+
+// PeerDB keeps the peers of the peerstore in the peer table of a database
+type PeerDB struct {
+	db *sql.DB
+}
+
+// peerMigrations are applied in order when the PeerDB is created. They must
+// be idempotent, as the database may be shared with other tables
+var peerMigrations = []migration{
+	execMigration(`CREATE TABLE IF NOT EXISTS peer (
+		id BLOB PRIMARY KEY,
+		addrs TEXT NOT NULL,
+		protocols TEXT NOT NULL,
+		latency INTEGER NOT NULL DEFAULT 0,
+		expiry INTEGER NOT NULL
+	) WITHOUT ROWID;`),
+	execMigration(`CREATE INDEX IF NOT EXISTS peer_expiry ON peer(expiry);`),
+}
+
+// NewPeerDB creates the peer table if it does not exist, and deletes the
+// expired peers
+func NewPeerDB(db *sql.DB) (*PeerDB, error) {
+	if db == nil {
+		return nil, errors.New("a database is required")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range peerMigrations {
+		if err := m(tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	result := &PeerDB{db: db}
+	if err := result.Prune(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetAll returns the peers that didn't expire
+func (p *PeerDB) GetAll() ([]StoredPeer, error) {
+	rows, err := p.db.Query(`SELECT id, addrs, protocols, latency, expiry FROM peer WHERE expiry >= ?`, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []StoredPeer
+	for rows.Next() {
+		var id []byte
+		var addrs, protocols string
+		var latency, expiry int64
+		if err := rows.Scan(&id, &addrs, &protocols, &latency, &expiry); err != nil {
+			return nil, err
+		}
+
+		stored := StoredPeer{
+			ID:        peer.ID(id),
+			Protocols: strings.Fields(protocols),
+			Latency:   time.Duration(latency),
+			Expiry:    time.Unix(0, expiry),
+		}
+
+		for _, a := range strings.Fields(addrs) {
+			addr, err := ma.NewMultiaddr(a)
+			if err != nil {
+				return nil, err
+			}
+			stored.Addrs = append(stored.Addrs, addr)
+		}
+
+		result = append(result, stored)
+	}
+
+	return result, rows.Err()
+}
+
+// Put inserts or updates peers. The expiry of a peer already stored is only
+// extended, never shortened
+func (p *PeerDB) Put(peers []StoredPeer) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO peer (id, addrs, protocols, latency, expiry)
+		VALUES (?, ?, ?, ?, MAX(?, IFNULL((SELECT expiry FROM peer WHERE id = ?), 0)))`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, stored := range peers {
+		addrs := make([]string, len(stored.Addrs))
+		for i, addr := range stored.Addrs {
+			addrs[i] = addr.String()
+		}
+
+		id := []byte(stored.ID)
+		_, err := stmt.Exec(id, strings.Join(addrs, " "), strings.Join(stored.Protocols, " "), int64(stored.Latency), stored.Expiry.UnixNano(), id)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete deletes a peer, so it's not known anymore after a restart
+func (p *PeerDB) Delete(id peer.ID) error {
+	_, err := p.db.Exec(`DELETE FROM peer WHERE id = ?`, []byte(id))
+	return err
+}
+
+// Prune deletes the peers expired before now
+func (p *PeerDB) Prune(now time.Time) error {
+	_, err := p.db.Exec(`DELETE FROM peer WHERE expiry < ?`, now.UnixNano())
+	return err
+}
@@ -0,0 +1,565 @@
+package persistence
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+type MessageProvider interface {
+	GetAll() ([]StoredMessage, error)
+	Put(cursor *pb.Index, pubsubTopic string, message *pb.WakuMessage) error
+	Stop()
+}
+
+// Number of messages inserted at once
+const batchSize = 100
+
+// Interval at which the pending messages are inserted
+const batchInterval = time.Second
+
+// Interval at which the messages outside of the retention policy are deleted
+const pruneInterval = time.Minute
+
+// PruneTarget is the share of the maximum size the messages are pruned down
+// to once it's exceeded, so they aren't pruned at each insert
+const PruneTarget = 0.9
+
+// Number of recently put messages remembered to drop duplicates without
+// querying the DB
+const seenCacheSize = 1000
+
+// MessageHash identifies a message published on a pubsub topic, from its
+// payload, content topic, pubsub topic and timestamp
+func MessageHash(pubsubTopic string, msg *pb.WakuMessage) []byte {
+	timestamp := make([]byte, 8)
+	binary.BigEndian.PutUint64(timestamp, math.Float64bits(msg.Timestamp))
+
+	h := sha256.New()
+	h.Write(msg.Payload)
+	h.Write([]byte(msg.ContentTopic))
+	h.Write([]byte(pubsubTopic))
+	h.Write(timestamp)
+	return h.Sum(nil)
+}
+
+// Approximate size of the index of a stored message: its digest, timestamps
+// and version
+const indexOverhead = 32 + 8 + 8 + 4
+
+// MessageSize is the approximate size taken by a stored message
+func MessageSize(pubsubTopic string, msg *pb.WakuMessage) int64 {
+	return int64(len(msg.Payload)+len(msg.ContentTopic)+len(pubsubTopic)) + indexOverhead
+}
+
+// DBStore is a MessageProvider that has a *sql.DB connection
+type DBStore struct {
+	MessageProvider
+	db *sql.DB
+	// Whether the connection was opened by the DBStore, and is closed by Stop
+	ownsDB bool
+
+	maxMessages int
+	maxDuration time.Duration
+	maxBytes    int64
+
+	// Held while writing, and guards size
+	writeMutex sync.Mutex
+	size       int64
+
+	batchMutex sync.Mutex
+	batch      []storedRow
+	// Hashes of the recently put messages
+	seen *lru.Cache
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+type storedRow struct {
+	id           []byte
+	hash         []byte
+	receiverTime float64
+	message      *pb.WakuMessage
+	pubsubTopic  string
+}
+
+type StoredMessage struct {
+	ID           []byte
+	PubsubTopic  string
+	ReceiverTime float64
+	Message      *pb.WakuMessage
+}
+
+// DBOption is an optional setting that can be used to configure the DBStore
+type DBOption func(*DBStore) error
+
+// WithDB is a DBOption that lets you use any custom *sql.DB with a DBStore.
+func WithDB(db *sql.DB) DBOption {
+	return func(d *DBStore) error {
+		d.db = db
+		return nil
+	}
+}
+
+// WithDriver is a DBOption that will open a *sql.DB connection
+func WithDriver(driverName string, datasourceName string) DBOption {
+	return func(d *DBStore) error {
+		db, err := sql.Open(driverName, datasourceName)
+		if err != nil {
+			return err
+		}
+		d.db = db
+		d.ownsDB = true
+		return nil
+	}
+}
+
+func WithRetentionPolicy(maxMessages int, maxDuration time.Duration) DBOption {
+	return func(d *DBStore) error {
+		d.maxDuration = maxDuration
+		d.maxMessages = maxMessages
+		return nil
+	}
+}
+
+// WithMaxSize is a DBOption that limits the approximate size of the stored
+// messages, as computed by MessageSize. Once it's exceeded, the oldest
+// messages are deleted until the size is back to PruneTarget of maxBytes
+func WithMaxSize(maxBytes int64) DBOption {
+	return func(d *DBStore) error {
+		if maxBytes < 0 {
+			return errors.New("the maximum size can't be negative")
+		}
+		d.maxBytes = maxBytes
+		return nil
+	}
+}
+
+// Creates a new DB store using the db specified via options.
+// It will create a messages table if it does not exist and
+// clean up records according to the retention policy used,
+// every minute while the store runs
+func NewDBStore(options ...DBOption) (*DBStore, error) {
+	result := new(DBStore)
+	result.quit = make(chan struct{})
+
+	seen, err := lru.New(seenCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	result.seen = seen
+
+	for _, opt := range options {
+		err := opt(result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = result.migrate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = result.cleanOlderRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	result.wg.Add(2)
+	go result.insertBatches()
+	go result.pruneRecords()
+
+	return result, nil
+}
+
+type migration func(tx *sql.Tx) error
+
+func execMigration(sqlStmt string) migration {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlStmt)
+		return err
+	}
+}
+
+// addColumnMigration adds a column to the message table, unless it exists
+func addColumnMigration(name string, definition string) migration {
+	return func(tx *sql.Tx) error {
+		var count int
+		err := tx.QueryRow(`SELECT count(*) FROM pragma_table_info('message') WHERE name = ?`, name).Scan(&count)
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(`ALTER TABLE message ADD COLUMN ` + name + ` ` + definition)
+		return err
+	}
+}
+
+// migrations are applied in order when the DBStore is created. They must be
+// idempotent, as the database may be shared with other tables
+var migrations = []migration{
+	execMigration(`CREATE TABLE IF NOT EXISTS message (
+		id BLOB PRIMARY KEY,
+		receiverTimestamp REAL NOT NULL,
+		senderTimestamp REAL NOT NULL,
+		contentTopic BLOB NOT NULL,
+		pubsubTopic BLOB NOT NULL,
+		payload BLOB,
+		version INTEGER NOT NULL DEFAULT 0
+	) WITHOUT ROWID;`),
+	execMigration(`CREATE INDEX IF NOT EXISTS message_senderTimestamp ON message(senderTimestamp);`),
+	execMigration(`CREATE INDEX IF NOT EXISTS message_receiverTimestamp ON message(receiverTimestamp);`),
+	execMigration(`CREATE INDEX IF NOT EXISTS message_contentTopic ON message(contentTopic, senderTimestamp);`),
+	execMigration(`CREATE INDEX IF NOT EXISTS message_pubsubTopic ON message(pubsubTopic, senderTimestamp);`),
+	// The messages stored before don't have a hash, and are only deduplicated by id
+	addColumnMigration("messageHash", "BLOB"),
+	execMigration(`CREATE UNIQUE INDEX IF NOT EXISTS message_messageHash ON message(messageHash);`),
+}
+
+func (d *DBStore) migrate() error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		err = m(tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (d *DBStore) pruneRecords() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-ticker.C:
+			err := d.cleanOlderRecords()
+			if err != nil {
+				log.Printf("could not delete older messages: %s", err)
+			}
+		}
+	}
+}
+
+func (d *DBStore) cleanOlderRecords() error {
+	d.writeMutex.Lock()
+	defer d.writeMutex.Unlock()
+
+	// Delete older messages
+	if d.maxDuration > 0 {
+		sqlStmt := `DELETE FROM message WHERE receiverTimestamp < ?`
+		_, err := d.db.Exec(sqlStmt, utils.GetUnixEpochFrom(time.Now().Add(-d.maxDuration)))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Limit number of records to a max N
+	if d.maxMessages > 0 {
+		sqlStmt := `DELETE FROM message WHERE id IN (SELECT id FROM message ORDER BY receiverTimestamp DESC LIMIT -1 OFFSET ?)`
+		_, err := d.db.Exec(sqlStmt, d.maxMessages)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := d.computeSize()
+	if err != nil {
+		return err
+	}
+
+	return d.pruneBySize()
+}
+
+// SQL expression of the size of a stored message, as computed by MessageSize
+const sizeExpr = `IFNULL(length(payload), 0) + length(CAST(contentTopic AS BLOB)) + length(CAST(pubsubTopic AS BLOB)) + ?`
+
+func (d *DBStore) computeSize() error {
+	var size sql.NullInt64
+	err := d.db.QueryRow(`SELECT SUM(`+sizeExpr+`) FROM message`, indexOverhead).Scan(&size)
+	if err != nil {
+		return err
+	}
+
+	d.size = size.Int64
+	return nil
+}
+
+// pruneBySize deletes the oldest messages until their size is back to
+// PruneTarget of maxBytes, if it's exceeded
+func (d *DBStore) pruneBySize() error {
+	if d.maxBytes == 0 || d.size <= d.maxBytes {
+		return nil
+	}
+
+	rows, err := d.db.Query(`SELECT id, `+sizeExpr+` FROM message ORDER BY receiverTimestamp ASC`, indexOverhead)
+	if err != nil {
+		return err
+	}
+
+	target := int64(float64(d.maxBytes) * PruneTarget)
+	size := d.size
+	var ids [][]byte
+	for size > target && rows.Next() {
+		var id []byte
+		var msgSize int64
+		err = rows.Scan(&id, &msgSize)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		size -= msgSize
+	}
+	rows.Close()
+
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`DELETE FROM message WHERE id = ?`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range ids {
+		_, err = stmt.Exec(id)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	d.size = size
+	return nil
+}
+
+// Size returns the approximate size of the stored messages, as computed by
+// MessageSize
+func (d *DBStore) Size() int64 {
+	d.writeMutex.Lock()
+	defer d.writeMutex.Unlock()
+	return d.size
+}
+
+// Stops the DBStore, inserting the pending messages. The DB connection is
+// only closed if it was opened WithDriver
+func (d *DBStore) Stop() {
+	close(d.quit)
+	d.wg.Wait()
+
+	err := d.flush()
+	if err != nil {
+		log.Printf("could not insert messages: %s", err)
+	}
+
+	if d.ownsDB {
+		d.db.Close()
+	}
+}
+
+// Inserts a WakuMessage into the DB. Messages are inserted in batches, every
+// second or once 100 messages are pending, so an error may come from
+// inserting messages put previously. A message already stored, as
+// identified by MessageHash, is ignored
+func (d *DBStore) Put(cursor *pb.Index, pubsubTopic string, message *pb.WakuMessage) error {
+	hash := MessageHash(pubsubTopic, message)
+	if ok, _ := d.seen.ContainsOrAdd(string(hash), struct{}{}); ok {
+		return nil
+	}
+
+	d.batchMutex.Lock()
+	d.batch = append(d.batch, storedRow{
+		id:           cursor.Digest,
+		hash:         hash,
+		receiverTime: cursor.ReceiverTime,
+		message:      message,
+		pubsubTopic:  pubsubTopic,
+	})
+	full := len(d.batch) >= batchSize
+	d.batchMutex.Unlock()
+
+	if full {
+		return d.flush()
+	}
+
+	return nil
+}
+
+func (d *DBStore) insertBatches() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(batchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-ticker.C:
+			err := d.flush()
+			if err != nil {
+				log.Printf("could not insert messages: %s", err)
+			}
+		}
+	}
+}
+
+// Flush inserts the pending messages right away, instead of waiting for the
+// next batch
+func (d *DBStore) Flush() error {
+	return d.flush()
+}
+
+// flush inserts the pending messages in a single transaction
+func (d *DBStore) flush() error {
+	d.batchMutex.Lock()
+	batch := d.batch
+	d.batch = nil
+	d.batchMutex.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	d.writeMutex.Lock()
+	defer d.writeMutex.Unlock()
+
+	size, err := d.insert(batch)
+	if err != nil {
+		// The messages can be put again
+		for _, row := range batch {
+			d.seen.Remove(string(row.hash))
+		}
+		return err
+	}
+
+	d.size += size
+	return d.pruneBySize()
+}
+
+// insert inserts messages, and returns the size of the messages which
+// weren't already stored
+func (d *DBStore) insert(batch []storedRow) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO message (id, messageHash, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var size int64
+	for _, row := range batch {
+		res, err := stmt.Exec(row.id, row.hash, row.receiverTime, row.message.Timestamp, row.message.ContentTopic, row.pubsubTopic, row.message.Payload, row.message.Version)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+
+		// Duplicates are ignored, and don't count in the size
+		inserted, err := res.RowsAffected()
+		if err == nil && inserted > 0 {
+			size += MessageSize(row.pubsubTopic, row.message)
+		}
+	}
+
+	return size, tx.Commit()
+}
+
+// Returns all the stored WakuMessages
+func (d *DBStore) GetAll() ([]StoredMessage, error) {
+	err := d.flush()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.Query("SELECT id, receiverTimestamp, senderTimestamp, contentTopic, pubsubTopic, payload, version FROM message ORDER BY senderTimestamp ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []StoredMessage
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id []byte
+		var receiverTimestamp float64
+		var senderTimestamp float64
+		var contentTopic string
+		var payload []byte
+		var version uint32
+		var pubsubTopic string
+
+		err = rows.Scan(&id, &receiverTimestamp, &senderTimestamp, &contentTopic, &pubsubTopic, &payload, &version)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		msg := new(pb.WakuMessage)
+		msg.ContentTopic = contentTopic
+		msg.Payload = payload
+		msg.Timestamp = senderTimestamp
+		msg.Version = version
+
+		record := StoredMessage{
+			ID:           id,
+			PubsubTopic:  pubsubTopic,
+			ReceiverTime: receiverTimestamp,
+			Message:      msg,
+		}
+
+		result = append(result, record)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
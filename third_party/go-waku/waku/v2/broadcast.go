@@ -0,0 +1,480 @@
+package v2
+
+import (
+	"context"
+	"sync"
+
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+)
+
+// Adapted from https://github.com/dustin/go-broadcast/commit/f664265f5a662fb4d1df7f3533b1e8d0e0277120
+// by Dustin Sallings (c) 2013, which was released under MIT license
+
+// OverflowPolicy is what happens when an envelope is broadcasted to a
+// subscriber whose queue is full
+type OverflowPolicy int
+
+const (
+	// Block waits until the subscriber has room for the envelope, which also
+	// delays the other subscribers. No envelope is lost
+	Block OverflowPolicy = iota
+	// DropOldest drops the oldest queued envelope to make room for the new one
+	DropOldest
+	// DropNewest drops the new envelope
+	DropNewest
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop_oldest"
+	case DropNewest:
+		return "drop_newest"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultQueueSize is the number of envelopes queued for each subscriber
+// unless specified WithQueueSize
+const DefaultQueueSize = 1024
+
+type registrationParameters struct {
+	name      string
+	policy    OverflowPolicy
+	queueSize int
+}
+
+// RegistrationOption is an option used when registering a channel
+type RegistrationOption func(*registrationParameters)
+
+// WithName is an option used to name a subscriber in the stats and metrics
+func WithName(name string) RegistrationOption {
+	return func(params *registrationParameters) {
+		params.name = name
+	}
+}
+
+// WithOverflowPolicy is an option used to set what happens once the queue of
+// a subscriber is full. By default the broadcaster Blocks
+func WithOverflowPolicy(policy OverflowPolicy) RegistrationOption {
+	return func(params *registrationParameters) {
+		params.policy = policy
+	}
+}
+
+// WithQueueSize is an option used to set the number of envelopes queued for a
+// subscriber, in addition to the buffer of its channel
+func WithQueueSize(size int) RegistrationOption {
+	return func(params *registrationParameters) {
+		params.queueSize = size
+	}
+}
+
+// SubscriberStats describes the queue of a registered channel
+type SubscriberStats struct {
+	Name string
+	// Pubsub topic of the subscriber, empty if it receives all the envelopes
+	Topic  string
+	Policy OverflowPolicy
+	// Number of envelopes waiting to be sent to the channel
+	Queued int
+	// Number of envelopes dropped because the queue was full
+	Dropped uint64
+}
+
+// unregistration is sent to the broadcaster loop, which closes done once
+// the channel is unregistered
+type unregistration struct {
+	ch   chan<- *protocol.Envelope
+	done chan struct{}
+}
+
+// drainRequest is sent to the broadcaster loop, which detaches the outputs of
+// the channel so they can be emptied
+type drainRequest struct {
+	ch      chan<- *protocol.Envelope
+	outputs chan []*output
+}
+
+type registration struct {
+	ch     chan<- *protocol.Envelope
+	topic  *string
+	params registrationParameters
+}
+
+// output queues the envelopes of a registered channel, and sends them to it
+type output struct {
+	registration
+	queue   chan *protocol.Envelope
+	dropped uint64
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newOutput(r registration) *output {
+	o := &output{
+		registration: r,
+		queue:        make(chan *protocol.Envelope, r.params.queueSize),
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	go o.forward()
+
+	return o
+}
+
+func (o *output) forward() {
+	defer close(o.done)
+	for {
+		select {
+		case m, ok := <-o.queue:
+			// Closed once drained
+			if !ok {
+				return
+			}
+			select {
+			case o.ch <- m:
+			case <-o.quit:
+				return
+			}
+		case <-o.quit:
+			return
+		}
+	}
+}
+
+func (o *output) stop() {
+	close(o.quit)
+	<-o.done
+}
+
+func (o *output) stats() SubscriberStats {
+	result := SubscriberStats{
+		Name:    o.params.name,
+		Policy:  o.params.policy,
+		Queued:  len(o.queue),
+		Dropped: o.dropped,
+	}
+	if o.topic != nil {
+		result.Topic = *o.topic
+	}
+	return result
+}
+
+type broadcaster struct {
+	input chan *protocol.Envelope
+	reg   chan registration
+	unreg chan unregistration
+	drain chan drainRequest
+	stats chan chan []SubscriberStats
+
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	outputs      map[chan<- *protocol.Envelope]*output
+	topicOutputs map[string]map[chan<- *protocol.Envelope]*output
+}
+
+// The Broadcaster interface describes the main entry points to
+// broadcasters.
+type Broadcaster interface {
+	// Register a new channel to receive broadcasts
+	Register(ch chan<- *protocol.Envelope, opts ...RegistrationOption)
+	// RegisterForTopic registers a new channel to receive the broadcasts of a single pubsub topic
+	RegisterForTopic(topic string, ch chan<- *protocol.Envelope, opts ...RegistrationOption)
+	// Unregister a channel so that it no longer receives broadcasts.
+	Unregister(chan<- *protocol.Envelope)
+	// Drain unregisters a channel once the envelopes submitted before are sent to it
+	Drain(ctx context.Context, ch chan<- *protocol.Envelope) error
+	// Stats describes the queues of the registered channels
+	Stats() []SubscriberStats
+	// Shut this broadcaster down.
+	Close()
+	// Submit a new object to all subscribers
+	Submit(*protocol.Envelope)
+}
+
+func (b *broadcaster) broadcast(m *protocol.Envelope) {
+	for _, o := range b.outputs {
+		b.send(o, m)
+	}
+
+	for _, o := range b.topicOutputs[m.PubsubTopic()] {
+		b.send(o, m)
+	}
+}
+
+func (b *broadcaster) send(o *output, m *protocol.Envelope) {
+	switch o.params.policy {
+	case DropNewest:
+		select {
+		case o.queue <- m:
+		default:
+			b.drop(o)
+		}
+	case DropOldest:
+		for {
+			select {
+			case o.queue <- m:
+				return
+			default:
+			}
+
+			// The queue may have been emptied meanwhile by the output
+			select {
+			case <-o.queue:
+				b.drop(o)
+			default:
+			}
+		}
+	default:
+		select {
+		case o.queue <- m:
+		case <-b.quit:
+		}
+	}
+}
+
+func (b *broadcaster) drop(o *output) {
+	o.dropped++
+	metrics.RecordBroadcastDrop(context.Background(), o.params.name)
+}
+
+func (b *broadcaster) register(r registration) {
+	outputs := b.outputs
+	if r.topic != nil {
+		var ok bool
+		outputs, ok = b.topicOutputs[*r.topic]
+		if !ok {
+			outputs = make(map[chan<- *protocol.Envelope]*output)
+			b.topicOutputs[*r.topic] = outputs
+		}
+	}
+
+	// A channel registered again for the same topic is replaced
+	if o, ok := outputs[r.ch]; ok {
+		o.stop()
+	}
+	outputs[r.ch] = newOutput(r)
+}
+
+func (b *broadcaster) unregister(ch chan<- *protocol.Envelope) {
+	if o, ok := b.outputs[ch]; ok {
+		o.stop()
+		delete(b.outputs, ch)
+	}
+
+	for topic, outputs := range b.topicOutputs {
+		if o, ok := outputs[ch]; ok {
+			o.stop()
+			delete(outputs, ch)
+		}
+		if len(outputs) == 0 {
+			delete(b.topicOutputs, topic)
+		}
+	}
+}
+
+// detach removes the outputs of a channel, and closes their queues so their
+// goroutine returns once the queued envelopes are sent to the channel
+func (b *broadcaster) detach(ch chan<- *protocol.Envelope) []*output {
+	var result []*output
+	if o, ok := b.outputs[ch]; ok {
+		result = append(result, o)
+		delete(b.outputs, ch)
+	}
+
+	for topic, outputs := range b.topicOutputs {
+		if o, ok := outputs[ch]; ok {
+			result = append(result, o)
+			delete(outputs, ch)
+		}
+		if len(outputs) == 0 {
+			delete(b.topicOutputs, topic)
+		}
+	}
+
+	for _, o := range result {
+		close(o.queue)
+	}
+
+	return result
+}
+
+func (b *broadcaster) subscriberStats() []SubscriberStats {
+	var result []SubscriberStats
+	for _, o := range b.outputs {
+		result = append(result, o.stats())
+	}
+
+	for _, outputs := range b.topicOutputs {
+		for _, o := range outputs {
+			result = append(result, o.stats())
+		}
+	}
+
+	return result
+}
+
+func (b *broadcaster) run() {
+	defer close(b.done)
+	for {
+		select {
+		case m := <-b.input:
+			b.broadcast(m)
+		case r := <-b.reg:
+			b.register(r)
+		case u := <-b.unreg:
+			b.unregister(u.ch)
+			close(u.done)
+		case d := <-b.drain:
+			// The envelopes submitted before the request are broadcasted first
+			for pending := len(b.input); pending > 0; pending-- {
+				b.broadcast(<-b.input)
+			}
+			d.outputs <- b.detach(d.ch)
+		case c := <-b.stats:
+			c <- b.subscriberStats()
+		case <-b.quit:
+			for _, o := range b.outputs {
+				o.stop()
+			}
+			for _, outputs := range b.topicOutputs {
+				for _, o := range outputs {
+					o.stop()
+				}
+			}
+			return
+		}
+	}
+}
+
+// NewBroadcaster creates a Broadcaster with an specified length
+// It's used to register subscriptors that will need to receive
+// an Envelope containing a WakuMessage
+func NewBroadcaster(buflen int) Broadcaster {
+	b := &broadcaster{
+		input:        make(chan *protocol.Envelope, buflen),
+		reg:          make(chan registration),
+		unreg:        make(chan unregistration),
+		drain:        make(chan drainRequest),
+		stats:        make(chan chan []SubscriberStats),
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+		outputs:      make(map[chan<- *protocol.Envelope]*output),
+		topicOutputs: make(map[string]map[chan<- *protocol.Envelope]*output),
+	}
+
+	go b.run()
+
+	return b
+}
+
+func newRegistration(ch chan<- *protocol.Envelope, topic *string, opts []RegistrationOption) registration {
+	params := registrationParameters{
+		policy:    Block,
+		queueSize: DefaultQueueSize,
+	}
+	for _, opt := range opts {
+		opt(&params)
+	}
+
+	return registration{ch: ch, topic: topic, params: params}
+}
+
+// Register a subscriptor channel. Each channel has its own queue, so a slow
+// subscriber only delays the others if its overflow policy Blocks
+func (b *broadcaster) Register(newch chan<- *protocol.Envelope, opts ...RegistrationOption) {
+	select {
+	case b.reg <- newRegistration(newch, nil, opts):
+	case <-b.quit:
+	}
+}
+
+// RegisterForTopic registers a subscriptor channel that only receives
+// the envelopes of a pubsub topic
+func (b *broadcaster) RegisterForTopic(topic string, newch chan<- *protocol.Envelope, opts ...RegistrationOption) {
+	select {
+	case b.reg <- newRegistration(newch, &topic, opts):
+	case <-b.quit:
+	}
+}
+
+// Unregister a subscriptor channel. Once it returns, no more envelopes
+// are sent to the channel
+func (b *broadcaster) Unregister(newch chan<- *protocol.Envelope) {
+	u := unregistration{ch: newch, done: make(chan struct{})}
+	select {
+	case b.unreg <- u:
+		<-u.done
+	case <-b.quit:
+		// The outputs are stopped once closed
+		<-b.done
+	}
+}
+
+// Drain waits until the envelopes submitted before are sent to a channel, and
+// unregisters it. If ctx is done before, the channel is unregistered anyway and
+// the envelopes still queued are dropped
+func (b *broadcaster) Drain(ctx context.Context, ch chan<- *protocol.Envelope) error {
+	d := drainRequest{ch: ch, outputs: make(chan []*output, 1)}
+	select {
+	case b.drain <- d:
+	case <-b.quit:
+		// The outputs are stopped once closed
+		<-b.done
+		return nil
+	case <-ctx.Done():
+		b.Unregister(ch)
+		return ctx.Err()
+	}
+
+	var err error
+	for _, o := range <-d.outputs {
+		select {
+		case <-o.done:
+		case <-ctx.Done():
+			o.stop()
+			err = ctx.Err()
+		}
+	}
+	return err
+}
+
+// Stats returns the queue length and the number of dropped envelopes of each
+// registered channel
+func (b *broadcaster) Stats() []SubscriberStats {
+	c := make(chan []SubscriberStats, 1)
+	select {
+	case b.stats <- c:
+		return <-c
+	case <-b.quit:
+		return nil
+	}
+}
+
+// Closes the broadcaster. Used to stop receiving new subscribers. Once it
+// returns, no more envelopes are sent to the registered channels
+func (b *broadcaster) Close() {
+	b.closeOnce.Do(func() {
+		close(b.quit)
+	})
+	<-b.done
+}
+
+// Submits an Envelope to be broadcasted among all registered subscriber channels
+func (b *broadcaster) Submit(m *protocol.Envelope) {
+	if b != nil {
+		select {
+		case b.input <- m:
+		case <-b.quit:
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package discv5
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	gcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/status-im/go-waku/tests"
+	"github.com/stretchr/testify/require"
+
+	"github.com/libp2p/go-libp2p"
+	libp2pcrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/discovery"
+	"github.com/libp2p/go-libp2p-core/host"
+)
+
+func createHost(t *testing.T) (host.Host, int, *ecdsa.PrivateKey) {
+	privKey, err := gcrypto.GenerateKey()
+	require.NoError(t, err)
+
+	sPrivKey := libp2pcrypto.PrivKey((*libp2pcrypto.Secp256k1PrivateKey)(privKey))
+
+	port, err := tests.FindFreePort(t, "127.0.0.1", 3)
+	require.NoError(t, err)
+
+	sourceMultiAddr, err := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", port))
+	require.NoError(t, err)
+
+	host, err := libp2p.New(
+		context.Background(),
+		libp2p.ListenAddrs(sourceMultiAddr),
+		libp2p.Identity(sPrivKey),
+	)
+	require.NoError(t, err)
+
+	return host, port, privKey
+}
+
+func TestDiscV5(t *testing.T) {
+	// Host1 <-> Host2 <-> Host3
+
+	host1, tcpPort1, prvKey1 := createHost(t)
+	udpPort1, err := tests.FindFreePort(t, "127.0.0.1", 3)
+	require.NoError(t, err)
+	d1, err := NewDiscoveryV5(host1, net.IPv4(127, 0, 0, 1), tcpPort1, prvKey1, NewWakuEnrBitfield(true, true, true, true), WithUDPPort(udpPort1))
+	require.NoError(t, err)
+
+	host2, tcpPort2, prvKey2 := createHost(t)
+	udpPort2, err := tests.FindFreePort(t, "127.0.0.1", 3)
+	require.NoError(t, err)
+	d2, err := NewDiscoveryV5(host2, net.IPv4(127, 0, 0, 1), tcpPort2, prvKey2, NewWakuEnrBitfield(true, true, true, true), WithUDPPort(udpPort2), WithBootnodes([]*enode.Node{d1.localnode.Node()}))
+	require.NoError(t, err)
+
+	host3, tcpPort3, prvKey3 := createHost(t)
+	udpPort3, err := tests.FindFreePort(t, "127.0.0.1", 3)
+	require.NoError(t, err)
+	d3, err := NewDiscoveryV5(host3, net.IPv4(127, 0, 0, 1), tcpPort3, prvKey3, NewWakuEnrBitfield(true, true, true, true), WithUDPPort(udpPort3), WithBootnodes([]*enode.Node{d2.localnode.Node()}))
+	require.NoError(t, err)
+
+	defer d1.Stop()
+	defer d2.Stop()
+	defer d3.Stop()
+
+	err = d1.Start()
+	require.NoError(t, err)
+
+	err = d2.Start()
+	require.NoError(t, err)
+
+	err = d3.Start()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	peerChan, err := d3.FindPeers(ctx, "", discovery.Limit(2))
+	require.NoError(t, err)
+
+	foundHost1 := false
+	foundHost2 := false
+	for p := range peerChan {
+		if p.Addrs[0].String() == host1.Addrs()[0].String() {
+			foundHost1 = true
+		}
+
+		if p.Addrs[0].String() == host2.Addrs()[0].String() {
+			foundHost2 = true
+
+		}
+	}
+
+	require.True(t, foundHost1 && foundHost2)
+
+	// Should return nodes from the cache
+
+	d3.Stop()
+
+	foundHost1 = false
+	foundHost2 = false
+
+	ctx1, cancel1 := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel1()
+
+	peerChan, err = d3.FindPeers(ctx1, "", discovery.Limit(2))
+	require.NoError(t, err)
+	for p := range peerChan {
+		if p.Addrs[0].String() == host1.Addrs()[0].String() {
+			foundHost1 = true
+		}
+
+		if p.Addrs[0].String() == host2.Addrs()[0].String() {
+			foundHost2 = true
+		}
+	}
+
+	require.True(t, foundHost1 && foundHost2)
+
+	// Simulate empty cache
+
+	for i := range d3.peerCache.recs {
+		delete(d3.peerCache.recs, i)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel2()
+
+	peerChan, err = d3.FindPeers(ctx2, "", discovery.Limit(2))
+	require.NoError(t, err)
+	for range peerChan {
+		require.Fail(t, "Should not have peers")
+	}
+
+	// Restart peer search
+	err = d3.Start()
+	require.NoError(t, err)
+
+	foundHost1 = false
+	foundHost2 = false
+
+	ctx3, cancel3 := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel3()
+
+	peerChan, err = d3.FindPeers(ctx3, "", discovery.Limit(2))
+	require.NoError(t, err)
+	for p := range peerChan {
+		if p.Addrs[0].String() == host1.Addrs()[0].String() {
+			foundHost1 = true
+		}
+
+		if p.Addrs[0].String() == host2.Addrs()[0].String() {
+			foundHost2 = true
+		}
+	}
+
+	require.True(t, foundHost1 && foundHost2)
+
+}
+
+func newTestDiscoveryV5(t *testing.T, opts ...DiscoveryV5Option) (*DiscoveryV5, host.Host) {
+	host, tcpPort, prvKey := createHost(t)
+	t.Cleanup(func() { host.Close() })
+
+	udpPort, err := tests.FindFreePort(t, "127.0.0.1", 3)
+	require.NoError(t, err)
+
+	opts = append([]DiscoveryV5Option{WithUDPPort(udpPort)}, opts...)
+	d, err := NewDiscoveryV5(host, net.IPv4(127, 0, 0, 1), tcpPort, prvKey, NewWakuEnrBitfield(true, true, true, true), opts...)
+	require.NoError(t, err)
+
+	return d, host
+}
+
+// findsPeer reports whether FindPeers returns the peer within a second
+func findsPeer(t *testing.T, d *DiscoveryV5, h host.Host) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	peerChan, err := d.FindPeers(ctx, "", discovery.Limit(1))
+	require.NoError(t, err)
+
+	found := false
+	for p := range peerChan {
+		if p.ID == h.ID() {
+			found = true
+		}
+	}
+	return found
+}
+
+func TestAddBootnodes(t *testing.T) {
+	d1, host1 := newTestDiscoveryV5(t)
+	require.NoError(t, d1.Start())
+	defer d1.Stop()
+
+	// Bootnodes added before starting are used once started
+	d2, _ := newTestDiscoveryV5(t)
+	require.NoError(t, d2.AddBootnodes([]*enode.Node{d1.Node()}))
+	require.NoError(t, d2.Start())
+	defer d2.Stop()
+	require.True(t, findsPeer(t, d2, host1))
+
+	// and the listener is restarted with the ones added while running
+	d3, _ := newTestDiscoveryV5(t)
+	require.NoError(t, d3.Start())
+	defer d3.Stop()
+	require.NoError(t, d3.AddBootnodes([]*enode.Node{d1.Node(), nil}))
+	require.NotNil(t, d3.ListenAddr())
+	require.True(t, findsPeer(t, d3, host1))
+
+	// Known bootnodes are ignored
+	require.NoError(t, d3.AddBootnodes([]*enode.Node{d1.Node()}))
+	require.Len(t, d3.params.bootnodes, 1)
+
+	d3.RemoveBootnode(d1.Node().ID())
+	require.Empty(t, d3.params.bootnodes)
+	require.Empty(t, d3.config.Bootnodes)
+}
+
+func TestKnownPeers(t *testing.T) {
+	d1, host1 := newTestDiscoveryV5(t)
+	require.NoError(t, d1.Start())
+	defer d1.Stop()
+
+	d2, _ := newTestDiscoveryV5(t, WithBootnodes([]*enode.Node{d1.Node()}))
+	require.Nil(t, d2.KnownPeers())
+	require.NoError(t, d2.Start())
+	defer d2.Stop()
+
+	var known DiscoveredPeer
+	require.Eventually(t, func() bool {
+		for _, p := range d2.KnownPeers() {
+			if p.ID == host1.ID() {
+				known = p
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.Equal(t, d1.Node().String(), known.ENR)
+	require.Equal(t, NewWakuEnrBitfield(true, true, true, true), known.Flags)
+	require.Len(t, known.Addrs, 1)
+	require.Equal(t, host1.Addrs()[0].String(), known.Addrs[0].Decapsulate(multiaddr.StringCast("/p2p/"+host1.ID().Pretty())).String())
+
+	// The time a node was first seen is kept
+	for _, p := range d2.KnownPeers() {
+		if p.ID == host1.ID() {
+			require.Equal(t, known.DiscoveredAt, p.DiscoveredAt)
+		}
+	}
+}
+
+func TestDiscoveryTimes(t *testing.T) {
+	var times discoveryTimes
+
+	key1, err := gcrypto.GenerateKey()
+	require.NoError(t, err)
+	key2, err := gcrypto.GenerateKey()
+	require.NoError(t, err)
+	n1 := enode.NewV4(&key1.PublicKey, net.IPv4(127, 0, 0, 1), 0, 0)
+	n2 := enode.NewV4(&key2.PublicKey, net.IPv4(127, 0, 0, 1), 0, 0)
+
+	seen1 := times.seen(n1.ID())
+	times.seen(n2.ID())
+	require.Equal(t, seen1, times.seen(n1.ID()))
+
+	// Nodes no longer in the routing table are forgotten
+	times.retain([]*enode.Node{n2})
+	require.NotContains(t, times.times, n1.ID())
+	require.Contains(t, times.times, n2.ID())
+}
+
+func TestUpdateAddr(t *testing.T) {
+	d, _ := newTestDiscoveryV5(t)
+	seq := d.Node().Seq()
+
+	require.Error(t, d.UpdateAddr(nil, -1))
+	require.Error(t, d.UpdateAddr(nil, 65536))
+
+	// A zero port is ignored
+	require.NoError(t, d.UpdateAddr(nil, 0))
+	require.Equal(t, seq, d.Node().Seq())
+
+	require.NoError(t, d.UpdateAddr(nil, 30303))
+	require.Equal(t, 30303, d.Node().TCP())
+	require.Greater(t, d.Node().Seq(), seq)
+
+	// The ip is only updated with auto updates
+	require.NoError(t, d.UpdateAddr(net.IPv4(8, 8, 8, 8), 0))
+	require.Equal(t, "127.0.0.1", d.Node().IP().String())
+
+	d, _ = newTestDiscoveryV5(t, WithAutoUpdate(true))
+	require.NoError(t, d.UpdateAddr(net.IPv4(192, 168, 1, 2), 0))
+	require.Equal(t, "192.168.1.2", d.Node().IP().String())
+}
+
+func TestShouldUpdateIP(t *testing.T) {
+	testCases := []struct {
+		current net.IP
+		addr    net.IP
+		update  bool
+	}{
+		{net.IPv4(127, 0, 0, 1), nil, false},
+		{net.IPv4(127, 0, 0, 1), net.IPv4zero, false},
+		{net.IPv4(127, 0, 0, 1), net.IPv4(127, 0, 0, 2), false},
+		{net.IPv4(127, 0, 0, 1), net.IPv4(192, 168, 1, 2), true},
+		{net.IPv4(127, 0, 0, 1), net.IPv4(8, 8, 8, 8), true},
+		{net.IPv4(192, 168, 1, 2), net.IPv4(10, 0, 0, 2), false},
+		{net.IPv4(192, 168, 1, 2), net.IPv4(127, 0, 0, 1), false},
+		{net.IPv4(192, 168, 1, 2), net.IPv4(8, 8, 8, 8), true},
+		{net.IPv4(8, 8, 8, 8), net.IPv4(8, 8, 8, 8), false},
+		{net.IPv4(8, 8, 8, 8), net.IPv4(8, 8, 4, 4), true},
+		{net.IPv4(8, 8, 8, 8), net.IPv4(192, 168, 1, 2), false},
+	}
+
+	d, _ := newTestDiscoveryV5(t)
+	for _, test := range testCases {
+		setFallbackIP(d.localnode, test.current)
+		require.Equal(t, test.update, d.shouldUpdateIP(test.addr), "from %s to %s", test.current, test.addr)
+	}
+}
+
+func TestFallbackIP(t *testing.T) {
+	testCases := []struct {
+		ip       net.IP
+		expected string
+	}{
+		{nil, "127.0.0.1"},
+		{net.IPv4zero, "127.0.0.1"},
+		{net.IPv6unspecified, "127.0.0.1"},
+		{net.ParseIP("192.168.1.1"), "192.168.1.1"},
+		{net.ParseIP("203.0.113.1"), "203.0.113.1"},
+	}
+
+	for _, tc := range testCases {
+		db, err := enode.OpenDB("")
+		require.NoError(t, err)
+		key, err := gcrypto.GenerateKey()
+		require.NoError(t, err)
+
+		localnode := enode.NewLocalNode(db, key)
+		setFallbackIP(localnode, tc.ip)
+		require.Equal(t, tc.expected, localnode.Node().IP().String(), tc.ip)
+		db.Close()
+	}
+}
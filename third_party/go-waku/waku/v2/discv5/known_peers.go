@@ -0,0 +1,103 @@
+package discv5
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+// DiscoveredPeer is a node found in the discv5 routing table
+type DiscoveredPeer struct {
+	ID           peer.ID         `json:"peerID"`
+	ENR          string          `json:"enr"`
+	Addrs        []ma.Multiaddr  `json:"addrs"`
+	Flags        WakuEnrBitfield `json:"flags"`
+	DiscoveredAt time.Time       `json:"discoveredAt"`
+}
+
+// discoveryTimes keeps the time each node was first seen, since the
+// routing table does not expose it
+type discoveryTimes struct {
+	sync.Mutex
+	times map[enode.ID]time.Time
+}
+
+func (d *discoveryTimes) seen(id enode.ID) time.Time {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.times == nil {
+		d.times = make(map[enode.ID]time.Time)
+	}
+
+	t, ok := d.times[id]
+	if !ok {
+		t = time.Now()
+		d.times[id] = t
+	}
+	return t
+}
+
+// retain forgets the nodes that are no longer in the routing table
+func (d *discoveryTimes) retain(nodes []*enode.Node) {
+	d.Lock()
+	defer d.Unlock()
+
+	known := make(map[enode.ID]struct{}, len(nodes))
+	for _, n := range nodes {
+		known[n.ID()] = struct{}{}
+	}
+
+	for id := range d.times {
+		if _, ok := known[id]; !ok {
+			delete(d.times, id)
+		}
+	}
+}
+
+// KnownPeers returns the nodes currently in the routing table, whether
+// a connection to them was attempted or not. Nodes without an ip and tcp
+// port or a multiaddrs field are returned without addresses
+func (d *DiscoveryV5) KnownPeers() []DiscoveredPeer {
+	d.Lock()
+	listener := d.listener
+	d.Unlock()
+
+	if listener == nil {
+		return nil
+	}
+
+	nodes := listener.AllNodes()
+	d.discoveryTimes.retain(nodes)
+
+	var result []DiscoveredPeer
+	for _, n := range nodes {
+		peerID, err := utils.EnodeToPeerID(n)
+		if err != nil {
+			log.Error("could not obtain peer id from enode:", err)
+			continue
+		}
+
+		discovered := DiscoveredPeer{
+			ID:           peerID,
+			ENR:          n.String(),
+			DiscoveredAt: d.discoveryTimes.seen(n.ID()),
+		}
+
+		if info, err := utils.EnrToAddrInfo(n); err == nil {
+			discovered.Addrs = info.Addrs
+		}
+
+		if flags, err := WakuEnrBitfieldFromNode(n); err == nil {
+			discovered.Flags = flags
+		}
+
+		result = append(result, discovered)
+	}
+
+	return result
+}
@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"context"
+
+	logging "github.com/ipfs/go-log"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var log = logging.Logger("metrics")
+
+var (
+	Messages            = stats.Int64("node_messages", "Number of messages received", stats.UnitDimensionless)
+	Peers               = stats.Int64("peers", "Number of connected peers", stats.UnitDimensionless)
+	Dials               = stats.Int64("dials", "Number of peer dials", stats.UnitDimensionless)
+	StoreMessages       = stats.Int64("store_messages", "Number of historical messages", stats.UnitDimensionless)
+	FilterSubscriptions = stats.Int64("filter_subscriptions", "Number of filter subscriptions", stats.UnitDimensionless)
+	StoreErrors         = stats.Int64("errors", "Number of errors in store protocol", stats.UnitDimensionless)
+	LightpushErrors     = stats.Int64("errors", "Number of errors in lightpush protocol", stats.UnitDimensionless)
+	PeerExchangeServed  = stats.Int64("peer_exchange_served", "Number of peer exchange requests served", stats.UnitDimensionless)
+	PeerExchangePeers   = stats.Int64("peer_exchange_peers", "Number of peers obtained with peer exchange", stats.UnitDimensionless)
+	GatedConnections    = stats.Int64("gated_connections", "Number of connections refused by the connection gater", stats.UnitDimensionless)
+	KeepAlivePings      = stats.Int64("keepalive_pings", "Number of keepalive pings attempted", stats.UnitDimensionless)
+	PeerstorePeers      = stats.Int64("peerstore_peers", "Number of peers in the peerstore", stats.UnitDimensionless)
+	DiscV5PeersSeen     = stats.Int64("discv5_peers_seen", "Number of nodes found by discv5", stats.UnitDimensionless)
+	DiscV5PeersAccepted = stats.Int64("discv5_peers_accepted", "Number of nodes found by discv5 matching the discovery predicate", stats.UnitDimensionless)
+	RendezvousFailures  = stats.Int64("rendezvous_registration_failures", "Number of failed rendezvous registrations", stats.UnitDimensionless)
+	RelayRejected       = stats.Int64("relay_rejected_messages", "Number of relay messages rejected by the validators", stats.UnitDimensionless)
+	StoreTopicMessages  = stats.Int64("store_topic_messages", "Number of historical messages of a pubsub topic", stats.UnitDimensionless)
+	StoreArchiveSize    = stats.Int64("store_archive_size", "Approximate size of the historical messages", stats.UnitBytes)
+	StoreOldestMessage  = stats.Float64("store_oldest_message", "Timestamp of the oldest historical message", stats.UnitSeconds)
+	StoreNewestMessage  = stats.Float64("store_newest_message", "Timestamp of the newest historical message", stats.UnitSeconds)
+	BroadcastDropped    = stats.Int64("broadcast_dropped", "Number of messages dropped because a subscriber queue was full", stats.UnitDimensionless)
+	BandwidthIn         = stats.Int64("bandwidth_in", "Data received by the node", stats.UnitBytes)
+	BandwidthOut        = stats.Int64("bandwidth_out", "Data sent by the node", stats.UnitBytes)
+)
+
+var (
+	KeyType, _   = tag.NewKey("type")
+	ErrorType, _ = tag.NewKey("error_type")
+	Topic, _     = tag.NewKey("pubsub_topic")
+	Protocol, _  = tag.NewKey("protocol")
+)
+
+// BandwidthTotal is the protocol tag of the bandwidth of all protocols
+const BandwidthTotal = "total"
+
+var (
+	PeersView = &view.View{
+		Name:        "gowaku_connected_peers",
+		Measure:     Peers,
+		Description: "Number of connected peers",
+		Aggregation: view.Sum(),
+	}
+	DialsView = &view.View{
+		Name:        "gowaku_peers_dials",
+		Measure:     Dials,
+		Description: "Number of peer dials",
+		Aggregation: view.Count(),
+	}
+	MessageView = &view.View{
+		Name:        "gowaku_node_messages",
+		Measure:     Messages,
+		Description: "The number of the messages received",
+		Aggregation: view.Count(),
+	}
+	StoreMessagesView = &view.View{
+		Name:        "gowaku_store_messages",
+		Measure:     StoreMessages,
+		Description: "The distribution of the store protocol messages",
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{KeyType},
+	}
+	FilterSubscriptionsView = &view.View{
+		Name:        "gowaku_filter_subscriptions",
+		Measure:     FilterSubscriptions,
+		Description: "The number of content filter subscriptions",
+		Aggregation: view.LastValue(),
+	}
+	StoreErrorTypesView = &view.View{
+		Name:        "gowaku_store_errors",
+		Measure:     StoreErrors,
+		Description: "The distribution of the store protocol errors",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyType},
+	}
+	LightpushErrorTypesView = &view.View{
+		Name:        "gowaku_lightpush_errors",
+		Measure:     LightpushErrors,
+		Description: "The distribution of the lightpush protocol errors",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyType},
+	}
+	PeerExchangeServedView = &view.View{
+		Name:        "gowaku_peer_exchange_served",
+		Measure:     PeerExchangeServed,
+		Description: "The number of peer exchange requests served",
+		Aggregation: view.Count(),
+	}
+	PeerExchangePeersView = &view.View{
+		Name:        "gowaku_peer_exchange_peers",
+		Measure:     PeerExchangePeers,
+		Description: "The number of peers obtained with peer exchange",
+		Aggregation: view.Sum(),
+	}
+	GatedConnectionsView = &view.View{
+		Name:        "gowaku_gated_connections",
+		Measure:     GatedConnections,
+		Description: "The distribution of the connections refused by the connection gater",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyType},
+	}
+	KeepAlivePingsView = &view.View{
+		Name:        "gowaku_keepalive_pings",
+		Measure:     KeepAlivePings,
+		Description: "The number of keepalive pings attempted",
+		Aggregation: view.Count(),
+	}
+	PeerstorePeersView = &view.View{
+		Name:        "gowaku_peerstore_peers",
+		Measure:     PeerstorePeers,
+		Description: "The number of peers in the peerstore",
+		Aggregation: view.LastValue(),
+	}
+	DiscV5PeersSeenView = &view.View{
+		Name:        "gowaku_discv5_peers_seen",
+		Measure:     DiscV5PeersSeen,
+		Description: "The number of nodes found by discv5",
+		Aggregation: view.Count(),
+	}
+	DiscV5PeersAcceptedView = &view.View{
+		Name:        "gowaku_discv5_peers_accepted",
+		Measure:     DiscV5PeersAccepted,
+		Description: "The number of nodes found by discv5 matching the discovery predicate",
+		Aggregation: view.Count(),
+	}
+	RendezvousFailuresView = &view.View{
+		Name:        "gowaku_rendezvous_registration_failures",
+		Measure:     RendezvousFailures,
+		Description: "The number of failed rendezvous registrations",
+		Aggregation: view.Count(),
+	}
+	RelayRejectedView = &view.View{
+		Name:        "gowaku_relay_rejected_messages",
+		Measure:     RelayRejected,
+		Description: "The distribution of the relay messages rejected by the validators",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Topic},
+	}
+	StoreTopicMessagesView = &view.View{
+		Name:        "gowaku_store_topic_messages",
+		Measure:     StoreTopicMessages,
+		Description: "The number of historical messages per pubsub topic",
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{Topic},
+	}
+	StoreArchiveSizeView = &view.View{
+		Name:        "gowaku_store_archive_size",
+		Measure:     StoreArchiveSize,
+		Description: "The approximate size of the historical messages",
+		Aggregation: view.LastValue(),
+	}
+	StoreOldestMessageView = &view.View{
+		Name:        "gowaku_store_oldest_message",
+		Measure:     StoreOldestMessage,
+		Description: "The timestamp of the oldest historical message",
+		Aggregation: view.LastValue(),
+	}
+	StoreNewestMessageView = &view.View{
+		Name:        "gowaku_store_newest_message",
+		Measure:     StoreNewestMessage,
+		Description: "The timestamp of the newest historical message",
+		Aggregation: view.LastValue(),
+	}
+	BroadcastDroppedView = &view.View{
+		Name:        "gowaku_broadcast_dropped",
+		Measure:     BroadcastDropped,
+		Description: "The distribution of the messages dropped because a subscriber queue was full",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyType},
+	}
+	BandwidthInView = &view.View{
+		Name:        "gowaku_bandwidth_in",
+		Measure:     BandwidthIn,
+		Description: "The data received by the node per protocol",
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{Protocol},
+	}
+	BandwidthOutView = &view.View{
+		Name:        "gowaku_bandwidth_out",
+		Measure:     BandwidthOut,
+		Description: "The data sent by the node per protocol",
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{Protocol},
+	}
+)
+
+func RecordLightpushError(ctx context.Context, tagType string) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(tag.Key(ErrorType), tagType)}, LightpushErrors.M(1)); err != nil {
+		log.Error("failed to record with tags", err)
+	}
+}
+
+func RecordMessage(ctx context.Context, tagType string, len int) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(KeyType, tagType)}, StoreMessages.M(int64(len))); err != nil {
+		log.Error("failed to record with tags", err)
+	}
+}
+
+// RecordArchive records the number of historical messages of each pubsub
+// topic, their size and the timestamps of the oldest and newest messages
+func RecordArchive(ctx context.Context, topicMessages map[string]int, size int64, oldest float64, newest float64) {
+	for topic, count := range topicMessages {
+		if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(Topic, topic)}, StoreTopicMessages.M(int64(count))); err != nil {
+			log.Error("failed to record with tags", err)
+		}
+	}
+
+	stats.Record(ctx, StoreArchiveSize.M(size), StoreOldestMessage.M(oldest), StoreNewestMessage.M(newest))
+}
+
+func RecordBroadcastDrop(ctx context.Context, subscriber string) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(KeyType, subscriber)}, BroadcastDropped.M(1)); err != nil {
+		log.Error("failed to record with tags", err)
+	}
+}
+
+// RecordBandwidth records the data received and sent by the node in the
+// streams of a protocol, or in total with BandwidthTotal
+func RecordBandwidth(ctx context.Context, protocol string, in int64, out int64) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(Protocol, protocol)}, BandwidthIn.M(in), BandwidthOut.M(out)); err != nil {
+		log.Error("failed to record with tags", err)
+	}
+}
+
+func RecordStoreError(ctx context.Context, tagType string) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(ErrorType, tagType)}, StoreErrors.M(1)); err != nil {
+		log.Error("failed to record with tags", err)
+	}
+}
+
+func RecordRejectedMessage(ctx context.Context, topic string) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(Topic, topic)}, RelayRejected.M(1)); err != nil {
+		log.Error("failed to record with tags", err)
+	}
+}
+
+func RecordGatedConnection(ctx context.Context, tagType string) {
+	if err := stats.RecordWithTags(ctx, []tag.Mutator{tag.Insert(KeyType, tagType)}, GatedConnections.M(1)); err != nil {
+		log.Error("failed to record with tags", err)
+	}
+}
@@ -0,0 +1,173 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var ErrPeerBanned = errors.New("peer is banned")
+
+// BannedPeer is a peer the node refuses to connect to until Expiry.
+// A zero Expiry means the ban is permanent
+type BannedPeer struct {
+	ID     peer.ID
+	Reason string
+	Expiry time.Time
+}
+
+func (b BannedPeer) expired(now time.Time) bool {
+	return !b.Expiry.IsZero() && now.After(b.Expiry)
+}
+
+// BanListStorage is used to persist the banned peers, so bans are kept
+// when the node is restarted
+type BanListStorage interface {
+	Load() ([]BannedPeer, error)
+	Save(peers []BannedPeer) error
+}
+
+type banList struct {
+	sync.RWMutex
+	peers   map[peer.ID]BannedPeer
+	storage BanListStorage
+}
+
+func newBanList(storage BanListStorage) (*banList, error) {
+	b := &banList{
+		peers:   make(map[peer.ID]BannedPeer),
+		storage: storage,
+	}
+
+	if storage != nil {
+		peers, err := storage.Load()
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		for _, p := range peers {
+			if !p.expired(now) {
+				b.peers[p.ID] = p
+			}
+		}
+	}
+
+	return b, nil
+}
+
+func (b *banList) isBanned(id peer.ID) bool {
+	b.RLock()
+	ban, ok := b.peers[id]
+	b.RUnlock()
+
+	if ok && ban.expired(time.Now()) {
+		b.remove(id)
+		return false
+	}
+
+	return ok
+}
+
+func (b *banList) get(id peer.ID) *BannedPeer {
+	if !b.isBanned(id) {
+		return nil
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+
+	ban, ok := b.peers[id]
+	if !ok {
+		return nil
+	}
+	return &ban
+}
+
+func (b *banList) add(ban BannedPeer) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.peers[ban.ID] = ban
+	b.save()
+}
+
+func (b *banList) remove(id peer.ID) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if _, ok := b.peers[id]; !ok {
+		return false
+	}
+
+	delete(b.peers, id)
+	b.save()
+	return true
+}
+
+func (b *banList) list() []BannedPeer {
+	b.RLock()
+	defer b.RUnlock()
+
+	now := time.Now()
+	result := make([]BannedPeer, 0, len(b.peers))
+	for _, ban := range b.peers {
+		if !ban.expired(now) {
+			result = append(result, ban)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result
+}
+
+// save must be called with the lock held
+func (b *banList) save() {
+	if b.storage == nil {
+		return
+	}
+
+	peers := make([]BannedPeer, 0, len(b.peers))
+	for _, ban := range b.peers {
+		peers = append(peers, ban)
+	}
+
+	if err := b.storage.Save(peers); err != nil {
+		log.Error("could not save banned peers", err)
+	}
+}
+
+// BanPeer disconnects a peer and refuses its connections for the given duration.
+// A duration of 0 bans the peer permanently
+func (w *WakuNode) BanPeer(id peer.ID, duration time.Duration, reason string) {
+	ban := BannedPeer{ID: id, Reason: reason}
+	if duration > 0 {
+		ban.Expiry = time.Now().Add(duration)
+	}
+	w.bans.add(ban)
+	w.forgetSavedPeer(id)
+
+	log.Info(fmt.Sprintf("Banning peer %s: %s", id, reason))
+
+	if err := w.closePeer(id); err != nil {
+		log.Debug(fmt.Sprintf("could not close connection to %s: %s", id, err.Error()))
+	}
+}
+
+// UnbanPeer allows a banned peer to connect again. It returns
+// false if the peer was not banned
+func (w *WakuNode) UnbanPeer(id peer.ID) bool {
+	return w.bans.remove(id)
+}
+
+// BannedPeers returns the peers that are currently banned
+func (w *WakuNode) BannedPeers() []BannedPeer {
+	return w.bans.list()
+}
@@ -0,0 +1,86 @@
+package node
+
+import (
+	"time"
+
+	libp2pmetrics "github.com/libp2p/go-libp2p-core/metrics"
+	p2pproto "github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+)
+
+// Interval between the recordings of the bandwidth metrics
+const bandwidthRecordingInterval = 10 * time.Second
+
+// BandwidthStats is the data sent and received by the node, in bytes, and
+// the current rates, in bytes per second
+type BandwidthStats struct {
+	TotalIn  int64
+	TotalOut int64
+	RateIn   float64
+	RateOut  float64
+}
+
+func newBandwidthStats(stats libp2pmetrics.Stats) BandwidthStats {
+	return BandwidthStats{
+		TotalIn:  stats.TotalIn,
+		TotalOut: stats.TotalOut,
+		RateIn:   stats.RateIn,
+		RateOut:  stats.RateOut,
+	}
+}
+
+// BandwidthTotals returns the data sent and received by the node, regardless
+// of the protocol. It's zero unless the node was created WithBandwidthReporter
+func (w *WakuNode) BandwidthTotals() BandwidthStats {
+	if w.opts.bandwidthCounter == nil {
+		return BandwidthStats{}
+	}
+
+	return newBandwidthStats(w.opts.bandwidthCounter.GetBandwidthTotals())
+}
+
+// BandwidthByProtocol returns the data sent and received in the streams of each
+// protocol. The traffic of the connections themselves, i.e. the handshakes, is
+// only counted in the totals. The relay traffic is accounted to the protocol
+// negotiated with each peer, usually gossipsub rather than relay.WakuRelayID_v200.
+// It's nil unless the node was created WithBandwidthReporter
+func (w *WakuNode) BandwidthByProtocol() map[p2pproto.ID]BandwidthStats {
+	if w.opts.bandwidthCounter == nil {
+		return nil
+	}
+
+	result := make(map[p2pproto.ID]BandwidthStats)
+	for proto, stats := range w.opts.bandwidthCounter.GetBandwidthByProtocol() {
+		result[proto] = newBandwidthStats(stats)
+	}
+	return result
+}
+
+// startBandwidthRecording periodically records the bandwidth metrics
+func (w *WakuNode) startBandwidthRecording() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(bandwidthRecordingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-ticker.C:
+				w.recordBandwidth()
+			}
+		}
+	}()
+}
+
+func (w *WakuNode) recordBandwidth() {
+	totals := w.BandwidthTotals()
+	metrics.RecordBandwidth(w.ctx, metrics.BandwidthTotal, totals.TotalIn, totals.TotalOut)
+
+	for proto, stats := range w.BandwidthByProtocol() {
+		metrics.RecordBandwidth(w.ctx, string(proto), stats.TotalIn, stats.TotalOut)
+	}
+}
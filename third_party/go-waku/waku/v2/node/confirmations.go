@@ -0,0 +1,274 @@
+package node
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	v2 "github.com/status-im/go-waku/waku/v2"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// Maximum number of messages waiting for confirmations. Once exceeded, the
+// oldest one is reported as unconfirmed
+const maxPendingConfirmations = 1000
+
+// Number of confirmation events buffered for each subscriber. When a
+// subscriber falls behind, the oldest event is dropped
+const confirmationsBufferSize = 100
+
+// MessageConfirmation tells whether a message published by the node was seen
+// by other peers: received back from them through relay, or returned by their
+// store. Relay doesn't send messages back to their publisher, so the messages
+// published with relay are usually confirmed by store queries
+type MessageConfirmation struct {
+	Hash        []byte
+	ConfirmedBy []peer.ID
+	Method      PublishPath
+	// False if the timeout expired before enough peers confirmed the message
+	Confirmed bool
+}
+
+type pendingConfirmation struct {
+	hash        string
+	method      PublishPath
+	confirmedBy []peer.ID
+	expires     time.Time
+}
+
+func (p *pendingConfirmation) confirmed(id peer.ID) bool {
+	for _, c := range p.confirmedBy {
+		if c == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *pendingConfirmation) event(confirmed bool) MessageConfirmation {
+	return MessageConfirmation{
+		Hash:        []byte(p.hash),
+		ConfirmedBy: p.confirmedBy,
+		Method:      p.method,
+		Confirmed:   confirmed,
+	}
+}
+
+// confirmationTracker waits for the confirmations of the messages published
+// by the node. The pending messages are kept in the order they were published,
+// so the oldest ones expire or are evicted first
+type confirmationTracker struct {
+	sync.Mutex
+	required int
+	timeout  time.Duration
+	pending  map[string]*list.Element
+	order    *list.List
+
+	subs confirmationSubscribers
+}
+
+func newConfirmationTracker(required int, timeout time.Duration) *confirmationTracker {
+	return &confirmationTracker{
+		required: required,
+		timeout:  timeout,
+		pending:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// track waits for the confirmations of a published message
+func (t *confirmationTracker) track(hash []byte, method PublishPath) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.pending[string(hash)]; ok {
+		return
+	}
+
+	t.pending[string(hash)] = t.order.PushBack(&pendingConfirmation{
+		hash:    string(hash),
+		method:  method,
+		expires: time.Now().Add(t.timeout),
+	})
+
+	if t.order.Len() > maxPendingConfirmations {
+		t.subs.publish(t.remove(t.order.Front()).event(false))
+	}
+}
+
+// confirm records that a peer has a message. Messages that are not pending
+// are ignored
+func (t *confirmationTracker) confirm(hash []byte, id peer.ID) {
+	t.Lock()
+	defer t.Unlock()
+
+	e, ok := t.pending[string(hash)]
+	if !ok {
+		return
+	}
+
+	p := e.Value.(*pendingConfirmation)
+	if p.confirmed(id) {
+		return
+	}
+
+	p.confirmedBy = append(p.confirmedBy, id)
+	if len(p.confirmedBy) >= t.required {
+		t.subs.publish(t.remove(e).event(true))
+	}
+}
+
+// expire reports the messages whose timeout expired as unconfirmed
+func (t *confirmationTracker) expire(now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	for e := t.order.Front(); e != nil && !now.Before(e.Value.(*pendingConfirmation).expires); e = t.order.Front() {
+		t.subs.publish(t.remove(e).event(false))
+	}
+}
+
+func (t *confirmationTracker) remove(e *list.Element) *pendingConfirmation {
+	p := t.order.Remove(e).(*pendingConfirmation)
+	delete(t.pending, p.hash)
+	return p
+}
+
+// retrieved confirms the messages returned by the store of a peer
+func (t *confirmationTracker) retrieved(id peer.ID, messages []*pb.WakuMessage) {
+	for _, msg := range messages {
+		data, err := msg.Marshal()
+		if err != nil {
+			continue
+		}
+		t.confirm(pb.Hash(data), id)
+	}
+}
+
+type confirmationSubscribers struct {
+	sync.Mutex
+	subs   map[chan MessageConfirmation]struct{}
+	closed bool
+}
+
+func (c *confirmationSubscribers) subscribe() (<-chan MessageConfirmation, func()) {
+	c.Lock()
+	defer c.Unlock()
+
+	ch := make(chan MessageConfirmation, confirmationsBufferSize)
+	if c.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if c.subs == nil {
+		c.subs = make(map[chan MessageConfirmation]struct{})
+	}
+	c.subs[ch] = struct{}{}
+
+	return ch, func() {
+		c.Lock()
+		defer c.Unlock()
+		if _, ok := c.subs[ch]; ok {
+			delete(c.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (c *confirmationSubscribers) publish(evt MessageConfirmation) {
+	c.Lock()
+	defer c.Unlock()
+
+	for ch := range c.subs {
+		select {
+		case ch <- evt:
+			continue
+		default:
+		}
+
+		// Drop the oldest event to make room for the new one
+		select {
+		case <-ch:
+		default:
+		}
+
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+func (c *confirmationSubscribers) close() {
+	c.Lock()
+	defer c.Unlock()
+
+	for ch := range c.subs {
+		close(ch)
+	}
+	c.subs = nil
+	c.closed = true
+}
+
+// SubscribeMessageConfirmations returns a channel where the confirmations of
+// the messages published by the node are pushed to, and a function to cancel
+// the subscription. Each message gets a single event, once enough peers
+// confirmed it or after the timeout set WithMessageConfirmations. The channel
+// is closed when the subscription is cancelled or the node is closed, and
+// right away if the confirmations are disabled
+func (w *WakuNode) SubscribeMessageConfirmations() (<-chan MessageConfirmation, func()) {
+	if w.confirmations == nil {
+		ch := make(chan MessageConfirmation)
+		close(ch)
+		return ch, func() {}
+	}
+	return w.confirmations.subs.subscribe()
+}
+
+// trackPublished waits for the confirmations of a message published by the node
+func (w *WakuNode) trackPublished(hash []byte, method PublishPath) {
+	if w.confirmations != nil {
+		w.confirmations.track(hash, method)
+	}
+}
+
+// startConfirmationTracking tracks the messages published with relay, which
+// the broadcaster receives from the node itself, and confirms the pending
+// messages received from other peers
+func (w *WakuNode) startConfirmationTracking() {
+	w.confirmationC = make(chan *protocol.Envelope, 1024)
+	w.bcaster.Register(w.confirmationC, v2.WithName("confirmations"))
+
+	interval := w.confirmations.timeout / 10
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.quit:
+				return
+			case env := <-w.confirmationC:
+				switch from := env.ReceivedFrom(); from {
+				case "":
+				case w.host.ID():
+					w.confirmations.track(env.Hash(), PublishedWithRelay)
+				default:
+					w.confirmations.confirm(env.Hash(), from)
+				}
+			case now := <-ticker.C:
+				w.confirmations.expire(now)
+			}
+		}
+	}()
+}
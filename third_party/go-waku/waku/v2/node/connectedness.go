@@ -0,0 +1,415 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+	"go.opencensus.io/stats"
+)
+
+// A map of peer IDs to supported protocols
+type PeerStats map[peer.ID][]string
+
+type ConnStatus struct {
+	IsOnline   bool
+	HasHistory bool
+	Peers      PeerStats
+	// Set when the notification was caused by a peer disconnection
+	Disconnection *PeerDisconnection
+	NAT           NATStatus
+	// Whether the node is publicly dialable. Only determined WithAutoNAT
+	Reachability network.Reachability
+	// Number of relay peers of each subscribed topic. Nil without relay
+	RelayPeers map[string]int
+	// Number of connected peers by protocol and direction
+	Counts PeerCounts
+	// Health of each subscribed topic. Only evaluated WithTopicHealthMonitoring
+	TopicHealth map[string]TopicHealth
+
+	ListenAddresses []string
+	// Empty if the node has no record
+	ENR string
+	// Number of connected peers supporting each waku protocol, by protocol ID
+	Protocols map[string]int
+}
+
+type ConnectionNotifier struct {
+	h              host.Host
+	ctx            context.Context
+	DisconnectChan chan peer.ID
+	quit           chan struct{}
+	bans           *banList
+	activity       *peerActivity
+	pingBackoffs   *pingBackoffs
+}
+
+func NewConnectionNotifier(ctx context.Context, h host.Host) ConnectionNotifier {
+	return ConnectionNotifier{
+		h:              h,
+		ctx:            ctx,
+		DisconnectChan: make(chan peer.ID, 100),
+		quit:           make(chan struct{}),
+	}
+}
+
+func (c ConnectionNotifier) Listen(n network.Network, m ma.Multiaddr) {
+	// called when network starts listening on an addr
+}
+
+func (c ConnectionNotifier) ListenClose(n network.Network, m ma.Multiaddr) {
+	// called when network stops listening on an addr
+}
+
+func (c ConnectionNotifier) Connected(n network.Network, cc network.Conn) {
+	// called when a connection opened
+	log.Info(fmt.Sprintf("Peer %s connected", cc.RemotePeer()))
+	stats.Record(c.ctx, metrics.Peers.M(1))
+	c.activity.seen(cc.RemotePeer())
+	c.pingBackoffs.reset(cc.RemotePeer())
+
+	// The connection gater refuses banned peers, but a connection
+	// can be established while the peer is being banned
+	if c.bans != nil && c.bans.isBanned(cc.RemotePeer()) {
+		go func() {
+			_ = cc.Close()
+		}()
+	}
+}
+
+func (c ConnectionNotifier) Disconnected(n network.Network, cc network.Conn) {
+	// called when a connection closed
+	log.Info(fmt.Sprintf("Peer %s disconnected", cc.RemotePeer()))
+	stats.Record(c.ctx, metrics.Peers.M(-1))
+	if n.Connectedness(cc.RemotePeer()) != network.Connected {
+		c.activity.remove(cc.RemotePeer())
+	}
+
+	// Nothing reads the channel once the node stopped
+	select {
+	case c.DisconnectChan <- cc.RemotePeer():
+	case <-c.quit:
+	}
+}
+
+func (c ConnectionNotifier) OpenedStream(n network.Network, s network.Stream) {
+	// called when a stream opened
+	c.activity.seen(s.Conn().RemotePeer())
+}
+
+func (c ConnectionNotifier) ClosedStream(n network.Network, s network.Stream) {
+	// called when a stream closed
+	c.activity.seen(s.Conn().RemotePeer())
+}
+
+func (c ConnectionNotifier) Close() {
+	close(c.quit)
+}
+
+// Number of connection status updates buffered for each subscriber. When a
+// subscriber falls behind, the oldest update is dropped
+const connStatusBufferSize = 10
+
+type connStatusSubscribers struct {
+	sync.Mutex
+	subs   map[chan ConnStatus]struct{}
+	closed bool
+}
+
+func (c *connStatusSubscribers) subscribe() (<-chan ConnStatus, func()) {
+	c.Lock()
+	defer c.Unlock()
+
+	ch := make(chan ConnStatus, connStatusBufferSize)
+	if c.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if c.subs == nil {
+		c.subs = make(map[chan ConnStatus]struct{})
+	}
+	c.subs[ch] = struct{}{}
+
+	return ch, func() {
+		c.Lock()
+		defer c.Unlock()
+		if _, ok := c.subs[ch]; ok {
+			delete(c.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (c *connStatusSubscribers) publish(connStatus ConnStatus) {
+	c.Lock()
+	defer c.Unlock()
+
+	for ch := range c.subs {
+		select {
+		case ch <- connStatus:
+			continue
+		default:
+		}
+
+		// Drop the oldest update to make room for the new one
+		select {
+		case <-ch:
+		default:
+		}
+
+		select {
+		case ch <- connStatus:
+		default:
+		}
+	}
+}
+
+func (c *connStatusSubscribers) close() {
+	c.Lock()
+	defer c.Unlock()
+
+	for ch := range c.subs {
+		close(ch)
+	}
+	c.subs = nil
+	c.closed = true
+}
+
+// Minimum interval of time between connection status updates. The changes
+// happening in between are coalesced in a single update
+const connStatusInterval = 500 * time.Millisecond
+
+// connStatusUpdates are the connection status changes not pushed yet
+type connStatusUpdates struct {
+	sync.Mutex
+	pending       bool
+	disconnection *PeerDisconnection
+	// Wakes up the connStatusPublisher when a change is pending
+	trigger chan struct{}
+}
+
+// SubscribeConnStatus returns a channel where the connection status changes are
+// pushed to, and a function to cancel the subscription. Updates are never
+// blocked by a slow subscriber, which only misses the oldest ones instead. The
+// channel is closed when the subscription is cancelled or the node is closed.
+// At most one update is pushed every 500ms: the changes happening in between
+// are coalesced, and the Disconnection is the last one since the previous
+// update. Every disconnection is available with SubscribePeerEvents
+func (w *WakuNode) SubscribeConnStatus() (<-chan ConnStatus, func()) {
+	return w.connStatusSubs.subscribe()
+}
+
+// forwardConnStatus pushes the status changes to the channel received with
+// WithConnectionStatusChannel, until the node is closed
+func (w *WakuNode) forwardConnStatus(connStatusC <-chan ConnStatus) {
+	for connStatus := range connStatusC {
+		select {
+		case w.connStatusChan <- connStatus:
+		case <-w.nodeCtx.Done():
+			return
+		}
+	}
+}
+
+// sendConnStatus records that the connection status changed. The update is
+// pushed by the connStatusPublisher
+func (w *WakuNode) sendConnStatus(disconnection *PeerDisconnection) {
+	w.connStatusUpdates.Lock()
+	w.connStatusUpdates.pending = true
+	if disconnection != nil {
+		w.connStatusUpdates.disconnection = disconnection
+	}
+	w.connStatusUpdates.Unlock()
+
+	select {
+	case w.connStatusUpdates.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// connStatusPublisher pushes the pending connection status changes, at most
+// once per connStatusInterval. A change is pushed right away if no update was
+// pushed during the last interval, so isolated changes aren't delayed
+func (w *WakuNode) connStatusPublisher() {
+	defer w.wg.Done()
+
+	var last time.Time
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-w.connStatusUpdates.trigger:
+			if timerC != nil {
+				continue
+			}
+			if wait := connStatusInterval - time.Since(last); wait > 0 {
+				timer = time.NewTimer(wait)
+				timerC = timer.C
+				continue
+			}
+		case <-timerC:
+			timer, timerC = nil, nil
+		}
+
+		if w.publishConnStatus() {
+			last = time.Now()
+		}
+	}
+}
+
+// publishConnStatus pushes the connection status if it changed since the last
+// update
+func (w *WakuNode) publishConnStatus() bool {
+	w.connStatusUpdates.Lock()
+	pending, disconnection := w.connStatusUpdates.pending, w.connStatusUpdates.disconnection
+	w.connStatusUpdates.pending, w.connStatusUpdates.disconnection = false, nil
+	w.connStatusUpdates.Unlock()
+
+	if !pending {
+		return false
+	}
+
+	isOnline, hasHistory := w.Status()
+	w.onlineChanged(isOnline)
+	connStatus := ConnStatus{IsOnline: isOnline, HasHistory: hasHistory, Peers: w.PeerStats(), Disconnection: disconnection, NAT: w.NATStatus(), Reachability: w.Reachability(), RelayPeers: w.relayPeerCounts(), Counts: w.PeerCounts(), TopicHealth: w.TopicHealth()}
+	for _, addr := range w.ListenAddresses() {
+		connStatus.ListenAddresses = append(connStatus.ListenAddresses, addr.String())
+	}
+	if node, err := w.ENR(); err == nil {
+		connStatus.ENR = node.String()
+	}
+	connStatus.Protocols = w.protocolPeerCounts()
+	w.connStatusSubs.publish(connStatus)
+	return true
+}
+
+func (w *WakuNode) connectednessListener() {
+	defer w.wg.Done()
+
+	// Nil unless AutoNAT is enabled, so it never fires
+	var reachabilityChan <-chan interface{}
+	if w.reachabilityEventSub != nil {
+		reachabilityChan = w.reachabilityEventSub.Out()
+	}
+
+	announced := make(announcedPeers)
+
+	for {
+		var disconnection *PeerDisconnection
+		select {
+		case <-w.quit:
+			return
+		case e := <-w.protocolEventSub.Out():
+			evt := e.(event.EvtPeerProtocolsUpdated)
+			if _, ok := announced[evt.Peer]; ok {
+				w.sendPeerEvent(PeerIdentified, evt.Peer, false)
+			}
+		case e := <-w.identificationEventSub.Out():
+			switch evt := e.(type) {
+			case event.EvtPeerIdentificationCompleted:
+				w.peerIdentified(announced, evt.Peer)
+				if w.filter != nil {
+					w.filter.PeerIdentified(evt.Peer)
+				}
+			case event.EvtPeerIdentificationFailed:
+				// The peer is still connected, even if its protocols are unknown
+				w.peerIdentified(announced, evt.Peer)
+			}
+		case <-w.relayPeersEventSub.Out():
+			// Only the relay peer counts of the status change
+		case id := <-w.connectionNotif.DisconnectChan:
+			disconnection = w.peerDisconnection(id)
+			w.peerDisconnected(disconnection)
+			w.peerEventDisconnected(announced, disconnection)
+			if w.filter != nil {
+				w.filter.PeerDisconnected(id)
+			}
+		case e := <-reachabilityChan:
+			evt := e.(event.EvtLocalReachabilityChanged)
+			log.Info(fmt.Sprintf("Node reachability changed to %s", evt.Reachability))
+			w.reachabilityMutex.Lock()
+			w.reachability = evt.Reachability
+			w.reachabilityMutex.Unlock()
+		}
+		w.sendConnStatus(disconnection)
+	}
+}
+
+func (w *WakuNode) relayPeerCounts() map[string]int {
+	if w.relay == nil {
+		return nil
+	}
+
+	result := make(map[string]int)
+	for _, topic := range w.relay.Topics() {
+		result[topic] = len(w.relay.PeersForTopic(topic))
+	}
+	return result
+}
+
+// Reachability returns whether the node is publicly dialable. It is always
+// unknown unless the node was created WithAutoNAT
+func (w *WakuNode) Reachability() network.Reachability {
+	w.reachabilityMutex.RLock()
+	defer w.reachabilityMutex.RUnlock()
+	return w.reachability
+}
+
+func (w *WakuNode) Status() (isOnline bool, hasHistory bool) {
+	hasRelay := false
+	hasLightPush := false
+	hasStore := false
+	hasFilter := false
+
+	for _, peer := range w.host.Network().Peers() {
+		protocols, err := w.host.Peerstore().GetProtocols(peer)
+		if err != nil {
+			log.Warn(fmt.Errorf("could not read peer %s protocols", peer))
+		}
+
+		for _, protocol := range protocols {
+			if !hasRelay && protocol == string(relay.WakuRelayID_v200) {
+				hasRelay = true
+			}
+			if !hasLightPush && protocol == string(lightpush.LightPushID_v20beta1) {
+				hasLightPush = true
+			}
+			if !hasStore && protocol == string(store.StoreID_v20beta3) {
+				hasStore = true
+			}
+			if !hasFilter && protocol == string(filter.FilterID_v20beta1) {
+				hasFilter = true
+			}
+		}
+	}
+
+	if hasStore {
+		hasHistory = true
+	}
+
+	if hasRelay || hasLightPush && (hasStore || hasFilter) {
+		isOnline = true
+	}
+
+	return
+}
@@ -0,0 +1,177 @@
+package node
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checkConnectedness waits for a connection status in which node is connected
+// or disconnected, as expected. The changes are coalesced, so the statuses in
+// between are skipped
+func checkConnectedness(t *testing.T, wg *sync.WaitGroup, connStatusChan <-chan ConnStatus, clientNode *WakuNode, node *WakuNode, nodeShouldBeConnected bool, shouldBeOnline bool, shouldHaveHistory bool, expectedPeers int) {
+	defer wg.Done()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case connStatus := <-connStatusChan:
+			_, ok := connStatus.Peers[node.Host().ID()]
+			if ok != nodeShouldBeConnected {
+				continue
+			}
+
+			require.True(t, connStatus.IsOnline == shouldBeOnline)
+			require.True(t, connStatus.HasHistory == shouldHaveHistory)
+			require.Len(t, clientNode.Host().Network().Peers(), expectedPeers)
+			return
+
+		case <-timeout:
+			require.Fail(t, "node should have connected")
+			return
+		}
+	}
+}
+
+func TestConnectionStatusChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connStatusChan := make(chan ConnStatus, 100)
+
+	// Node1: Only Relay
+	hostAddr1, err := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	node1, err := New(ctx,
+		WithHostAddress(hostAddr1),
+		WithWakuRelay(),
+		WithConnectionStatusChannel(connStatusChan),
+	)
+	require.NoError(t, err)
+	err = node1.Start()
+	require.NoError(t, err)
+
+	// Node2: Relay
+	hostAddr2, err := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	node2, err := New(ctx,
+		WithHostAddress(hostAddr2),
+		WithWakuRelay(),
+	)
+	require.NoError(t, err)
+	err = node2.Start()
+	require.NoError(t, err)
+
+	// Node3: Relay + Store
+	hostAddr3, err := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	node3, err := New(ctx,
+		WithHostAddress(hostAddr3),
+		WithWakuRelay(),
+		WithWakuStore(false, false),
+	)
+	require.NoError(t, err)
+	err = node3.Start()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go checkConnectedness(t, &wg, connStatusChan, node1, node2, true, true, false, 1)
+
+	err = node1.DialPeer(ctx, node2.ListenAddresses()[0].String())
+	require.NoError(t, err)
+
+	wg.Wait()
+
+	wg.Add(1)
+	go checkConnectedness(t, &wg, connStatusChan, node1, node3, true, true, true, 2)
+
+	err = node1.DialPeer(ctx, node3.ListenAddresses()[0].String())
+	require.NoError(t, err)
+	wg.Wait()
+
+	wg.Add(1)
+	go checkConnectedness(t, &wg, connStatusChan, node1, node3, false, true, false, 1)
+
+	node3.Stop()
+
+	wg.Wait()
+
+	wg.Add(1)
+	go checkConnectedness(t, &wg, connStatusChan, node1, node2, false, false, false, 0)
+
+	err = node1.ClosePeerById(node2.Host().ID())
+	require.NoError(t, err)
+	wg.Wait()
+
+	wg.Add(1)
+	go checkConnectedness(t, &wg, connStatusChan, node1, node2, true, true, false, 1)
+
+	err = node1.DialPeerByID(ctx, node2.Host().ID())
+	require.NoError(t, err)
+	wg.Wait()
+}
+
+func TestConnStatusSubscribers(t *testing.T) {
+	var subs connStatusSubscribers
+
+	stalled, _ := subs.subscribe()
+	active, cancel := subs.subscribe()
+
+	for i := 0; i <= connStatusBufferSize; i++ {
+		subs.publish(ConnStatus{IsOnline: i%2 == 0})
+		<-active
+	}
+
+	// A subscriber that falls behind loses the oldest updates, without blocking
+	// the others
+	require.Len(t, stalled, connStatusBufferSize)
+	require.Equal(t, false, (<-stalled).IsOnline)
+
+	cancel()
+	cancel()
+	_, ok := <-active
+	require.False(t, ok)
+
+	subs.close()
+	for range stalled {
+	}
+
+	closed, _ := subs.subscribe()
+	_, ok = <-closed
+	require.False(t, ok)
+}
+
+func TestSubscribeConnStatus(t *testing.T) {
+	ctx := context.Background()
+	hostAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}
+
+	node1, err := New(ctx, WithHostAddress(hostAddr), WithWakuRelay())
+	require.NoError(t, err)
+	require.NoError(t, node1.Start())
+	defer node1.Stop()
+
+	sub1, cancel1 := node1.SubscribeConnStatus()
+	defer cancel1()
+	sub2, cancel2 := node1.SubscribeConnStatus()
+	defer cancel2()
+
+	node2, err := New(ctx, WithHostAddress(hostAddr), WithWakuRelay())
+	require.NoError(t, err)
+	require.NoError(t, node2.Start())
+	defer node2.Stop()
+
+	require.NoError(t, node2.DialPeerWithMultiAddress(ctx, node1.ListenAddresses()[0]))
+
+	for _, sub := range []<-chan ConnStatus{sub1, sub2} {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		checkConnectedness(t, &wg, sub, node1, node2, true, true, false, 1)
+	}
+}
@@ -0,0 +1,206 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+)
+
+// Default number of connections the node prunes down to
+const DefaultConnectionsLowWater = 200
+
+// Default number of connections above which the node starts pruning
+const DefaultConnectionsHighWater = 300
+
+const connectionPruningInterval = time.Minute
+
+// PeerDisconnection describes a peer disconnection. Pruned is true
+// when the connection was closed by the connection manager, and Local
+// when the node closed the connection itself, for any reason
+type PeerDisconnection struct {
+	ID     peer.ID
+	Pruned bool
+	Local  bool
+}
+
+type peerSet struct {
+	sync.Mutex
+	peers map[peer.ID]struct{}
+}
+
+func newPeerSet() peerSet {
+	return peerSet{peers: make(map[peer.ID]struct{})}
+}
+
+func (s *peerSet) add(id peer.ID) {
+	s.Lock()
+	defer s.Unlock()
+	s.peers[id] = struct{}{}
+}
+
+// contains returns whether the peer is in the set, and removes it if forget is true
+func (s *peerSet) contains(id peer.ID, forget bool) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	_, ok := s.peers[id]
+	if ok && forget {
+		delete(s.peers, id)
+	}
+	return ok
+}
+
+type protectionTags struct {
+	sync.RWMutex
+	tags map[string]struct{}
+}
+
+func newProtectionTags() protectionTags {
+	return protectionTags{
+		tags: map[string]struct{}{
+			// Used by the protocols to protect the peers they select
+			string(filter.FilterID_v20beta1): {},
+			string(store.StoreID_v20beta3):   {},
+		},
+	}
+}
+
+// ProtectPeer prevents a peer from being pruned by the connection manager.
+// A peer can be protected with several tags, and remains protected until
+// all of them are removed
+func (w *WakuNode) ProtectPeer(id peer.ID, tag string) {
+	w.protectionTags.Lock()
+	w.protectionTags.tags[tag] = struct{}{}
+	w.protectionTags.Unlock()
+
+	w.host.ConnManager().Protect(id, tag)
+}
+
+// UnprotectPeer removes a protection tag from a peer. It returns
+// whether the peer is still protected by other tags
+func (w *WakuNode) UnprotectPeer(id peer.ID, tag string) bool {
+	return w.host.ConnManager().Unprotect(id, tag)
+}
+
+// peerProtectionTags returns the tags a peer is protected with
+func (w *WakuNode) peerProtectionTags(id peer.ID) []string {
+	w.protectionTags.RLock()
+	tags := make([]string, 0, len(w.protectionTags.tags))
+	for tag := range w.protectionTags.tags {
+		tags = append(tags, tag)
+	}
+	w.protectionTags.RUnlock()
+
+	// Gossipsub protects the peers in the mesh of each topic
+	if w.relay != nil {
+		for _, topic := range w.relay.Topics() {
+			tags = append(tags, "pubsub:"+topic)
+		}
+	}
+
+	var result []string
+	for _, tag := range tags {
+		if w.host.ConnManager().IsProtected(id, tag) {
+			result = append(result, tag)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// peerDisconnection returns whether a disconnection was caused by pruning,
+// or by the node closing the connection
+func (w *WakuNode) peerDisconnection(id peer.ID) *PeerDisconnection {
+	disconnected := w.host.Network().Connectedness(id) != network.Connected
+	pruned := w.prunedPeers.contains(id, disconnected)
+	closed := w.closedPeers.contains(id, disconnected)
+	return &PeerDisconnection{ID: id, Pruned: pruned, Local: pruned || closed}
+}
+
+// closePeer closes the connections to a peer, which are reported
+// as disconnections caused by the node
+func (w *WakuNode) closePeer(id peer.ID) error {
+	if w.host.Network().Connectedness(id) == network.Connected {
+		w.closedPeers.add(id)
+	}
+	return w.host.Network().ClosePeer(id)
+}
+
+// startConnectionPruning periodically closes connections when their number exceeds the
+// high watermark. libp2p's connection manager is set up without watermarks, and is only
+// used for tagging and protecting peers, so the node knows which disconnections it causes
+func (w *WakuNode) startConnectionPruning() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(connectionPruningInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-ticker.C:
+				w.pruneConnections()
+			}
+		}
+	}()
+}
+
+// pruneConnections closes the connections of the peers with the lowest value
+// until the low watermark is reached. Protected peers and peers within the grace
+// period are never pruned
+func (w *WakuNode) pruneConnections() {
+	peers := w.host.Network().Peers()
+	if len(peers) <= w.opts.connMgrHighWater {
+		return
+	}
+
+	type candidate struct {
+		id    peer.ID
+		value int
+	}
+
+	cm := w.host.ConnManager()
+	gracePeriodStart := time.Now().Add(-w.opts.connMgrGracePeriod)
+
+	var candidates []candidate
+	for _, p := range peers {
+		if cm.IsProtected(p, "") {
+			continue
+		}
+
+		tagInfo := cm.GetTagInfo(p)
+		if tagInfo == nil || tagInfo.FirstSeen.After(gracePeriodStart) {
+			continue
+		}
+
+		candidates = append(candidates, candidate{id: p, value: tagInfo.Value})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].value < candidates[j].value
+	})
+
+	target := len(peers) - w.opts.connMgrLowWater
+	if target > len(candidates) {
+		target = len(candidates)
+	}
+
+	log.Info(fmt.Sprintf("Pruning %d connections", target))
+
+	for _, c := range candidates[:target] {
+		w.prunedPeers.add(c.id)
+
+		if err := w.host.Network().ClosePeer(c.id); err != nil {
+			log.Debug(fmt.Sprintf("could not close connection to %s: %s", c.id, err.Error()))
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	swarm "github.com/libp2p/go-libp2p-swarm"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Number of times the static peers are dialed when the node starts, and the
+// delay before the first retry
+const (
+	staticPeerDialAttempts = 5
+	staticPeerDialBackoff  = time.Second
+)
+
+// ErrPeerIDMismatch is returned when the peer reached at an address is not
+// the one expected
+var ErrPeerIDMismatch = errors.New("peer ID mismatch")
+
+// isPeerIDMismatch returns whether the security handshake of a dial failed
+// since the remote key doesn't match the peer ID. The security transports
+// don't export an error for it
+func isPeerIDMismatch(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "peer id mismatch") || strings.Contains(msg, "peer IDs don't match")
+}
+
+// isPermanentDialError returns whether dialing again a peer can't succeed
+func isPermanentDialError(err error) bool {
+	return errors.Is(err, ErrPeerIDMismatch) || errors.Is(err, ErrPeerGated) || errors.Is(err, ErrPeerBanned)
+}
+
+// dialBackoff doubles the delay before each retry, with up to 50% of jitter
+func dialBackoff(backoff time.Duration, failures int) time.Duration {
+	for i := 1; i < failures; i++ {
+		backoff *= 2
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)) // nolint: gosec
+}
+
+// DialPeerWithRetry connects to a peer, trying again up to attempts times
+// when the dial fails. The delay before each retry starts at backoff and
+// doubles, with some jitter. It stops right away when ctx is done, or when the
+// peer can't be dialed: it's banned, gated, or its key doesn't match the peer
+// ID of the address
+func (w *WakuNode) DialPeerWithRetry(ctx context.Context, address string, attempts int, backoff time.Duration) error {
+	if attempts <= 0 {
+		return errors.New("the number of attempts must be positive")
+	}
+	if backoff <= 0 {
+		return errors.New("the backoff must be positive")
+	}
+
+	p, err := ma.NewMultiaddr(address)
+	if err != nil {
+		return err
+	}
+
+	info, err := peer.AddrInfoFromP2pAddr(p)
+	if err != nil {
+		return err
+	}
+
+	return w.connectWithRetry(ctx, *info, attempts, backoff)
+}
+
+func (w *WakuNode) connectWithRetry(ctx context.Context, info peer.AddrInfo, attempts int, backoff time.Duration) error {
+	for attempt := 1; ; attempt++ {
+		err := w.connect(ctx, info)
+		if err == nil || isPermanentDialError(err) || attempt == attempts {
+			return err
+		}
+
+		delay := dialBackoff(backoff, attempt)
+		log.Debug(fmt.Sprintf("Could not dial %s, retrying in %s: %s", info.ID, delay, err.Error()))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		// Otherwise the swarm refuses to dial the peer again so soon
+		if s, ok := w.host.Network().(*swarm.Swarm); ok {
+			s.Backoff().Clear(info.ID)
+		}
+	}
+}
+
+// dialStaticPeers connects to the peers set WithStaticPeers, retrying the
+// dials that fail. The dials are cancelled once the node stops
+func (w *WakuNode) dialStaticPeers() {
+	ctx, cancel := context.WithCancel(w.ctx)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		<-w.quit
+		cancel()
+	}()
+
+	for _, info := range w.opts.staticPeers {
+		w.wg.Add(1)
+		go func(info peer.AddrInfo) {
+			defer w.wg.Done()
+			if err := w.connectWithRetry(ctx, info, staticPeerDialAttempts, staticPeerDialBackoff); err != nil {
+				log.Error(fmt.Sprintf("could not dial static peer %s", info.ID), err)
+			}
+		}(info)
+	}
+}
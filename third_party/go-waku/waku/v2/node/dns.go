@@ -0,0 +1,143 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
+)
+
+// DefaultDNSResolutionTimeout is the maximum time spent resolving the DNS
+// components of a multiaddress unless specified otherwise
+const DefaultDNSResolutionTimeout = 5 * time.Second
+
+// DefaultDNSCacheTTL is the time during which a resolved multiaddress is
+// reused unless specified otherwise
+const DefaultDNSCacheTTL = time.Minute
+
+// Bounds of the delay before resolving again a multiaddress that could not be
+// resolved, which doubles after each failure
+const (
+	dnsMinBackoff = 5 * time.Second
+	dnsMaxBackoff = 5 * time.Minute
+)
+
+// ErrUnresolvedAddress is returned when the DNS components of a multiaddress
+// could not be resolved
+var ErrUnresolvedAddress = errors.New("could not resolve address")
+
+type dnsEntry struct {
+	addrs   []ma.Multiaddr
+	expires time.Time
+
+	err     error
+	backoff time.Duration
+	retry   time.Time
+}
+
+// dnsCache resolves the dns4, dns6 and dnsaddr components of multiaddresses,
+// so their IP can be extracted and they can be dialed. Resolved addresses are
+// cached during a TTL. Failures are only logged when the address is resolved
+// again, after a backoff
+type dnsCache struct {
+	resolver *madns.Resolver
+	timeout  time.Duration
+	ttl      time.Duration
+
+	sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+func newDNSCache(resolver madns.BasicResolver, timeout time.Duration, ttl time.Duration) (*dnsCache, error) {
+	r, err := madns.NewResolver(madns.WithDefaultResolver(resolver))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dnsCache{
+		resolver: r,
+		timeout:  timeout,
+		ttl:      ttl,
+		entries:  make(map[string]*dnsEntry),
+	}, nil
+}
+
+// resolve returns the addresses a multiaddress resolves to. Addresses without
+// DNS components are returned as they are
+func (d *dnsCache) resolve(ctx context.Context, addr ma.Multiaddr) ([]ma.Multiaddr, error) {
+	if !madns.Matches(addr) {
+		return []ma.Multiaddr{addr}, nil
+	}
+
+	key := addr.String()
+	now := time.Now()
+
+	d.Lock()
+	entry, ok := d.entries[key]
+	if ok {
+		if entry.err == nil && now.Before(entry.expires) {
+			d.Unlock()
+			return entry.addrs, nil
+		}
+		if entry.err != nil && now.Before(entry.retry) {
+			d.Unlock()
+			return nil, entry.err
+		}
+	}
+	d.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	addrs, err := d.resolver.Resolve(ctx, addr)
+	if err == nil && len(addrs) == 0 {
+		err = errors.New("no records found")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("%w %s: %s", ErrUnresolvedAddress, addr, err.Error())
+		log.Warn(err)
+
+		backoff := dnsMinBackoff
+		if ok && entry.err != nil {
+			backoff = entry.backoff * 2
+			if backoff > dnsMaxBackoff {
+				backoff = dnsMaxBackoff
+			}
+		}
+
+		d.entries[key] = &dnsEntry{err: err, backoff: backoff, retry: time.Now().Add(backoff)}
+		return nil, err
+	}
+
+	d.entries[key] = &dnsEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+	return addrs, nil
+}
+
+// resolveAll resolves a list of multiaddresses. The ones that can't be
+// resolved are skipped, unless none of them can, in which case the last
+// error is returned
+func (d *dnsCache) resolveAll(ctx context.Context, addrs []ma.Multiaddr) ([]ma.Multiaddr, error) {
+	var result []ma.Multiaddr
+	var lastErr error
+	for _, addr := range addrs {
+		resolved, err := d.resolve(ctx, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		result = append(result, resolved...)
+	}
+
+	if len(result) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return result, nil
+}
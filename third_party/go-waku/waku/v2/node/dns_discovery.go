@@ -0,0 +1,181 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/status-im/go-waku/waku/v2/discv5"
+	"github.com/status-im/go-waku/waku/v2/dnsdisc"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+// Default number of DNS discovered peers the node tries to stay connected to
+const DefaultDNSDiscoveryTarget = 10
+
+// Default interval of time between resolutions of the ENR tree
+const DefaultDNSDiscoveryInterval = 5 * time.Minute
+
+const dnsDiscoveryTimeout = 30 * time.Second
+
+// DiscoveredPeer is a peer obtained from a DNS discoverable ENR tree
+type DiscoveredPeer struct {
+	PeerInfo  peer.AddrInfo
+	ENR       *enode.Node
+	Protocols []string
+}
+
+type dnsDiscoveredPeers struct {
+	sync.RWMutex
+	peers []DiscoveredPeer
+}
+
+// protocolsFromWakuEnrBitfield returns the protocols a node advertises in its ENR
+func protocolsFromWakuEnrBitfield(flags discv5.WakuEnrBitfield) []string {
+	var protocols []string
+	if flags&(1<<0) != 0 {
+		protocols = append(protocols, string(relay.WakuRelayID_v200))
+	}
+	if flags&(1<<1) != 0 {
+		protocols = append(protocols, string(store.StoreID_v20beta3))
+	}
+	if flags&(1<<2) != 0 {
+		protocols = append(protocols, string(filter.FilterID_v20beta1))
+	}
+	if flags&(1<<3) != 0 {
+		protocols = append(protocols, string(lightpush.LightPushID_v20beta1))
+	}
+	return protocols
+}
+
+// addDiscoveredPeer adds a node record to the peerstore, with
+// the protocols advertised in its waku capabilities field
+func (w *WakuNode) addDiscoveredPeer(node *enode.Node) (*DiscoveredPeer, error) {
+	peerInfo, err := utils.EnrToAddrInfo(node)
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := discv5.WakuEnrBitfieldFromNode(node)
+	if err != nil {
+		log.Debug(fmt.Sprintf("could not obtain waku capabilities of %s: %s", peerInfo.ID, err.Error()))
+	}
+
+	protocols := protocolsFromWakuEnrBitfield(flags)
+
+	w.host.Peerstore().AddAddrs(peerInfo.ID, peerInfo.Addrs, peerstore.AddressTTL)
+	if len(protocols) > 0 {
+		if err := w.host.Peerstore().AddProtocols(peerInfo.ID, protocols...); err != nil {
+			return nil, err
+		}
+	}
+
+	return &DiscoveredPeer{
+		PeerInfo:  *peerInfo,
+		ENR:       node,
+		Protocols: protocols,
+	}, nil
+}
+
+func (w *WakuNode) startDNSDiscovery() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.opts.dnsDiscInterval)
+		defer ticker.Stop()
+
+		for {
+			w.resolveDNSDiscovery()
+
+			select {
+			case <-w.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (w *WakuNode) resolveDNSDiscovery() {
+	ctx, cancel := context.WithTimeout(w.ctx, dnsDiscoveryTimeout)
+	defer cancel()
+
+	nodes, err := dnsdisc.RetrieveENRs(ctx, w.opts.dnsDiscURL, dnsdisc.WithNameserver(w.opts.dnsDiscNameserver))
+	if err != nil {
+		log.Error(fmt.Sprintf("could not retrieve nodes from %s: %s", w.opts.dnsDiscURL, err.Error()))
+		return
+	}
+
+	var discoveredPeers []DiscoveredPeer
+	for _, node := range nodes {
+		discoveredPeer, err := w.addDiscoveredPeer(node)
+		if err != nil {
+			log.Error("could not add DNS discovered peer:", err)
+			continue
+		}
+		discoveredPeers = append(discoveredPeers, *discoveredPeer)
+	}
+
+	w.dnsDiscoveredPeers.Lock()
+	w.dnsDiscoveredPeers.peers = discoveredPeers
+	w.dnsDiscoveredPeers.Unlock()
+
+	log.Info(fmt.Sprintf("Discovered %d peers using DNS discovery", len(discoveredPeers)))
+
+	w.dialDNSDiscoveredPeers(discoveredPeers)
+}
+
+// dialDNSDiscoveredPeers connects to discovered peers until the target is reached
+func (w *WakuNode) dialDNSDiscoveredPeers(discoveredPeers []DiscoveredPeer) {
+	connected := 0
+	for _, p := range discoveredPeers {
+		if w.host.Network().Connectedness(p.PeerInfo.ID) == network.Connected {
+			connected++
+		}
+	}
+
+	for _, p := range discoveredPeers {
+		if connected >= w.opts.dnsDiscTarget {
+			return
+		}
+
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+
+		if p.PeerInfo.ID == w.host.ID() || w.host.Network().Connectedness(p.PeerInfo.ID) == network.Connected {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(w.ctx, 10*time.Second)
+		err := w.connect(ctx, p.PeerInfo)
+		cancel()
+		if err != nil {
+			log.Debug(fmt.Sprintf("could not connect to DNS discovered peer %s: %s", p.PeerInfo.ID, err.Error()))
+			continue
+		}
+		connected++
+	}
+}
+
+// DNSDiscoveredPeers returns the peers found in the last resolution of the ENR tree
+func (w *WakuNode) DNSDiscoveredPeers() []DiscoveredPeer {
+	w.dnsDiscoveredPeers.RLock()
+	defer w.dnsDiscoveredPeers.RUnlock()
+
+	result := make([]DiscoveredPeer, len(w.dnsDiscoveredPeers.peers))
+	copy(result, w.dnsDiscoveredPeers.peers)
+	return result
+}
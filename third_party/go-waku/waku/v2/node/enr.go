@@ -0,0 +1,169 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/status-im/go-waku/waku/v2/discv5"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+// ErrNoENRKey is returned when the node has no private key to sign its record
+var ErrNoENRKey = errors.New("a private key set WithPrivateKey is required to sign the node record")
+
+// setupENR creates the record of the node, which is kept up to date with its
+// addresses. If discv5 is enabled, the record it advertises is used instead
+func (w *WakuNode) setupENR() error {
+	if w.opts.privKey == nil {
+		return nil
+	}
+
+	db, err := enode.OpenDB("")
+	if err != nil {
+		return err
+	}
+
+	w.localNode = enode.NewLocalNode(db, w.opts.privKey)
+	w.localNode.Set(enr.WithEntry(discv5.WakuENRField, w.wakuFlags()))
+	w.updateENR(w.ListenAddresses())
+
+	return nil
+}
+
+// wakuFlags are the capabilities advertised in the record. Filter is only
+// advertised by full nodes, since light nodes can't serve subscriptions
+func (w *WakuNode) wakuFlags() discv5.WakuEnrBitfield {
+	return discv5.NewWakuEnrBitfield(w.opts.enableLightPush, w.opts.enableFilter && w.opts.isFilterFullNode, w.opts.enableStore, w.opts.enableRelay)
+}
+
+// updateWakuFlags sets the capabilities advertised in the record, once the
+// protocols served by the node changed
+func (w *WakuNode) updateWakuFlags() {
+	if w.discoveryV5 != nil {
+		w.discoveryV5.UpdateWakuFlags(w.wakuFlags())
+	}
+
+	if w.localNode != nil {
+		w.localNode.Set(enr.WithEntry(discv5.WakuENRField, w.wakuFlags()))
+	}
+
+	// The status includes the record
+	w.sendConnStatus(nil)
+}
+
+// ENR returns the current record of the node, with its waku capabilities,
+// ip and tcp port, and its websocket addresses
+func (w *WakuNode) ENR() (*enode.Node, error) {
+	if w.discoveryV5 != nil {
+		return w.discoveryV5.Node(), nil
+	}
+
+	if w.localNode == nil {
+		return nil, ErrNoENRKey
+	}
+
+	return w.localNode.Node(), nil
+}
+
+// updateENR sets the address and the websocket multiaddresses of the record
+func (w *WakuNode) updateENR(addrs []ma.Multiaddr) {
+	var wsAddrs []ma.Multiaddr
+	for _, addr := range addrs {
+		if utils.IsWebsocketAddress(addr) {
+			wsAddrs = append(wsAddrs, addr)
+		}
+	}
+
+	if w.discoveryV5 != nil {
+		w.discoveryV5.UpdateMultiaddrs(wsAddrs...)
+	}
+
+	if w.localNode == nil {
+		return
+	}
+
+	seq := w.localNode.Seq()
+
+	if addr := selectAddress(w.resolvedTCPAddresses(addrs)); addr != nil {
+		if err := setENRAddress(w.localNode, addr); err != nil {
+			log.Error("could not set ENR address", err)
+		}
+	}
+
+	entry := utils.MultiaddrsENREntry(wsAddrs...)
+	if len(wsAddrs) > 0 {
+		w.localNode.Set(entry)
+	} else {
+		w.localNode.Delete(entry)
+	}
+
+	if w.localNode.Seq() != seq {
+		log.Info(fmt.Sprintf("ENR: %s", w.localNode.Node()))
+	}
+}
+
+// tcpAddresses returns the addresses that can be advertised in the tcp field
+// of a record. Other transports (i.e. udp based ones), websocket addresses,
+// which are included in the multiaddrs field instead, and circuit addresses
+// are skipped
+func tcpAddresses(addrs []ma.Multiaddr) []ma.Multiaddr {
+	var result []ma.Multiaddr
+	for _, a := range addrs {
+		if utils.IsWebsocketAddress(a) || utils.IsCircuitAddress(a) {
+			continue
+		}
+		if _, err := a.ValueForProtocol(ma.P_TCP); err == nil {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// resolvedTCPAddresses returns the tcp addresses of the node with their DNS
+// components resolved, so the IP they point to can be advertised
+func (w *WakuNode) resolvedTCPAddresses(addrs []ma.Multiaddr) []ma.Multiaddr {
+	// Addresses that can't be resolved are skipped, and the error logged
+	resolved, _ := w.dns.resolveAll(w.nodeCtx, tcpAddresses(addrs))
+	return resolved
+}
+
+// tcpEndpoint returns the ip and tcp port of an address
+func tcpEndpoint(addr ma.Multiaddr) (net.IP, int, error) {
+	ip, err := utils.ExtractIP(addr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	portStr, err := addr.ValueForProtocol(ma.P_TCP)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if port <= 0 || port > math.MaxUint16 {
+		return nil, 0, fmt.Errorf("invalid tcp port %d", port)
+	}
+
+	return ip, port, nil
+}
+
+func setENRAddress(localNode *enode.LocalNode, addr ma.Multiaddr) error {
+	ip, port, err := tcpEndpoint(addr)
+	if err != nil {
+		return err
+	}
+
+	localNode.Set(enr.IP(ip))
+	localNode.Set(enr.TCP(uint16(port))) // lgtm [go/incorrect-integer-conversion]
+	return nil
+}
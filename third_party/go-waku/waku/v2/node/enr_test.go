@@ -0,0 +1,112 @@
+package node
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/discv5"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestENR(t *testing.T) {
+	ctx := context.Background()
+	hostAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}
+
+	// A record can't be signed without a private key
+	wakuNode, err := New(ctx, WithHostAddress(hostAddr))
+	require.NoError(t, err)
+	defer wakuNode.Close()
+	_, err = wakuNode.ENR()
+	require.ErrorIs(t, err, ErrNoENRKey)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	wakuNode, err = New(ctx, WithPrivateKey(key), WithHostAddress(hostAddr), WithWakuRelay(), WithWakuStore(false, false))
+	require.NoError(t, err)
+	require.NoError(t, wakuNode.Start())
+	defer wakuNode.Stop()
+
+	record, err := wakuNode.ENR()
+	require.NoError(t, err)
+
+	var flags discv5.WakuEnrBitfield
+	require.NoError(t, record.Load(enr.WithEntry(discv5.WakuENRField, &flags)))
+	require.Equal(t, discv5.NewWakuEnrBitfield(false, false, true, true), flags)
+
+	ip, port, err := tcpEndpoint(wakuNode.ListenAddresses()[0])
+	require.NoError(t, err)
+	require.True(t, ip.Equal(record.IP()))
+	require.Equal(t, port, record.TCP())
+
+	info, err := utils.EnrToAddrInfo(record)
+	require.NoError(t, err)
+	require.Equal(t, wakuNode.Host().ID(), info.ID)
+}
+
+func TestDiscV5RecordAddress(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	udpPort, err := tests.FindFreePort(t, "127.0.0.1", 3)
+	require.NoError(t, err)
+
+	wakuNode, err := New(context.Background(),
+		WithPrivateKey(key),
+		WithHostAddress(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")}),
+		WithWakuRelay(),
+		WithDiscoveryV5(udpPort, nil, false),
+	)
+	require.NoError(t, err)
+	require.NoError(t, wakuNode.Start())
+	defer wakuNode.Stop()
+
+	// The host listens on a random port, which discv5 only learns once started
+	_, port, err := tcpEndpoint(wakuNode.ListenAddresses()[0])
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		record, err := wakuNode.ENR()
+		return err == nil && record.TCP() == port
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDiscV5AdvertisedAddress(t *testing.T) {
+	testCases := []struct {
+		addrs    []string
+		expected string
+	}{
+		{[]string{"127.0.0.1"}, "127.0.0.1"},
+		{[]string{"::1", "127.0.0.1"}, "127.0.0.1"},
+	}
+
+	for _, tc := range testCases {
+		var hostAddrs []*net.TCPAddr
+		for _, addr := range tc.addrs {
+			hostAddrs = append(hostAddrs, &net.TCPAddr{IP: net.ParseIP(addr)})
+		}
+
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		wakuNode, err := New(context.Background(),
+			WithPrivateKey(key),
+			WithHostAddress(hostAddrs...),
+			WithDiscoveryV5(0, nil, false),
+		)
+		require.NoError(t, err)
+		require.NoError(t, wakuNode.Start())
+		require.NoError(t, wakuNode.DiscV5().Start())
+
+		record, err := wakuNode.ENR()
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, record.IP().String(), tc.addrs)
+		require.Equal(t, wakuNode.DiscV5().ListenAddr().Port, record.UDP())
+
+		wakuNode.DiscV5().Stop()
+		require.NoError(t, wakuNode.Stop())
+	}
+}
@@ -0,0 +1,183 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/control"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	swarm "github.com/libp2p/go-libp2p-swarm"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+)
+
+var ErrPeerGated = errors.New("connection to peer refused by the connection gater")
+
+type ipDenyList struct {
+	sync.RWMutex
+	cidrs []*net.IPNet
+}
+
+func newIPDenyList(ips []net.IP, cidrs []*net.IPNet) *ipDenyList {
+	l := &ipDenyList{}
+	for _, ip := range ips {
+		l.add(ipNet(ip))
+	}
+	for _, cidr := range cidrs {
+		l.add(cidr)
+	}
+	return l
+}
+
+// ipNet returns the network containing only the given IP
+func ipNet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+func sameIPNet(a *net.IPNet, b *net.IPNet) bool {
+	return a.IP.Equal(b.IP) && a.Mask.String() == b.Mask.String()
+}
+
+func (l *ipDenyList) add(cidr *net.IPNet) {
+	l.Lock()
+	defer l.Unlock()
+
+	for _, c := range l.cidrs {
+		if sameIPNet(c, cidr) {
+			return
+		}
+	}
+	l.cidrs = append(l.cidrs, cidr)
+}
+
+func (l *ipDenyList) remove(cidr *net.IPNet) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	for i, c := range l.cidrs {
+		if sameIPNet(c, cidr) {
+			l.cidrs = append(l.cidrs[:i], l.cidrs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (l *ipDenyList) denies(addr ma.Multiaddr) bool {
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		// Not an IP address (i.e. DNS), nothing to check
+		return false
+	}
+
+	l.RLock()
+	defer l.RUnlock()
+
+	for _, c := range l.cidrs {
+		if c.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// deniesAll returns true if there are addresses and all of them are denied
+func (l *ipDenyList) deniesAll(addrs []ma.Multiaddr) bool {
+	for _, addr := range addrs {
+		if !l.denies(addr) {
+			return false
+		}
+	}
+	return len(addrs) > 0
+}
+
+// connectionGater refuses the inbound and outbound connections of banned
+// peers, and those from or to a denied IP range. Refused connections are
+// recorded in the gated connections metric
+type connectionGater struct {
+	ctx    context.Context
+	bans   *banList
+	denied *ipDenyList
+}
+
+func (g *connectionGater) refuse(reason string) bool {
+	metrics.RecordGatedConnection(g.ctx, reason)
+	return false
+}
+
+func (g *connectionGater) InterceptPeerDial(p peer.ID) bool {
+	if g.bans.isBanned(p) {
+		return g.refuse("banned")
+	}
+	return true
+}
+
+func (g *connectionGater) InterceptAddrDial(p peer.ID, addr ma.Multiaddr) bool {
+	if g.bans.isBanned(p) {
+		return g.refuse("banned")
+	}
+	if g.denied.denies(addr) {
+		return g.refuse("denied_ip")
+	}
+	return true
+}
+
+func (g *connectionGater) InterceptAccept(addrs network.ConnMultiaddrs) bool {
+	// Checked before the handshake, so denied ranges are dropped as soon as possible
+	if g.denied.denies(addrs.RemoteMultiaddr()) {
+		return g.refuse("denied_ip")
+	}
+	return true
+}
+
+func (g *connectionGater) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
+	if g.bans.isBanned(p) {
+		return g.refuse("banned")
+	}
+	return true
+}
+
+func (g *connectionGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+// isGated returns whether a dial was refused because of the node's connection policy
+func (w *WakuNode) isGated(info peer.AddrInfo, err error) bool {
+	if errors.Is(err, swarm.ErrGaterDisallowedConnection) {
+		return true
+	}
+
+	if errors.Is(err, swarm.ErrNoGoodAddresses) {
+		return w.gater.denied.deniesAll(w.host.Peerstore().Addrs(info.ID))
+	}
+
+	return false
+}
+
+// AddDeniedCIDR refuses the connections from or to an IP range and
+// closes the existing connections within it
+func (w *WakuNode) AddDeniedCIDR(cidr *net.IPNet) {
+	w.gater.denied.add(cidr)
+
+	for _, conn := range w.host.Network().Conns() {
+		if w.gater.denied.denies(conn.RemoteMultiaddr()) {
+			log.Info(fmt.Sprintf("Closing connection to %s in denied range %s", conn.RemotePeer(), cidr))
+			w.closedPeers.add(conn.RemotePeer())
+			_ = conn.Close()
+		}
+	}
+}
+
+// RemoveDeniedCIDR allows again the connections from or to an IP range.
+// It returns false if the range was not denied
+func (w *WakuNode) RemoveDeniedCIDR(cidr *net.IPNet) bool {
+	return w.gater.denied.remove(cidr)
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// MobileGossipSubParams returns gossipsub parameters for relays running on
+// battery constrained devices. The mesh is smaller than the default one, so
+// less messages are forwarded, and the heartbeat runs less often, so the
+// device wakes up less. The message cache keeps about the same time span
+func MobileGossipSubParams() pubsub.GossipSubParams {
+	params := pubsub.DefaultGossipSubParams()
+	params.D = 4
+	params.Dlo = 3
+	params.Dhi = 6
+	params.Dscore = 2
+	params.Dout = 1
+	params.Dlazy = 4
+	params.HeartbeatInterval = 2 * time.Second
+	params.HistoryLength = 3
+	params.HistoryGossip = 2
+	return params
+}
+
+func validateGossipSubParams(params pubsub.GossipSubParams) error {
+	if params.Dlo > params.Dhi {
+		return fmt.Errorf("gossipsub Dlo (%d) can not be greater than Dhi (%d)", params.Dlo, params.Dhi)
+	}
+
+	if params.D < params.Dlo || params.D > params.Dhi {
+		return fmt.Errorf("gossipsub D (%d) must be between Dlo (%d) and Dhi (%d)", params.D, params.Dlo, params.Dhi)
+	}
+
+	if params.Dscore > params.D {
+		return fmt.Errorf("gossipsub Dscore (%d) can not be greater than D (%d)", params.Dscore, params.D)
+	}
+
+	if params.Dout >= params.Dlo || params.Dout > params.D/2 {
+		return fmt.Errorf("gossipsub Dout (%d) must be lower than Dlo (%d) and at most half of D (%d)", params.Dout, params.Dlo, params.D)
+	}
+
+	if params.HeartbeatInterval <= 0 {
+		return errors.New("gossipsub heartbeat interval must be positive")
+	}
+
+	if params.HistoryLength <= 0 || params.HistoryGossip > params.HistoryLength {
+		return fmt.Errorf("gossipsub history gossip (%d) can not be greater than the history length (%d), which must be positive", params.HistoryGossip, params.HistoryLength)
+	}
+
+	return nil
+}
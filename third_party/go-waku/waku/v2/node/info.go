@@ -0,0 +1,116 @@
+package node
+
+import (
+	"time"
+
+	rendezvous "github.com/status-im/go-waku-rendezvous"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/peer_exchange"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+)
+
+// Identifier of the discv5 wire protocol, which isn't a libp2p protocol
+const discV5ProtocolID = "discv5"
+
+// ProtocolInfo is a protocol mounted by the node, with the identifier of the
+// version it speaks
+type ProtocolInfo struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// KeepAliveInfo describes the keepalive of the node. Interval is the one
+// currently in use, which varies between MinInterval and MaxInterval with the
+// relay activity when the keepalive is adaptive. It's 0 if it's disabled
+type KeepAliveInfo struct {
+	Interval    time.Duration `json:"interval"`
+	MinInterval time.Duration `json:"minInterval"`
+	MaxInterval time.Duration `json:"maxInterval,omitempty"`
+	Timeout     time.Duration `json:"timeout"`
+	MaxFailures int           `json:"maxFailures"`
+}
+
+// NodeInfo describes the node: its identity, addresses and the protocols it
+// runs
+type NodeInfo struct {
+	ID              string         `json:"id"`
+	ListenAddresses []string       `json:"listenAddresses"`
+	ENR             string         `json:"enr,omitempty"`
+	Protocols       []ProtocolInfo `json:"protocols"`
+	// Whether the filter serves subscriptions to light nodes
+	FilterFullNode bool `json:"filterFullNode"`
+	// Zero unless discv5 is started
+	DiscV5UDPPort int           `json:"discv5UdpPort,omitempty"`
+	KeepAlive     KeepAliveInfo `json:"keepAlive"`
+}
+
+// Info returns a description of the node. The protocols are the ones mounted
+// by the current run, so none of them are listed unless the node is running,
+// except discv5 which is started separately
+func (w *WakuNode) Info() NodeInfo {
+	info := NodeInfo{
+		ID:        w.ID(),
+		Protocols: []ProtocolInfo{},
+		KeepAlive: KeepAliveInfo{
+			Interval:    w.KeepAliveInterval(),
+			MinInterval: w.opts.keepAliveInterval,
+			Timeout:     w.opts.keepAliveTimeout,
+			MaxFailures: w.opts.keepAliveMaxFailures,
+		},
+	}
+
+	for _, addr := range w.ListenAddresses() {
+		info.ListenAddresses = append(info.ListenAddresses, addr.String())
+	}
+
+	if node, err := w.ENR(); err == nil {
+		info.ENR = node.String()
+	}
+
+	if w.opts.adaptiveKeepAlive {
+		info.KeepAlive.MaxInterval = w.opts.keepAliveMaxInterval
+	}
+
+	w.lifecycleMutex.Lock()
+	if w.state == stateRunning {
+		info.Protocols = w.mountedProtocols()
+		info.FilterFullNode = w.filter != nil && w.filter.IsFullNode()
+	}
+	w.lifecycleMutex.Unlock()
+
+	if w.discoveryV5 != nil {
+		if addr := w.discoveryV5.ListenAddr(); addr != nil {
+			info.Protocols = append(info.Protocols, ProtocolInfo{Name: "discv5", ID: discV5ProtocolID})
+			info.DiscV5UDPPort = addr.Port
+		}
+	}
+
+	return info
+}
+
+// mountedProtocols returns the protocols of the current run. Store and
+// lightpush are always created, but only serve requests when they're enabled
+func (w *WakuNode) mountedProtocols() []ProtocolInfo {
+	var result []ProtocolInfo
+	if w.relay != nil {
+		result = append(result, ProtocolInfo{Name: "relay", ID: string(relay.WakuRelayID_v200)})
+	}
+	if w.filter != nil {
+		result = append(result, ProtocolInfo{Name: "filter", ID: string(filter.FilterID_v20beta1)})
+	}
+	if w.store != nil && w.opts.enableStore {
+		result = append(result, ProtocolInfo{Name: "store", ID: string(store.StoreID_v20beta3)})
+	}
+	if w.lightPush != nil && w.opts.enableLightPush {
+		result = append(result, ProtocolInfo{Name: "lightpush", ID: string(lightpush.LightPushID_v20beta1)})
+	}
+	if w.rendezvous != nil {
+		result = append(result, ProtocolInfo{Name: "rendezvous", ID: string(rendezvous.RendezvousID_v001)})
+	}
+	if w.peerExchange != nil && w.opts.enablePeerExchange {
+		result = append(result, ProtocolInfo{Name: "peer-exchange", ID: string(peer_exchange.PeerExchangeID_v20alpha1)})
+	}
+	return result
+}
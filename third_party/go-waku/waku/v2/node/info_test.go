@@ -0,0 +1,106 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+	"github.com/stretchr/testify/require"
+)
+
+func protocolNames(info NodeInfo) []string {
+	var names []string
+	for _, p := range info.Protocols {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestNodeInfo(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	w, err := New(context.Background(),
+		WithPrivateKey(key),
+		WithHostAddress(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")}),
+		WithoutWakuRelay(),
+		WithWakuStore(true, false),
+		WithWakuFilter(false),
+		WithDiscoveryV5(0, nil, false),
+		WithKeepAliveParams(3*time.Second, time.Second, 2),
+	)
+	require.NoError(t, err)
+
+	// Nothing is mounted before the node starts
+	info := w.Info()
+	require.Equal(t, w.ID(), info.ID)
+	require.Empty(t, info.Protocols)
+
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	info = w.Info()
+	require.Len(t, info.ListenAddresses, 1)
+	require.Contains(t, info.ENR, "enr:")
+	require.Equal(t, []ProtocolInfo{
+		{Name: "filter", ID: string(filter.FilterID_v20beta1)},
+		{Name: "store", ID: string(store.StoreID_v20beta3)},
+	}, info.Protocols)
+	require.False(t, info.FilterFullNode)
+	require.Zero(t, info.DiscV5UDPPort)
+	require.Equal(t, KeepAliveInfo{Interval: 3 * time.Second, MinInterval: 3 * time.Second, Timeout: time.Second, MaxFailures: 2}, info.KeepAlive)
+
+	// discv5 is started separately, and listed once it listens
+	require.NoError(t, w.DiscV5().Start())
+	info = w.Info()
+	require.Equal(t, []string{"filter", "store", "discv5"}, protocolNames(info))
+	require.NotZero(t, info.DiscV5UDPPort)
+
+	w.DiscV5().Stop()
+	require.Equal(t, []string{"filter", "store"}, protocolNames(w.Info()))
+	require.Zero(t, w.Info().DiscV5UDPPort)
+}
+
+func TestNodeInfoFullNode(t *testing.T) {
+	w := newKeepAliveNode(t, WithWakuFilter(true), WithLightPush(), WithAdaptiveKeepAlive(time.Second, 4*time.Second))
+
+	info := w.Info()
+	require.Equal(t, []ProtocolInfo{
+		{Name: "relay", ID: string(relay.WakuRelayID_v200)},
+		{Name: "filter", ID: string(filter.FilterID_v20beta1)},
+		{Name: "lightpush", ID: string(lightpush.LightPushID_v20beta1)},
+	}, info.Protocols)
+	require.True(t, info.FilterFullNode)
+	require.Equal(t, time.Second, info.KeepAlive.MinInterval)
+	require.Equal(t, 4*time.Second, info.KeepAlive.MaxInterval)
+
+	require.NoError(t, w.Stop())
+	info = w.Info()
+	require.Empty(t, info.Protocols)
+	require.False(t, info.FilterFullNode)
+}
+
+func TestNodeInfoJSON(t *testing.T) {
+	w := newKeepAliveNode(t, WithKeepAliveParams(0, time.Second, 1))
+
+	b, err := json.Marshal(w.Info())
+	require.NoError(t, err)
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(b, &fields))
+	require.Contains(t, fields, "listenAddresses")
+	require.Contains(t, fields, "protocols")
+	require.NotContains(t, fields, "discv5UdpPort")
+
+	var keepAlive map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(fields["keepAlive"], &keepAlive))
+	require.JSONEq(t, "0", string(keepAlive["interval"]))
+	require.NotContains(t, keepAlive, "maxInterval")
+}
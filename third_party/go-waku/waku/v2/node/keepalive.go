@@ -0,0 +1,470 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	v2 "github.com/status-im/go-waku/waku/v2"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"go.opencensus.io/stats"
+)
+
+// Default time to wait for a ping response
+const DefaultKeepAliveTimeout = 3 * time.Second
+
+// Default number of consecutive failed pings allowed before a peer is disconnected
+const DefaultMaxPingFailures = 2
+
+// Number of failed attempts to reconnect to a recent peer before forgetting it
+const maxRecentPeerDialFailures = 2
+
+// Maximum number of peers pinged at the same time
+const maxConcurrentPings = 20
+
+// Maximum number of disconnected peers the keepalive tries to reconnect to
+const maxRecentPeers = 20
+
+type recentPeer struct {
+	disconnectedAt time.Time
+	dialFailures   int
+}
+
+// recentPeers are the peers that were connected recently. Once the connections
+// are lost, i.e. when the device wakes from sleep, they are dialed again
+type recentPeers struct {
+	sync.Mutex
+	peers map[peer.ID]*recentPeer
+	// Peers disconnected on purpose, which must not be dialed again
+	closed map[peer.ID]struct{}
+}
+
+func (r *recentPeers) add(id peer.ID) {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.closed[id]; ok {
+		delete(r.closed, id)
+		return
+	}
+
+	if len(r.peers) >= maxRecentPeers {
+		// Forget the peer that disconnected first
+		var oldest peer.ID
+		for p, info := range r.peers {
+			if oldest == "" || info.disconnectedAt.Before(r.peers[oldest].disconnectedAt) {
+				oldest = p
+			}
+		}
+		delete(r.peers, oldest)
+	}
+
+	r.peers[id] = &recentPeer{disconnectedAt: time.Now()}
+}
+
+// close prevents the next disconnection of a peer from being recorded
+func (r *recentPeers) close(id peer.ID) {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.peers, id)
+	r.closed[id] = struct{}{}
+}
+
+// forget stops trying to reconnect to a peer
+func (r *recentPeers) forget(id peer.ID) {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.peers, id)
+}
+
+func (r *recentPeers) list() peer.IDSlice {
+	r.Lock()
+	defer r.Unlock()
+
+	result := make(peer.IDSlice, 0, len(r.peers))
+	for p := range r.peers {
+		result = append(result, p)
+	}
+	return result
+}
+
+// dialed updates the state of a peer after a dial attempt. Peers are
+// forgotten once connected, or after failing too many times
+func (r *recentPeers) dialed(id peer.ID, err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	info, ok := r.peers[id]
+	if !ok {
+		return
+	}
+
+	if err == nil {
+		delete(r.peers, id)
+		return
+	}
+
+	info.dialFailures++
+	if info.dialFailures > maxRecentPeerDialFailures {
+		delete(r.peers, id)
+	}
+}
+
+// keepAliveInterval is the interval of time between keepalive rounds
+// currently in use, which varies when the adaptive keepalive is enabled
+type keepAliveInterval struct {
+	sync.RWMutex
+	interval time.Duration
+}
+
+func (k *keepAliveInterval) get() time.Duration {
+	k.RLock()
+	defer k.RUnlock()
+	return k.interval
+}
+
+// set returns true if the interval changed
+func (k *keepAliveInterval) set(t time.Duration) bool {
+	k.Lock()
+	defer k.Unlock()
+
+	changed := k.interval != t
+	k.interval = t
+	return changed
+}
+
+// peerActivity records the last time some traffic was exchanged with each
+// peer: connections and streams being opened or closed, and relay messages
+// received from it. A nil peerActivity records nothing
+type peerActivity struct {
+	sync.Mutex
+	lastSeen map[peer.ID]time.Time
+}
+
+func newPeerActivity() *peerActivity {
+	return &peerActivity{lastSeen: make(map[peer.ID]time.Time)}
+}
+
+func (a *peerActivity) seen(id peer.ID) {
+	if a == nil {
+		return
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	a.lastSeen[id] = time.Now()
+}
+
+func (a *peerActivity) remove(id peer.ID) {
+	if a == nil {
+		return
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	delete(a.lastSeen, id)
+}
+
+// inactive returns the peers without activity since the time received
+func (a *peerActivity) inactive(peers peer.IDSlice, since time.Time) peer.IDSlice {
+	a.Lock()
+	defer a.Unlock()
+
+	var result peer.IDSlice
+	for _, p := range peers {
+		if lastSeen, ok := a.lastSeen[p]; !ok || lastSeen.Before(since) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// KeepAliveInterval returns the interval of time between keepalive rounds
+// currently in use. It's 0 if the keepalive is disabled
+func (w *WakuNode) KeepAliveInterval() time.Duration {
+	return w.keepAliveInterval.get()
+}
+
+func (w *WakuNode) setKeepAliveInterval(t time.Duration) {
+	if w.keepAliveInterval.set(t) {
+		log.Info("Keepalive interval set to ", t)
+	}
+}
+
+// startKeepAlive creates a go routine that periodically pings connected peers.
+// This is necessary because TCP connections are automatically closed due to inactivity,
+// and doing a ping will avoid this (with a small bandwidth cost)
+func (w *WakuNode) startKeepAlive() {
+	w.setKeepAliveInterval(w.opts.keepAliveInterval)
+
+	if w.opts.adaptiveKeepAlive {
+		w.activityC = make(chan *protocol.Envelope, 1024)
+		// Only the recent activity matters
+		w.bcaster.Register(w.activityC, v2.WithName("keepalive"), v2.WithOverflowPolicy(v2.DropOldest))
+
+		w.wg.Add(2)
+		go w.recordRelayActivity()
+		go w.adaptiveKeepAlive(w.opts.keepAliveInterval, w.opts.keepAliveMaxInterval)
+		return
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		t := w.opts.keepAliveInterval
+		log.Info("Setting up ping protocol with duration of ", t)
+		ticker := time.NewTicker(t)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.keepAliveRound(time.Time{})
+			case <-w.quit:
+				return
+			}
+		}
+	}()
+}
+
+// recordRelayActivity marks as active the peers relay messages are received from
+func (w *WakuNode) recordRelayActivity() {
+	defer w.wg.Done()
+	for {
+		select {
+		case env := <-w.activityC:
+			if from := env.ReceivedFrom(); from != "" && from != w.host.ID() {
+				w.activity.seen(from)
+			}
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// adaptiveKeepAlive checks every min interval whether a keepalive round is
+// due. The interval between rounds doubles each time all the pings succeed,
+// up to max, and goes back to min when a ping fails. A gap between ticks much
+// longer than expected means the device was sleeping, so every connected peer
+// is pinged right away
+func (w *WakuNode) adaptiveKeepAlive(min time.Duration, max time.Duration) {
+	defer w.wg.Done()
+	log.Info(fmt.Sprintf("Setting up adaptive ping protocol with durations between %s and %s", min, max))
+
+	ticker := time.NewTicker(min)
+	defer ticker.Stop()
+
+	// Round(0) strips the monotonic clock reading, which on some platforms
+	// does not advance while the device sleeps
+	lastTick := time.Now().Round(0)
+	lastRound := lastTick
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.quit:
+			return
+		}
+
+		now := time.Now().Round(0)
+		interval := w.KeepAliveInterval()
+		switch {
+		case now.Sub(lastTick) > 2*min:
+			log.Info(fmt.Sprintf("No keepalive tick for %s, running a keepalive round after wake", now.Sub(lastTick)))
+			w.keepAliveRound(time.Time{})
+			w.setKeepAliveInterval(min)
+			lastRound = now
+		case now.Sub(lastRound) >= interval:
+			if w.keepAliveRound(lastRound) > 0 {
+				interval = min
+			} else {
+				interval *= 2
+				if interval > max {
+					interval = max
+				}
+			}
+			w.setKeepAliveInterval(interval)
+			lastRound = now
+		}
+
+		lastTick = time.Now().Round(0)
+	}
+}
+
+// keepAliveRound pings the peers and returns the number of failed pings.
+// If activeSince is not zero, peers with some activity after that time are
+// not pinged, as their connections are known to be alive
+func (w *WakuNode) keepAliveRound(activeSince time.Time) int {
+	peerstorePeers := w.host.Peerstore().Peers()
+	stats.Record(w.ctx, metrics.PeerstorePeers.M(int64(len(peerstorePeers))))
+
+	var peers peer.IDSlice
+	if w.opts.aggressiveReconnection {
+		// Compared to Network's peers collection,
+		// Peerstore contains all peers ever connected to,
+		// thus if a host goes down and back again,
+		// pinging a peer will trigger identification process,
+		// which is not possible when iterating
+		// through Network's peer collection, as it will be empty
+		peers = peerstorePeers
+	} else {
+		peers = w.host.Network().Peers()
+	}
+
+	peers = w.pingBackoffs.skip(peers, time.Now())
+
+	if !activeSince.IsZero() && w.activity != nil {
+		total := len(peers)
+		peers = w.activity.inactive(peers, activeSince)
+		log.Debug(fmt.Sprintf("Skipping keepalive of %d active peers", total-len(peers)))
+	}
+
+	failures := w.pingPeers(peers)
+
+	if !w.opts.aggressiveReconnection {
+		w.dialRecentPeers()
+	}
+
+	return failures
+}
+
+// dialRecentPeers tries to reconnect to the peers that were recently connected
+func (w *WakuNode) dialRecentPeers() {
+	var wg sync.WaitGroup
+	for _, p := range w.recentPeers.list() {
+		if w.host.Network().Connectedness(p) == network.Connected {
+			w.recentPeers.dialed(p, nil)
+			continue
+		}
+
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(w.ctx, 10*time.Second)
+			defer cancel()
+
+			err := w.connect(ctx, w.host.Peerstore().PeerInfo(p))
+			if err != nil {
+				log.Debug(fmt.Sprintf("Could not reconnect to %s: %s", p, err.Error()))
+			}
+			w.recentPeers.dialed(p, err)
+		}(p)
+	}
+	wg.Wait()
+}
+
+// peerDisconnected remembers the peers that were not disconnected by the node
+// itself, so the keepalive can reconnect to them
+func (w *WakuNode) peerDisconnected(disconnection *PeerDisconnection) {
+	if disconnection.Pruned || w.bans.isBanned(disconnection.ID) || w.host.Network().Connectedness(disconnection.ID) == network.Connected {
+		return
+	}
+	w.recentPeers.add(disconnection.ID)
+}
+
+// pingPeers pings the peers in parallel, and returns the number of failed
+// pings once all of them are done. The ticker drops the ticks missed
+// meanwhile, so rounds never overlap
+func (w *WakuNode) pingPeers(peers peer.IDSlice) int {
+	var wg sync.WaitGroup
+	var failuresMutex sync.Mutex
+	failures := 0
+	sem := make(chan struct{}, maxConcurrentPings)
+
+loop:
+	for _, p := range peers {
+		if p == w.host.ID() {
+			continue
+		}
+
+		select {
+		case <-w.quit:
+			break loop
+		case sem <- struct{}{}:
+		}
+
+		stats.Record(w.ctx, metrics.KeepAlivePings.M(1))
+
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := w.pingPeer(p); err != nil {
+				failuresMutex.Lock()
+				failures++
+				failuresMutex.Unlock()
+			}
+		}(p)
+	}
+
+	wg.Wait()
+	return failures
+}
+
+func (w *WakuNode) pingPeer(peer peer.ID) error {
+	ctx, cancel := context.WithTimeout(w.ctx, w.opts.keepAliveTimeout)
+	defer cancel()
+
+	log.Debug("Pinging ", peer)
+	_, err := w.ping(ctx, peer)
+	if err != nil {
+		log.Debug(fmt.Sprintf("Could not ping %s: %s", peer, err.Error()))
+	}
+
+	failures := w.recordPingResult(peer, err)
+	if err != nil && w.host.Network().Connectedness(peer) != network.Connected {
+		// A peer the keepalive gave up on before is backed off after its first
+		// failure, the others once they fail as many times as connected peers
+		if failures > w.opts.keepAliveMaxFailures || w.pingBackoffs.failed(peer) {
+			w.backOffPeer(peer)
+			w.resetPingFailures(peer)
+		}
+		return err
+	}
+
+	if failures > w.opts.keepAliveMaxFailures && w.host.Network().Connectedness(peer) == network.Connected {
+		if w.IsPinned(peer) {
+			// Pinned peers are not disconnected, their failures keep being counted
+			if failures == w.opts.keepAliveMaxFailures+1 {
+				log.Info("Pinned peer degraded ", peer)
+				w.sendPeerEvent(PeerDegraded, peer, false)
+			}
+			return err
+		}
+
+		log.Info("Disconnecting peer ", peer)
+		if err := w.closePeer(peer); err != nil {
+			log.Debug(fmt.Sprintf("Could not close conn to peer %s: %s", peer, err))
+		}
+		w.resetPingFailures(peer)
+		w.backOffPeer(peer)
+	}
+
+	return err
+}
+
+// recordPingResult returns the number of consecutive failed pings of a peer
+func (w *WakuNode) recordPingResult(peer peer.ID, err error) int {
+	w.keepAliveMutex.Lock()
+	defer w.keepAliveMutex.Unlock()
+
+	if err != nil {
+		w.keepAliveFails[peer]++
+	} else {
+		w.keepAliveFails[peer] = 0
+	}
+
+	return w.keepAliveFails[peer]
+}
+
+func (w *WakuNode) resetPingFailures(peer peer.ID) {
+	w.keepAliveMutex.Lock()
+	defer w.keepAliveMutex.Unlock()
+
+	delete(w.keepAliveFails, peer)
+}
@@ -0,0 +1,150 @@
+package node
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepAlive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	host1, err := libp2p.New(ctx, libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+	host2, err := libp2p.New(ctx, libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	host1.Peerstore().AddAddrs(host2.ID(), host2.Addrs(), peerstore.PermanentAddrTTL)
+
+	err = host1.Connect(ctx, host1.Peerstore().PeerInfo(host2.ID()))
+	require.NoError(t, err)
+
+	require.Len(t, host1.Network().Peers(), 1)
+
+	ctx2, cancel2 := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel2()
+
+	w := &WakuNode{
+		host: host1,
+		ctx:  ctx2,
+		opts: &WakuNodeParameters{
+			keepAliveTimeout:     DefaultKeepAliveTimeout,
+			keepAliveMaxFailures: DefaultMaxPingFailures,
+		},
+		keepAliveMutex: sync.Mutex{},
+		keepAliveFails: make(map[peer.ID]int),
+		pingBackoffs:   newPingBackoffs(DefaultPingBackoff),
+	}
+	w.latencies.peers = make(map[peer.ID]*latencySamples)
+
+	require.NoError(t, w.pingPeer(host2.ID()))
+	require.Equal(t, 0, w.keepAliveFails[host2.ID()])
+
+	require.NoError(t, ctx.Err())
+}
+
+func newKeepAliveNode(t *testing.T, opts ...WakuNodeOption) *WakuNode {
+	opts = append([]WakuNodeOption{WithHostAddress(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")})}, opts...)
+	w, err := New(context.Background(), opts...)
+	require.NoError(t, err)
+	require.NoError(t, w.Start())
+	t.Cleanup(func() { _ = w.Stop() })
+	return w
+}
+
+// connectUnresponsivePeer connects the node to a host that never answers pings
+func connectUnresponsivePeer(t *testing.T, w *WakuNode) host.Host {
+	h, err := libp2p.New(context.Background(), libp2p.Ping(false), libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+
+	h.SetStreamHandler(ping.ID, func(s network.Stream) {})
+	require.NoError(t, w.host.Connect(context.Background(), peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()}))
+	return h
+}
+
+func TestPingPeersConcurrently(t *testing.T) {
+	timeout := 500 * time.Millisecond
+	w := newKeepAliveNode(t, WithKeepAliveParams(time.Hour, timeout, DefaultMaxPingFailures))
+
+	var peers peer.IDSlice
+	for i := 0; i < 4; i++ {
+		peers = append(peers, connectUnresponsivePeer(t, w).ID())
+	}
+
+	start := time.Now()
+	require.Equal(t, len(peers), w.pingPeers(peers))
+	// Pinged one after the other, the round would take a timeout per peer
+	require.Less(t, int64(time.Since(start)), int64(2*timeout))
+}
+
+func TestKeepAliveMaxFailures(t *testing.T) {
+	w := newKeepAliveNode(t, WithKeepAliveParams(time.Hour, 200*time.Millisecond, 1))
+	h := connectUnresponsivePeer(t, w)
+
+	require.Error(t, w.pingPeer(h.ID()))
+	require.Equal(t, 1, w.keepAliveFails[h.ID()])
+	require.Equal(t, network.Connected, w.host.Network().Connectedness(h.ID()))
+
+	// Disconnected on failure maxFailures+1
+	require.Error(t, w.pingPeer(h.ID()))
+	require.NotContains(t, w.keepAliveFails, h.ID())
+	require.NotEqual(t, network.Connected, w.host.Network().Connectedness(h.ID()))
+}
+
+func TestKeepAliveParamsValidation(t *testing.T) {
+	params := new(WakuNodeParameters)
+	require.Error(t, WithKeepAliveParams(time.Minute, 0, 1)(params))
+	require.Error(t, WithKeepAliveParams(time.Minute, time.Second, -1)(params))
+	require.NoError(t, WithKeepAliveParams(time.Minute, time.Second, 0)(params))
+	require.Equal(t, time.Second, params.keepAliveTimeout)
+	require.Equal(t, 0, params.keepAliveMaxFailures)
+
+	require.NoError(t, WithKeepAlive(time.Minute)(params))
+	require.Equal(t, DefaultKeepAliveTimeout, params.keepAliveTimeout)
+	require.Equal(t, DefaultMaxPingFailures, params.keepAliveMaxFailures)
+}
+
+func TestPeerActivity(t *testing.T) {
+	var unset *peerActivity
+	unset.seen("p1")
+	unset.remove("p1")
+
+	a := newPeerActivity()
+	a.seen("p1")
+	since := time.Now()
+	a.seen("p2")
+
+	require.Equal(t, peer.IDSlice{"p1", "p3"}, a.inactive(peer.IDSlice{"p1", "p2", "p3"}, since))
+
+	a.remove("p2")
+	require.Equal(t, peer.IDSlice{"p2"}, a.inactive(peer.IDSlice{"p2"}, since))
+}
+
+func TestAdaptiveKeepAlive(t *testing.T) {
+	min := 100 * time.Millisecond
+	max := 400 * time.Millisecond
+	w := newKeepAliveNode(t, WithKeepAliveParams(time.Hour, 200*time.Millisecond, DefaultMaxPingFailures), WithAdaptiveKeepAlive(min, max))
+	require.Equal(t, min, w.KeepAliveInterval())
+
+	peerNode := newKeepAliveNode(t)
+	require.NoError(t, w.host.Connect(context.Background(), peer.AddrInfo{ID: peerNode.host.ID(), Addrs: peerNode.host.Addrs()}))
+
+	// The interval doubles while the pings succeed, up to max
+	require.Eventually(t, func() bool { return w.KeepAliveInterval() == max }, 5*time.Second, 10*time.Millisecond)
+
+	// and goes back to min once one fails, growing again from there
+	connectUnresponsivePeer(t, w)
+	require.Eventually(t, func() bool { return w.KeepAliveInterval() < max }, 5*time.Second, 10*time.Millisecond)
+}
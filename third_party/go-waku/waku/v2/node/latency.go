@@ -0,0 +1,92 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Number of round-trip times kept for each peer
+const maxLatencySamples = 10
+
+// LatencySample is the round-trip time of a successful ping
+type LatencySample struct {
+	RTT  time.Duration
+	Time time.Time
+}
+
+// latencySamples is a ring buffer with the last round-trip times of a peer
+type latencySamples struct {
+	samples [maxLatencySamples]LatencySample
+	next    int
+	count   int
+}
+
+func (l *latencySamples) add(sample LatencySample) {
+	l.samples[l.next] = sample
+	l.next = (l.next + 1) % maxLatencySamples
+	if l.count < maxLatencySamples {
+		l.count++
+	}
+}
+
+// list returns the samples from the oldest to the newest
+func (l *latencySamples) list() []LatencySample {
+	result := make([]LatencySample, 0, l.count)
+	start := (l.next - l.count + maxLatencySamples) % maxLatencySamples
+	for i := 0; i < l.count; i++ {
+		result = append(result, l.samples[(start+i)%maxLatencySamples])
+	}
+	return result
+}
+
+// latencyHistory keeps the round-trip times of the pings sent to each peer
+type latencyHistory struct {
+	sync.Mutex
+	peers map[peer.ID]*latencySamples
+}
+
+func (h *latencyHistory) record(id peer.ID, rtt time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+
+	samples, ok := h.peers[id]
+	if !ok {
+		samples = new(latencySamples)
+		h.peers[id] = samples
+	}
+	samples.add(LatencySample{RTT: rtt, Time: time.Now()})
+}
+
+func (h *latencyHistory) get(id peer.ID) []LatencySample {
+	h.Lock()
+	defer h.Unlock()
+
+	samples, ok := h.peers[id]
+	if !ok {
+		return nil
+	}
+	return samples.list()
+}
+
+func (h *latencyHistory) remove(id peer.ID) {
+	h.Lock()
+	defer h.Unlock()
+
+	delete(h.peers, id)
+}
+
+// PeerLatency returns the moving average of the round-trip times of a peer,
+// which is also recorded in the peerstore, and its last samples from the
+// oldest to the newest. Both are empty if the peer was never pinged
+// successfully, or it was removed since
+func (w *WakuNode) PeerLatency(id peer.ID) (current time.Duration, samples []LatencySample) {
+	samples = w.latencies.get(id)
+	if len(samples) == 0 {
+		// The peerstore does not forget the latency of removed peers
+		return 0, nil
+	}
+
+	return w.host.Peerstore().LatencyEWMA(id), samples
+}
@@ -0,0 +1,65 @@
+package node
+
+import (
+	"net"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+// NATMapping is a port mapping established in a UPnP or NAT-PMP device.
+// ExternalAddr is nil until the mapping is ready
+type NATMapping struct {
+	Protocol     string
+	InternalPort int
+	ExternalPort int
+	ExternalAddr net.Addr
+}
+
+// NATStatus describes the port mappings of the node. Enabled is true when the
+// node was created WithNAT, and Available once a NAT device has been found
+type NATStatus struct {
+	Enabled   bool
+	Available bool
+	Mappings  []NATMapping
+}
+
+// NATStatus returns the state of the port mappings in the NAT device
+func (w *WakuNode) NATStatus() NATStatus {
+	status := NATStatus{Enabled: w.natManager != nil}
+	if w.natManager == nil || w.natManager.NAT() == nil {
+		return status
+	}
+
+	status.Available = true
+	for _, m := range w.natManager.NAT().Mappings() {
+		mapping := NATMapping{
+			Protocol:     m.Protocol(),
+			InternalPort: m.InternalPort(),
+			ExternalPort: m.ExternalPort(),
+		}
+		if addr, err := m.ExternalAddr(); err == nil {
+			mapping.ExternalAddr = addr
+		}
+		status.Mappings = append(status.Mappings, mapping)
+	}
+
+	return status
+}
+
+// PublicAddress returns the globally routable address other peers can use to
+// dial the node, i.e. the one mapped in the NAT device. It returns nil if the
+// node only has private addresses
+func (w *WakuNode) PublicAddress() ma.Multiaddr {
+	addr := selectAddress(w.ListenAddresses())
+	if addr == nil {
+		return nil
+	}
+
+	ip, err := utils.ExtractIP(addr)
+	if err != nil || ipRank(ip) != ipRoutable {
+		return nil
+	}
+
+	return addr
+}
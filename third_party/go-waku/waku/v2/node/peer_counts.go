@@ -0,0 +1,100 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	rendezvous "github.com/status-im/go-waku-rendezvous"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+)
+
+// PeerCounts is the number of connected peers, in total, supporting each
+// waku protocol, and by the direction of their connection
+type PeerCounts struct {
+	Total int
+
+	Relay      int
+	Store      int
+	Filter     int
+	LightPush  int
+	Rendezvous int
+
+	// Peers are counted by the direction of their oldest connection, so
+	// Inbound and Outbound add up to Total
+	Inbound  int
+	Outbound int
+}
+
+// PeerCounts returns the number of connected peers, in total, supporting
+// each waku protocol, and by the direction of their connection
+func (w *WakuNode) PeerCounts() PeerCounts {
+	var counts PeerCounts
+	for _, id := range w.host.Network().Peers() {
+		conns := w.host.Network().ConnsToPeer(id)
+		if len(conns) == 0 {
+			continue
+		}
+		counts.Total++
+
+		oldest := conns[0].Stat()
+		for _, c := range conns[1:] {
+			if stat := c.Stat(); stat.Opened.Before(oldest.Opened) {
+				oldest = stat
+			}
+		}
+		if oldest.Direction == network.DirInbound {
+			counts.Inbound++
+		} else {
+			counts.Outbound++
+		}
+
+		protocols, err := w.host.Peerstore().GetProtocols(id)
+		if err != nil {
+			log.Warn(fmt.Errorf("could not read peer %s protocols", id))
+			continue
+		}
+
+		for _, protocol := range protocols {
+			switch protocol {
+			case string(relay.WakuRelayID_v200):
+				counts.Relay++
+			case string(store.StoreID_v20beta3):
+				counts.Store++
+			case string(filter.FilterID_v20beta1):
+				counts.Filter++
+			case string(lightpush.LightPushID_v20beta1):
+				counts.LightPush++
+			case string(rendezvous.RendezvousID_v001):
+				counts.Rendezvous++
+			}
+		}
+	}
+	return counts
+}
+
+// Prefix of the waku protocol IDs
+const wakuProtocolPrefix = "/vac/waku/"
+
+// protocolPeerCounts returns the number of connected peers supporting each
+// waku protocol, by protocol ID
+func (w *WakuNode) protocolPeerCounts() map[string]int {
+	result := make(map[string]int)
+	for _, id := range w.host.Network().Peers() {
+		protocols, err := w.host.Peerstore().GetProtocols(id)
+		if err != nil {
+			log.Warn(fmt.Errorf("could not read peer %s protocols", id))
+			continue
+		}
+
+		for _, protocol := range protocols {
+			if strings.HasPrefix(protocol, wakuProtocolPrefix) {
+				result[protocol]++
+			}
+		}
+	}
+	return result
+}
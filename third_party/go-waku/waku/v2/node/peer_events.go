@@ -0,0 +1,171 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Number of peer events buffered for each subscriber. When a
+// subscriber falls behind, the oldest event is dropped
+const peerEventsBufferSize = 100
+
+type PeerEventType int
+
+const (
+	// PeerConnected is emitted once the first identification of a
+	// connected peer is done, so its protocols are known
+	PeerConnected PeerEventType = iota
+	// PeerIdentified is emitted when the protocols of a connected peer change
+	PeerIdentified
+	// PeerDisconnected is emitted when the last connection to a peer is closed
+	PeerDisconnected
+	// PeerDegraded is emitted when a pinned peer fails more consecutive pings
+	// than allowed, instead of being disconnected
+	PeerDegraded
+)
+
+func (t PeerEventType) String() string {
+	switch t {
+	case PeerConnected:
+		return "connected"
+	case PeerIdentified:
+		return "identified"
+	case PeerDisconnected:
+		return "disconnected"
+	case PeerDegraded:
+		return "degraded"
+	default:
+		return "unknown"
+	}
+}
+
+// PeerEvent describes a change in the connection to a peer
+type PeerEvent struct {
+	Type      PeerEventType
+	ID        peer.ID
+	Protocols []string
+	Addrs     []ma.Multiaddr
+	// Only set in PeerDisconnected events. True when the node closed the
+	// connection itself (i.e. keepalive, pruning, bans or ClosePeer)
+	Local bool
+}
+
+type peerEventSubscribers struct {
+	sync.Mutex
+	subs   map[chan PeerEvent]struct{}
+	closed bool
+}
+
+func (p *peerEventSubscribers) subscribe() (<-chan PeerEvent, func()) {
+	p.Lock()
+	defer p.Unlock()
+
+	ch := make(chan PeerEvent, peerEventsBufferSize)
+	if p.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if p.subs == nil {
+		p.subs = make(map[chan PeerEvent]struct{})
+	}
+	p.subs[ch] = struct{}{}
+
+	return ch, func() {
+		p.Lock()
+		defer p.Unlock()
+		if _, ok := p.subs[ch]; ok {
+			delete(p.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (p *peerEventSubscribers) publish(evt PeerEvent) {
+	p.Lock()
+	defer p.Unlock()
+
+	for ch := range p.subs {
+		select {
+		case ch <- evt:
+			continue
+		default:
+		}
+
+		// Drop the oldest event to make room for the new one
+		select {
+		case <-ch:
+		default:
+		}
+
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+func (p *peerEventSubscribers) close() {
+	p.Lock()
+	defer p.Unlock()
+
+	for ch := range p.subs {
+		close(ch)
+	}
+	p.subs = nil
+	p.closed = true
+}
+
+// SubscribePeerEvents returns a channel where peer connections, disconnections
+// and protocol changes are pushed to, and a function to cancel the subscription.
+// The channel is closed when the subscription is cancelled or the node is closed
+func (w *WakuNode) SubscribePeerEvents() (<-chan PeerEvent, func()) {
+	return w.peerEventSubs.subscribe()
+}
+
+func (w *WakuNode) sendPeerEvent(evtType PeerEventType, id peer.ID, local bool) {
+	protocols, err := w.host.Peerstore().GetProtocols(id)
+	if err != nil {
+		log.Debug("could not read peer protocols", err)
+	}
+
+	w.peerEventSubs.publish(PeerEvent{
+		Type:      evtType,
+		ID:        id,
+		Protocols: protocols,
+		Addrs:     w.host.Peerstore().Addrs(id),
+		Local:     local,
+	})
+}
+
+// announcedPeers are the peers a PeerConnected event was sent for. It's
+// only used by the connectedness listener
+type announcedPeers map[peer.ID]struct{}
+
+// peerIdentified sends the PeerConnected event of a peer after its first
+// identification, and PeerIdentified on the next ones
+func (w *WakuNode) peerIdentified(announced announcedPeers, id peer.ID) {
+	if w.host.Network().Connectedness(id) != network.Connected {
+		return
+	}
+
+	if _, ok := announced[id]; ok {
+		w.sendPeerEvent(PeerIdentified, id, false)
+		return
+	}
+
+	announced[id] = struct{}{}
+	w.sendPeerEvent(PeerConnected, id, false)
+}
+
+func (w *WakuNode) peerEventDisconnected(announced announcedPeers, disconnection *PeerDisconnection) {
+	if _, ok := announced[disconnection.ID]; !ok || w.host.Network().Connectedness(disconnection.ID) == network.Connected {
+		return
+	}
+
+	delete(announced, disconnection.ID)
+	w.sendPeerEvent(PeerDisconnected, disconnection.ID, disconnection.Local)
+}
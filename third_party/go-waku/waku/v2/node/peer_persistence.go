@@ -0,0 +1,135 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/status-im/go-waku/waku/persistence"
+)
+
+// Interval at which the peerstore is saved in the database
+const peersSaveInterval = 30 * time.Second
+
+// Time during which the peers the node was connected to are kept after
+// their last connection. The peers that were only discovered are kept as
+// long as their addresses are valid in the peerstore, peerstore.AddressTTL
+const peerRetention = 7 * 24 * time.Hour
+
+// loadPeers adds the peers saved in the database to the peerstore, with
+// their addresses, protocols and latency. Banned peers are skipped
+func (w *WakuNode) loadPeers() error {
+	peers, err := w.peerDB.GetAll()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, p := range peers {
+		if p.ID == w.host.ID() || w.bans.isBanned(p.ID) {
+			continue
+		}
+
+		ttl := time.Until(p.Expiry)
+		if ttl <= 0 || len(p.Addrs) == 0 {
+			continue
+		}
+
+		w.host.Peerstore().AddAddrs(p.ID, p.Addrs, ttl)
+
+		if len(p.Protocols) > 0 {
+			if err := w.host.Peerstore().AddProtocols(p.ID, p.Protocols...); err != nil {
+				return err
+			}
+		}
+
+		if p.Latency > 0 {
+			w.host.Peerstore().RecordLatency(p.ID, p.Latency)
+		}
+
+		count++
+	}
+
+	log.Info(fmt.Sprintf("Loaded %d peers from the database", count))
+	return nil
+}
+
+// savePeers saves the peers of the peerstore in the database, and deletes
+// the expired ones
+func (w *WakuNode) savePeers() {
+	now := time.Now()
+
+	var peers []persistence.StoredPeer
+	for _, id := range w.host.Peerstore().PeersWithAddrs() {
+		if id == w.host.ID() || w.bans.isBanned(id) {
+			continue
+		}
+
+		addrs := w.host.Peerstore().Addrs(id)
+		if len(addrs) == 0 {
+			continue
+		}
+
+		protocols, err := w.host.Peerstore().GetProtocols(id)
+		if err != nil {
+			log.Debug(fmt.Sprintf("could not get the protocols of %s: %s", id, err.Error()))
+			continue
+		}
+
+		expiry := now.Add(peerstore.AddressTTL)
+		if w.host.Network().Connectedness(id) == network.Connected {
+			expiry = now.Add(peerRetention)
+		}
+
+		peers = append(peers, persistence.StoredPeer{
+			ID:        id,
+			Addrs:     addrs,
+			Protocols: protocols,
+			Latency:   w.host.Peerstore().LatencyEWMA(id),
+			Expiry:    expiry,
+		})
+	}
+
+	if err := w.peerDB.Put(peers); err != nil {
+		log.Error("could not save peers", err)
+	}
+
+	if err := w.peerDB.Prune(now); err != nil {
+		log.Error("could not delete expired peers", err)
+	}
+}
+
+// forgetSavedPeer deletes a peer from the database, so it's not added again
+// to the peerstore when the node restarts
+func (w *WakuNode) forgetSavedPeer(id peer.ID) {
+	if w.peerDB == nil {
+		return
+	}
+
+	if err := w.peerDB.Delete(id); err != nil {
+		log.Error(fmt.Sprintf("could not delete peer %s", id), err)
+	}
+}
+
+// startPeersPersistence periodically saves the peerstore. It's saved one
+// last time when the node stops
+func (w *WakuNode) startPeersPersistence() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(peersSaveInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-ticker.C:
+				w.savePeers()
+			}
+		}
+	}()
+}
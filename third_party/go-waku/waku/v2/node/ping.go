@@ -0,0 +1,74 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// ErrPingTimeout is returned when a peer does not answer a ping before the context deadline
+var ErrPingTimeout = errors.New("ping timed out")
+
+// Ping sends a single libp2p ping to a peer and returns the round-trip time.
+// The peer is dialed first if there is no connection to it
+func (w *WakuNode) Ping(ctx context.Context, peerID peer.ID) (time.Duration, error) {
+	if w.host.Network().Connectedness(peerID) != network.Connected {
+		if err := w.DialPeerByID(ctx, peerID); err != nil {
+			return 0, pingError(ctx, err)
+		}
+	}
+
+	return w.ping(ctx, peerID)
+}
+
+// PingAddress is like Ping, but the peer is obtained from a multiaddress
+// that must include its peer ID
+func (w *WakuNode) PingAddress(ctx context.Context, address ma.Multiaddr) (time.Duration, error) {
+	info, err := peer.AddrInfoFromP2pAddr(address)
+	if err != nil {
+		return 0, err
+	}
+
+	if w.host.Network().Connectedness(info.ID) != network.Connected {
+		if err := w.connect(ctx, *info); err != nil {
+			return 0, pingError(ctx, err)
+		}
+	}
+
+	return w.ping(ctx, info.ID)
+}
+
+// ping waits for the first response of the ping protocol. Successful
+// round-trip times are recorded in the peerstore latency metrics and in the
+// latency history of the peer
+func (w *WakuNode) ping(ctx context.Context, peerID peer.ID) (time.Duration, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr := ping.Ping(ctx, w.host, peerID)
+	select {
+	case res, ok := <-pr:
+		if !ok {
+			return 0, pingError(ctx, ctx.Err())
+		}
+		if res.Error != nil {
+			return 0, pingError(ctx, res.Error)
+		}
+		w.latencies.record(peerID, res.RTT)
+		return res.RTT, nil
+	case <-ctx.Done():
+		return 0, pingError(ctx, ctx.Err())
+	}
+}
+
+func pingError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrPingTimeout
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// DefaultPingBackoff are the periods during which the keepalive doesn't ping a
+// peer after giving up on it, unless specified WithPingBackoff. Each time the
+// peer fails again the next period is used, the last one once they run out
+var DefaultPingBackoff = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute}
+
+// PingBackoff describes a peer the keepalive gave up on
+type PingBackoff struct {
+	// Number of times the keepalive gave up on the peer since it was last
+	// connected
+	Failures int
+	// The peer is not pinged until then
+	Until time.Time
+}
+
+// pingBackoffs are the peers the keepalive doesn't ping for a while, since
+// their pings kept failing. A peer is forgotten once a connection to it is
+// established
+type pingBackoffs struct {
+	sync.Mutex
+	periods []time.Duration
+	peers   map[peer.ID]*PingBackoff
+}
+
+func newPingBackoffs(periods []time.Duration) *pingBackoffs {
+	return &pingBackoffs{periods: periods, peers: make(map[peer.ID]*PingBackoff)}
+}
+
+// fail backs off a peer for the period following the previous one
+func (b *pingBackoffs) fail(id peer.ID) time.Duration {
+	b.Lock()
+	defer b.Unlock()
+
+	backoff, ok := b.peers[id]
+	if !ok {
+		backoff = &PingBackoff{}
+		b.peers[id] = backoff
+	}
+
+	period := b.periods[len(b.periods)-1]
+	if backoff.Failures < len(b.periods) {
+		period = b.periods[backoff.Failures]
+	}
+
+	backoff.Failures++
+	backoff.Until = time.Now().Add(period)
+	return period
+}
+
+// failed returns whether the keepalive already gave up on a peer
+func (b *pingBackoffs) failed(id peer.ID) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	_, ok := b.peers[id]
+	return ok
+}
+
+func (b *pingBackoffs) reset(id peer.ID) {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	delete(b.peers, id)
+}
+
+// skip removes from peers the ones backed off at the time received
+func (b *pingBackoffs) skip(peers peer.IDSlice, now time.Time) peer.IDSlice {
+	b.Lock()
+	defer b.Unlock()
+
+	var result peer.IDSlice
+	for _, p := range peers {
+		if backoff, ok := b.peers[p]; ok && now.Before(backoff.Until) {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func (b *pingBackoffs) list() map[peer.ID]PingBackoff {
+	b.Lock()
+	defer b.Unlock()
+
+	result := make(map[peer.ID]PingBackoff, len(b.peers))
+	for p, backoff := range b.peers {
+		result[p] = *backoff
+	}
+	return result
+}
+
+// PingBackoff returns the peers the keepalive gave up on, since their pings
+// kept failing. It's meant for debugging
+func (w *WakuNode) PingBackoff() map[peer.ID]PingBackoff {
+	return w.pingBackoffs.list()
+}
+
+// backOffPeer stops pinging a peer for a while. Pinned peers are never backed
+// off, as they are kept connected
+func (w *WakuNode) backOffPeer(id peer.ID) {
+	if w.IsPinned(id) {
+		return
+	}
+
+	period := w.pingBackoffs.fail(id)
+	log.Info("Not pinging ", id, " during ", period)
+}
@@ -0,0 +1,172 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Tag protecting the pinned peers from connection pruning
+const pinnedPeerTag = "pinned"
+
+// Interval between the checks of the connections to the pinned peers
+const pinnedPeersCheckInterval = time.Second
+
+// Bounds of the delay between the attempts to redial a pinned peer, which
+// doubles after each failure
+const (
+	pinnedPeerMinBackoff = time.Second
+	pinnedPeerMaxBackoff = 5 * time.Minute
+)
+
+type pinnedPeer struct {
+	backoff  time.Duration
+	nextDial time.Time
+	dialing  bool
+}
+
+// pinnedPeers are the peers the node always stays connected to
+type pinnedPeers struct {
+	sync.Mutex
+	peers map[peer.ID]*pinnedPeer
+}
+
+func (p *pinnedPeers) add(id peer.ID) {
+	p.Lock()
+	defer p.Unlock()
+
+	if _, ok := p.peers[id]; !ok {
+		p.peers[id] = &pinnedPeer{backoff: pinnedPeerMinBackoff}
+	}
+}
+
+func (p *pinnedPeers) remove(id peer.ID) {
+	p.Lock()
+	defer p.Unlock()
+
+	delete(p.peers, id)
+}
+
+func (p *pinnedPeers) contains(id peer.ID) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	_, ok := p.peers[id]
+	return ok
+}
+
+// due returns the pinned peers which can be dialed now, and marks them as
+// being dialed. connected returns whether the node is connected to a peer
+func (p *pinnedPeers) due(connected func(peer.ID) bool) peer.IDSlice {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	var result peer.IDSlice
+	for id, info := range p.peers {
+		if info.dialing || connected(id) {
+			continue
+		}
+
+		if now.Before(info.nextDial) {
+			continue
+		}
+
+		info.dialing = true
+		result = append(result, id)
+	}
+	return result
+}
+
+// dialed schedules the next attempt to dial a peer. The backoff is reset
+// once the peer is connected
+func (p *pinnedPeers) dialed(id peer.ID, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	info, ok := p.peers[id]
+	if !ok {
+		return
+	}
+
+	info.dialing = false
+	if err == nil {
+		info.backoff = pinnedPeerMinBackoff
+		info.nextDial = time.Time{}
+		return
+	}
+
+	info.nextDial = time.Now().Add(info.backoff)
+	info.backoff *= 2
+	if info.backoff > pinnedPeerMaxBackoff {
+		info.backoff = pinnedPeerMaxBackoff
+	}
+}
+
+// PinPeer keeps the node connected to a peer until it's unpinned: it's never
+// pruned, keepalive doesn't disconnect it when its pings fail but sends a
+// PeerDegraded event instead, and it's redialed with a backoff once disconnected
+// instead of the keepalive PingBackoff
+func (w *WakuNode) PinPeer(id peer.ID) {
+	w.pins.add(id)
+	w.ProtectPeer(id, pinnedPeerTag)
+	w.pingBackoffs.reset(id)
+}
+
+// UnpinPeer stops keeping the node connected to a peer
+func (w *WakuNode) UnpinPeer(id peer.ID) {
+	w.pins.remove(id)
+	w.UnprotectPeer(id, pinnedPeerTag)
+}
+
+// IsPinned returns whether the node is kept connected to a peer
+func (w *WakuNode) IsPinned(id peer.ID) bool {
+	return w.pins.contains(id)
+}
+
+// startPinnedPeersRedial periodically dials the pinned peers the node is not
+// connected to
+func (w *WakuNode) startPinnedPeersRedial() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(pinnedPeersCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-ticker.C:
+				w.redialPinnedPeers()
+			}
+		}
+	}()
+}
+
+func (w *WakuNode) redialPinnedPeers() {
+	connected := func(id peer.ID) bool {
+		return w.host.Network().Connectedness(id) == network.Connected
+	}
+
+	for _, id := range w.pins.due(connected) {
+		w.wg.Add(1)
+		go func(id peer.ID) {
+			defer w.wg.Done()
+
+			ctx, cancel := context.WithTimeout(w.ctx, 10*time.Second)
+			defer cancel()
+
+			err := w.connect(ctx, w.host.Peerstore().PeerInfo(id))
+			if err != nil {
+				log.Debug(fmt.Sprintf("Could not redial pinned peer %s: %s", id, err.Error()))
+			}
+			w.pins.dialed(id, err)
+		}(id)
+	}
+}
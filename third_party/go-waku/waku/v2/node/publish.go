@@ -0,0 +1,112 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+)
+
+// PublishPath is the protocol a message was published with
+type PublishPath int
+
+const (
+	// PublishedWithRelay messages were sent to the relay peers of the topic
+	PublishedWithRelay PublishPath = iota
+	// PublishedWithLightPush messages were pushed to a lightpush peer, which
+	// relays them
+	PublishedWithLightPush
+)
+
+func (p PublishPath) String() string {
+	switch p {
+	case PublishedWithRelay:
+		return "relay"
+	case PublishedWithLightPush:
+		return "lightpush"
+	default:
+		return fmt.Sprintf("PublishPath(%d)", int(p))
+	}
+}
+
+// PublishResult is the result of a message published with Publish
+type PublishResult struct {
+	Hash []byte
+	Path PublishPath
+}
+
+// PublishError is returned by Publish when the message couldn't be published
+// with relay nor lightpush
+type PublishError struct {
+	Relay     error
+	LightPush error
+}
+
+func (e *PublishError) Error() string {
+	return fmt.Sprintf("could not publish message with relay (%s) nor lightpush (%s)", e.Relay, e.LightPush)
+}
+
+// Publish publishes a message on a pubsub topic with relay. When relay is
+// disabled, the topic has no relay peers or publishing fails, the message is
+// pushed to a connected lightpush peer instead. The result tells which one
+// was used, so callers can tell how confident the delivery is
+func (w *WakuNode) Publish(ctx context.Context, msg *pb.WakuMessage, topic string) (*PublishResult, error) {
+	if msg == nil {
+		return nil, errors.New("message can't be null")
+	}
+
+	var relayErr error
+	if w.relay == nil {
+		relayErr = ErrRelayDisabled
+	} else if len(w.relay.PeersForTopic(topic)) == 0 {
+		relayErr = fmt.Errorf("%w: %s has no peers", relay.ErrNotEnoughPeers, topic)
+	} else {
+		hash, err := w.relay.PublishToTopic(ctx, msg, topic)
+		if err == nil {
+			return &PublishResult{Hash: hash, Path: PublishedWithRelay}, nil
+		}
+		relayErr = err
+	}
+
+	log.Debug(fmt.Sprintf("falling back to lightpush, relay can't publish on %s: %s", topic, relayErr))
+
+	hash, err := w.lightPush.PublishToTopic(ctx, msg, topic)
+	if err != nil {
+		return nil, &PublishError{Relay: relayErr, LightPush: err}
+	}
+	w.trackPublished(hash, PublishedWithLightPush)
+
+	return &PublishResult{Hash: hash, Path: PublishedWithLightPush}, nil
+}
+
+// LightpushTo pushes a message to be relayed on a pubsub topic to a specific
+// peer. Unlike the automatic peer selection, it never falls back to another
+// peer: lightpush.ErrPeerNotConnected or lightpush.ErrProtocolNotSupported
+// are returned when the peer can't be used
+func (w *WakuNode) LightpushTo(ctx context.Context, peerID peer.ID, msg *pb.WakuMessage, topic string) ([]byte, error) {
+	if w.host.Network().Connectedness(peerID) != network.Connected {
+		return nil, fmt.Errorf("%w: %s", lightpush.ErrPeerNotConnected, peerID)
+	}
+
+	protocols, err := w.host.Peerstore().SupportsProtocols(peerID, string(lightpush.LightPushID_v20beta1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(protocols) == 0 {
+		return nil, fmt.Errorf("%w: %s", lightpush.ErrProtocolNotSupported, peerID)
+	}
+
+	hash, err := w.lightPush.PublishToTopic(ctx, msg, topic, lightpush.WithPeer(peerID))
+	if err != nil {
+		return nil, err
+	}
+	w.trackPublished(hash, PublishedWithLightPush)
+
+	return hash, nil
+}
@@ -0,0 +1,94 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/discovery"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"go.opencensus.io/stats"
+)
+
+const (
+	// Registrations are renewed at 75% of the TTL returned by the
+	// rendezvous point, plus or minus 5% of it
+	rendezvousRenewalRatio  = 0.75
+	rendezvousRenewalJitter = 0.05
+
+	rendezvousMinBackoff = 10 * time.Second
+	rendezvousMaxBackoff = 10 * time.Minute
+)
+
+// rendezvousDiscovery renews the registrations in each namespace before they
+// expire on the rendezvous point. Pubsub advertises a namespace again once the
+// duration returned by Advertise elapses, which would otherwise be the whole TTL
+type rendezvousDiscovery struct {
+	discovery.Discovery
+
+	sync.Mutex
+	failures map[string]int
+}
+
+func newRendezvousDiscovery(d discovery.Discovery) *rendezvousDiscovery {
+	return &rendezvousDiscovery{
+		Discovery: d,
+		failures:  make(map[string]int),
+	}
+}
+
+func (r *rendezvousDiscovery) Advertise(ctx context.Context, ns string, opts ...discovery.Option) (time.Duration, error) {
+	ttl, err := r.Discovery.Advertise(ctx, ns, opts...)
+	if err != nil {
+		stats.Record(ctx, metrics.RendezvousFailures.M(1))
+		backoff := rendezvousBackoff(r.failed(ns))
+		log.Debug(fmt.Sprintf("could not register in rendezvous namespace %s, retrying in %s: %s", ns, backoff, err.Error()))
+		return backoff, err
+	}
+
+	r.succeeded(ns)
+
+	renewal := rendezvousRenewal(ttl)
+	log.Debug(fmt.Sprintf("registered in rendezvous namespace %s for %s, renewing in %s", ns, ttl, renewal))
+	return renewal, nil
+}
+
+// failed returns the number of consecutive failed registrations in a namespace
+func (r *rendezvousDiscovery) failed(ns string) int {
+	r.Lock()
+	defer r.Unlock()
+	r.failures[ns]++
+	return r.failures[ns]
+}
+
+func (r *rendezvousDiscovery) succeeded(ns string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.failures, ns)
+}
+
+func rendezvousRenewal(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return rendezvousMinBackoff
+	}
+
+	jitter := (2*rand.Float64() - 1) * rendezvousRenewalJitter // nolint: gosec
+	return time.Duration(float64(ttl) * (rendezvousRenewalRatio + jitter))
+}
+
+// rendezvousBackoff doubles the delay after each consecutive failure, with
+// up to 50% of jitter, until it reaches rendezvousMaxBackoff
+func rendezvousBackoff(failures int) time.Duration {
+	backoff := rendezvousMinBackoff
+	for i := 1; i < failures && backoff < rendezvousMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > rendezvousMaxBackoff {
+		backoff = rendezvousMaxBackoff
+	}
+
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2))) // nolint: gosec
+}
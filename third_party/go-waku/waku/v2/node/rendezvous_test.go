@@ -0,0 +1,71 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/discovery"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDiscovery struct {
+	ttl time.Duration
+	err error
+}
+
+func (m *mockDiscovery) Advertise(ctx context.Context, ns string, opts ...discovery.Option) (time.Duration, error) {
+	return m.ttl, m.err
+}
+
+func (m *mockDiscovery) FindPeers(ctx context.Context, ns string, opts ...discovery.Option) (<-chan peer.AddrInfo, error) {
+	return nil, m.err
+}
+
+func TestRendezvousRenewal(t *testing.T) {
+	require.Equal(t, rendezvousMinBackoff, rendezvousRenewal(0))
+
+	ttl := 2 * time.Hour
+	for i := 0; i < 100; i++ {
+		renewal := rendezvousRenewal(ttl)
+		require.GreaterOrEqual(t, int64(renewal), int64(float64(ttl)*(rendezvousRenewalRatio-rendezvousRenewalJitter)))
+		require.LessOrEqual(t, int64(renewal), int64(float64(ttl)*(rendezvousRenewalRatio+rendezvousRenewalJitter)))
+	}
+}
+
+func TestRendezvousBackoff(t *testing.T) {
+	for failures, max := range map[int]time.Duration{
+		1:   rendezvousMinBackoff,
+		2:   2 * rendezvousMinBackoff,
+		3:   4 * rendezvousMinBackoff,
+		100: rendezvousMaxBackoff,
+	} {
+		for i := 0; i < 100; i++ {
+			backoff := rendezvousBackoff(failures)
+			require.GreaterOrEqual(t, int64(backoff), int64(max/2))
+			require.Less(t, int64(backoff), int64(max))
+		}
+	}
+}
+
+func TestRendezvousDiscoveryAdvertise(t *testing.T) {
+	mock := &mockDiscovery{err: errors.New("unreachable")}
+	d := newRendezvousDiscovery(mock)
+
+	// The delay before retrying grows with the consecutive failures
+	_, err := d.Advertise(context.Background(), "ns")
+	require.Error(t, err)
+	_, err = d.Advertise(context.Background(), "ns")
+	require.Error(t, err)
+	require.Equal(t, 2, d.failures["ns"])
+
+	// and the registration is renewed before it expires once it succeeds
+	mock.err = nil
+	mock.ttl = 2 * time.Hour
+	renewal, err := d.Advertise(context.Background(), "ns")
+	require.NoError(t, err)
+	require.Less(t, int64(renewal), int64(mock.ttl))
+	require.NotContains(t, d.failures, "ns")
+}
@@ -0,0 +1,60 @@
+package node
+
+import (
+	"context"
+	"time"
+)
+
+// StopWithTimeout stops the node like Stop, but the messages already received
+// are stored for at most d. Those which could not be stored within d are lost
+func (w *WakuNode) StopWithTimeout(d time.Duration) error {
+	w.lifecycleMutex.Lock()
+	defer w.lifecycleMutex.Unlock()
+
+	if w.state != stateRunning {
+		return nil
+	}
+
+	w.state = stateStopped
+	return w.stopWithin(d)
+}
+
+// messageFlusher is implemented by the message providers which write the
+// messages in batches, like persistence.DBStore
+type messageFlusher interface {
+	Flush() error
+}
+
+// drainMessages stores the messages received before stopping. The relay
+// subscriptions are closed first, so no more messages are received, then the
+// envelopes queued in the broadcaster and the store are stored and the
+// message provider is flushed
+func (w *WakuNode) drainMessages(d time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	if w.relay != nil {
+		if err := w.relay.StopReceiving(ctx); err != nil {
+			log.Warn("could not wait for the relay messages: ", err)
+		}
+	}
+
+	if w.store == nil || w.store.MsgC == nil {
+		return
+	}
+
+	if err := w.bcaster.Drain(ctx, w.store.MsgC); err != nil {
+		log.Warn("could not wait for the messages to store: ", err)
+	}
+	w.store.Stop()
+
+	provider := w.opts.messageProvider
+	if w.dbStore != nil {
+		provider = w.dbStore
+	}
+	if f, ok := provider.(messageFlusher); ok {
+		if err := f.Flush(); err != nil {
+			log.Error("could not flush the stored messages", err)
+		}
+	}
+}
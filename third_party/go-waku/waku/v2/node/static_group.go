@@ -0,0 +1,40 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/staticgroup"
+)
+
+// StaticGroupRelay returns the spam protection of the default relay topic.
+// It's nil unless the node was created WithStaticGroupRelay and is started
+func (w *WakuNode) StaticGroupRelay() *staticgroup.GroupRelay {
+	return w.groupRelay
+}
+
+// mountStaticGroupRelay protects the default relay topic with the static
+// group. The validator runs before the messages are broadcasted, so the ones
+// rejected never reach the other protocols
+func (w *WakuNode) mountStaticGroupRelay() error {
+	tree, err := staticgroup.NewMembershipTree(w.opts.staticGroup...)
+	if err != nil {
+		return err
+	}
+
+	w.groupRelay, err = staticgroup.NewGroupRelay(relay.DefaultWakuTopic, tree, w.opts.staticGroupKey, uint64(w.opts.staticGroupIndex))
+	if err != nil {
+		return err
+	}
+
+	if err := w.relay.AddValidator(relay.DefaultWakuTopic, w.groupRelay.Validator()); err != nil {
+		return err
+	}
+
+	// Without membership key, publishing fails with staticgroup.ErrNoMembershipKey
+	w.relay.SetProofGenerator(relay.DefaultWakuTopic, w.groupRelay.GenerateProof)
+
+	log.Info(fmt.Sprintf("static group relay protecting %s with a group of %d members, root %x", relay.DefaultWakuTopic, tree.Size(), tree.Root()))
+
+	return nil
+}
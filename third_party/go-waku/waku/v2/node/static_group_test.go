@@ -0,0 +1,132 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/staticgroup"
+	"github.com/stretchr/testify/require"
+)
+
+// connectRelayPeers connects two nodes, and waits until they relay the
+// messages of the default topic to each other, i.e. once they are in the
+// mesh of each other
+func connectRelayPeers(t *testing.T, w1 *WakuNode, w2 *WakuNode) {
+	require.NoError(t, w1.DialPeer(context.Background(), w2.ListenAddresses()[0].String()))
+	require.Eventually(t, func() bool {
+		return len(w1.Relay().MeshPeers(relay.DefaultWakuTopic)) > 0 && len(w2.Relay().MeshPeers(relay.DefaultWakuTopic)) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// receivePayload waits for the message with the given payload, failing if one
+// of the rejected payloads is received before
+func receivePayload(t *testing.T, sub *relay.Subscription, payload string, rejected ...string) *pb.WakuMessage {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case envelope := <-sub.C:
+			msg := envelope.Message()
+			require.NotContains(t, rejected, string(msg.Payload))
+			if string(msg.Payload) == payload {
+				return msg
+			}
+		case <-timeout:
+			require.Fail(t, "message not received", payload)
+			return nil
+		}
+	}
+}
+
+func groupMessage(payload string) *pb.WakuMessage {
+	return &pb.WakuMessage{Payload: []byte(payload), ContentTopic: "/test/1/static-group/proto"}
+}
+
+func TestStaticGroupRelay(t *testing.T) {
+	ctx := context.Background()
+
+	member0, err := staticgroup.NewMembershipKeyPair()
+	require.NoError(t, err)
+	member1, err := staticgroup.NewMembershipKeyPair()
+	require.NoError(t, err)
+	group := []staticgroup.IDCommitment{member0.IDCommitment, member1.IDCommitment}
+
+	publisher := newKeepAliveNode(t, WithStaticGroupRelay(group, 0, member0))
+	verifier := newKeepAliveNode(t, WithStaticGroupRelay(group, 0, nil))
+	// Doesn't verify the messages, so it relays anything
+	spammer := newKeepAliveNode(t)
+
+	require.NotNil(t, verifier.StaticGroupRelay())
+	require.Nil(t, spammer.StaticGroupRelay())
+
+	connectRelayPeers(t, publisher, verifier)
+	connectRelayPeers(t, spammer, verifier)
+
+	sub, err := verifier.Relay().Subscribe(ctx)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// The rate limit is checked within an epoch, so the test doesn't start
+	// right before the next one
+	if remaining := staticgroup.EpochUnitSeconds - time.Now().Unix()%staticgroup.EpochUnitSeconds; remaining < 4 {
+		time.Sleep(time.Duration(remaining) * time.Second)
+	}
+
+	_, err = publisher.Relay().Publish(ctx, groupMessage("1"))
+	require.NoError(t, err)
+	require.NotEmpty(t, receivePayload(t, sub, "1").Proof)
+
+	_, err = publisher.Relay().Publish(ctx, groupMessage("2"))
+	require.ErrorIs(t, err, staticgroup.ErrRateLimitExceeded)
+
+	// The verifier can't publish without membership key
+	_, err = verifier.Relay().Publish(ctx, groupMessage("3"))
+	require.ErrorIs(t, err, staticgroup.ErrNoMembershipKey)
+
+	// The messages without proof are rejected before they're delivered
+	_, err = spammer.Relay().Publish(ctx, groupMessage("without proof"))
+	require.NoError(t, err)
+
+	tree, err := staticgroup.NewMembershipTree(group...)
+	require.NoError(t, err)
+	path, err := tree.Path(1)
+	require.NoError(t, err)
+	epoch := staticgroup.CalcEpoch(time.Now())
+
+	withProof := func(payload string) *pb.WakuMessage {
+		msg := groupMessage(payload)
+		proof, err := staticgroup.GenerateProof(relay.DefaultWakuTopic, msg, member1, 1, path, epoch)
+		require.NoError(t, err)
+		msg.Proof = proof.Bytes()
+		return msg
+	}
+
+	_, err = spammer.Relay().Publish(ctx, withProof("4"))
+	require.NoError(t, err)
+	receivePayload(t, sub, "4", "without proof")
+
+	// A second message of member 1 in the epoch is reported
+	_, err = spammer.Relay().Publish(ctx, withProof("5"))
+	require.NoError(t, err)
+
+	select {
+	case signal := <-verifier.StaticGroupRelay().DoubleSignals():
+		require.Equal(t, staticgroup.DoubleSignal{Epoch: epoch, Index: 1, IDCommitment: member1.IDCommitment, PeerID: spammer.Host().ID()}, signal)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "double signal not reported")
+	}
+
+	// Nothing else was delivered
+	select {
+	case envelope := <-sub.C:
+		require.Fail(t, "unexpected message", string(envelope.Message().Payload))
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	doubleSignals := verifier.StaticGroupRelay().DoubleSignals()
+	require.NoError(t, verifier.Stop())
+	_, ok := <-doubleSignals
+	require.False(t, ok)
+}
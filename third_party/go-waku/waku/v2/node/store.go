@@ -0,0 +1,199 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+)
+
+// Query retrieves the history of messages published on a pubsub topic with
+// some content topics. Empty topics match any topic, and startTime and
+// endTime of 0 match any time. Results are paginated with
+// store.DefaultPageSize messages unless specified otherwise WithPaging, and
+// the following pages are retrieved with Next
+func (w *WakuNode) Query(ctx context.Context, topic string, contentTopics []string, startTime float64, endTime float64, opts ...store.HistoryRequestOption) (*store.Result, error) {
+	query := store.Query{
+		Topic:         topic,
+		ContentTopics: contentTopics,
+		StartTime:     startTime,
+		EndTime:       endTime,
+	}
+
+	return w.store.Query(ctx, query, opts...)
+}
+
+// Next retrieves the page following a query result, from the same store
+// node. Nothing is queried once the result IsComplete, and a result without
+// messages is returned instead
+func (w *WakuNode) Next(ctx context.Context, result *store.Result) (*store.Result, error) {
+	return w.store.Next(ctx, result)
+}
+
+// EvtResumeCompleted is emitted on the host event bus when the node is done
+// resuming the store history
+type EvtResumeCompleted struct {
+	// Number of retrieved messages
+	Count   int
+	Results []store.ResumeResult
+}
+
+type resumeState struct {
+	sync.Mutex
+	wasOnline bool
+	running   bool
+	// Set while the running resume waits for a store node, in which case it
+	// covers the resumes requested meanwhile
+	waiting bool
+	// Set when a resume is requested while another one runs
+	again bool
+
+	lastTime  time.Time
+	lastCount int
+	lastErr   error
+}
+
+// LastResume returns when the store history was last resumed, the number of
+// retrieved messages, and an error if some topics couldn't be resumed. The
+// time is zero if the history was never resumed
+func (w *WakuNode) LastResume() (time.Time, int, error) {
+	w.resume.Lock()
+	defer w.resume.Unlock()
+
+	return w.resume.lastTime, w.resume.lastCount, w.resume.lastErr
+}
+
+// onlineChanged resumes the store history when the node goes online, which
+// retrieves the messages published while it was offline
+func (w *WakuNode) onlineChanged(isOnline bool) {
+	w.resume.Lock()
+	wentOnline := isOnline && !w.resume.wasOnline
+	w.resume.wasOnline = isOnline
+	w.resume.Unlock()
+
+	if wentOnline && w.opts.shouldResume {
+		w.triggerResume()
+	}
+}
+
+// triggerResume starts resuming the store history. Only one resume runs at a
+// time: a resume requested meanwhile runs once the current one completes
+func (w *WakuNode) triggerResume() {
+	w.resume.Lock()
+	defer w.resume.Unlock()
+
+	if w.resume.running {
+		if !w.resume.waiting {
+			w.resume.again = true
+		}
+		return
+	}
+	w.resume.running = true
+	w.resume.waiting = true
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		for {
+			w.resumeHistory()
+
+			w.resume.Lock()
+			if !w.resume.again {
+				w.resume.running = false
+				w.resume.Unlock()
+				return
+			}
+			w.resume.again = false
+			w.resume.waiting = true
+			w.resume.Unlock()
+		}
+	}()
+}
+
+// resumeHistory waits for a connected store node, and retrieves the messages
+// published since the last stored message of each topic. The topics which
+// fail are retried every 10s
+func (w *WakuNode) resumeHistory() {
+	// Cancelled once the run stops, which waits for the resume to return
+	ctx, cancel := context.WithCancel(w.ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-w.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var pending []string
+	var results []store.ResumeResult
+	for {
+		var storePeers []peer.ID
+		for len(storePeers) == 0 {
+			if _, err := w.WaitForPeer(ctx, store.StoreID_v20beta3); err != nil {
+				return
+			}
+
+			// The store peer might be gone already
+			for _, p := range w.PeersByProtocol(store.StoreID_v20beta3, true) {
+				storePeers = append(storePeers, p.ID)
+			}
+		}
+
+		// The topics are only known once the node subscribed to them
+		if pending == nil {
+			pending = w.resumeTopics()
+		}
+
+		topicResults, err := w.store.ResumeAll(w.ctx, pending, storePeers)
+
+		// Only the topics which failed are resumed again
+		var failed []string
+		for _, result := range topicResults {
+			if result.Err != nil {
+				failed = append(failed, result.Topic)
+			} else {
+				results = append(results, result)
+			}
+		}
+		pending = failed
+
+		w.resumeCompleted(results, err)
+		if err == nil {
+			return
+		}
+
+		log.Info("Retrying in 10s...")
+		select {
+		case <-w.quit:
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+func (w *WakuNode) resumeCompleted(results []store.ResumeResult, err error) {
+	count := 0
+	for _, result := range results {
+		count += result.Count
+	}
+
+	w.resume.Lock()
+	w.resume.lastTime = time.Now()
+	w.resume.lastCount = count
+	w.resume.lastErr = err
+	w.resume.Unlock()
+
+	if err != nil {
+		return
+	}
+
+	log.Info(fmt.Sprintf("Resumed the history with %d messages", count))
+	if emitErr := w.resumeEmitter.Emit(EvtResumeCompleted{Count: count, Results: results}); emitErr != nil {
+		log.Debug("could not emit resume event", emitErr)
+	}
+}
@@ -0,0 +1,214 @@
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+)
+
+// TopicHealth tells whether the messages published on a relay topic are
+// likely to reach other peers
+type TopicHealth int
+
+const (
+	// TopicUnhealthy topics have no mesh peers, so the messages published on
+	// them go nowhere
+	TopicUnhealthy TopicHealth = iota
+	// TopicDegraded topics have fewer mesh peers than required
+	// WithTopicHealthMonitoring, or no store peer is connected, so the
+	// messages published on them can't be retrieved later
+	TopicDegraded
+	// TopicHealthy topics have enough mesh peers and a store peer is connected
+	TopicHealthy
+)
+
+func (h TopicHealth) String() string {
+	switch h {
+	case TopicUnhealthy:
+		return "unhealthy"
+	case TopicDegraded:
+		return "degraded"
+	case TopicHealthy:
+		return "healthy"
+	default:
+		return "unknown"
+	}
+}
+
+// Number of topic health updates buffered for each subscriber. When a
+// subscriber falls behind, the oldest update is dropped
+const topicHealthBufferSize = 10
+
+type topicHealthSubscribers struct {
+	sync.Mutex
+	subs   map[chan map[string]TopicHealth]struct{}
+	closed bool
+}
+
+func (c *topicHealthSubscribers) subscribe() (<-chan map[string]TopicHealth, func()) {
+	c.Lock()
+	defer c.Unlock()
+
+	ch := make(chan map[string]TopicHealth, topicHealthBufferSize)
+	if c.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if c.subs == nil {
+		c.subs = make(map[chan map[string]TopicHealth]struct{})
+	}
+	c.subs[ch] = struct{}{}
+
+	return ch, func() {
+		c.Lock()
+		defer c.Unlock()
+		if _, ok := c.subs[ch]; ok {
+			delete(c.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends a copy of the health to each subscriber, so none of them can
+// modify the map received by the others
+func (c *topicHealthSubscribers) publish(health map[string]TopicHealth) {
+	c.Lock()
+	defer c.Unlock()
+
+	for ch := range c.subs {
+		update := copyTopicHealth(health)
+
+		select {
+		case ch <- update:
+			continue
+		default:
+		}
+
+		// Drop the oldest update to make room for the new one
+		select {
+		case <-ch:
+		default:
+		}
+
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+func (c *topicHealthSubscribers) close() {
+	c.Lock()
+	defer c.Unlock()
+
+	for ch := range c.subs {
+		close(ch)
+	}
+	c.subs = nil
+	c.closed = true
+}
+
+func copyTopicHealth(health map[string]TopicHealth) map[string]TopicHealth {
+	if health == nil {
+		return nil
+	}
+
+	result := make(map[string]TopicHealth, len(health))
+	for topic, h := range health {
+		result[topic] = h
+	}
+	return result
+}
+
+// topicHealthState is the health of the subscribed topics, as last evaluated
+type topicHealthState struct {
+	sync.Mutex
+	topics map[string]TopicHealth
+}
+
+// SubscribeTopicHealth returns a channel where the health of the subscribed
+// relay topics is pushed to whenever it changes, and a function to cancel the
+// subscription. The health is only evaluated WithTopicHealthMonitoring. The
+// channel is closed when the subscription is cancelled or the node is closed
+func (w *WakuNode) SubscribeTopicHealth() (<-chan map[string]TopicHealth, func()) {
+	return w.topicHealthSubs.subscribe()
+}
+
+// TopicHealth returns the health of the subscribed relay topics, as last
+// evaluated. It's nil unless the node was created WithTopicHealthMonitoring
+func (w *WakuNode) TopicHealth() map[string]TopicHealth {
+	w.topicHealth.Lock()
+	defer w.topicHealth.Unlock()
+	return copyTopicHealth(w.topicHealth.topics)
+}
+
+// evaluateTopicHealth computes the health of the subscribed relay topics
+func (w *WakuNode) evaluateTopicHealth() map[string]TopicHealth {
+	hasStore := len(w.PeersByProtocol(store.StoreID_v20beta3, true)) > 0
+
+	result := make(map[string]TopicHealth)
+	for _, topic := range w.relay.Topics() {
+		meshPeers := len(w.relay.MeshPeers(topic))
+		switch {
+		case meshPeers == 0:
+			result[topic] = TopicUnhealthy
+		case meshPeers < w.opts.topicHealthMinPeers || !hasStore:
+			result[topic] = TopicDegraded
+		default:
+			result[topic] = TopicHealthy
+		}
+	}
+	return result
+}
+
+// updateTopicHealth evaluates the health of the topics, and notifies the
+// subscribers only if it changed
+func (w *WakuNode) updateTopicHealth() {
+	health := w.evaluateTopicHealth()
+
+	w.topicHealth.Lock()
+	changed := w.topicHealth.topics == nil || len(health) != len(w.topicHealth.topics)
+	for topic, h := range health {
+		if previous, ok := w.topicHealth.topics[topic]; !ok || previous != h {
+			changed = true
+		}
+	}
+	if changed {
+		w.topicHealth.topics = health
+	}
+	w.topicHealth.Unlock()
+
+	if changed {
+		w.topicHealthSubs.publish(health)
+		w.sendConnStatus(nil)
+	}
+}
+
+// startTopicHealthMonitoring periodically evaluates the health of the topics.
+// The health of the previous run is discarded, so the first evaluation is
+// always pushed
+func (w *WakuNode) startTopicHealthMonitoring() {
+	w.topicHealth.Lock()
+	w.topicHealth.topics = nil
+	w.topicHealth.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.opts.topicHealthInterval)
+		defer ticker.Stop()
+
+		w.updateTopicHealth()
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-ticker.C:
+				w.updateTopicHealth()
+			}
+		}
+	}()
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"context"
+	"sort"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	p2pproto "github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// WaitForPeer blocks until the node is connected to a peer supporting all
+// the protocols, and returns it. A peer that is connected already is returned
+// right away, otherwise the peers are checked as they're identified or their
+// protocols change. It returns the context error if it's done first
+func (w *WakuNode) WaitForPeer(ctx context.Context, protocols ...p2pproto.ID) (peer.ID, error) {
+	// Subscribed before looking at the connected peers, so no peer is missed
+	sub, err := w.host.EventBus().Subscribe([]interface{}{
+		new(event.EvtPeerIdentificationCompleted),
+		new(event.EvtPeerProtocolsUpdated),
+		new(event.EvtPeerConnectednessChanged),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer sub.Close()
+
+	connected := w.host.Network().Peers()
+	sort.Sort(peer.IDSlice(connected))
+	for _, id := range connected {
+		if w.supportsAll(id, protocols) {
+			return id, nil
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case e, ok := <-sub.Out():
+			if !ok {
+				return "", ctx.Err()
+			}
+
+			var id peer.ID
+			switch evt := e.(type) {
+			case event.EvtPeerIdentificationCompleted:
+				id = evt.Peer
+			case event.EvtPeerProtocolsUpdated:
+				id = evt.Peer
+			case event.EvtPeerConnectednessChanged:
+				id = evt.Peer
+			}
+
+			if w.supportsAll(id, protocols) {
+				return id, nil
+			}
+		}
+	}
+}
+
+// supportsAll returns whether the node is connected to a peer supporting all
+// the protocols
+func (w *WakuNode) supportsAll(id peer.ID, protocols []p2pproto.ID) bool {
+	if w.host.Network().Connectedness(id) != network.Connected {
+		return false
+	}
+
+	for _, p := range protocols {
+		supported, err := w.host.Peerstore().SupportsProtocols(id, string(p))
+		if err != nil || len(supported) == 0 {
+			return false
+		}
+	}
+	return true
+}
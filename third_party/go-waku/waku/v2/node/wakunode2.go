@@ -0,0 +1,1728 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p"
+	circuit "github.com/libp2p/go-libp2p-circuit"
+	connmgr "github.com/libp2p/go-libp2p-connmgr"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	p2pproto "github.com/libp2p/go-libp2p-core/protocol"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	basichost "github.com/libp2p/go-libp2p/p2p/host/basic"
+	ma "github.com/multiformats/go-multiaddr"
+	"go.opencensus.io/stats"
+
+	rendezvous "github.com/status-im/go-waku-rendezvous"
+	"github.com/status-im/go-waku/waku/persistence"
+	v2 "github.com/status-im/go-waku/waku/v2"
+	"github.com/status-im/go-waku/waku/v2/discv5"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/protocol/peer_exchange"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/staticgroup"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+var log = logging.Logger("wakunode")
+
+type Message []byte
+
+type Peer struct {
+	ID        peer.ID
+	Protocols []string
+	Addrs     []ma.Multiaddr
+	Connected bool
+	// Tags protecting the peer from connection pruning
+	Protected []string
+	// Set while the peer is banned
+	Ban *BannedPeer
+	// True if the node is kept connected to the peer, see PinPeer
+	Pinned bool
+	// True if the connection to the peer goes through a relay
+	Relayed bool
+	// Direction and opening time of the oldest connection to the peer.
+	// Unknown and zero if the peer is not connected
+	Direction   network.Direction
+	ConnectedAt time.Time
+	// Moving average of the round-trip time measured by pings
+	Latency      time.Duration
+	AgentVersion string
+}
+
+type WakuNode struct {
+	host host.Host
+	opts *WakuNodeParameters
+
+	relay        *relay.WakuRelay
+	groupRelay   *staticgroup.GroupRelay
+	filter       *filter.WakuFilter
+	lightPush    *lightpush.WakuLightPush
+	rendezvous   *rendezvous.RendezvousService
+	store        *store.WakuStore
+	peerExchange *peer_exchange.WakuPeerExchange
+	// Message provider created WithMessageProviderDB
+	dbStore *persistence.DBStore
+	peerDB  *persistence.PeerDB
+
+	addrChan chan []ma.Multiaddr
+
+	discoveryV5 *discv5.DiscoveryV5
+	localNode   *enode.LocalNode
+
+	dnsDiscoveredPeers dnsDiscoveredPeers
+
+	bcaster v2.Broadcaster
+
+	connectionNotif        ConnectionNotifier
+	protocolEventSub       event.Subscription
+	identificationEventSub event.Subscription
+	relayPeersEventSub     event.Subscription
+	resumeEmitter          event.Emitter
+	addressChangesSub      event.Subscription
+	reachabilityEventSub   event.Subscription
+
+	reachabilityMutex sync.RWMutex
+	reachability      network.Reachability
+
+	prunedPeers    peerSet
+	closedPeers    peerSet
+	protectionTags protectionTags
+	bans           *banList
+	gater          *connectionGater
+	natManager     basichost.NATManager
+
+	peerSelector *utils.PeerSelector
+
+	keepAliveMutex    sync.Mutex
+	keepAliveFails    map[peer.ID]int
+	pingBackoffs      *pingBackoffs
+	keepAliveInterval keepAliveInterval
+	recentPeers       recentPeers
+	pins              pinnedPeers
+	latencies         latencyHistory
+	dns               *dnsCache
+	resume            resumeState
+	activity          *peerActivity
+	activityC         chan *protocol.Envelope
+
+	confirmations *confirmationTracker
+	confirmationC chan *protocol.Envelope
+
+	topicHealth     topicHealthState
+	topicHealthSubs topicHealthSubscribers
+
+	// Cancelled once the node is closed. The context of each run derives from it
+	nodeCtx    context.Context
+	nodeCancel context.CancelFunc
+
+	lifecycleMutex sync.Mutex
+	state          nodeState
+
+	// Recreated each time the node starts
+	ctx        context.Context
+	cancel     context.CancelFunc
+	quit       chan struct{}
+	wg         *sync.WaitGroup
+	components []component
+
+	// Channel passed to WakuNode constructor
+	// receiving connection status notifications
+	connStatusChan    chan ConnStatus
+	connStatusSubs    connStatusSubscribers
+	connStatusUpdates connStatusUpdates
+
+	peerEventSubs peerEventSubscribers
+}
+
+// nodeState is the lifecycle state of a node. A created node can be started,
+// and then stopped and started again as many times as needed, until it's closed
+type nodeState int
+
+const (
+	stateCreated nodeState = iota
+	stateRunning
+	stateStopped
+	stateClosed
+)
+
+// component is a part of the node set up by Start. The components of a run
+// are stopped in the reverse order, so the ones depending on others go first
+type component struct {
+	name string
+	stop func()
+}
+
+var ErrDiscV5Disabled = errors.New("discv5 is not enabled")
+
+// ErrFilterDisabled is returned by the filter methods when the node was not
+// created WithWakuFilter
+var ErrFilterDisabled = errors.New("filter is not enabled")
+
+// ErrRelayDisabled is returned by the relay methods when the node was created
+// WithoutWakuRelay, or it isn't started
+var ErrRelayDisabled = errors.New("relay is not enabled")
+
+// ErrNodeRunning is returned by Start when the node is already running
+var ErrNodeRunning = errors.New("the node is already running")
+
+// ErrNodeClosed is returned by Start once the node is closed
+var ErrNodeClosed = errors.New("the node is closed")
+
+// ErrStopTimeout is returned by Stop when the goroutines of the node don't
+// return in time
+var ErrStopTimeout = errors.New("timed out waiting for the node to stop")
+
+func New(ctx context.Context, opts ...WakuNodeOption) (*WakuNode, error) {
+	params := new(WakuNodeParameters)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	params.userAgent = DefaultUserAgent
+	params.dnsDiscTarget = DefaultDNSDiscoveryTarget
+	params.dnsDiscInterval = DefaultDNSDiscoveryInterval
+	params.keepAliveTimeout = DefaultKeepAliveTimeout
+	params.stopTimeout = DefaultStopTimeout
+	params.pingBackoff = DefaultPingBackoff
+	params.keepAliveMaxFailures = DefaultMaxPingFailures
+	params.connMgrLowWater = DefaultConnectionsLowWater
+	params.connMgrHighWater = DefaultConnectionsHighWater
+	params.dnsResolver = net.DefaultResolver
+	params.dnsTimeout = DefaultDNSResolutionTimeout
+	params.dnsCacheTTL = DefaultDNSCacheTTL
+
+	opts = append(DefaultWakuNodeOptions, opts...)
+	for _, opt := range opts {
+		err := opt(params)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	// Setting default host address if none was provided
+	if len(params.hostAddrs) == 0 {
+		err := WithHostAddress(&net.TCPAddr{IP: net.ParseIP("0.0.0.0"), Port: 0})(params)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	if err := params.validate(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if params.libP2POpts == nil {
+		params.libP2POpts = DefaultLibP2POptions
+		if params.noDefaultTransports {
+			params.libP2POpts = defaultLibP2POptions
+		}
+	}
+
+	if params.noDefaultTransports {
+		// Prevents libp2p from falling back to the default transports
+		params.libP2POpts = append([]libp2p.Option{libp2p.NoTransports}, params.libP2POpts...)
+	}
+
+	// Prepended, since the options set WithLibP2POptions replace the default ones
+	params.libP2POpts = append([]libp2p.Option{libp2p.UserAgent(params.userAgent)}, params.libP2POpts...)
+
+	if len(params.multiAddr) > 0 {
+		params.libP2POpts = append(params.libP2POpts, libp2p.ListenAddrs(params.multiAddr...))
+	}
+
+	if params.bandwidthCounter != nil {
+		params.libP2POpts = append(params.libP2POpts, libp2p.BandwidthReporter(params.bandwidthCounter))
+	}
+
+	if params.enableWSS {
+		params.libP2POpts = append(params.libP2POpts, libp2p.Transport(newSecureWebsocketTransport(params.tlsCert)))
+	}
+
+	bans, err := newBanList(params.banListStorage)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	gater := &connectionGater{
+		ctx:    ctx,
+		bans:   bans,
+		denied: newIPDenyList(params.deniedIPs, params.deniedCIDRs),
+	}
+	params.libP2POpts = append(params.libP2POpts, libp2p.ConnectionGater(gater))
+
+	// Pruning is done by the node, see startConnectionPruning
+	params.libP2POpts = append(params.libP2POpts, libp2p.ConnectionManager(connmgr.NewConnManager(0, 0, params.connMgrGracePeriod)))
+
+	if len(params.circuitRelays) > 0 {
+		params.libP2POpts = append(params.libP2POpts, libp2p.EnableAutoRelay(), libp2p.StaticRelays(params.circuitRelays))
+	}
+
+	if params.enableRelayService {
+		params.libP2POpts = append(params.libP2POpts, libp2p.EnableRelay(circuit.OptHop))
+	}
+
+	// Same as libp2p.NATPortMap, keeping a reference to the
+	// manager to report the mappings
+	var natManager basichost.NATManager
+	if params.enableNAT {
+		params.libP2POpts = append(params.libP2POpts, libp2p.NATManager(func(n network.Network) basichost.NATManager {
+			natManager = basichost.NewNATManager(n)
+			return natManager
+		}))
+	}
+
+	if params.privKey != nil {
+		params.libP2POpts = append(params.libP2POpts, params.Identity())
+	}
+
+	if params.addressFactory != nil {
+		params.libP2POpts = append(params.libP2POpts, libp2p.AddrsFactory(params.addressFactory))
+	}
+
+	// Appended last, since they replace the ones set by any other option
+	if params.muxers != nil {
+		params.libP2POpts = append(params.libP2POpts, clearMuxers)
+		params.libP2POpts = append(params.libP2POpts, params.muxers...)
+	}
+
+	if params.security != nil {
+		params.libP2POpts = append(params.libP2POpts, clearSecurity)
+		params.libP2POpts = append(params.libP2POpts, params.security...)
+	}
+
+	host, err := libp2p.New(ctx, params.libP2POpts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The host listens from now on, so it's closed if the node can't be created
+	created := false
+	defer func() {
+		if !created {
+			host.Close()
+			cancel()
+		}
+	}()
+
+	w := new(WakuNode)
+	w.bcaster = v2.NewBroadcaster(1024)
+	w.host = host
+	w.nodeCancel = cancel
+	w.nodeCtx = ctx
+	w.opts = params
+	w.wg = &sync.WaitGroup{}
+	w.keepAliveFails = make(map[peer.ID]int)
+	w.pingBackoffs = newPingBackoffs(params.pingBackoff)
+	w.recentPeers.peers = make(map[peer.ID]*recentPeer)
+	w.recentPeers.closed = make(map[peer.ID]struct{})
+	w.latencies.peers = make(map[peer.ID]*latencySamples)
+	w.pins.peers = make(map[peer.ID]*pinnedPeer)
+	w.connStatusUpdates.trigger = make(chan struct{}, 1)
+
+	w.dns, err = newDNSCache(params.dnsResolver, params.dnsTimeout, params.dnsCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	w.peerSelector = utils.NewPeerSelector(params.peerSelection, utils.DefaultPeerFailureCooldown)
+	w.prunedPeers = newPeerSet()
+	w.closedPeers = newPeerSet()
+	w.protectionTags = newProtectionTags()
+	w.bans = bans
+	w.gater = gater
+	w.natManager = natManager
+
+	if params.peerDB != nil {
+		w.peerDB, err = persistence.NewPeerDB(params.peerDB)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := w.loadPeers(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.setupENR(); err != nil {
+		return nil, err
+	}
+
+	if params.adaptiveKeepAlive {
+		w.activity = newPeerActivity()
+	}
+
+	if params.confirmationsRequired > 0 {
+		w.confirmations = newConfirmationTracker(params.confirmationsRequired, params.confirmationsTimeout)
+	}
+
+	if w.resumeEmitter, err = host.EventBus().Emitter(new(EvtResumeCompleted)); err != nil {
+		return nil, err
+	}
+
+	if params.connStatusC != nil {
+		w.connStatusChan = params.connStatusC
+		connStatusC, _ := w.SubscribeConnStatus()
+		go w.forwardConnStatus(connStatusC)
+	}
+
+	created = true
+	return w, nil
+}
+
+// subscribeEvents subscribes to the host events handled by the
+// connectednessListener during a run
+func (w *WakuNode) subscribeEvents() error {
+	var err error
+	if w.protocolEventSub, err = w.host.EventBus().Subscribe(new(event.EvtPeerProtocolsUpdated)); err != nil {
+		return err
+	}
+
+	if w.identificationEventSub, err = w.host.EventBus().Subscribe([]interface{}{new(event.EvtPeerIdentificationCompleted), new(event.EvtPeerIdentificationFailed)}); err != nil {
+		return err
+	}
+
+	if w.relayPeersEventSub, err = w.host.EventBus().Subscribe(new(relay.EvtRelayPeersChanged)); err != nil {
+		return err
+	}
+
+	if w.addressChangesSub, err = w.host.EventBus().Subscribe(new(event.EvtLocalAddressesUpdated)); err != nil {
+		return err
+	}
+
+	if w.opts.enableAutoNAT {
+		if w.reachabilityEventSub, err = w.host.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// closeEventSubscriptions closes the subscriptions made by subscribeEvents,
+// since the event bus blocks the emitters once a subscription is full
+func (w *WakuNode) closeEventSubscriptions() {
+	for _, sub := range []*event.Subscription{&w.protocolEventSub, &w.identificationEventSub, &w.relayPeersEventSub, &w.addressChangesSub, &w.reachabilityEventSub} {
+		if *sub != nil {
+			(*sub).Close()
+			*sub = nil
+		}
+	}
+}
+
+// onAddrChange updates the ENR of the node with the addresses of the host,
+// until checkForAddressChanges closes addrChan
+func (w *WakuNode) onAddrChange() {
+	defer w.wg.Done()
+
+	for addrs := range w.addrChan {
+		if w.opts.enableDiscV5 {
+			w.updateDiscV5Addr(addrs)
+		}
+
+		w.updateENR(addrs)
+
+		// The status includes the addresses and the ENR, and the NAT mapping
+		// might have changed
+		w.sendConnStatus(nil)
+	}
+}
+
+// updateDiscV5Addr sets the ip and tcp port advertised by discv5. Addresses
+// without a tcp endpoint are only advertised in the multiaddrs field
+func (w *WakuNode) updateDiscV5Addr(addrs []ma.Multiaddr) {
+	addr := selectAddress(w.resolvedTCPAddresses(addrs))
+	if addr == nil {
+		return
+	}
+
+	ip, port, err := tcpEndpoint(addr)
+	if err != nil {
+		log.Error(fmt.Sprintf("could not obtain DiscV5 address from %s: %s", addr, err.Error()))
+		return
+	}
+
+	// The port is still updated, since it's the one the node listens on
+	if ipRank(ip) == ipUnusable {
+		ip = net.IPv4zero
+	}
+
+	if err := w.discoveryV5.UpdateAddr(ip, port); err != nil {
+		log.Error(fmt.Sprintf("could not update DiscV5 address with %s:%d: %s", ip, port, err.Error()))
+	}
+}
+
+const (
+	ipUnusable = iota
+	ipPrivate
+	ipRoutable
+)
+
+// ipRank classifies an IP by how suitable it is to be advertised to other peers
+func ipRank(ip net.IP) int {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return ipUnusable
+	}
+	if discv5.IsPrivate(ip) {
+		return ipPrivate
+	}
+	return ipRoutable
+}
+
+// selectAddress returns the address with the most suitable IP to be advertised:
+// globally routable addresses are preferred over private ones, and IPv4 over
+// IPv6 among equally suitable ones. Loopback, link-local and unspecified
+// addresses are only returned if there is nothing better. Among addresses
+// equally suitable, i.e. when the node listens on several public interfaces,
+// the first one is returned. Use WithAdvertiseAddress to advertise another one.
+// Addresses without an IP are ignored
+func selectAddress(addrs []ma.Multiaddr) ma.Multiaddr {
+	var result ma.Multiaddr
+	var resultIP net.IP
+	resultRank := ipUnusable
+	for _, addr := range addrs {
+		ip, err := utils.ExtractIP(addr)
+		if err != nil {
+			log.Debug(err)
+			continue
+		}
+		rank := ipRank(ip)
+		preferredFamily := ip.To4() != nil && resultIP.To4() == nil
+		if result == nil || rank > resultRank || rank == resultRank && preferredFamily {
+			result = addr
+			resultIP = ip
+			resultRank = rank
+		}
+	}
+	return result
+}
+
+func (w *WakuNode) logAddress(addr ma.Multiaddr) {
+	log.Info("Listening on ", addr)
+}
+
+// checkForAddressChanges sends the addresses of the host to addrChan when
+// they change. As the only sender, it closes addrChan once the node stops
+func (w *WakuNode) checkForAddressChanges() {
+	defer w.wg.Done()
+	defer close(w.addrChan)
+
+	addrs := w.ListenAddresses()
+	first := make(chan struct{}, 1)
+	first <- struct{}{}
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-first:
+			for _, addr := range addrs {
+				w.logAddress(addr)
+			}
+		case <-w.addressChangesSub.Out():
+			newAddrs := w.ListenAddresses()
+			if !sameAddresses(addrs, newAddrs) {
+				addrs = newAddrs
+				log.Warn("Change in host multiaddresses")
+				select {
+				case w.addrChan <- newAddrs:
+				case <-w.quit:
+					return
+				}
+				for _, addr := range newAddrs {
+					w.logAddress(addr)
+				}
+			}
+		}
+	}
+}
+
+// sameAddresses returns true if both lists contain the same multiaddresses,
+// regardless of their order. The host does not guarantee the order of its
+// addresses, which is relevant once it listens on more than one (i.e. tcp and ws)
+func sameAddresses(a []ma.Multiaddr, b []ma.Multiaddr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	addrs := make(map[string]int)
+	for _, addr := range a {
+		addrs[addr.String()]++
+	}
+	for _, addr := range b {
+		addrs[addr.String()]--
+		if addrs[addr.String()] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Start starts the protocols of the node. A stopped node can be started
+// again: the libp2p host, its peerstore and the broadcaster are kept, while
+// the protocols are recreated, so relay and filter subscriptions have to be
+// made again. ErrNodeRunning and ErrNodeClosed are returned if the node is
+// running or closed
+func (w *WakuNode) Start() error {
+	w.lifecycleMutex.Lock()
+	defer w.lifecycleMutex.Unlock()
+
+	switch w.state {
+	case stateRunning:
+		return ErrNodeRunning
+	case stateClosed:
+		return ErrNodeClosed
+	}
+
+	w.ctx, w.cancel = context.WithCancel(w.nodeCtx)
+	w.quit = make(chan struct{})
+	w.addrChan = make(chan []ma.Multiaddr, 1024)
+
+	if err := w.start(); err != nil {
+		// Whatever started is torn down, so that Start can be called again
+		if stopErr := w.stop(); stopErr != nil {
+			return fmt.Errorf("%w, and could not roll back the start: %s", err, stopErr.Error())
+		}
+		return err
+	}
+
+	w.state = stateRunning
+	return nil
+}
+
+// started records a component, which is stopped with the run
+func (w *WakuNode) started(name string, stop func()) {
+	w.components = append(w.components, component{name: name, stop: stop})
+}
+
+// start sets up the components of a run. The errors name the component which
+// failed, and the broadcaster is only wired to the components once they are
+// all set up
+func (w *WakuNode) start() error {
+	// The protocols of the previous run are stopped
+	w.relay, w.groupRelay, w.filter, w.lightPush, w.rendezvous, w.store, w.peerExchange = nil, nil, nil, nil, nil, nil, nil
+	w.connectionNotif = ConnectionNotifier{}
+
+	if err := w.subscribeEvents(); err != nil {
+		return fmt.Errorf("could not subscribe to the host events: %w", err)
+	}
+
+	w.connectionNotif = NewConnectionNotifier(w.ctx, w.host)
+	w.connectionNotif.bans = w.bans
+	w.connectionNotif.activity = w.activity
+	w.connectionNotif.pingBackoffs = w.pingBackoffs
+	w.host.Network().Notify(w.connectionNotif)
+
+	messageProvider := w.opts.messageProvider
+	if w.opts.providerDB != nil && messageProvider == nil {
+		dbStore, err := persistence.NewDBStore(persistence.WithDB(w.opts.providerDB), persistence.WithRetentionPolicy(w.opts.maxMessages, w.opts.maxDuration), persistence.WithMaxSize(w.opts.maxBytes))
+		if err != nil {
+			return fmt.Errorf("could not open the message provider: %w", err)
+		}
+		w.dbStore = dbStore
+		messageProvider = dbStore
+	}
+
+	w.store = store.NewWakuStore(w.host, messageProvider, w.opts.maxMessages, w.opts.maxDuration)
+	w.store.SetMaxSize(w.opts.maxBytes)
+	w.store.SetPeerSelector(w.peerSelector)
+	if w.confirmations != nil {
+		w.store.SetQueryObserver(w.confirmations.retrieved)
+	}
+	w.started("store", w.store.Stop)
+	if w.opts.enableStore {
+		w.startStore()
+	}
+
+	if w.opts.enableFilter {
+		w.filter = filter.NewWakuFilter(w.ctx, w.host, w.opts.isFilterFullNode)
+		if w.opts.filterLimits != nil {
+			w.filter.SetLimits(*w.opts.filterLimits)
+		}
+		w.filter.SetPeerSelector(w.peerSelector)
+		w.started("filter", w.filter.Stop)
+	}
+
+	// The options are copied, since they are extended on each run
+	relayOpts := append([]pubsub.Option{}, w.opts.wOpts...)
+
+	if w.opts.enableRendezvous {
+		rendezvous := newRendezvousDiscovery(rendezvous.NewRendezvousDiscovery(w.host))
+		relayOpts = append(relayOpts, pubsub.WithDiscovery(rendezvous, w.opts.rendezvousOpts...))
+	}
+
+	// Like the host, discv5 is kept between runs
+	if w.opts.enableDiscV5 && w.discoveryV5 == nil {
+		err := w.mountDiscV5()
+		if err != nil {
+			return fmt.Errorf("could not mount discv5: %w", err)
+		}
+	}
+
+	if w.opts.enableDiscV5 {
+		relayOpts = append(relayOpts, pubsub.WithDiscovery(w.discoveryV5, w.opts.discV5Opts...))
+	}
+
+	if w.opts.gossipSubParams != nil {
+		relayOpts = append(relayOpts, pubsub.WithGossipSubParams(*w.opts.gossipSubParams))
+	}
+
+	if w.opts.peerScore != nil {
+		relayOpts = append(relayOpts, pubsub.WithPeerScore(w.opts.peerScore, w.opts.peerScoreLimits))
+	}
+
+	w.peerExchange = peer_exchange.NewWakuPeerExchange(w.ctx, w.host, w.discoveryV5)
+	w.started("peer exchange", w.peerExchange.Stop)
+	if w.opts.enablePeerExchange {
+		if err := w.peerExchange.Start(); err != nil {
+			return fmt.Errorf("could not start peer exchange: %w", err)
+		}
+	}
+
+	if w.opts.enableRelay {
+		err := w.mountRelay(relayOpts...)
+		// The relay may be mounted even if setting it up failed
+		if w.relay != nil {
+			w.started("relay", w.relay.Stop)
+		}
+		if w.groupRelay != nil {
+			w.started("static group relay", w.groupRelay.Stop)
+		}
+		if err != nil {
+			return fmt.Errorf("could not mount relay: %w", err)
+		}
+	}
+
+	// Without relay, lightpush is only a client
+	w.lightPush = lightpush.NewWakuLightPush(w.ctx, w.host, w.relay)
+	if w.opts.lightPushRateLimit != nil {
+		w.lightPush.SetRateLimit(w.lightPushRateLimit())
+	}
+	w.lightPush.SetPeerSelector(w.peerSelector)
+	w.started("lightpush", w.lightPush.Stop)
+	if w.opts.enableLightPush {
+		if err := w.lightPush.Start(); err != nil {
+			return fmt.Errorf("could not start lightpush: %w", err)
+		}
+	}
+
+	if w.opts.enableRendezvousServer {
+		err := w.mountRendezvous()
+		if w.rendezvous != nil {
+			w.started("rendezvous", w.rendezvous.Stop)
+		}
+		if err != nil {
+			return fmt.Errorf("could not start rendezvous: %w", err)
+		}
+	}
+
+	w.wg.Add(4)
+	go w.connStatusPublisher()
+	go w.connectednessListener()
+	go w.checkForAddressChanges()
+	go w.onAddrChange()
+
+	if w.opts.keepAliveInterval > time.Duration(0) {
+		w.startKeepAlive()
+	}
+
+	w.startConnectionPruning()
+	w.startPinnedPeersRedial()
+
+	if len(w.opts.staticPeers) > 0 {
+		w.dialStaticPeers()
+	}
+
+	if w.peerDB != nil {
+		w.startPeersPersistence()
+	}
+
+	if w.opts.bandwidthCounter != nil {
+		w.startBandwidthRecording()
+	}
+
+	if w.confirmations != nil {
+		w.startConfirmationTracking()
+	}
+
+	if w.opts.topicHealthInterval > 0 {
+		w.startTopicHealthMonitoring()
+	}
+
+	if w.opts.enableDNSDisc {
+		w.startDNSDiscovery()
+	}
+
+	// Subscribe store to topic
+	if w.opts.storeMsgs {
+		log.Info("Subscribing store to broadcaster")
+		if len(w.opts.storeTopics) == 0 {
+			w.bcaster.Register(w.store.MsgC, v2.WithName("store"))
+		}
+		for _, topic := range w.opts.storeTopics {
+			w.bcaster.RegisterForTopic(topic, w.store.MsgC, v2.WithName("store"))
+		}
+	}
+
+	if w.filter != nil {
+		log.Info("Subscribing filter to broadcaster")
+		w.bcaster.Register(w.filter.MsgC, v2.WithName("filter"))
+	}
+
+	return nil
+}
+
+// Stop stops the protocols of the node, closes its connections and waits
+// for its goroutines to return. The libp2p host keeps listening, so the node
+// can be started again, until it's closed. Stopping a node which isn't
+// running does nothing. The messages already received are stored before the
+// protocols stop. ErrStopTimeout is returned if the goroutines don't return
+// within the timeout set WithStopTimeout, which also bounds the time spent
+// storing the messages
+func (w *WakuNode) Stop() error {
+	w.lifecycleMutex.Lock()
+	defer w.lifecycleMutex.Unlock()
+
+	if w.state != stateRunning {
+		return nil
+	}
+
+	w.state = stateStopped
+	return w.stop()
+}
+
+// stop tears down the state of the current run. It's also used to undo a
+// failed start, so anything may not have been set up yet
+func (w *WakuNode) stop() error {
+	return w.stopWithin(w.opts.stopTimeout)
+}
+
+// stopWithin tears down the state of the current run, spending at most d
+// storing the messages already received
+func (w *WakuNode) stopWithin(d time.Duration) error {
+	defer w.cancel()
+
+	// The broadcaster is kept for the next run, so the subscribers of this
+	// one are unregistered before their channels are closed
+	if w.activityC != nil {
+		w.bcaster.Unregister(w.activityC)
+	}
+
+	if w.confirmationC != nil {
+		w.bcaster.Unregister(w.confirmationC)
+	}
+
+	if w.filter != nil {
+		w.bcaster.Unregister(w.filter.MsgC)
+	}
+
+	w.drainMessages(d)
+
+	// Saved while the peers are still connected, so they're kept longer
+	if w.peerDB != nil {
+		w.savePeers()
+	}
+
+	close(w.quit)
+
+	if w.connectionNotif.quit != nil {
+		w.host.Network().StopNotify(w.connectionNotif)
+		w.connectionNotif.Close()
+	}
+
+	for i := len(w.components) - 1; i >= 0; i-- {
+		log.Debug("Stopping ", w.components[i].name)
+		w.components[i].stop()
+	}
+	w.components = nil
+
+	for _, id := range w.host.Network().Peers() {
+		if err := w.host.Network().ClosePeer(id); err != nil {
+			log.Debug(fmt.Sprintf("could not close the connections to %s: %s", id, err.Error()))
+		}
+	}
+
+	err := w.waitGoroutines()
+
+	w.closeEventSubscriptions()
+
+	if w.dbStore != nil {
+		w.dbStore.Stop()
+		w.dbStore = nil
+	}
+
+	// The history is resumed again once the next run goes online
+	w.resume.Lock()
+	w.resume.wasOnline = false
+	w.resume.Unlock()
+
+	return err
+}
+
+// Close stops the node if it's running and releases the libp2p host. A
+// closed node can't be started again, and the channels obtained with
+// SubscribeConnStatus, SubscribePeerEvents, SubscribeMessageConfirmations and
+// SubscribeTopicHealth are closed. Closing a closed node does nothing
+func (w *WakuNode) Close() error {
+	w.lifecycleMutex.Lock()
+	defer w.lifecycleMutex.Unlock()
+
+	if w.state == stateClosed {
+		return nil
+	}
+
+	var err error
+	if w.state == stateRunning {
+		err = w.stop()
+	}
+	w.state = stateClosed
+
+	defer w.nodeCancel()
+
+	w.bcaster.Close()
+
+	if closeErr := w.host.Close(); closeErr != nil {
+		log.Error("could not close the host", closeErr)
+	}
+
+	w.connStatusSubs.close()
+	w.peerEventSubs.close()
+	if w.confirmations != nil {
+		w.confirmations.subs.close()
+	}
+	w.topicHealthSubs.close()
+
+	if err := w.resumeEmitter.Close(); err != nil {
+		log.Error("could not close resume events emitter", err)
+	}
+
+	return err
+}
+
+// waitGoroutines waits for the goroutines of the node to return, and logs
+// the ones still running after the stop timeout
+func (w *WakuNode) waitGoroutines() error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(w.opts.stopTimeout):
+	}
+
+	stacks := make([]byte, 1<<20)
+	stacks = stacks[:runtime.Stack(stacks, true)]
+	for _, stack := range strings.Split(string(stacks), "\n\n") {
+		if strings.Contains(stack, "waku/v2/node.(*WakuNode)") {
+			log.Error("goroutine still running after stopping the node: ", stack)
+		}
+	}
+
+	return ErrStopTimeout
+}
+
+func (w *WakuNode) Host() host.Host {
+	return w.host
+}
+
+func (w *WakuNode) ID() string {
+	return w.host.ID().Pretty()
+}
+
+func (w *WakuNode) ListenAddresses() []ma.Multiaddr {
+	hostInfo, _ := ma.NewMultiaddr(fmt.Sprintf("/p2p/%s", w.host.ID().Pretty()))
+	var result []ma.Multiaddr
+	for _, addr := range w.host.Addrs() {
+		result = append(result, addr.Encapsulate(hostInfo))
+	}
+	return result
+}
+
+// Relay returns the relay protocol. It's nil when the node was created
+// WithoutWakuRelay, or it isn't started
+func (w *WakuNode) Relay() *relay.WakuRelay {
+	return w.relay
+}
+
+// MessageCache describes the cache of the relay messages seen recently, enabled
+// WithSeenMessagesTTL. It's empty unless the cache is enabled and the node was started
+func (w *WakuNode) MessageCache() relay.SeenMessagesStats {
+	if w.relay == nil {
+		return relay.SeenMessagesStats{}
+	}
+	return w.relay.SeenMessagesStats()
+}
+
+// SubscribeToTopic subscribes to a relay pubsub topic. Each subscription
+// receives a copy of the messages of the topic, and only those
+func (w *WakuNode) SubscribeToTopic(ctx context.Context, topic string) (*relay.Subscription, error) {
+	if w.relay == nil {
+		return nil, ErrRelayDisabled
+	}
+	return w.relay.SubscribeToTopic(ctx, topic)
+}
+
+// RelayPeersForTopic returns the relay peers known to be subscribed to a topic
+func (w *WakuNode) RelayPeersForTopic(topic string) []peer.ID {
+	if w.relay == nil {
+		return nil
+	}
+	return w.relay.PeersForTopic(topic)
+}
+
+// AddRelayValidator registers a validator for the messages of a relay pubsub
+// topic. Messages rejected by it are neither delivered to the subscribers, the
+// store and filter, nor forwarded to other peers
+func (w *WakuNode) AddRelayValidator(topic string, fn func(ctx context.Context, msg *pb.WakuMessage, peerID peer.ID) bool) error {
+	if w.relay == nil {
+		return ErrRelayDisabled
+	}
+	return w.relay.AddValidator(topic, fn)
+}
+
+// AddProtectedTopic only accepts the relay messages of a topic signed by the
+// private key matching pubKey. Unsigned messages and messages signed with
+// another key are rejected, so they don't propagate. Nodes publishing on the
+// topic need the private key, set WithProtectedTopic
+func (w *WakuNode) AddProtectedTopic(topic string, pubKey *ecdsa.PublicKey) error {
+	if w.relay == nil {
+		return ErrRelayDisabled
+	}
+	return w.relay.AddProtectedTopic(topic, pubKey)
+}
+
+// UnsubscribeFromTopic closes all the subscriptions to a relay pubsub topic
+// and leaves it. The default topic can't be left while messages are stored
+func (w *WakuNode) UnsubscribeFromTopic(ctx context.Context, topic string) error {
+	if topic == relay.DefaultWakuTopic && w.opts.storeMsgs {
+		return fmt.Errorf("can not unsubscribe from %s: the store archives its messages", topic)
+	}
+
+	if w.relay == nil {
+		return ErrRelayDisabled
+	}
+	return w.relay.Unsubscribe(ctx, topic)
+}
+
+func (w *WakuNode) Store() *store.WakuStore {
+	return w.store
+}
+
+// SetFilterFullNode switches the node between serving filter subscriptions to
+// light nodes and only being a filter client, i.e. depending on its
+// connectivity or battery. The light nodes subscribed are notified when it's
+// disabled, see filter.WakuFilter.SetFullNode. The filter capability of the
+// ENR is updated accordingly. The role is kept when the node restarts
+func (w *WakuNode) SetFilterFullNode(ctx context.Context, enabled bool) error {
+	w.lifecycleMutex.Lock()
+	defer w.lifecycleMutex.Unlock()
+
+	if !w.opts.enableFilter {
+		return ErrFilterDisabled
+	}
+	if w.opts.isFilterFullNode == enabled {
+		return nil
+	}
+
+	w.opts.isFilterFullNode = enabled
+	w.updateWakuFlags()
+
+	if w.filter == nil {
+		return nil
+	}
+	return w.filter.SetFullNode(ctx, enabled)
+}
+
+func (w *WakuNode) Filter() *filter.WakuFilter {
+	return w.filter
+}
+
+func (w *WakuNode) Lightpush() *lightpush.WakuLightPush {
+	return w.lightPush
+}
+
+func (w *WakuNode) lightPushRateLimit() lightpush.RateLimit {
+	limit := *w.opts.lightPushRateLimit
+	if w.opts.lightPushMaxViolations > 0 {
+		limit.MaxViolations = w.opts.lightPushMaxViolations
+		limit.OnOffender = func(id peer.ID) {
+			w.BanPeer(id, w.opts.lightPushBanDuration, "lightpush rate limit exceeded")
+		}
+	}
+	return limit
+}
+
+func (w *WakuNode) DiscV5() *discv5.DiscoveryV5 {
+	return w.discoveryV5
+}
+
+// AddBootnodes adds discv5 bootnodes while the node is running. The new nodes
+// are used to seed the routing table right away
+func (w *WakuNode) AddBootnodes(nodes []*enode.Node) error {
+	if !w.opts.enableDiscV5 {
+		return ErrDiscV5Disabled
+	}
+
+	if w.discoveryV5 == nil {
+		w.opts.discV5bootnodes = append(w.opts.discV5bootnodes, nodes...)
+		return nil
+	}
+
+	return w.discoveryV5.AddBootnodes(nodes)
+}
+
+// RemoveBootnode removes a node from the discv5 bootnodes
+func (w *WakuNode) RemoveBootnode(id enode.ID) error {
+	if !w.opts.enableDiscV5 {
+		return ErrDiscV5Disabled
+	}
+
+	if w.discoveryV5 == nil {
+		var bootnodes []*enode.Node
+		for _, n := range w.opts.discV5bootnodes {
+			if n.ID() != id {
+				bootnodes = append(bootnodes, n)
+			}
+		}
+		w.opts.discV5bootnodes = bootnodes
+		return nil
+	}
+
+	w.discoveryV5.RemoveBootnode(id)
+	return nil
+}
+
+// DiscV5Peer is a node found by discv5, with the state of the
+// connection to it
+type DiscV5Peer struct {
+	discv5.DiscoveredPeer
+	InPeerstore bool `json:"inPeerstore"`
+	Connected   bool `json:"connected"`
+}
+
+// DiscoveredPeers returns the nodes in the discv5 routing table, including
+// the ones the node never connected to. It's empty until discv5 is started
+func (w *WakuNode) DiscoveredPeers() ([]DiscV5Peer, error) {
+	if !w.opts.enableDiscV5 {
+		return nil, ErrDiscV5Disabled
+	}
+
+	if w.discoveryV5 == nil {
+		return nil, nil
+	}
+
+	stored := make(map[peer.ID]struct{})
+	for _, id := range w.host.Peerstore().Peers() {
+		stored[id] = struct{}{}
+	}
+
+	var result []DiscV5Peer
+	for _, p := range w.discoveryV5.KnownPeers() {
+		_, inPeerstore := stored[p.ID]
+		result = append(result, DiscV5Peer{
+			DiscoveredPeer: p,
+			InPeerstore:    inPeerstore,
+			Connected:      w.host.Network().Connectedness(p.ID) == network.Connected,
+		})
+	}
+
+	return result, nil
+}
+
+func (w *WakuNode) PeerExchange() *peer_exchange.WakuPeerExchange {
+	return w.peerExchange
+}
+
+// PeerExchangeRequest asks a peer for up to numPeers waku nodes, and adds
+// them to the peerstore. It returns the number of peers added
+func (w *WakuNode) PeerExchangeRequest(ctx context.Context, numPeers int, opts ...peer_exchange.PeerExchangeOption) (int, error) {
+	nodes, err := w.peerExchange.Request(ctx, numPeers, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, node := range nodes {
+		if _, err := w.addDiscoveredPeer(node); err != nil {
+			log.Debug("could not add peer obtained with peer exchange:", err)
+			continue
+		}
+		added++
+	}
+
+	return added, nil
+}
+
+func (w *WakuNode) Broadcaster() v2.Broadcaster {
+	return w.bcaster
+}
+
+func (w *WakuNode) mountRelay(opts ...pubsub.Option) error {
+	var err error
+	w.relay, err = relay.NewWakuRelay(w.ctx, w.host, w.bcaster, opts...)
+	if err != nil {
+		return err
+	}
+
+	w.relay.SetMinPeersToPublish(w.opts.minRelayPeers)
+
+	if w.opts.seenMessagesTTL > 0 {
+		maxSize := w.opts.seenMessagesMaxSize
+		if maxSize == 0 {
+			maxSize = relay.DefaultSeenMessagesMaxSize
+		}
+		if err := w.relay.SetSeenMessagesCache(w.opts.seenMessagesTTL, maxSize); err != nil {
+			return err
+		}
+	}
+
+	if w.opts.defaultValidator != nil {
+		if err := w.relay.AddDefaultValidator(w.opts.defaultValidator); err != nil {
+			return err
+		}
+	}
+
+	for topic, privKey := range w.opts.protectedTopics {
+		if err := w.relay.AddSigningKey(topic, privKey); err != nil {
+			return err
+		}
+	}
+
+	if w.opts.enableStaticGroupRelay {
+		if err := w.mountStaticGroupRelay(); err != nil {
+			return err
+		}
+	}
+
+	sub, err := w.relay.Subscribe(w.ctx)
+	if err != nil {
+		return err
+	}
+
+	// Store, filter and keepalive receive the messages through the broadcaster,
+	// this subscription only keeps the node in the default topic
+	go func() {
+		for range sub.C {
+		}
+	}()
+
+	return err
+}
+
+func (w *WakuNode) mountDiscV5() error {
+	discV5Options := []discv5.DiscoveryV5Option{
+		discv5.WithBootnodes(w.opts.discV5bootnodes),
+		discv5.WithUDPPort(w.opts.udpPort),
+		discv5.WithAutoUpdate(w.opts.discV5autoUpdate),
+	}
+
+	if w.opts.discV5Predicate != nil {
+		discV5Options = append(discV5Options, discv5.WithPredicate(w.opts.discV5Predicate))
+	}
+
+	addr := selectAddress(w.resolvedTCPAddresses(w.ListenAddresses()))
+	if addr == nil {
+		return errors.New("no tcp listen address available for discv5")
+	}
+
+	ip, port, err := tcpEndpoint(addr)
+	if err != nil {
+		return err
+	}
+
+	// Without a suitable address, the one advertised is updated once the host
+	// listens on a better one
+	if ipRank(ip) == ipUnusable {
+		log.Warn(fmt.Sprintf("no suitable address to advertise with discv5 (best is %s), waiting for a better one", addr))
+		ip = net.IPv4zero
+		discV5Options = append(discV5Options, discv5.WithAutoUpdate(true))
+	} else {
+		log.Info(fmt.Sprintf("Advertising %s with discv5", addr))
+	}
+
+	discoveryV5, err := discv5.NewDiscoveryV5(w.Host(), ip, port, w.opts.privKey, w.wakuFlags(), discV5Options...)
+	if err != nil {
+		return err
+	}
+
+	w.discoveryV5 = discoveryV5
+	w.updateENR(w.ListenAddresses())
+	return nil
+}
+
+func (w *WakuNode) mountRendezvous() error {
+	w.rendezvous = rendezvous.NewRendezvousService(w.host, w.opts.rendevousStorage)
+
+	if err := w.rendezvous.Start(); err != nil {
+		return err
+	}
+
+	log.Info("Rendezvous service started")
+	return nil
+}
+
+func (w *WakuNode) startStore() {
+	w.store.Start(w.ctx)
+
+	if w.opts.shouldResume {
+		// Later the history is resumed whenever the node goes back online
+		w.triggerResume()
+	}
+}
+
+// resumeTopics returns the pubsub topics whose history is resumed: the ones
+// set WithResumeTopics, or else the ones set WithStoreTopics, or else the
+// topics of the relay and filter subscriptions
+func (w *WakuNode) resumeTopics() []string {
+	if len(w.opts.resumeTopics) > 0 {
+		return w.opts.resumeTopics
+	}
+
+	if len(w.opts.storeTopics) > 0 {
+		return w.opts.storeTopics
+	}
+
+	topics := make(map[string]struct{})
+	if w.relay != nil {
+		for _, topic := range w.relay.Topics() {
+			topics[topic] = struct{}{}
+		}
+	}
+
+	if w.filter != nil {
+		for _, topic := range w.filter.Topics() {
+			topics[topic] = struct{}{}
+		}
+	}
+
+	if len(topics) == 0 {
+		return []string{relay.DefaultWakuTopic}
+	}
+
+	result := make([]string, 0, len(topics))
+	for topic := range topics {
+		result = append(result, topic)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (w *WakuNode) addPeer(info *peer.AddrInfo, protocols ...p2pproto.ID) error {
+	if w.bans.isBanned(info.ID) {
+		return ErrPeerBanned
+	}
+
+	log.Info(fmt.Sprintf("Adding peer %s to peerstore", info.ID.Pretty()))
+	w.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+
+	// Without protocols, they are obtained by identify once the peer is dialed
+	if len(protocols) == 0 {
+		return nil
+	}
+
+	protocolIDs := make([]string, len(protocols))
+	for i, p := range protocols {
+		protocolIDs[i] = string(p)
+	}
+
+	return w.host.Peerstore().AddProtocols(info.ID, protocolIDs...)
+}
+
+// AddPeer adds a peer to the peerstore with the protocols it supports, and
+// returns the peer info obtained from the multiaddress so it can be dialed.
+// DNS multiaddresses are resolved before they're added
+func (w *WakuNode) AddPeer(address ma.Multiaddr, protocols ...p2pproto.ID) (*peer.AddrInfo, error) {
+	info, err := peer.AddrInfoFromP2pAddr(address)
+	if err != nil {
+		return nil, err
+	}
+
+	info.Addrs, err = w.dns.resolveAll(w.nodeCtx, info.Addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, w.addPeer(info, protocols...)
+}
+
+// AddPeerFromENR adds the peer of a node record to the peerstore, and returns
+// its peer info so it can be dialed. The addresses are obtained from the ip
+// and tcp fields and the multiaddrs field, which includes the websocket ones.
+// Without protocols, the ones advertised in the waku capabilities field of
+// the record are added
+func (w *WakuNode) AddPeerFromENR(enrStr string, protocols ...p2pproto.ID) (*peer.AddrInfo, error) {
+	node, err := enode.Parse(enode.ValidSchemes, enrStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENR: %w", err)
+	}
+
+	info, err := utils.EnrToAddrInfo(node)
+	if err != nil {
+		return nil, fmt.Errorf("could not obtain the addresses of the ENR: %w", err)
+	}
+
+	info.Addrs, err = w.dns.resolveAll(w.nodeCtx, info.Addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(protocols) == 0 {
+		flags, err := discv5.WakuEnrBitfieldFromNode(node)
+		if err != nil && !enr.IsNotFound(err) {
+			return nil, fmt.Errorf("invalid waku capabilities in the ENR of %s: %w", info.ID, err)
+		}
+		for _, p := range protocolsFromWakuEnrBitfield(flags) {
+			protocols = append(protocols, p2pproto.ID(p))
+		}
+	}
+
+	return info, w.addPeer(info, protocols...)
+}
+
+// AddPeerWithProtocol adds a peer to the peerstore with a single protocol.
+//
+// Deprecated: use AddPeer, which accepts several protocols
+func (w *WakuNode) AddPeerWithProtocol(address ma.Multiaddr, protocolID p2pproto.ID) (*peer.ID, error) {
+	info, err := w.AddPeer(address, protocolID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info.ID, nil
+}
+
+func (w *WakuNode) DialPeerWithMultiAddress(ctx context.Context, address ma.Multiaddr) error {
+	info, err := peer.AddrInfoFromP2pAddr(address)
+	if err != nil {
+		return err
+	}
+
+	return w.connect(ctx, *info)
+}
+
+func (w *WakuNode) DialPeer(ctx context.Context, address string) error {
+	p, err := ma.NewMultiaddr(address)
+	if err != nil {
+		return err
+	}
+
+	info, err := peer.AddrInfoFromP2pAddr(p)
+	if err != nil {
+		return err
+	}
+
+	return w.connect(ctx, *info)
+}
+
+// DialPeerByENR connects to the node described by an ENR. The protocols
+// advertised in its waku capabilities field are stored in the peerstore
+func (w *WakuNode) DialPeerByENR(ctx context.Context, enr string) error {
+	node, err := enode.Parse(enode.ValidSchemes, enr)
+	if err != nil {
+		return err
+	}
+
+	discoveredPeer, err := w.addDiscoveredPeer(node)
+	if err != nil {
+		return err
+	}
+
+	return w.connect(ctx, discoveredPeer.PeerInfo)
+}
+
+// connect dials a peer, resolving its DNS multiaddresses first
+func (w *WakuNode) connect(ctx context.Context, info peer.AddrInfo) error {
+	if w.bans.isBanned(info.ID) {
+		return ErrPeerBanned
+	}
+
+	addrs, err := w.dns.resolveAll(ctx, info.Addrs)
+	if err != nil {
+		return err
+	}
+	info.Addrs = addrs
+
+	if w.gater.denied.deniesAll(info.Addrs) {
+		return ErrPeerGated
+	}
+
+	err = w.host.Connect(ctx, info)
+	if err != nil {
+		if w.isGated(info, err) {
+			return ErrPeerGated
+		}
+
+		if isPeerIDMismatch(err) {
+			return fmt.Errorf("%w: %s", ErrPeerIDMismatch, err.Error())
+		}
+
+		if len(w.opts.circuitRelays) == 0 {
+			return err
+		}
+
+		relayErr := w.connectThroughRelay(ctx, info.ID)
+		if relayErr != nil {
+			log.Debug(fmt.Sprintf("could not connect to %s through a relay: %s", info.ID, relayErr.Error()))
+			return err
+		}
+	}
+
+	stats.Record(ctx, metrics.Dials.M(1))
+	return nil
+}
+
+// connectThroughRelay dials a peer using the static relays
+func (w *WakuNode) connectThroughRelay(ctx context.Context, id peer.ID) error {
+	circuitMa, err := ma.NewMultiaddr("/p2p-circuit")
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, relay := range w.opts.circuitRelays {
+		if relay.ID == id {
+			continue
+		}
+
+		relayMa, err := ma.NewMultiaddr(fmt.Sprintf("/p2p/%s", relay.ID.Pretty()))
+		if err != nil {
+			return err
+		}
+
+		w.host.Peerstore().AddAddrs(relay.ID, relay.Addrs, peerstore.PermanentAddrTTL)
+
+		lastErr = w.host.Connect(ctx, peer.AddrInfo{ID: id, Addrs: []ma.Multiaddr{relayMa.Encapsulate(circuitMa)}})
+		if lastErr == nil {
+			log.Info(fmt.Sprintf("Connected to %s through relay %s", id, relay.ID))
+			return nil
+		}
+	}
+
+	return lastErr
+}
+
+func (w *WakuNode) DialPeerByID(ctx context.Context, peerID peer.ID) error {
+	info := w.host.Peerstore().PeerInfo(peerID)
+	return w.connect(ctx, info)
+}
+
+func (w *WakuNode) ClosePeerByAddress(address string) error {
+	p, err := ma.NewMultiaddr(address)
+	if err != nil {
+		return err
+	}
+
+	// Extract the peer ID from the multiaddr.
+	info, err := peer.AddrInfoFromP2pAddr(p)
+	if err != nil {
+		return err
+	}
+
+	return w.ClosePeerById(info.ID)
+}
+
+func (w *WakuNode) ClosePeerById(id peer.ID) error {
+	if w.host.Network().Connectedness(id) == network.Connected {
+		w.recentPeers.close(id)
+	}
+
+	err := w.closePeer(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RemovePeer closes the connections to a peer and removes its addresses and
+// protocols from the peerstore, so it's not dialed or selected anymore
+func (w *WakuNode) RemovePeer(id peer.ID) error {
+	if err := w.ClosePeerById(id); err != nil {
+		return err
+	}
+
+	w.host.Peerstore().ClearAddrs(id)
+
+	protocols, err := w.host.Peerstore().GetProtocols(id)
+	if err != nil {
+		return err
+	}
+	if len(protocols) > 0 {
+		if err := w.host.Peerstore().RemoveProtocols(id, protocols...); err != nil {
+			return err
+		}
+	}
+
+	w.recentPeers.forget(id)
+	w.resetPingFailures(id)
+	w.latencies.remove(id)
+	w.UnpinPeer(id)
+	w.forgetSavedPeer(id)
+
+	return nil
+}
+
+func (w *WakuNode) PeerCount() int {
+	return len(w.host.Network().Peers())
+}
+
+func (w *WakuNode) PeerStats() PeerStats {
+	p := make(PeerStats)
+	for _, peerID := range w.host.Network().Peers() {
+		protocols, err := w.host.Peerstore().GetProtocols(peerID)
+		if err != nil {
+			continue
+		}
+		p[peerID] = protocols
+	}
+	return p
+}
+
+func (w *WakuNode) Peers() ([]*Peer, error) {
+	var peers []*Peer
+	for _, peerId := range w.host.Peerstore().Peers() {
+		p, err := w.peerInfo(peerId)
+		if err != nil {
+			return nil, err
+		}
+
+		// The keys of removed peers are kept in the peerstore
+		if !p.Connected && len(p.Addrs) == 0 && len(p.Protocols) == 0 {
+			continue
+		}
+
+		peers = append(peers, p)
+	}
+	return peers, nil
+}
+
+// PeersByProtocol returns the peers in the peerstore that support a protocol,
+// sorted by peer ID. If connectedOnly is true, only connected peers are returned
+func (w *WakuNode) PeersByProtocol(proto p2pproto.ID, connectedOnly bool) []*Peer {
+	var ids peer.IDSlice
+	for _, peerId := range w.host.Peerstore().Peers() {
+		if connectedOnly && w.host.Network().Connectedness(peerId) != network.Connected {
+			continue
+		}
+
+		protocols, err := w.host.Peerstore().SupportsProtocols(peerId, string(proto))
+		if err != nil {
+			log.Error("error obtaining the protocols supported by peers", err)
+			continue
+		}
+
+		if len(protocols) > 0 {
+			ids = append(ids, peerId)
+		}
+	}
+
+	sort.Sort(ids)
+
+	var peers []*Peer
+	for _, peerId := range ids {
+		p, err := w.peerInfo(peerId)
+		if err != nil {
+			log.Error("error obtaining peer info", err)
+			continue
+		}
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// StorePeers returns the connected peers that support the store protocol
+func (w *WakuNode) StorePeers() []*Peer {
+	return w.PeersByProtocol(store.StoreID_v20beta3, true)
+}
+
+// FilterPeers returns the connected peers that support the filter protocol
+func (w *WakuNode) FilterPeers() []*Peer {
+	return w.PeersByProtocol(filter.FilterID_v20beta1, true)
+}
+
+// LightpushPeers returns the connected peers that support the lightpush protocol
+func (w *WakuNode) LightpushPeers() []*Peer {
+	return w.PeersByProtocol(lightpush.LightPushID_v20beta1, true)
+}
+
+func (w *WakuNode) peerInfo(peerId peer.ID) (*Peer, error) {
+	connected := w.host.Network().Connectedness(peerId) == network.Connected
+	protocols, err := w.host.Peerstore().GetProtocols(peerId)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := w.host.Peerstore().Addrs(peerId)
+	latency, _ := w.PeerLatency(peerId)
+	p := &Peer{
+		ID:           peerId,
+		Protocols:    protocols,
+		Connected:    connected,
+		Addrs:        addrs,
+		Protected:    w.peerProtectionTags(peerId),
+		Ban:          w.bans.get(peerId),
+		Pinned:       w.IsPinned(peerId),
+		Relayed:      w.isRelayed(peerId),
+		Latency:      latency,
+		AgentVersion: w.agentVersion(peerId),
+	}
+
+	for _, conn := range w.host.Network().ConnsToPeer(peerId) {
+		stat := conn.Stat()
+		if p.ConnectedAt.IsZero() || stat.Opened.Before(p.ConnectedAt) {
+			p.ConnectedAt = stat.Opened
+			p.Direction = stat.Direction
+		}
+	}
+
+	return p, nil
+}
+
+// agentVersion returns the agent version a peer sent during identification,
+// or an empty string if it is unknown
+func (w *WakuNode) agentVersion(id peer.ID) string {
+	av, err := w.host.Peerstore().Get(id, "AgentVersion")
+	if err != nil {
+		return ""
+	}
+
+	agentVersion, _ := av.(string)
+	return agentVersion
+}
+
+// isRelayed returns true if the node is only connected to a peer through relays
+func (w *WakuNode) isRelayed(id peer.ID) bool {
+	conns := w.host.Network().ConnsToPeer(id)
+	for _, conn := range conns {
+		if !utils.IsCircuitAddress(conn.RemoteMultiaddr()) {
+			return false
+		}
+	}
+	return len(conns) > 0
+}
@@ -0,0 +1,185 @@
+package node
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	_ "github.com/mattn/go-sqlite3" // Blank import to register the sqlite3 driver
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWakuNode2(t *testing.T) {
+	hostAddr, _ := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+
+	key, err := tests.RandomHex(32)
+	require.NoError(t, err)
+	prvKey, err := crypto.HexToECDSA(key)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	wakuNode, err := New(ctx,
+		WithPrivateKey(prvKey),
+		WithHostAddress(hostAddr),
+		WithWakuRelay(),
+	)
+	require.NoError(t, err)
+
+	err = wakuNode.Start()
+	defer wakuNode.Stop()
+
+	require.NoError(t, err)
+}
+
+func TestNewClosesHostOnError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	hostAddr := l.Addr().(*net.TCPAddr)
+	require.NoError(t, l.Close())
+
+	// The peerstore can't be loaded from a closed database, which fails New
+	// once the host is listening
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = New(context.Background(),
+		WithHostAddress(hostAddr),
+		WithPeerstorePersistence(db),
+	)
+	require.Error(t, err)
+
+	// libp2p binds with SO_REUSEPORT, so only a listener without it tells
+	// whether the host was closed
+	l, err = net.Listen("tcp", hostAddr.String())
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+}
+
+func TestRemovePeer(t *testing.T) {
+	ctx := context.Background()
+	hostAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}
+
+	storeNode, err := New(ctx, WithHostAddress(hostAddr), WithWakuRelay(), WithWakuStore(false, false))
+	require.NoError(t, err)
+	require.NoError(t, storeNode.Start())
+	defer storeNode.Stop()
+
+	wakuNode, err := New(ctx, WithHostAddress(hostAddr), WithWakuRelay())
+	require.NoError(t, err)
+	require.NoError(t, wakuNode.Start())
+	defer wakuNode.Stop()
+
+	id := storeNode.Host().ID()
+	_, err = wakuNode.AddPeer(storeNode.ListenAddresses()[0], store.StoreID_v20beta3)
+	require.NoError(t, err)
+	require.NoError(t, wakuNode.DialPeerByID(ctx, id))
+
+	require.NoError(t, wakuNode.RemovePeer(id))
+	// Unknown peers are ignored
+	require.NoError(t, wakuNode.RemovePeer(peer.ID("unknown")))
+
+	require.Eventually(t, func() bool {
+		return wakuNode.Host().Network().Connectedness(id) != network.Connected
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, wakuNode.Host().Peerstore().Addrs(id))
+	require.NotContains(t, wakuNode.recentPeers.list(), id)
+
+	_, err = utils.SelectPeer(wakuNode.Host(), string(store.StoreID_v20beta3))
+	require.Error(t, err)
+
+	peers, err := wakuNode.Peers()
+	require.NoError(t, err)
+	for _, p := range peers {
+		require.NotEqual(t, id, p.ID)
+	}
+}
+
+func TestBootnodesWithoutDiscV5(t *testing.T) {
+	wakuNode, err := New(context.Background(), WithHostAddress(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")}))
+	require.NoError(t, err)
+	defer wakuNode.Close()
+
+	require.ErrorIs(t, wakuNode.AddBootnodes(nil), ErrDiscV5Disabled)
+	require.ErrorIs(t, wakuNode.RemoveBootnode(enode.ID{}), ErrDiscV5Disabled)
+}
+
+func TestDiscoveredPeersWithoutDiscV5(t *testing.T) {
+	wakuNode, err := New(context.Background(), WithHostAddress(&net.TCPAddr{IP: net.ParseIP("127.0.0.1")}))
+	require.NoError(t, err)
+	defer wakuNode.Close()
+
+	_, err = wakuNode.DiscoveredPeers()
+	require.ErrorIs(t, err, ErrDiscV5Disabled)
+}
+
+func TestIPRank(t *testing.T) {
+	testCases := []struct {
+		ip   string
+		rank int
+	}{
+		{"127.0.0.1", ipUnusable},
+		{"::1", ipUnusable},
+		{"0.0.0.0", ipUnusable},
+		{"::", ipUnusable},
+		{"169.254.1.1", ipUnusable},
+		{"fe80::1", ipUnusable},
+		{"10.0.0.1", ipPrivate},
+		{"192.168.1.1", ipPrivate},
+		{"fd00::2", ipPrivate},
+		{"203.0.113.1", ipRoutable},
+		{"2001:4860::1", ipRoutable},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.rank, ipRank(net.ParseIP(tc.ip)), tc.ip)
+	}
+}
+
+func TestSelectAddress(t *testing.T) {
+	testCases := []struct {
+		name     string
+		addrs    []string
+		expected string
+	}{
+		{"no addresses", nil, ""},
+		{"routable over loopback", []string{"/ip4/127.0.0.1/tcp/60000", "/ip4/203.0.113.1/tcp/60000"}, "/ip4/203.0.113.1/tcp/60000"},
+		{"routable over private", []string{"/ip4/192.168.1.1/tcp/60000", "/ip4/203.0.113.1/tcp/60000"}, "/ip4/203.0.113.1/tcp/60000"},
+		{"private over loopback", []string{"/ip4/127.0.0.1/tcp/60000", "/ip4/10.0.0.1/tcp/60000"}, "/ip4/10.0.0.1/tcp/60000"},
+		{"routable ipv6 over private ipv4", []string{"/ip4/10.0.0.1/tcp/60000", "/ip6/2001:4860::1/tcp/60000"}, "/ip6/2001:4860::1/tcp/60000"},
+		{"ipv4 over ipv6", []string{"/ip6/2001:4860::1/tcp/60000", "/ip4/203.0.113.1/tcp/60000"}, "/ip4/203.0.113.1/tcp/60000"},
+		{"ipv4 over ipv6 loopback", []string{"/ip6/::1/tcp/60000", "/ip4/127.0.0.1/tcp/60000"}, "/ip4/127.0.0.1/tcp/60000"},
+		{"unusable when nothing better", []string{"/ip4/127.0.0.1/tcp/60000"}, "/ip4/127.0.0.1/tcp/60000"},
+		{"first of equals", []string{"/ip4/203.0.113.1/tcp/60000", "/ip4/203.0.113.2/tcp/60000"}, "/ip4/203.0.113.1/tcp/60000"},
+		{"without ip ignored", []string{"/dns4/example.com/tcp/60000", "/ip4/127.0.0.1/tcp/60000"}, "/ip4/127.0.0.1/tcp/60000"},
+		{"only without ip", []string{"/dns4/example.com/tcp/60000"}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var addrs []ma.Multiaddr
+			for _, s := range tc.addrs {
+				addrs = append(addrs, ma.StringCast(s))
+			}
+
+			addr := selectAddress(addrs)
+			if tc.expected == "" {
+				require.Nil(t, addr)
+				return
+			}
+			require.NotNil(t, addr)
+			require.Equal(t, tc.expected, addr.String())
+		})
+	}
+}
@@ -0,0 +1,1185 @@
+package node
+
+import (
+	"crypto/ecdsa"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	libp2pmetrics "github.com/libp2p/go-libp2p-core/metrics"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/config"
+	basichost "github.com/libp2p/go-libp2p/p2p/host/basic"
+	"github.com/multiformats/go-multiaddr"
+	ma "github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
+	manet "github.com/multiformats/go-multiaddr/net"
+	rendezvous "github.com/status-im/go-waku-rendezvous"
+	"github.com/status-im/go-waku/waku/v2/discv5"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/protocol/staticgroup"
+	"github.com/status-im/go-waku/waku/v2/protocol/store"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+// DefaultUserAgent is the user agent the node sends to its peers during
+// identify unless set WithUserAgent: go-waku followed by the version of the
+// go-waku module the binary was built with
+var DefaultUserAgent = "go-waku/" + moduleVersion()
+
+// moduleVersion returns the version of the go-waku module found in the build
+// information of the binary
+func moduleVersion() string {
+	const modulePath = "github.com/status-im/go-waku"
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+
+	return "unknown"
+}
+
+type WakuNodeParameters struct {
+	hostAddrs      []*net.TCPAddr
+	advertiseAddr  *net.IP
+	multiAddr      []ma.Multiaddr
+	addressFactory basichost.AddrsFactory
+	privKey        *ecdsa.PrivateKey
+	libP2POpts     []libp2p.Option
+	userAgent      string
+
+	muxers              []libp2p.Option
+	security            []libp2p.Option
+	noDefaultTransports bool
+
+	bandwidthCounter *libp2pmetrics.BandwidthCounter
+
+	enableWS  bool
+	wsAddress net.IP
+	wsPort    int
+
+	enableWSS bool
+	tlsCert   *tlsCertificate
+
+	enableNAT     bool
+	enableAutoNAT bool
+
+	circuitRelays      []peer.AddrInfo
+	enableRelayService bool
+
+	enableRelay      bool
+	enableFilter     bool
+	isFilterFullNode bool
+	filterLimits     *filter.Limits
+	wOpts            []pubsub.Option
+	gossipSubParams  *pubsub.GossipSubParams
+	peerScore        *pubsub.PeerScoreParams
+	peerScoreLimits  *pubsub.PeerScoreThresholds
+	defaultValidator relay.Validator
+	protectedTopics  map[string]*ecdsa.PrivateKey
+	minRelayPeers    int
+
+	enableStaticGroupRelay bool
+	staticGroup            []staticgroup.IDCommitment
+	staticGroupIndex       uint
+	staticGroupKey         *staticgroup.MembershipKeyPair
+
+	seenMessagesTTL     time.Duration
+	seenMessagesMaxSize int
+
+	lightPushRateLimit     *lightpush.RateLimit
+	lightPushMaxViolations int
+	lightPushBanDuration   time.Duration
+
+	enableStore     bool
+	shouldResume    bool
+	resumeTopics    []string
+	storeTopics     []string
+	storeMsgs       bool
+	messageProvider store.MessageProvider
+	providerDB      *sql.DB
+	maxMessages     int
+	maxDuration     time.Duration
+	maxBytes        int64
+
+	enableRendezvous       bool
+	enableRendezvousServer bool
+	rendevousStorage       rendezvous.Storage
+	rendezvousOpts         []pubsub.DiscoverOpt
+
+	enableDiscV5     bool
+	udpPort          int
+	discV5bootnodes  []*enode.Node
+	discV5Opts       []pubsub.DiscoverOpt
+	discV5autoUpdate bool
+	discV5Predicate  discv5.Predicate
+
+	enableDNSDisc     bool
+	dnsDiscURL        string
+	dnsDiscNameserver string
+	dnsDiscTarget     int
+	dnsDiscInterval   time.Duration
+
+	enablePeerExchange bool
+
+	peerSelection utils.PeerSelection
+
+	confirmationsRequired int
+	confirmationsTimeout  time.Duration
+
+	topicHealthMinPeers int
+	topicHealthInterval time.Duration
+
+	dnsResolver madns.BasicResolver
+	dnsTimeout  time.Duration
+	dnsCacheTTL time.Duration
+
+	banListStorage BanListStorage
+	peerDB         *sql.DB
+	deniedIPs      []net.IP
+	deniedCIDRs    []*net.IPNet
+
+	connMgrLowWater    int
+	connMgrHighWater   int
+	connMgrGracePeriod time.Duration
+
+	keepAliveInterval      time.Duration
+	keepAliveTimeout       time.Duration
+	stopTimeout            time.Duration
+	keepAliveMaxFailures   int
+	keepAliveMaxInterval   time.Duration
+	adaptiveKeepAlive      bool
+	aggressiveReconnection bool
+	pingBackoff            []time.Duration
+
+	staticPeers []peer.AddrInfo
+
+	enableLightPush bool
+
+	connStatusC chan ConnStatus
+}
+
+type WakuNodeOption func(*WakuNodeParameters) error
+
+// Default options used in the libp2p node
+var DefaultWakuNodeOptions = []WakuNodeOption{
+	WithWakuRelay(),
+}
+
+// MultiAddresses return the list of multiaddresses configured in the node
+func (w WakuNodeParameters) MultiAddresses() []ma.Multiaddr {
+	return w.multiAddr
+}
+
+// Identity returns a libp2p option containing the identity used by the node
+func (w WakuNodeParameters) Identity() config.Option {
+	return libp2p.Identity(*w.GetPrivKey())
+}
+
+func (w WakuNodeParameters) AddressFactory() basichost.AddrsFactory {
+	return w.addressFactory
+}
+
+// WithHostAddress is a WakuNodeOption that configures libp2p to listen on
+// specific addresses. It can be used more than once, i.e. to listen on several
+// interfaces of a dual-homed machine, the addresses are added to the previous
+// ones. Both IPv4 and IPv6 addresses are supported
+func WithHostAddress(hostAddrs ...*net.TCPAddr) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if len(hostAddrs) == 0 {
+			return errors.New("at least one host address is required")
+		}
+
+		for _, hostAddr := range hostAddrs {
+			hostAddrMA, err := manet.FromNetAddr(hostAddr)
+			if err != nil {
+				return err
+			}
+			params.hostAddrs = append(params.hostAddrs, hostAddr)
+			params.addListenAddress(hostAddrMA)
+		}
+
+		return nil
+	}
+}
+
+// addListenAddress adds a multiaddress to the ones libp2p listens on, unless
+// it's already there
+func (w *WakuNodeParameters) addListenAddress(addr ma.Multiaddr) {
+	for _, a := range w.multiAddr {
+		if a.Equal(addr) {
+			return
+		}
+	}
+	w.multiAddr = append(w.multiAddr, addr)
+}
+
+// WithNAT is a WakuNodeOption used to map the ports of the node in the router
+// with UPnP or NAT-PMP, so peers outside the local network can dial it. The
+// mapped address is announced with discv5 and renewed by the router lease
+func WithNAT() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableNAT = true
+		return nil
+	}
+}
+
+// WithAutoNAT is a WakuNodeOption used to track whether the node is publicly
+// dialable, as determined by libp2p's AutoNAT with the help of other peers. The
+// reachability is reported in ConnStatus and by WakuNode.Reachability
+func WithAutoNAT() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableAutoNAT = true
+		return nil
+	}
+}
+
+// WithCircuitRelay is a WakuNodeOption used to reach the node through relays when
+// it is not publicly dialable. The node keeps reservations with the static relays,
+// advertises the relayed addresses, and falls back to them when a direct dial fails
+func WithCircuitRelay(staticRelays []ma.Multiaddr) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		for _, addr := range staticRelays {
+			info, err := peer.AddrInfoFromP2pAddr(addr)
+			if err != nil {
+				return fmt.Errorf("invalid relay address %s: %w", addr, err)
+			}
+			params.circuitRelays = append(params.circuitRelays, *info)
+		}
+		return nil
+	}
+}
+
+// WithCircuitRelayService is a WakuNodeOption used to relay the
+// connections of peers that can't be dialed directly
+func WithCircuitRelayService() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableRelayService = true
+		return nil
+	}
+}
+
+// WithAdvertiseAddress is a WakuNodeOption that allows overriding the address used in the waku node with custom value
+func WithAdvertiseAddress(address *net.TCPAddr, enableWS bool, wsPort int) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.advertiseAddr = &address.IP
+
+		advertiseAddress, err := manet.FromNetAddr(address)
+		if err != nil {
+			return err
+		}
+
+		params.addressFactory = func([]ma.Multiaddr) []ma.Multiaddr {
+			var result []multiaddr.Multiaddr
+			result = append(result, advertiseAddress)
+			if enableWS {
+				wsMa, _ := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/%s/tcp/%d/ws", address, wsPort))
+				result = append(result, wsMa)
+			}
+			return result
+		}
+		return nil
+	}
+}
+
+// WithWebsockets is a WakuNodeOption used to listen for websocket connections
+// on an specific address and port. The websocket transport is part of the
+// libp2p default transports, included in DefaultLibP2POptions. If these
+// options are replaced with WithLibP2POptions, the transport must be added too
+func WithWebsockets(address net.IP, port int) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableWS = true
+		params.wsAddress = address
+		params.wsPort = port
+
+		tcpAddr, err := manet.FromNetAddr(&net.TCPAddr{IP: address, Port: port})
+		if err != nil {
+			return err
+		}
+		wsMa, err := ma.NewMultiaddr("/ws")
+		if err != nil {
+			return err
+		}
+		params.addListenAddress(tcpAddr.Encapsulate(wsMa))
+
+		return nil
+	}
+}
+
+// WithSecureWebsockets is a WakuNodeOption used to listen for websocket connections
+// over TLS (/wss) on an specific address and port, using the certificate and
+// key stored in certPath and keyPath. The node fails to be created if the
+// certificate can't be loaded or is expired. WakuNode.ReloadTLS can be used
+// to load a renewed certificate
+func WithSecureWebsockets(address net.IP, port int, certPath string, keyPath string) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		cert, err := newTLSCertificate(certPath, keyPath)
+		if err != nil {
+			return err
+		}
+
+		params.enableWSS = true
+		params.tlsCert = cert
+
+		tcpAddr, err := manet.FromNetAddr(&net.TCPAddr{IP: address, Port: port})
+		if err != nil {
+			return err
+		}
+		wssMa, err := ma.NewMultiaddr("/wss")
+		if err != nil {
+			return err
+		}
+		params.addListenAddress(tcpAddr.Encapsulate(wssMa))
+
+		return nil
+	}
+}
+
+// WithMultiaddress is a WakuNodeOption that configures libp2p to listen on a list of multiaddresses
+func WithMultiaddress(addresses []ma.Multiaddr) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		for _, addr := range addresses {
+			params.addListenAddress(addr)
+		}
+		return nil
+	}
+}
+
+// WithDNSResolver is a WakuNodeOption used to set the resolver of the dns4,
+// dns6 and dnsaddr components of the multiaddresses the node dials, adds to
+// the peerstore or advertises (net.DefaultResolver by default), the maximum
+// time spent resolving each of them, and the time during which the resolved
+// addresses are reused
+func WithDNSResolver(resolver madns.BasicResolver, timeout time.Duration, cacheTTL time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if resolver == nil {
+			return errors.New("the DNS resolver can't be null")
+		}
+		if timeout <= 0 || cacheTTL <= 0 {
+			return errors.New("the DNS resolution timeout and cache TTL must be positive")
+		}
+		params.dnsResolver = resolver
+		params.dnsTimeout = timeout
+		params.dnsCacheTTL = cacheTTL
+		return nil
+	}
+}
+
+// WithPrivateKey is used to set an ECDSA private key in a libp2p node
+func WithPrivateKey(privKey *ecdsa.PrivateKey) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.privKey = privKey
+		return nil
+	}
+}
+
+func (w *WakuNodeParameters) GetPrivKey() *crypto.PrivKey {
+	privKey := crypto.PrivKey((*crypto.Secp256k1PrivateKey)(w.privKey))
+	return &privKey
+}
+
+// WithLibP2POptions is a WakuNodeOption used to configure the libp2p node.
+// This can potentially override any libp2p config that was set with other
+// WakuNodeOption
+func WithLibP2POptions(opts ...libp2p.Option) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.libP2POpts = opts
+		return nil
+	}
+}
+
+// WithMuxers is a WakuNodeOption used to set the stream multiplexers of the
+// libp2p node, i.e. libp2p.Muxer options. They replace the default ones
+// (yamux and mplex), including the ones set WithLibP2POptions
+func WithMuxers(muxers ...libp2p.Option) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if len(muxers) == 0 {
+			return errors.New("at least one stream multiplexer is required")
+		}
+		params.muxers = muxers
+		return nil
+	}
+}
+
+// WithSecurity is a WakuNodeOption used to set the security transports of
+// the libp2p node, i.e. libp2p.Security options. They replace the default ones
+// (noise and TLS), including the ones set WithLibP2POptions
+func WithSecurity(security ...libp2p.Option) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if len(security) == 0 {
+			return errors.New("at least one security transport is required")
+		}
+		params.security = security
+		return nil
+	}
+}
+
+// WithNoDefaultTransports is a WakuNodeOption used to leave the default
+// libp2p transports (tcp and websocket) out, so the node only uses the ones
+// set WithLibP2POptions, and the ones required by other options, like
+// WithSecureWebsockets
+func WithNoDefaultTransports() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.noDefaultTransports = true
+		return nil
+	}
+}
+
+// WithUserAgent is a WakuNodeOption used to set the user agent sent to the
+// peers during identify, instead of DefaultUserAgent. Applications can append
+// their own version to it, i.e. DefaultUserAgent + " status-go/0.1.0". A user
+// agent set WithLibP2POptions takes precedence
+func WithUserAgent(agent string) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if agent == "" {
+			return errors.New("the user agent can not be empty")
+		}
+		params.userAgent = agent
+		return nil
+	}
+}
+
+// WithBandwidthReporter is a WakuNodeOption used to count the data sent and
+// received by the node, in total and per protocol. The counters are read with
+// BandwidthTotals and BandwidthByProtocol, and periodically recorded as metrics
+func WithBandwidthReporter() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.bandwidthCounter = libp2pmetrics.NewBandwidthCounter()
+		return nil
+	}
+}
+
+// WithWakuRelay enables the Waku V2 Relay protocol. This WakuNodeOption
+// accepts a list of WakuRelay gossipsub option to setup the protocol
+func WithWakuRelay(opts ...pubsub.Option) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableRelay = true
+		params.wOpts = opts
+		return nil
+	}
+}
+
+// WithoutWakuRelay disables the relay protocol, enabled by default, so
+// gossipsub isn't mounted. It suits resource-restricted light clients, which
+// receive messages WithWakuFilter and send them to a lightpush peer
+func WithoutWakuRelay() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableRelay = false
+		params.wOpts = nil
+		return nil
+	}
+}
+
+// WithGossipSubParams is a WakuNodeOption used to tune the gossipsub router of
+// the relay, i.e. with MobileGossipSubParams. Inconsistent mesh degrees and
+// non positive heartbeat intervals are rejected
+func WithGossipSubParams(gossipSubParams pubsub.GossipSubParams) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if err := validateGossipSubParams(gossipSubParams); err != nil {
+			return err
+		}
+		params.gossipSubParams = &gossipSubParams
+		return nil
+	}
+}
+
+// WithPeerScoreParams is a WakuNodeOption used to enable the peer scoring of
+// the gossipsub router of the relay. The parameters are validated by gossipsub
+// when the relay is mounted
+func WithPeerScoreParams(scoreParams *pubsub.PeerScoreParams, thresholds *pubsub.PeerScoreThresholds) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if scoreParams == nil || thresholds == nil {
+			return errors.New("peer score parameters and thresholds are required")
+		}
+		params.peerScore = scoreParams
+		params.peerScoreLimits = thresholds
+		return nil
+	}
+}
+
+// WithDefaultValidator is a WakuNodeOption used to reject the relay messages
+// without content topic, with a payload bigger than maxPayloadSize, or with a
+// timestamp deviating more than maxTimestampSkew from the local clock, in every
+// topic. Zero values use relay.DefaultMaxPayloadSize and relay.DefaultMaxTimestampSkew
+func WithDefaultValidator(maxPayloadSize int, maxTimestampSkew time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if maxPayloadSize < 0 || maxTimestampSkew < 0 {
+			return errors.New("the limits of the default validator can not be negative")
+		}
+		params.defaultValidator = relay.NewDefaultValidator(maxPayloadSize, maxTimestampSkew)
+		return nil
+	}
+}
+
+// WithMinRelayPeersToPublish is a WakuNodeOption used to make relay publishing
+// fail with relay.ErrNotEnoughPeers when the topic has fewer than n peers, so
+// callers can retry or fall back to lightpush
+func WithMinRelayPeersToPublish(n int) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if n < 0 {
+			return errors.New("the minimum number of relay peers can not be negative")
+		}
+		params.minRelayPeers = n
+		return nil
+	}
+}
+
+// WithSeenMessagesTTL is a WakuNodeOption used to ignore the relay messages
+// received again within ttl, instead of the 2 minutes of gossipsub. Up to
+// relay.DefaultSeenMessagesMaxSize message IDs are kept unless specified
+// WithSeenMessagesMaxSize. See relay.WakuRelay.SetSeenMessagesCache
+func WithSeenMessagesTTL(ttl time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if ttl <= 0 {
+			return errors.New("the seen messages TTL must be positive")
+		}
+		params.seenMessagesTTL = ttl
+		return nil
+	}
+}
+
+// WithSeenMessagesMaxSize is a WakuNodeOption used to set the maximum number
+// of message IDs kept by the cache enabled WithSeenMessagesTTL
+func WithSeenMessagesMaxSize(n int) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if n <= 0 {
+			return errors.New("the maximum size of the seen messages cache must be positive")
+		}
+		params.seenMessagesMaxSize = n
+		return nil
+	}
+}
+
+// WithProtectedTopic is a WakuNodeOption used to sign the messages published
+// on a relay topic with privKey, and to only accept the ones signed with it
+func WithProtectedTopic(topic string, privKey *ecdsa.PrivateKey) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if privKey == nil {
+			return relay.ErrNoTopicKey
+		}
+		if params.protectedTopics == nil {
+			params.protectedTopics = make(map[string]*ecdsa.PrivateKey)
+		}
+		params.protectedTopics[topic] = privKey
+		return nil
+	}
+}
+
+// WithStaticGroupRelay is a WakuNodeOption used to protect the default relay
+// topic from spam with a static group of members, in the order of their
+// index: each member can only publish one message per epoch, signed with its
+// key, and the messages without a valid proof are rejected. The node
+// publishes as the member with index membershipIndex, whose key is key.
+// Without key, the node verifies the messages it relays, but can't publish any
+func WithStaticGroupRelay(group []staticgroup.IDCommitment, membershipIndex uint, key *staticgroup.MembershipKeyPair) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if len(group) == 0 {
+			return errors.New("the static group can not be empty")
+		}
+		if len(group) > 1<<staticgroup.MerkleTreeDepth {
+			return staticgroup.ErrTreeFull
+		}
+		params.enableStaticGroupRelay = true
+		params.staticGroup = group
+		params.staticGroupIndex = membershipIndex
+		params.staticGroupKey = key
+		return nil
+	}
+}
+
+// WithDiscoveryV5 is a WakuOption used to enable DiscV5 peer discovery
+func WithDiscoveryV5(udpPort int, bootnodes []*enode.Node, autoUpdate bool, discoverOpts ...pubsub.DiscoverOpt) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableDiscV5 = true
+		params.udpPort = udpPort
+		params.discV5bootnodes = bootnodes
+		params.discV5Opts = discoverOpts
+		params.discV5autoUpdate = autoUpdate
+		return nil
+	}
+}
+
+// WithDiscV5Predicate is a WakuOption used to only connect to the peers found
+// with DiscV5 that are accepted by the predicate (i.e. discv5.CapabilityFilter)
+func WithDiscV5Predicate(predicate discv5.Predicate) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.discV5Predicate = predicate
+		return nil
+	}
+}
+
+// WithDNSDiscovery is a WakuOption used to bootstrap the node with the peers
+// published in an EIP-1459 ENR tree (enrtree://...). The tree is resolved when
+// the node starts and then periodically, using an optional nameserver
+func WithDNSDiscovery(url string, nameserver string) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableDNSDisc = true
+		params.dnsDiscURL = url
+		params.dnsDiscNameserver = nameserver
+		return nil
+	}
+}
+
+// WithDNSDiscoveryParams is a WakuOption used to set the number of DNS discovered
+// peers to be connected to, and the interval between resolutions of the ENR tree
+func WithDNSDiscoveryParams(target int, interval time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.dnsDiscTarget = target
+		params.dnsDiscInterval = interval
+		return nil
+	}
+}
+
+// WithPeerExchange is a WakuOption used to answer the peer exchange requests
+// of other nodes with the peers found using DiscV5, which must be enabled too
+func WithPeerExchange() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enablePeerExchange = true
+		return nil
+	}
+}
+
+// WithRendezvous is a WakuOption used to enable go-waku-rendezvous discovery.
+// It accepts an optional list of DiscoveryOpt options
+func WithRendezvous(discoverOpts ...pubsub.DiscoverOpt) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableRendezvous = true
+		params.rendezvousOpts = discoverOpts
+		return nil
+	}
+}
+
+// WithRendezvousServer is a WakuOption used to set the node as a rendezvous
+// point, using an specific storage for the peer information (i.e.
+// rendezvous.NewPersistentStorage). The registrations are kept in memory
+// if no storage is specified
+func WithRendezvousServer(storage ...rendezvous.Storage) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if len(storage) > 1 {
+			return errors.New("only one rendezvous storage can be specified")
+		}
+
+		params.enableRendezvousServer = true
+		if len(storage) == 1 {
+			params.rendevousStorage = storage[0]
+		} else {
+			params.rendevousStorage = rendezvous.NewMemoryStorage()
+		}
+		return nil
+	}
+}
+
+// WithWakuFilter enables the Waku V2 Filter protocol. This WakuNodeOption
+// accepts a list of WakuFilter gossipsub options to setup the protocol
+func WithWakuFilter(fullNode bool) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableFilter = true
+		params.isFilterFullNode = fullNode
+		return nil
+	}
+}
+
+// WithFilterLimits is a WakuNodeOption used to limit the subscriptions a
+// filter full node accepts. Zero values are unlimited
+func WithFilterLimits(limits filter.Limits) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if limits.MaxSubscribers < 0 || limits.MaxContentFilters < 0 || limits.MaxTotalContentFilters < 0 {
+			return errors.New("the filter limits can not be negative")
+		}
+		params.filterLimits = &limits
+		return nil
+	}
+}
+
+// WithWakuStore enables the Waku V2 Store protocol and if the messages should
+// be stored or not in a message provider
+func WithWakuStore(shouldStoreMessages bool, shouldResume bool) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableStore = true
+		params.storeMsgs = shouldStoreMessages
+		params.shouldResume = shouldResume
+		return nil
+	}
+}
+
+// WithResumeTopics is a WakuNodeOption used to set the pubsub topics whose
+// history is resumed when the store starts. By default the topics set
+// WithStoreTopics, or else the topics the node is subscribed to with relay
+// and filter are resumed
+func WithResumeTopics(topics ...string) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if len(topics) == 0 {
+			return errors.New("at least one topic to resume is required")
+		}
+		params.resumeTopics = topics
+		return nil
+	}
+}
+
+// WithStoreTopics is a WakuNodeOption used to only archive the messages of
+// some pubsub topics. By default the messages of all the topics are archived.
+// Unless set WithResumeTopics, the history of these topics is resumed
+func WithStoreTopics(topics ...string) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if len(topics) == 0 {
+			return errors.New("at least one topic to archive is required")
+		}
+		params.storeTopics = topics
+		return nil
+	}
+}
+
+// WithWakuStoreAndRetentionPolicy enables the Waku V2 Store protocol, storing them in an optional message provider
+// applying an specific retention policy
+func WithWakuStoreAndRetentionPolicy(shouldResume bool, maxDuration time.Duration, maxMessages int) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableStore = true
+		params.storeMsgs = true
+		params.shouldResume = shouldResume
+		params.maxDuration = maxDuration
+		params.maxMessages = maxMessages
+		return nil
+	}
+}
+
+// WithStoreMaxSize is a WakuNodeOption that limits the approximate size of
+// the messages archived by the store, and of the messages persisted
+// WithMessageProviderDB. The oldest messages are pruned once it's exceeded
+func WithStoreMaxSize(maxBytes int64) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if maxBytes <= 0 {
+			return errors.New("the maximum size must be positive")
+		}
+		params.maxBytes = maxBytes
+		return nil
+	}
+}
+
+// WithMessageProvider is a WakuNodeOption that sets the MessageProvider
+// used to store and retrieve persisted messages
+func WithMessageProvider(s store.MessageProvider) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.messageProvider = s
+		return nil
+	}
+}
+
+// DefaultStopTimeout is the time Stop waits for the goroutines of the node
+// unless specified WithStopTimeout
+const DefaultStopTimeout = 10 * time.Second
+
+// WithStopTimeout is a WakuNodeOption that sets the time Stop waits for the
+// goroutines of the node to return
+func WithStopTimeout(timeout time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if timeout <= 0 {
+			return errors.New("the stop timeout must be positive")
+		}
+		params.stopTimeout = timeout
+		return nil
+	}
+}
+
+// WithMessageProviderDB is a WakuNodeOption that persists the messages in a
+// table of an existing database, with the retention policy of the store.
+// The database connection isn't closed when the node stops
+func WithMessageProviderDB(db *sql.DB) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if db == nil {
+			return errors.New("a database is required")
+		}
+		params.providerDB = db
+		return nil
+	}
+}
+
+// WithLightPush is a WakuNodeOption that enables the lightpush protocol
+func WithLightPush() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableLightPush = true
+		return nil
+	}
+}
+
+// WithLightPushRateLimit is a WakuNodeOption used to limit the lightpush
+// requests accepted from each peer, with a token bucket refilled at
+// requestsPerSecond and holding up to burst requests
+func WithLightPushRateLimit(requestsPerSecond float64, burst int) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if requestsPerSecond <= 0 || burst <= 0 {
+			return errors.New("the lightpush rate limit must be positive")
+		}
+		params.lightPushRateLimit = &lightpush.RateLimit{RequestsPerSecond: requestsPerSecond, Burst: burst}
+		return nil
+	}
+}
+
+// WithLightPushRateLimitBan is a WakuNodeOption used to ban for banDuration the
+// peers exceeding the lightpush rate limit maxViolations times within a minute.
+// A banDuration of 0 bans them permanently
+func WithLightPushRateLimitBan(maxViolations int, banDuration time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if maxViolations <= 0 {
+			return errors.New("the maximum number of violations must be positive")
+		}
+		params.lightPushMaxViolations = maxViolations
+		params.lightPushBanDuration = banDuration
+		return nil
+	}
+}
+
+// WithPeerSelection is a WakuNodeOption used to set how the filter and
+// lightpush peers, and the store peers history is resumed from, are selected
+// when no peer is specified. Store queries are sent to the peers ranked by
+// latency and error rate instead, see store.WithAutomaticPeerSelection.
+// Whatever the strategy, the peers a store or lightpush request failed with are
+// avoided for utils.DefaultPeerFailureCooldown. Peers are selected randomly by
+// default
+func WithPeerSelection(strategy utils.PeerSelection) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		switch strategy {
+		case utils.RandomPeerSelection, utils.LowestLatencyPeerSelection, utils.RoundRobinPeerSelection:
+		default:
+			return fmt.Errorf("unknown peer selection strategy %d", strategy)
+		}
+		params.peerSelection = strategy
+		return nil
+	}
+}
+
+// WithMessageConfirmations is a WakuNodeOption used to report whether the
+// messages published by the node reached other peers. Once n peers confirm a
+// message, or after the timeout, a MessageConfirmation is sent to the channels
+// obtained with SubscribeMessageConfirmations
+func WithMessageConfirmations(n int, timeout time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if n <= 0 {
+			return errors.New("the number of confirmations must be positive")
+		}
+		if timeout <= 0 {
+			return errors.New("the confirmations timeout must be positive")
+		}
+		params.confirmationsRequired = n
+		params.confirmationsTimeout = timeout
+		return nil
+	}
+}
+
+// WithTopicHealthMonitoring is a WakuNodeOption used to evaluate the health
+// of the subscribed relay topics at each interval. A topic is healthy when it
+// has at least minMeshPeers mesh peers and a store peer is connected. Changes
+// are pushed to the ConnStatus channels and the ones obtained with
+// SubscribeTopicHealth
+func WithTopicHealthMonitoring(minMeshPeers int, interval time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if minMeshPeers <= 0 {
+			return errors.New("the minimum number of mesh peers must be positive")
+		}
+		if interval <= 0 {
+			return errors.New("the topic health interval must be positive")
+		}
+		params.topicHealthMinPeers = minMeshPeers
+		params.topicHealthInterval = interval
+		return nil
+	}
+}
+
+// WithKeepAlive is a WakuNodeOption used to set the interval of time when
+// each peer will be ping to keep the TCP connection alive
+func WithKeepAlive(t time.Duration) WakuNodeOption {
+	return WithKeepAliveParams(t, DefaultKeepAliveTimeout, DefaultMaxPingFailures)
+}
+
+// WithKeepAliveParams is a WakuNodeOption used to set the interval of time when each
+// peer will be ping, how long to wait for the response, and the number of consecutive
+// failed pings allowed. A peer is disconnected when a ping fails maxFailures+1 times
+func WithKeepAliveParams(interval time.Duration, timeout time.Duration, maxFailures int) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if timeout <= 0 {
+			return errors.New("keepalive timeout must be greater than 0")
+		}
+		if maxFailures < 0 {
+			return errors.New("keepalive max failures can not be negative")
+		}
+		params.keepAliveInterval = interval
+		params.keepAliveTimeout = timeout
+		params.keepAliveMaxFailures = maxFailures
+		return nil
+	}
+}
+
+// WithAdaptiveKeepAlive is a WakuNodeOption used to make the interval between
+// pings vary from min to max. Peers that had some activity recently are not
+// pinged, the interval grows while the pings succeed, and it goes back to min
+// when a ping fails or after the device wakes from sleep
+func WithAdaptiveKeepAlive(min time.Duration, max time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if min <= 0 {
+			return errors.New("minimum keepalive interval must be greater than 0")
+		}
+		if max < min {
+			return errors.New("maximum keepalive interval can not be lower than the minimum")
+		}
+		params.keepAliveInterval = min
+		params.keepAliveMaxInterval = max
+		params.adaptiveKeepAlive = true
+		return nil
+	}
+}
+
+// WithAggressiveReconnection is a WakuNodeOption used to make the keepalive
+// ping every peer in the peerstore instead of only the connected ones, so the
+// node tries to reconnect to every peer it has ever seen
+func WithAggressiveReconnection() WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.aggressiveReconnection = true
+		return nil
+	}
+}
+
+// WithPingBackoff is a WakuNodeOption used to set the periods during which the
+// keepalive doesn't ping a peer it gave up on: a connected peer it disconnected
+// or, WithAggressiveReconnection, a peer it could not reconnect to. The next
+// period is used each time the peer fails again, and the last one once they
+// run out. Pinned peers are never backed off
+func WithPingBackoff(periods ...time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if len(periods) == 0 {
+			return errors.New("at least one ping backoff period is required")
+		}
+		for _, p := range periods {
+			if p <= 0 {
+				return errors.New("the ping backoff periods must be positive")
+			}
+		}
+		params.pingBackoff = append([]time.Duration(nil), periods...)
+		return nil
+	}
+}
+
+// WithStaticPeers is a WakuNodeOption used to dial peers each time the node
+// starts. The dials that fail are retried with an exponential backoff. The
+// addresses must include the peer ID
+func WithStaticPeers(addrs ...ma.Multiaddr) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		for _, addr := range addrs {
+			info, err := peer.AddrInfoFromP2pAddr(addr)
+			if err != nil {
+				return fmt.Errorf("invalid static peer %s: %w", addr, err)
+			}
+			params.staticPeers = append(params.staticPeers, *info)
+		}
+		return nil
+	}
+}
+
+// WithConnectionManager is a WakuNodeOption used to set the number of connections the
+// node keeps. When there are more than high connections, the connections of the peers
+// with the lowest value are closed until only low remain. Peers connected for less
+// than gracePeriod, or protected with ProtectPeer, are not pruned
+func WithConnectionManager(low int, high int, gracePeriod time.Duration) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if low < 0 || high < low {
+			return fmt.Errorf("invalid connection watermarks: low %d, high %d", low, high)
+		}
+		params.connMgrLowWater = low
+		params.connMgrHighWater = high
+		params.connMgrGracePeriod = gracePeriod
+		return nil
+	}
+}
+
+// WithBanListStorage is a WakuNodeOption used to persist the list of banned
+// peers, so the bans are not lost when the node is restarted
+func WithBanListStorage(storage BanListStorage) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.banListStorage = storage
+		return nil
+	}
+}
+
+// WithPeerstorePersistence is a WakuNodeOption used to keep the peerstore in
+// a database, so a restarted node knows the addresses, protocols and latency
+// of its previous peers right away, instead of discovering them again. The
+// peerstore is loaded by New, and saved periodically and when the node stops.
+// The peers the node was connected to are kept for a week, and the ones that
+// were only discovered for as long as their addresses are valid. Banned and
+// removed peers are deleted
+func WithPeerstorePersistence(db *sql.DB) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if db == nil {
+			return errors.New("a database is required")
+		}
+		params.peerDB = db
+		return nil
+	}
+}
+
+// WithConnectionGater is a WakuNodeOption used to refuse the inbound and outbound
+// connections of a list of IPs and IP ranges. Inbound connections are dropped
+// before the handshake. The ranges can be changed later with AddDeniedCIDR and
+// RemoveDeniedCIDR
+func WithConnectionGater(denyIPs []net.IP, denyCIDRs []*net.IPNet) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.deniedIPs = denyIPs
+		params.deniedCIDRs = denyCIDRs
+		return nil
+	}
+}
+
+// WithConnectionStatusChannel is a WakuNodeOption used to set a channel where the
+// connection status changes will be pushed to. It's useful to identify when peer
+// connections and disconnections occur. WakuNode.SubscribeConnStatus allows
+// more than one subscriber
+func WithConnectionStatusChannel(connStatus chan ConnStatus) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.connStatusC = connStatus
+		return nil
+	}
+}
+
+// Default options used in the libp2p node, besides the transports
+var defaultLibP2POptions = []libp2p.Option{
+	libp2p.EnableNATService(), // TODO: is this needed?)
+}
+
+// Default options used in the libp2p node
+var DefaultLibP2POptions = append([]libp2p.Option{libp2p.DefaultTransports}, defaultLibP2POptions...)
+
+// clearMuxers removes the stream multiplexers set by the previous options, so
+// the ones set WithMuxers replace them
+func clearMuxers(cfg *libp2p.Config) error {
+	cfg.Muxers = nil
+	return nil
+}
+
+// clearSecurity removes the security transports set by the previous options,
+// so the ones set WithSecurity replace them
+func clearSecurity(cfg *libp2p.Config) error {
+	cfg.SecurityTransports = nil
+	return nil
+}
+
+// ErrIncompatibleOptions is returned by New when options can not be used
+// together, or an option requires another one
+var ErrIncompatibleOptions = errors.New("incompatible options")
+
+// validate checks the invariants between options, which can't be checked
+// by each option on its own
+func (w *WakuNodeParameters) validate() error {
+	incompatible := func(msg string) error {
+		return fmt.Errorf("%w: %s", ErrIncompatibleOptions, msg)
+	}
+
+	if w.enableLightPush && !w.enableRelay {
+		return incompatible("WithLightPush requires WithWakuRelay, since the messages pushed are relayed")
+	}
+	if w.lightPushRateLimit != nil && !w.enableLightPush {
+		return incompatible("WithLightPushRateLimit requires WithLightPush")
+	}
+	if w.lightPushMaxViolations > 0 && w.lightPushRateLimit == nil {
+		return incompatible("WithLightPushRateLimitBan requires WithLightPushRateLimit")
+	}
+
+	if w.shouldResume && !w.enableStore {
+		return incompatible("resuming the history requires WithWakuStore")
+	}
+	if len(w.resumeTopics) > 0 && !w.shouldResume {
+		return incompatible("WithResumeTopics requires resuming the history with WithWakuStore")
+	}
+	if len(w.storeTopics) > 0 && !w.storeMsgs {
+		return incompatible("WithStoreTopics requires storing the messages with WithWakuStore")
+	}
+	if w.maxBytes > 0 && !w.storeMsgs {
+		return incompatible("WithStoreMaxSize requires storing the messages with WithWakuStore")
+	}
+	if w.providerDB != nil && !w.storeMsgs {
+		return incompatible("WithMessageProviderDB requires storing the messages with WithWakuStore")
+	}
+	if w.providerDB != nil && w.messageProvider != nil {
+		return incompatible("WithMessageProvider and WithMessageProviderDB can not be used together")
+	}
+
+	if w.filterLimits != nil && (!w.enableFilter || !w.isFilterFullNode) {
+		return incompatible("WithFilterLimits requires a full node WithWakuFilter")
+	}
+
+	if w.enableDiscV5 {
+		if w.privKey == nil {
+			return incompatible("WithDiscoveryV5 requires WithPrivateKey, since the key signs the ENR")
+		}
+		if len(tcpAddresses(w.multiAddr)) == 0 {
+			return incompatible("WithDiscoveryV5 requires a tcp address, set WithHostAddress")
+		}
+	}
+	if w.discV5Predicate != nil && !w.enableDiscV5 {
+		return incompatible("WithDiscV5Predicate requires WithDiscoveryV5")
+	}
+	if w.enablePeerExchange && !w.enableDiscV5 {
+		return incompatible("WithPeerExchange requires WithDiscoveryV5, since the peers exchanged are found with it")
+	}
+
+	if w.seenMessagesMaxSize > 0 && w.seenMessagesTTL == 0 {
+		return incompatible("WithSeenMessagesMaxSize requires WithSeenMessagesTTL")
+	}
+
+	if len(w.circuitRelays) > 0 && w.enableRelayService {
+		return incompatible("WithCircuitRelayService can not use static relays set WithCircuitRelay")
+	}
+
+	if w.keepAliveInterval < 0 {
+		return incompatible("the keepalive interval set WithKeepAlive can not be negative")
+	}
+	if w.aggressiveReconnection && w.keepAliveInterval == 0 {
+		return incompatible("WithAggressiveReconnection requires WithKeepAlive")
+	}
+
+	if w.enableStaticGroupRelay && w.staticGroupKey != nil && (w.staticGroupIndex >= uint(len(w.staticGroup)) || w.staticGroup[w.staticGroupIndex] != w.staticGroupKey.IDCommitment) {
+		return incompatible("the key set WithStaticGroupRelay is not the one of the member with its index")
+	}
+
+	if w.topicHealthInterval > 0 && !w.enableRelay {
+		return incompatible("WithTopicHealthMonitoring requires WithWakuRelay")
+	}
+	if !w.enableRelay {
+		switch {
+		case w.gossipSubParams != nil:
+			return incompatible("WithGossipSubParams requires WithWakuRelay")
+		case w.peerScore != nil:
+			return incompatible("WithPeerScoreParams requires WithWakuRelay")
+		case w.seenMessagesTTL > 0:
+			return incompatible("WithSeenMessagesTTL requires WithWakuRelay")
+		case len(w.protectedTopics) > 0:
+			return incompatible("WithProtectedTopic requires WithWakuRelay")
+		case w.enableStaticGroupRelay:
+			return incompatible("WithStaticGroupRelay requires WithWakuRelay")
+		case w.enableRendezvous:
+			return incompatible("WithRendezvous requires WithWakuRelay, since the peers are discovered for its topics")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/multiformats/go-multiaddr"
+	rendezvous "github.com/status-im/go-waku-rendezvous"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/persistence"
+	"github.com/status-im/go-waku/waku/v2/discv5"
+	"github.com/status-im/go-waku/waku/v2/protocol/filter"
+	"github.com/status-im/go-waku/waku/v2/protocol/lightpush"
+	"github.com/status-im/go-waku/waku/v2/protocol/staticgroup"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWakuOptions(t *testing.T) {
+	connStatusChan := make(chan ConnStatus, 100)
+
+	key, err := tests.RandomHex(32)
+	require.NoError(t, err)
+
+	prvKey, err := crypto.HexToECDSA(key)
+	require.NoError(t, err)
+
+	hostAddr, _ := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+
+	addr, err := multiaddr.NewMultiaddr("/ip4/0.0.0.0/tcp/4000/ws")
+	require.NoError(t, err)
+
+	advertiseAddr, _ := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+
+	options := []WakuNodeOption{
+		WithHostAddress(hostAddr),
+		WithAdvertiseAddress(advertiseAddr, false, 4000),
+		WithMultiaddress([]multiaddr.Multiaddr{addr}),
+		WithPrivateKey(prvKey),
+		WithLibP2POptions(),
+		WithWakuRelay(),
+		WithRendezvous(),
+		WithRendezvousServer(rendezvous.NewStorage(nil)),
+		WithWakuFilter(true),
+		WithDiscoveryV5(123, nil, false),
+		WithWakuStore(true, true),
+		WithWakuStoreAndRetentionPolicy(true, time.Hour, 100),
+		WithMessageProvider(nil),
+		WithLightPush(),
+		WithKeepAlive(time.Hour),
+		WithConnectionStatusChannel(connStatusChan),
+	}
+
+	params := new(WakuNodeParameters)
+
+	for _, opt := range options {
+		require.NoError(t, opt(params))
+	}
+
+	require.NotNil(t, params.multiAddr)
+	require.NotNil(t, params.privKey)
+	require.NotNil(t, params.connStatusC)
+}
+
+func TestValidateOptions(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	member, err := staticgroup.NewMembershipKeyPair()
+	require.NoError(t, err)
+	group := []staticgroup.IDCommitment{{}, member.IDCommitment}
+	tcpAddr := multiaddr.StringCast("/ip4/127.0.0.1/tcp/60000")
+	wsAddr := multiaddr.StringCast("/ip4/127.0.0.1/tcp/60001/ws")
+
+	testCases := []struct {
+		name   string
+		params WakuNodeParameters
+		valid  bool
+	}{
+		{"defaults", WakuNodeParameters{}, true},
+		{"lightpush with relay", WakuNodeParameters{enableLightPush: true, enableRelay: true}, true},
+		{"lightpush without relay", WakuNodeParameters{enableLightPush: true}, false},
+		{"lightpush rate limit without lightpush", WakuNodeParameters{enableRelay: true, lightPushRateLimit: &lightpush.RateLimit{}}, false},
+		{"lightpush ban without rate limit", WakuNodeParameters{enableRelay: true, enableLightPush: true, lightPushMaxViolations: 1}, false},
+		{"resume without store", WakuNodeParameters{shouldResume: true}, false},
+		{"resume topics without resume", WakuNodeParameters{enableStore: true, resumeTopics: []string{"t"}}, false},
+		{"store topics without storing", WakuNodeParameters{enableStore: true, storeTopics: []string{"t"}}, false},
+		{"store max size without storing", WakuNodeParameters{enableStore: true, maxBytes: 1}, false},
+		{"provider db without storing", WakuNodeParameters{enableStore: true, providerDB: &sql.DB{}}, false},
+		{"provider db and provider", WakuNodeParameters{enableStore: true, storeMsgs: true, providerDB: &sql.DB{}, messageProvider: &persistence.DBStore{}}, false},
+		{"filter limits on a light node", WakuNodeParameters{enableFilter: true, filterLimits: &filter.Limits{}}, false},
+		{"filter limits on a full node", WakuNodeParameters{enableFilter: true, isFilterFullNode: true, filterLimits: &filter.Limits{}}, true},
+		{"discv5", WakuNodeParameters{enableDiscV5: true, privKey: key, multiAddr: []multiaddr.Multiaddr{tcpAddr}}, true},
+		{"discv5 without key", WakuNodeParameters{enableDiscV5: true, multiAddr: []multiaddr.Multiaddr{tcpAddr}}, false},
+		{"discv5 without tcp address", WakuNodeParameters{enableDiscV5: true, privKey: key, multiAddr: []multiaddr.Multiaddr{wsAddr}}, false},
+		{"discv5 predicate without discv5", WakuNodeParameters{discV5Predicate: discv5.CapabilityFilter(true, false, false, false)}, false},
+		{"peer exchange without discv5", WakuNodeParameters{enablePeerExchange: true}, false},
+		{"seen messages max size without ttl", WakuNodeParameters{enableRelay: true, seenMessagesMaxSize: 1}, false},
+		{"static relays with relay service", WakuNodeParameters{enableRelayService: true, circuitRelays: []peer.AddrInfo{{}}}, false},
+		{"negative keepalive", WakuNodeParameters{keepAliveInterval: -1}, false},
+		{"aggressive reconnection without keepalive", WakuNodeParameters{aggressiveReconnection: true}, false},
+		{"topic health without relay", WakuNodeParameters{topicHealthInterval: time.Second}, false},
+		{"gossipsub params without relay", WakuNodeParameters{gossipSubParams: &pubsub.GossipSubParams{}}, false},
+		{"peer score without relay", WakuNodeParameters{peerScore: &pubsub.PeerScoreParams{}}, false},
+		{"seen messages ttl without relay", WakuNodeParameters{seenMessagesTTL: time.Second}, false},
+		{"protected topic without relay", WakuNodeParameters{protectedTopics: map[string]*ecdsa.PrivateKey{"t": key}}, false},
+		{"rendezvous without relay", WakuNodeParameters{enableRendezvous: true}, false},
+		{"rendezvous with relay", WakuNodeParameters{enableRendezvous: true, enableRelay: true}, true},
+		{"static group relay", WakuNodeParameters{enableRelay: true, enableStaticGroupRelay: true, staticGroupIndex: 1, staticGroup: group, staticGroupKey: member}, true},
+		{"static group relay without key", WakuNodeParameters{enableRelay: true, enableStaticGroupRelay: true, staticGroup: group}, true},
+		{"static group relay without relay", WakuNodeParameters{enableStaticGroupRelay: true, staticGroup: group}, false},
+		{"static group key of another member", WakuNodeParameters{enableRelay: true, enableStaticGroupRelay: true, staticGroup: group, staticGroupKey: member}, false},
+		{"static group index out of the group", WakuNodeParameters{enableRelay: true, enableStaticGroupRelay: true, staticGroupIndex: 2, staticGroup: group, staticGroupKey: member}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.params.validate()
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrIncompatibleOptions)
+			}
+		})
+	}
+
+	// New fails before creating the host
+	_, err = New(context.Background(), WithPeerExchange())
+	require.ErrorIs(t, err, ErrIncompatibleOptions)
+}
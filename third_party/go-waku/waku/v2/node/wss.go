@@ -0,0 +1,241 @@
+package node
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/transport"
+	tptu "github.com/libp2p/go-libp2p-transport-upgrader"
+	websocket "github.com/libp2p/go-ws-transport"
+	ma "github.com/multiformats/go-multiaddr"
+	mafmt "github.com/multiformats/go-multiaddr-fmt"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+var ErrSecureWebsocketsDisabled = errors.New("secure websockets are not enabled")
+
+var wssDialMatcher = mafmt.And(mafmt.Or(mafmt.IP, mafmt.DNS), mafmt.Base(ma.P_TCP), mafmt.Base(ma.P_WSS))
+
+// wssDialer dials the secure websocket connections, verifying the certificate
+// of the remote node with the system roots
+var wssDialer = ws.DefaultDialer
+
+var wssUpgrader = ws.Upgrader{
+	// Allow requests from *all* origins.
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// tlsCertificate holds the certificate served by the secure websocket listener.
+// It can be reloaded from disk while the node is running
+type tlsCertificate struct {
+	sync.RWMutex
+	certPath string
+	keyPath  string
+	cert     *tls.Certificate
+}
+
+func newTLSCertificate(certPath string, keyPath string) (*tlsCertificate, error) {
+	c := &tlsCertificate{certPath: certPath, keyPath: keyPath}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *tlsCertificate) reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+	if err != nil {
+		return err
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate %s expired on %s", c.certPath, leaf.NotAfter)
+	}
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("certificate %s is not valid before %s", c.certPath, leaf.NotBefore)
+	}
+	cert.Leaf = leaf
+
+	c.Lock()
+	defer c.Unlock()
+	c.cert = &cert
+	return nil
+}
+
+func (c *tlsCertificate) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.cert, nil
+}
+
+// secureWebsocketTransport is a libp2p transport for websocket connections over TLS (/wss).
+// libp2p connections are still secured and multiplexed by the upgrader, TLS is only
+// required so browsers accept the connection
+type secureWebsocketTransport struct {
+	upgrader *tptu.Upgrader
+	cert     *tlsCertificate
+}
+
+func newSecureWebsocketTransport(cert *tlsCertificate) func(u *tptu.Upgrader) *secureWebsocketTransport {
+	return func(u *tptu.Upgrader) *secureWebsocketTransport {
+		return &secureWebsocketTransport{upgrader: u, cert: cert}
+	}
+}
+
+// ReloadTLS loads again the certificate and key used by the secure websocket
+// listener, so a renewed certificate is served without restarting the node
+func (w *WakuNode) ReloadTLS() error {
+	if w.opts.tlsCert == nil {
+		return ErrSecureWebsocketsDisabled
+	}
+	return w.opts.tlsCert.reload()
+}
+
+func (t *secureWebsocketTransport) CanDial(a ma.Multiaddr) bool {
+	return wssDialMatcher.Matches(a)
+}
+
+func (t *secureWebsocketTransport) Protocols() []int {
+	return []int{ma.P_WSS}
+}
+
+func (t *secureWebsocketTransport) Proxy() bool {
+	return false
+}
+
+func (t *secureWebsocketTransport) Dial(ctx context.Context, raddr ma.Multiaddr, p peer.ID) (transport.CapableConn, error) {
+	_, host, err := manet.DialArgs(raddr)
+	if err != nil {
+		return nil, err
+	}
+
+	wscon, _, err := wssDialer.DialContext(ctx, "wss://"+host, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	macon, err := manet.WrapNetConn(websocket.NewConn(wscon))
+	if err != nil {
+		wscon.Close()
+		return nil, err
+	}
+
+	return t.upgrader.UpgradeOutbound(ctx, t, &secureWebsocketConn{macon}, p)
+}
+
+func (t *secureWebsocketTransport) Listen(a ma.Multiaddr) (transport.Listener, error) {
+	lnet, lnaddr, err := manet.DialArgs(a)
+	if err != nil {
+		return nil, err
+	}
+
+	nl, err := net.Listen(lnet, lnaddr)
+	if err != nil {
+		return nil, err
+	}
+
+	laddr, err := manet.FromNetAddr(nl.Addr())
+	if err != nil {
+		nl.Close()
+		return nil, err
+	}
+
+	wssMa, err := ma.NewMultiaddr("/wss")
+	if err != nil {
+		nl.Close()
+		return nil, err
+	}
+
+	l := &secureWebsocketListener{
+		Listener: tls.NewListener(nl, &tls.Config{GetCertificate: t.cert.getCertificate}),
+		laddr:    laddr.Encapsulate(wssMa),
+		incoming: make(chan *websocket.Conn),
+		closed:   make(chan struct{}),
+	}
+
+	go l.serve()
+
+	return t.upgrader.UpgradeListener(t, l), nil
+}
+
+type secureWebsocketListener struct {
+	net.Listener
+
+	laddr ma.Multiaddr
+
+	closed   chan struct{}
+	incoming chan *websocket.Conn
+}
+
+func (l *secureWebsocketListener) serve() {
+	defer close(l.closed)
+	_ = http.Serve(l.Listener, l)
+}
+
+func (l *secureWebsocketListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, err := wssUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader writes a response for us.
+		return
+	}
+
+	select {
+	case l.incoming <- websocket.NewConn(c):
+	case <-l.closed:
+		c.Close()
+	}
+}
+
+func (l *secureWebsocketListener) Accept() (manet.Conn, error) {
+	select {
+	case c := <-l.incoming:
+		mnc, err := manet.WrapNetConn(c)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		return &secureWebsocketConn{mnc}, nil
+	case <-l.closed:
+		return nil, fmt.Errorf("listener is closed")
+	}
+}
+
+func (l *secureWebsocketListener) Multiaddr() ma.Multiaddr {
+	return l.laddr
+}
+
+// secureWebsocketConn reports the addresses of a websocket connection over TLS
+// with /wss instead of /ws, so the peerstore doesn't keep a /ws address for a
+// port only accepting TLS
+type secureWebsocketConn struct {
+	manet.Conn
+}
+
+func (c *secureWebsocketConn) LocalMultiaddr() ma.Multiaddr {
+	return toSecureWebsocketAddr(c.Conn.LocalMultiaddr())
+}
+
+func (c *secureWebsocketConn) RemoteMultiaddr() ma.Multiaddr {
+	return toSecureWebsocketAddr(c.Conn.RemoteMultiaddr())
+}
+
+func toSecureWebsocketAddr(a ma.Multiaddr) ma.Multiaddr {
+	return a.Decapsulate(ma.StringCast("/ws")).Encapsulate(ma.StringCast("/wss"))
+}
@@ -0,0 +1,160 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and its
+// key to certPath and keyPath
+func writeTestCertificate(t *testing.T, certPath string, keyPath string, serial int64, notAfter time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		NotBefore:             notAfter.Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return cert
+}
+
+// trustTestCertificate makes the secure websocket transport accept the
+// certificate while the test runs
+func trustTestCertificate(t *testing.T, cert *x509.Certificate) {
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	dialer := *ws.DefaultDialer
+	dialer.TLSClientConfig = &tls.Config{RootCAs: pool}
+	wssDialer = &dialer
+	t.Cleanup(func() { wssDialer = ws.DefaultDialer })
+}
+
+// listenAddress returns the listen address of the node using the protocol,
+// e.g. ma.P_WS
+func listenAddress(t *testing.T, w *WakuNode, code int) ma.Multiaddr {
+	for _, addr := range w.ListenAddresses() {
+		if _, err := addr.ValueForProtocol(code); err == nil {
+			return addr
+		}
+	}
+	require.Fail(t, "no listen address", ma.ProtocolWithCode(code).Name)
+	return nil
+}
+
+func TestWebsocketsRelay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	trustTestCertificate(t, writeTestCertificate(t, certPath, keyPath, 1, time.Now().Add(time.Hour)))
+
+	localhost := net.ParseIP("127.0.0.1")
+	listener := newKeepAliveNode(t, WithWebsockets(localhost, 0), WithSecureWebsockets(localhost, 0, certPath, keyPath))
+
+	sub, err := listener.Relay().Subscribe(ctx)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	for _, tc := range []struct {
+		name string
+		code int
+		opts []WakuNodeOption
+	}{
+		{name: "ws", code: ma.P_WS},
+		// The secure websocket transport is only available with the option
+		{name: "wss", code: ma.P_WSS, opts: []WakuNodeOption{WithSecureWebsockets(localhost, 0, certPath, keyPath)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newKeepAliveNode(t, tc.opts...)
+
+			addr := listenAddress(t, listener, tc.code)
+			require.NoError(t, w.DialPeer(ctx, addr.String()))
+			require.Eventually(t, func() bool {
+				return len(w.Relay().MeshPeers(relay.DefaultWakuTopic)) > 0
+			}, 5*time.Second, 10*time.Millisecond)
+
+			conns := w.Host().Network().ConnsToPeer(listener.Host().ID())
+			require.Len(t, conns, 1)
+			_, err := conns[0].RemoteMultiaddr().ValueForProtocol(tc.code)
+			require.NoError(t, err)
+			conns = listener.Host().Network().ConnsToPeer(w.Host().ID())
+			require.Len(t, conns, 1)
+			_, err = conns[0].LocalMultiaddr().ValueForProtocol(tc.code)
+			require.NoError(t, err)
+
+			_, err = w.Relay().Publish(ctx, &pb.WakuMessage{Payload: []byte(tc.name), ContentTopic: "/test/1/websockets/proto"})
+			require.NoError(t, err)
+			receivePayload(t, sub, tc.name)
+		})
+	}
+}
+
+// servedCertificate returns the certificate served by the secure websocket
+// listener of the node in a new TLS handshake
+func servedCertificate(t *testing.T, w *WakuNode) *x509.Certificate {
+	port, err := listenAddress(t, w, ma.P_WSS).ValueForProtocol(ma.P_TCP)
+	require.NoError(t, err)
+
+	conn, err := tls.Dial("tcp", net.JoinHostPort("127.0.0.1", port), &tls.Config{InsecureSkipVerify: true}) // nolint: gosec
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0]
+}
+
+func TestReloadTLS(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCertificate(t, certPath, keyPath, 1, time.Now().Add(time.Hour))
+
+	localhost := net.ParseIP("127.0.0.1")
+	w := newKeepAliveNode(t, WithSecureWebsockets(localhost, 0, certPath, keyPath))
+	require.Equal(t, int64(1), servedCertificate(t, w).SerialNumber.Int64())
+
+	// The renewed certificate is only served once reloaded
+	writeTestCertificate(t, certPath, keyPath, 2, time.Now().Add(2*time.Hour))
+	require.Equal(t, int64(1), servedCertificate(t, w).SerialNumber.Int64())
+	require.NoError(t, w.ReloadTLS())
+	require.Equal(t, int64(2), servedCertificate(t, w).SerialNumber.Int64())
+
+	// An expired certificate isn't loaded, the previous one is still served
+	writeTestCertificate(t, certPath, keyPath, 3, time.Now().Add(-time.Hour))
+	require.Error(t, w.ReloadTLS())
+	require.Equal(t, int64(2), servedCertificate(t, w).SerialNumber.Int64())
+
+	_, err := New(context.Background(), WithSecureWebsockets(localhost, 0, certPath, keyPath))
+	require.Error(t, err)
+
+	require.Equal(t, ErrSecureWebsocketsDisabled, newKeepAliveNode(t).ReloadTLS())
+}
@@ -0,0 +1,64 @@
+package protocol
+
+import (
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// Envelope contains information about the pubsub topic of a WakuMessage
+// and a hash used to identify a message based on the bytes of a WakuMessage
+// protobuffer
+type Envelope struct {
+	msg          *pb.WakuMessage
+	pubsubTopic  string
+	size         int
+	hash         []byte
+	receivedFrom peer.ID
+}
+
+// NewEnvelope creates a new Envelope that contains a WakuMessage
+// It's used as a way to know to which Pubsub topic belongs a WakuMessage
+// as well as generating a hash based on the bytes that compose the message
+func NewEnvelope(msg *pb.WakuMessage, pubSubTopic string) *Envelope {
+	data, _ := msg.Marshal()
+	return &Envelope{
+		msg:         msg,
+		pubsubTopic: pubSubTopic,
+		size:        len(data),
+		hash:        pb.Hash(data),
+	}
+}
+
+// NewEnvelopeFromPeer creates a new Envelope for a WakuMessage that was
+// received from a remote peer
+func NewEnvelopeFromPeer(msg *pb.WakuMessage, pubSubTopic string, receivedFrom peer.ID) *Envelope {
+	envelope := NewEnvelope(msg, pubSubTopic)
+	envelope.receivedFrom = receivedFrom
+	return envelope
+}
+
+// Message returns the WakuMessage associated to an Envelope
+func (e *Envelope) Message() *pb.WakuMessage {
+	return e.msg
+}
+
+// PubsubTopic returns the topic on which a WakuMessage was received
+func (e *Envelope) PubsubTopic() string {
+	return e.pubsubTopic
+}
+
+// Hash returns a 32 byte hash calculated from the WakuMessage bytes
+func (e *Envelope) Hash() []byte {
+	return e.hash
+}
+
+// Size returns the byte size of the WakuMessage
+func (e *Envelope) Size() int {
+	return e.size
+}
+
+// ReceivedFrom returns the peer that sent the WakuMessage to this node.
+// It's empty if the origin of the message is unknown
+func (e *Envelope) ReceivedFrom() peer.ID {
+	return e.receivedFrom
+}
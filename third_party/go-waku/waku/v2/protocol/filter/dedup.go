@@ -0,0 +1,47 @@
+package filter
+
+import (
+	"sync"
+	"time"
+)
+
+// Time a pushed message is remembered, to drop the copies pushed by the other
+// full nodes of a subscription
+const dedupTTL = 2 * time.Minute
+
+type seenMessages struct {
+	sync.Mutex
+	hashes    map[string]time.Time
+	lastPrune time.Time
+}
+
+func newSeenMessages() *seenMessages {
+	return &seenMessages{
+		hashes:    make(map[string]time.Time),
+		lastPrune: time.Now(),
+	}
+}
+
+// isNew returns whether a message wasn't pushed within the TTL, and
+// remembers it
+func (s *seenMessages) isNew(hash []byte) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPrune) > dedupTTL {
+		s.lastPrune = now
+		for h, t := range s.hashes {
+			if now.Sub(t) > dedupTTL {
+				delete(s.hashes, h)
+			}
+		}
+	}
+
+	if t, ok := s.hashes[string(hash)]; ok && now.Sub(t) <= dedupTTL {
+		return false
+	}
+
+	s.hashes[string(hash)] = now
+	return true
+}
@@ -0,0 +1,256 @@
+package filter
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+var (
+	ErrTooManySubscribers         = errors.New("too many filter subscribers")
+	ErrTooManyContentFilters      = errors.New("too many content filters for the subscriber")
+	ErrTooManyTotalContentFilters = errors.New("too many content filters")
+)
+
+// Limits of the subscriptions a full node accepts. Zero values are unlimited
+type Limits struct {
+	// Number of peers with subscriptions
+	MaxSubscribers int
+	// Number of content filters of a peer, in all its subscriptions
+	MaxContentFilters int
+	// Number of content filters of all the peers
+	MaxTotalContentFilters int
+}
+
+type Subscriber struct {
+	peer      peer.ID
+	requestId string
+	topic     string
+	// Content topics of the subscription. Messages are pushed once even if
+	// several match
+	contentTopics map[string]struct{}
+}
+
+type subscriberKey struct {
+	peer      peer.ID
+	requestId string
+}
+
+// SubscriptionCounts are the subscriptions served by a full node
+type SubscriptionCounts struct {
+	Subscribers    int
+	Subscriptions  int
+	ContentFilters int
+}
+
+// Subscribers are the subscriptions of the light nodes to a full node. They
+// are indexed by content topic, so matching a message only costs as much as
+// the subscriptions it matches
+type Subscribers struct {
+	sync.RWMutex
+	limits         Limits
+	subscribers    map[subscriberKey]*Subscriber
+	byContentTopic map[string]map[subscriberKey]struct{}
+	// Number of content filters of each peer
+	peerFilters  map[peer.ID]int
+	totalFilters int
+}
+
+func NewSubscribers() *Subscribers {
+	return NewSubscribersWithLimits(Limits{})
+}
+
+func NewSubscribersWithLimits(limits Limits) *Subscribers {
+	return &Subscribers{
+		limits:         limits,
+		subscribers:    make(map[subscriberKey]*Subscriber),
+		byContentTopic: make(map[string]map[subscriberKey]struct{}),
+		peerFilters:    make(map[peer.ID]int),
+	}
+}
+
+func (sub *Subscribers) SetLimits(limits Limits) {
+	sub.Lock()
+	defer sub.Unlock()
+
+	sub.limits = limits
+}
+
+// Append adds the content topics of a request to the subscription of a
+// peer, and returns the number of subscriptions. Nothing is added when
+// any of the limits would be exceeded
+func (sub *Subscribers) Append(peerID peer.ID, requestId string, filter *pb.FilterRequest) (int, error) {
+	sub.Lock()
+	defer sub.Unlock()
+
+	key := subscriberKey{peer: peerID, requestId: requestId}
+	s, ok := sub.subscribers[key]
+
+	var newTopics []string
+	for _, cf := range filter.ContentFilters {
+		if ok {
+			if _, exists := s.contentTopics[cf.ContentTopic]; exists {
+				continue
+			}
+		}
+		newTopics = append(newTopics, cf.ContentTopic)
+	}
+	newTopics = unique(newTopics)
+	if len(newTopics) == 0 {
+		return len(sub.subscribers), nil
+	}
+
+	peerFilters, isSubscriber := sub.peerFilters[peerID]
+	if !isSubscriber && sub.limits.MaxSubscribers > 0 && len(sub.peerFilters) >= sub.limits.MaxSubscribers {
+		return len(sub.subscribers), ErrTooManySubscribers
+	}
+
+	if sub.limits.MaxContentFilters > 0 && peerFilters+len(newTopics) > sub.limits.MaxContentFilters {
+		return len(sub.subscribers), ErrTooManyContentFilters
+	}
+
+	if sub.limits.MaxTotalContentFilters > 0 && sub.totalFilters+len(newTopics) > sub.limits.MaxTotalContentFilters {
+		return len(sub.subscribers), ErrTooManyTotalContentFilters
+	}
+
+	if !ok {
+		s = &Subscriber{peer: peerID, requestId: requestId, topic: filter.Topic, contentTopics: make(map[string]struct{})}
+		sub.subscribers[key] = s
+	}
+
+	for _, contentTopic := range newTopics {
+		s.contentTopics[contentTopic] = struct{}{}
+
+		if sub.byContentTopic[contentTopic] == nil {
+			sub.byContentTopic[contentTopic] = make(map[subscriberKey]struct{})
+		}
+		sub.byContentTopic[contentTopic][key] = struct{}{}
+	}
+
+	sub.peerFilters[peerID] += len(newTopics)
+	sub.totalFilters += len(newTopics)
+
+	return len(sub.subscribers), nil
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	var result []string
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func (sub *Subscribers) Items() <-chan Subscriber {
+	c := make(chan Subscriber)
+
+	f := func() {
+		sub.RLock()
+		defer sub.RUnlock()
+		for _, value := range sub.subscribers {
+			c <- *value
+		}
+		close(c)
+	}
+	go f()
+
+	return c
+}
+
+// Match returns the subscribers to the messages of a content topic published
+// on a pubsub topic
+func (sub *Subscribers) Match(topic string, contentTopic string) []Subscriber {
+	sub.RLock()
+	defer sub.RUnlock()
+
+	var result []Subscriber
+	for key := range sub.byContentTopic[contentTopic] {
+		s := sub.subscribers[key]
+		if s.topic != "" && s.topic != topic {
+			continue
+		}
+		result = append(result, *s)
+	}
+	return result
+}
+
+func (sub *Subscribers) Length() int {
+	sub.RLock()
+	defer sub.RUnlock()
+
+	return len(sub.subscribers)
+}
+
+func (sub *Subscribers) Counts() SubscriptionCounts {
+	sub.RLock()
+	defer sub.RUnlock()
+
+	return SubscriptionCounts{
+		Subscribers:    len(sub.peerFilters),
+		Subscriptions:  len(sub.subscribers),
+		ContentFilters: sub.totalFilters,
+	}
+}
+
+// RemoveContentFilters removes content topics from the subscriptions of a
+// peer to a pubsub topic, or to any topic if it's empty. Subscriptions
+// without content topics left are removed
+func (sub *Subscribers) RemoveContentFilters(peerID peer.ID, topic string, contentFilters []*pb.FilterRequest_ContentFilter) {
+	sub.Lock()
+	defer sub.Unlock()
+
+	for key, s := range sub.subscribers {
+		if s.peer != peerID || (topic != "" && s.topic != topic) {
+			continue
+		}
+
+		for _, cf := range contentFilters {
+			if _, ok := s.contentTopics[cf.ContentTopic]; !ok {
+				continue
+			}
+
+			delete(s.contentTopics, cf.ContentTopic)
+			delete(sub.byContentTopic[cf.ContentTopic], key)
+			if len(sub.byContentTopic[cf.ContentTopic]) == 0 {
+				delete(sub.byContentTopic, cf.ContentTopic)
+			}
+
+			sub.peerFilters[peerID]--
+			sub.totalFilters--
+		}
+
+		// make sure we delete the subscriber
+		// if no more content filters left
+		if len(s.contentTopics) == 0 {
+			delete(sub.subscribers, key)
+		}
+	}
+
+	if count, ok := sub.peerFilters[peerID]; ok && count == 0 {
+		delete(sub.peerFilters, peerID)
+	}
+}
+
+// RemoveAll removes the subscriptions of all the peers, and returns them
+func (sub *Subscribers) RemoveAll() []Subscriber {
+	sub.Lock()
+	defer sub.Unlock()
+
+	var result []Subscriber
+	for _, s := range sub.subscribers {
+		result = append(result, *s)
+	}
+
+	sub.subscribers = make(map[subscriberKey]*Subscriber)
+	sub.byContentTopic = make(map[string]map[subscriberKey]struct{})
+	sub.peerFilters = make(map[peer.ID]int)
+	sub.totalFilters = 0
+
+	return result
+}
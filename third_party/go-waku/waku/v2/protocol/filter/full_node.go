@@ -0,0 +1,109 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-msgio/protoio"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"go.opencensus.io/stats"
+)
+
+// Info of the response pushed to the light nodes whose subscriptions are
+// dropped because the node stopped being a full node
+const fullNodeDisabledInfo = "filter full node disabled"
+
+// IsFullNode returns whether the node serves filter subscriptions to light nodes
+func (wf *WakuFilter) IsFullNode() bool {
+	wf.modeMutex.RLock()
+	defer wf.modeMutex.RUnlock()
+	return wf.isFullNode
+}
+
+// SetFullNode switches the node between serving filter subscriptions to light
+// nodes and only being a client. Once disabled, subscription requests are
+// rejected and the light nodes already subscribed are sent an error response
+// for each of their subscriptions, so they subscribe to another full node.
+// It returns ctx.Err() if ctx is done before they are all notified.
+//
+// The stream handler stays registered, and so the protocol keeps being
+// announced with identify, since the messages pushed by the full nodes the
+// node is subscribed to arrive on the same protocol
+func (wf *WakuFilter) SetFullNode(ctx context.Context, enabled bool) error {
+	wf.modeMutex.Lock()
+	if wf.isFullNode == enabled {
+		wf.modeMutex.Unlock()
+		return nil
+	}
+	wf.isFullNode = enabled
+
+	var dropped []Subscriber
+	if !enabled {
+		dropped = wf.subscribers.RemoveAll()
+	}
+	wf.modeMutex.Unlock()
+
+	if enabled {
+		log.Info("Filter full node enabled")
+		return nil
+	}
+
+	log.Info(fmt.Sprintf("Filter full node disabled, dropping %d subscriptions", len(dropped)))
+	stats.Record(wf.ctx, metrics.FilterSubscriptions.M(0))
+
+	return wf.notifyDropped(ctx, dropped)
+}
+
+// notifyDropped sends an error response to the light nodes for each of
+// their subscriptions which were dropped
+func (wf *WakuFilter) notifyDropped(ctx context.Context, dropped []Subscriber) error {
+	var wg sync.WaitGroup
+	for _, s := range dropped {
+		wg.Add(1)
+		go func(s Subscriber) {
+			defer wg.Done()
+
+			conn, err := wf.h.NewStream(ctx, s.peer, FilterID_v20beta1)
+			if err != nil {
+				log.Info(fmt.Sprintf("could not notify %s of its dropped subscription: %s", s.peer, err))
+				return
+			}
+			defer conn.Close()
+
+			response := &pb.FilterResponse{IsSuccess: false, Info: fullNodeDisabledInfo}
+			writer := protoio.NewDelimitedWriter(conn)
+			if err := writer.WriteMsg(&pb.FilterRPC{RequestId: s.requestId, Response: response}); err != nil {
+				log.Info(fmt.Sprintf("could not notify %s of its dropped subscription: %s", s.peer, err))
+			}
+		}(s)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// subscriptionDropped degrades a subscription the full node dropped, so
+// another full node is subscribed to right away
+func (wf *WakuFilter) subscriptionDropped(filterID string, peerID peer.ID, info string) {
+	f, ok := wf.filters.Get(filterID)
+	if !ok || !f.HasPeer(peerID) {
+		return
+	}
+
+	log.Info(fmt.Sprintf("filter subscription %s dropped by %s: %s", filterID, peerID, info))
+	wf.peerLost(filterID, peerID, false)
+	wf.unprotectPeer(peerID)
+}
@@ -0,0 +1,135 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+)
+
+// DefaultHealthCheckInterval is the interval at which the full nodes serving
+// the subscriptions are pinged
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// Time to wait for the ping response of a full node
+const healthCheckTimeout = 10 * time.Second
+
+type peersHealth struct {
+	sync.Mutex
+	interval  time.Duration
+	unhealthy map[peer.ID]struct{}
+}
+
+func newPeersHealth() *peersHealth {
+	return &peersHealth{
+		interval:  DefaultHealthCheckInterval,
+		unhealthy: make(map[peer.ID]struct{}),
+	}
+}
+
+func (h *peersHealth) checkInterval() time.Duration {
+	h.Lock()
+	defer h.Unlock()
+	return h.interval
+}
+
+func (h *peersHealth) setHealthy(p peer.ID, healthy bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	if healthy {
+		delete(h.unhealthy, p)
+	} else {
+		h.unhealthy[p] = struct{}{}
+	}
+}
+
+// forget the health of a disconnected peer, which is checked again if it
+// reconnects
+func (h *peersHealth) forget(p peer.ID) {
+	h.setHealthy(p, true)
+}
+
+func (h *peersHealth) unhealthyPeers() []peer.ID {
+	h.Lock()
+	defer h.Unlock()
+
+	var result []peer.ID
+	for p := range h.unhealthy {
+		result = append(result, p)
+	}
+	return result
+}
+
+// SetHealthCheckInterval sets the interval at which the full nodes serving
+// the subscriptions are pinged. Full nodes not responding are replaced by
+// other connected full nodes
+func (wf *WakuFilter) SetHealthCheckInterval(interval time.Duration) {
+	wf.health.Lock()
+	defer wf.health.Unlock()
+
+	wf.health.interval = interval
+}
+
+func (wf *WakuFilter) checkPeersHealth() {
+	defer wf.wg.Done()
+
+	for {
+		select {
+		case <-wf.ctx.Done():
+			return
+		case <-time.After(wf.health.checkInterval()):
+		}
+
+		servingPeers := make(map[peer.ID]struct{})
+		for item := range wf.filters.Items() {
+			for _, p := range item.Value.Peers {
+				servingPeers[p] = struct{}{}
+			}
+		}
+
+		var wg sync.WaitGroup
+		for p := range servingPeers {
+			wg.Add(1)
+			go func(p peer.ID) {
+				defer wg.Done()
+				wf.checkPeerHealth(p)
+			}(p)
+		}
+		wg.Wait()
+	}
+}
+
+func (wf *WakuFilter) checkPeerHealth(p peer.ID) {
+	ctx, cancel := context.WithTimeout(wf.ctx, healthCheckTimeout)
+	defer cancel()
+
+	var err error
+	select {
+	case res, ok := <-ping.Ping(ctx, wf.h, p):
+		if !ok {
+			err = ctx.Err()
+		} else {
+			err = res.Error
+		}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if wf.ctx.Err() != nil {
+		return
+	}
+
+	wf.health.setHealthy(p, err == nil)
+	if err == nil {
+		return
+	}
+
+	log.Info(fmt.Sprintf("filter peer %s failed the health check: %s", p, err))
+	for _, filterID := range wf.filterIDsOfPeer(p) {
+		wf.peerLost(filterID, p, false)
+	}
+}
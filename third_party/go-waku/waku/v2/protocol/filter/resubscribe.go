@@ -0,0 +1,374 @@
+package filter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// DefaultResubscribeTimeout is the time waited for the full node of a
+// subscription to reconnect before subscribing to another full node
+const DefaultResubscribeTimeout = 30 * time.Second
+
+// SubscriptionState is the state of a subscription to a full node
+type SubscriptionState int
+
+const (
+	// SubscriptionActive subscriptions were just created
+	SubscriptionActive SubscriptionState = iota
+	// SubscriptionDegraded subscriptions lost one of their full nodes.
+	// Messages may be missed until they are restored if it was the last one
+	SubscriptionDegraded
+	// SubscriptionRestored subscriptions were subscribed again, to the same
+	// full node or to another one. Messages published while the
+	// subscription had no full node should be queried from a store node
+	SubscriptionRestored
+)
+
+func (s SubscriptionState) String() string {
+	switch s {
+	case SubscriptionActive:
+		return "active"
+	case SubscriptionDegraded:
+		return "degraded"
+	case SubscriptionRestored:
+		return "restored"
+	default:
+		return fmt.Sprintf("SubscriptionState(%d)", int(s))
+	}
+}
+
+// EvtSubscriptionStateChanged is emitted on the host event bus when the
+// state of a subscription changes
+type EvtSubscriptionStateChanged struct {
+	FilterID string
+	Peer     peer.ID
+	State    SubscriptionState
+	// Time the subscription was degraded, for restored subscriptions
+	DegradedAt time.Time
+}
+
+type degradedFilter struct {
+	since         time.Time
+	timer         *time.Timer
+	resubscribing bool
+	// Number of full nodes to subscribe to
+	missing int
+	// Disconnected full nodes which are subscribed again if they reconnect
+	awaited map[peer.ID]struct{}
+}
+
+type degradedFilters struct {
+	sync.Mutex
+	filters map[string]*degradedFilter
+	timeout time.Duration
+	stopped bool
+}
+
+func newDegradedFilters() *degradedFilters {
+	return &degradedFilters{
+		filters: make(map[string]*degradedFilter),
+		timeout: DefaultResubscribeTimeout,
+	}
+}
+
+// SetResubscribeTimeout sets the time waited for the full node of a
+// subscription to reconnect before subscribing to another full node
+func (wf *WakuFilter) SetResubscribeTimeout(timeout time.Duration) {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	wf.degraded.timeout = timeout
+}
+
+// PeerDisconnected degrades the subscriptions to a peer once it is fully
+// disconnected. They are subscribed again when the peer reconnects, or to
+// another full node after the resubscribe timeout
+func (wf *WakuFilter) PeerDisconnected(peerID peer.ID) {
+	if wf.h.Network().Connectedness(peerID) == network.Connected {
+		return
+	}
+
+	wf.health.forget(peerID)
+	for _, filterID := range wf.filterIDsOfPeer(peerID) {
+		wf.peerLost(filterID, peerID, true)
+	}
+}
+
+// PeerIdentified subscribes again the degraded subscriptions to a peer
+// which reconnected
+func (wf *WakuFilter) PeerIdentified(peerID peer.ID) {
+	for _, filterID := range wf.awaitingPeer(peerID) {
+		if wf.startResubscribing(filterID) {
+			go wf.resubscribe(filterID, peerID)
+		}
+	}
+}
+
+func (wf *WakuFilter) filterIDsOfPeer(peerID peer.ID) []string {
+	var result []string
+	for item := range wf.filters.Items() {
+		if item.Value.HasPeer(peerID) {
+			result = append(result, item.Key)
+		}
+	}
+	return result
+}
+
+func (wf *WakuFilter) awaitingPeer(peerID peer.ID) []string {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	var result []string
+	for filterID, d := range wf.degraded.filters {
+		if _, ok := d.awaited[peerID]; ok {
+			result = append(result, filterID)
+		}
+	}
+	return result
+}
+
+// peerLost degrades a subscription which lost one of its full nodes. If
+// awaitReconnect is set the peer is subscribed again when it reconnects
+// within the resubscribe timeout, otherwise another full node is looked for
+// right away
+func (wf *WakuFilter) peerLost(filterID string, peerID peer.ID, awaitReconnect bool) {
+	if !wf.filters.RemovePeer(filterID, peerID) {
+		return
+	}
+
+	wf.degraded.Lock()
+	if wf.degraded.stopped {
+		wf.degraded.Unlock()
+		return
+	}
+
+	d, ok := wf.degraded.filters[filterID]
+	if !ok {
+		d = &degradedFilter{
+			since:   time.Now(),
+			timer:   time.AfterFunc(wf.degraded.timeout, func() { wf.failover(filterID) }),
+			awaited: make(map[peer.ID]struct{}),
+		}
+		wf.degraded.filters[filterID] = d
+	}
+	d.missing++
+	if awaitReconnect {
+		d.awaited[peerID] = struct{}{}
+	}
+	wf.degraded.Unlock()
+
+	log.Info(fmt.Sprintf("filter subscription %s degraded, lost %s", filterID, peerID))
+	wf.emitState(EvtSubscriptionStateChanged{FilterID: filterID, Peer: peerID, State: SubscriptionDegraded})
+
+	if !awaitReconnect {
+		go wf.failover(filterID)
+	}
+}
+
+// startResubscribing returns whether a degraded subscription should be
+// subscribed again, so no more than one attempt is done at a time
+func (wf *WakuFilter) startResubscribing(filterID string) bool {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	d, ok := wf.degraded.filters[filterID]
+	if !ok || d.resubscribing || wf.degraded.stopped {
+		return false
+	}
+
+	d.timer.Stop()
+	d.resubscribing = true
+	return true
+}
+
+// failover subscribes a degraded subscription to other full nodes, either
+// after its full nodes didn't reconnect in time or right away when they
+// failed the health checks
+func (wf *WakuFilter) failover(filterID string) {
+	if !wf.startResubscribing(filterID) {
+		return
+	}
+
+	f, ok := wf.filters.Get(filterID)
+	if !ok {
+		wf.clearDegraded(filterID)
+		return
+	}
+
+	exclude := append([]peer.ID{}, f.Peers...)
+	exclude = append(exclude, wf.health.unhealthyPeers()...)
+	for _, p := range connectedFilterPeers(wf.h, exclude...) {
+		if wf.resubscribeTo(filterID, f, p) && wf.missingPeers(filterID) == 0 {
+			return
+		}
+	}
+
+	log.Info(fmt.Sprintf("not enough filter peers available for subscription %s, retrying in %s", filterID, wf.resubscribeTimeout()))
+	wf.retryLater(filterID)
+}
+
+func (wf *WakuFilter) resubscribe(filterID string, peerID peer.ID) {
+	f, ok := wf.filters.Get(filterID)
+	if !ok {
+		wf.clearDegraded(filterID)
+		return
+	}
+
+	if !wf.resubscribeTo(filterID, f, peerID) || wf.missingPeers(filterID) > 0 {
+		wf.retryLater(filterID)
+	}
+}
+
+// resubscribeTo sends the subscription request of a filter again to a peer,
+// with the same request id, so the local filter keeps matching the pushes
+func (wf *WakuFilter) resubscribeTo(filterID string, f Filter, peerID peer.ID) bool {
+	cf := ContentFilter{Topic: f.Topic, ContentTopics: f.ContentFilters}
+	_, err := wf.subscribe(wf.ctx, filterID, cf, peerID)
+	if err != nil {
+		log.Info(fmt.Sprintf("could not resubscribe %s to %s: %s", filterID, peerID, err))
+		return false
+	}
+
+	if !wf.filters.AddPeer(filterID, peerID) {
+		// The filter was removed meanwhile
+		wf.clearDegraded(filterID)
+		wf.unprotectPeer(peerID)
+		return true
+	}
+
+	since := wf.peerRestored(filterID, peerID)
+
+	log.Info(fmt.Sprintf("filter subscription %s restored with %s", filterID, peerID))
+	wf.emitState(EvtSubscriptionStateChanged{FilterID: filterID, Peer: peerID, State: SubscriptionRestored, DegradedAt: since})
+
+	return true
+}
+
+// peerRestored records a full node subscribed again, and returns since when
+// the subscription was degraded. The subscription is no longer degraded
+// once it has as many full nodes as it was created with
+func (wf *WakuFilter) peerRestored(filterID string, peerID peer.ID) time.Time {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	d, ok := wf.degraded.filters[filterID]
+	if !ok {
+		return time.Time{}
+	}
+
+	d.missing--
+	delete(d.awaited, peerID)
+	// Another full node replaced one of the disconnected ones
+	for p := range d.awaited {
+		if len(d.awaited) <= d.missing {
+			break
+		}
+		delete(d.awaited, p)
+	}
+
+	if d.missing <= 0 {
+		d.timer.Stop()
+		delete(wf.degraded.filters, filterID)
+	}
+
+	return d.since
+}
+
+func (wf *WakuFilter) missingPeers(filterID string) int {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	d, ok := wf.degraded.filters[filterID]
+	if !ok {
+		return 0
+	}
+	return d.missing
+}
+
+// clearDegraded forgets a degraded subscription, and returns since when it was
+// degraded
+func (wf *WakuFilter) clearDegraded(filterID string) time.Time {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	d, ok := wf.degraded.filters[filterID]
+	if !ok {
+		return time.Time{}
+	}
+
+	d.timer.Stop()
+	delete(wf.degraded.filters, filterID)
+	return d.since
+}
+
+func (wf *WakuFilter) retryLater(filterID string) {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	d, ok := wf.degraded.filters[filterID]
+	if !ok || wf.degraded.stopped {
+		return
+	}
+
+	d.resubscribing = false
+	d.timer = time.AfterFunc(wf.degraded.timeout, func() { wf.failover(filterID) })
+}
+
+func (wf *WakuFilter) resubscribeTimeout() time.Duration {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	return wf.degraded.timeout
+}
+
+// connectedFilterPeers returns the connected peers supporting the filter
+// protocol, except the excluded ones
+func connectedFilterPeers(h host.Host, exclude ...peer.ID) []peer.ID {
+	excluded := make(map[peer.ID]struct{}, len(exclude))
+	for _, p := range exclude {
+		excluded[p] = struct{}{}
+	}
+
+	var result []peer.ID
+	for _, p := range h.Network().Peers() {
+		if _, ok := excluded[p]; ok {
+			continue
+		}
+
+		protocols, err := h.Peerstore().SupportsProtocols(p, string(FilterID_v20beta1))
+		if err != nil || len(protocols) == 0 {
+			continue
+		}
+
+		result = append(result, p)
+	}
+	return result
+}
+
+func (wf *WakuFilter) emitState(evt EvtSubscriptionStateChanged) {
+	if wf.stateEmitter == nil {
+		return
+	}
+
+	err := wf.stateEmitter.Emit(evt)
+	if err != nil {
+		log.Debug("could not emit filter subscription state", err)
+	}
+}
+
+// stopResubscribing cancels the pending resubscriptions
+func (wf *WakuFilter) stopResubscribing() {
+	wf.degraded.Lock()
+	defer wf.degraded.Unlock()
+
+	wf.degraded.stopped = true
+	for filterID, d := range wf.degraded.filters {
+		d.timer.Stop()
+		delete(wf.degraded.filters, filterID)
+	}
+}
@@ -0,0 +1,227 @@
+package filter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/status-im/go-waku/tests"
+	v2 "github.com/status-im/go-waku/waku/v2"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/stretchr/testify/require"
+)
+
+const resubscribeTestTopic = "/waku/2/go/filter/test"
+
+// makeFullNode creates a relay node serving filter subscriptions
+func makeFullNode(t *testing.T) (*relay.WakuRelay, host.Host) {
+	broadcaster := v2.NewBroadcaster(10)
+	node, sub, h := makeWakuRelay(t, resubscribeTestTopic, broadcaster)
+	fullNode := NewWakuFilter(context.Background(), h, true)
+	broadcaster.Register(fullNode.MsgC)
+
+	t.Cleanup(func() {
+		sub.Unsubscribe()
+		fullNode.Stop()
+		node.Stop()
+		h.Close()
+	})
+
+	return node, h
+}
+
+func connectFullNode(t *testing.T, h host.Host, fullNode host.Host) {
+	h.Peerstore().AddAddr(fullNode.ID(), tests.GetHostAddress(fullNode), peerstore.PermanentAddrTTL)
+	require.NoError(t, h.Peerstore().AddProtocols(fullNode.ID(), string(FilterID_v20beta1)))
+	require.NoError(t, h.Connect(context.Background(), h.Peerstore().PeerInfo(fullNode.ID())))
+}
+
+func nextState(t *testing.T, sub event.Subscription) EvtSubscriptionStateChanged {
+	select {
+	case evt := <-sub.Out():
+		return evt.(EvtSubscriptionStateChanged)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "no subscription state change")
+	}
+	return EvtSubscriptionStateChanged{}
+}
+
+func receive(t *testing.T, c chan *protocol.Envelope) *protocol.Envelope {
+	select {
+	case env := <-c:
+		return env
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no message received")
+	}
+	return nil
+}
+
+// disconnect closes the connections to a full node, and reports the
+// disconnection as the node does once it's notified
+func disconnect(t *testing.T, wf *WakuFilter, p peer.ID) {
+	require.NoError(t, wf.h.Network().ClosePeer(p))
+	wf.PeerDisconnected(p)
+}
+
+func TestFilterResubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	lightNode, host1 := makeWakuFilter(t)
+	defer lightNode.Stop()
+
+	sub, err := host1.EventBus().Subscribe(new(EvtSubscriptionStateChanged))
+	require.NoError(t, err)
+	defer sub.Close()
+
+	fullNode1, host2 := makeFullNode(t)
+	connectFullNode(t, host1, host2)
+
+	contentFilter := ContentFilter{Topic: resubscribeTestTopic, ContentTopics: []string{"TopicA"}}
+	filterID, f, err := lightNode.Subscribe(ctx, contentFilter, WithPeer(host2.ID()))
+	require.NoError(t, err)
+	require.Equal(t, SubscriptionActive, nextState(t, sub).State)
+
+	// The subscription is restored with the same full node once it reconnects
+	disconnect(t, lightNode, host2.ID())
+	evt := nextState(t, sub)
+	require.Equal(t, SubscriptionDegraded, evt.State)
+	require.Equal(t, filterID, evt.FilterID)
+	require.Equal(t, host2.ID(), evt.Peer)
+
+	connectFullNode(t, host1, host2)
+	lightNode.PeerIdentified(host2.ID())
+	evt = nextState(t, sub)
+	require.Equal(t, SubscriptionRestored, evt.State)
+	require.Equal(t, host2.ID(), evt.Peer)
+	require.False(t, evt.DegradedAt.IsZero())
+
+	time.Sleep(500 * time.Millisecond)
+	_, err = fullNode1.PublishToTopic(ctx, tests.CreateWakuMessage("TopicA", 1), resubscribeTestTopic)
+	require.NoError(t, err)
+	receive(t, f.Chan)
+
+	// or with another one, if it doesn't reconnect in time
+	lightNode.SetResubscribeTimeout(200 * time.Millisecond)
+	fullNode2, host3 := makeFullNode(t)
+	connectFullNode(t, host1, host3)
+
+	disconnect(t, lightNode, host2.ID())
+	require.Equal(t, SubscriptionDegraded, nextState(t, sub).State)
+	evt = nextState(t, sub)
+	require.Equal(t, SubscriptionRestored, evt.State)
+	require.Equal(t, host3.ID(), evt.Peer)
+
+	peers, err := lightNode.ServingPeers(filterID)
+	require.NoError(t, err)
+	require.Equal(t, []peer.ID{host3.ID()}, peers)
+
+	time.Sleep(500 * time.Millisecond)
+	_, err = fullNode2.PublishToTopic(ctx, tests.CreateWakuMessage("TopicA", 2), resubscribeTestTopic)
+	require.NoError(t, err)
+	receive(t, f.Chan)
+}
+
+func TestSubscriptionStateString(t *testing.T) {
+	require.Equal(t, "active", SubscriptionActive.String())
+	require.Equal(t, "degraded", SubscriptionDegraded.String())
+	require.Equal(t, "restored", SubscriptionRestored.String())
+	require.Equal(t, "SubscriptionState(7)", SubscriptionState(7).String())
+}
+
+func TestMultipleFullNodes(t *testing.T) {
+	ctx := context.Background()
+
+	lightNode, host1 := makeWakuFilter(t)
+	defer lightNode.Stop()
+
+	sub, err := host1.EventBus().Subscribe(new(EvtSubscriptionStateChanged))
+	require.NoError(t, err)
+	defer sub.Close()
+
+	fullNode1, host2 := makeFullNode(t)
+	connectFullNode(t, host1, host2)
+	fullNode2, host3 := makeFullNode(t)
+	connectFullNode(t, host1, host3)
+
+	contentFilter := ContentFilter{Topic: resubscribeTestTopic, ContentTopics: []string{"TopicA"}}
+	filterID, f, err := lightNode.Subscribe(ctx, contentFilter, WithPeers(host2.ID(), host3.ID()))
+	require.NoError(t, err)
+	require.Equal(t, SubscriptionActive, nextState(t, sub).State)
+	require.Equal(t, SubscriptionActive, nextState(t, sub).State)
+
+	peers, err := lightNode.ServingPeers(filterID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []peer.ID{host2.ID(), host3.ID()}, peers)
+
+	// A message pushed by both full nodes is delivered once
+	time.Sleep(500 * time.Millisecond)
+	msg := tests.CreateWakuMessage("TopicA", 1)
+	_, err = fullNode1.PublishToTopic(ctx, msg, resubscribeTestTopic)
+	require.NoError(t, err)
+	_, err = fullNode2.PublishToTopic(ctx, msg, resubscribeTestTopic)
+	require.NoError(t, err)
+	receive(t, f.Chan)
+	select {
+	case <-f.Chan:
+		require.Fail(t, "the message should be delivered once")
+	case <-time.After(time.Second):
+	}
+
+	// The other full node keeps pushing messages when one disconnects
+	disconnect(t, lightNode, host2.ID())
+	require.Equal(t, SubscriptionDegraded, nextState(t, sub).State)
+	peers, err = lightNode.ServingPeers(filterID)
+	require.NoError(t, err)
+	require.Equal(t, []peer.ID{host3.ID()}, peers)
+
+	_, err = fullNode2.PublishToTopic(ctx, tests.CreateWakuMessage("TopicA", 2), resubscribeTestTopic)
+	require.NoError(t, err)
+	receive(t, f.Chan)
+}
+
+func TestAutomaticPeersSelection(t *testing.T) {
+	lightNode, host1 := makeWakuFilter(t)
+	defer lightNode.Stop()
+
+	_, host2 := makeFullNode(t)
+	connectFullNode(t, host1, host2)
+	_, host3 := makeFullNode(t)
+	connectFullNode(t, host1, host3)
+
+	params := new(FilterSubscribeParameters)
+	params.host = host1
+	WithAutomaticPeersSelection(1)(params)
+	require.Len(t, params.selectedPeers, 1)
+
+	WithAutomaticPeersSelection(3)(params)
+	require.ElementsMatch(t, []peer.ID{host2.ID(), host3.ID()}, params.selectedPeers)
+}
+
+func TestSeenMessages(t *testing.T) {
+	s := newSeenMessages()
+	require.True(t, s.isNew([]byte{1}))
+	require.False(t, s.isNew([]byte{1}))
+	require.True(t, s.isNew([]byte{2}))
+
+	// Copies pushed after the TTL are delivered again
+	s.hashes[string([]byte{1})] = time.Now().Add(-2 * dedupTTL)
+	require.True(t, s.isNew([]byte{1}))
+}
+
+func TestPeersHealth(t *testing.T) {
+	h := newPeersHealth()
+	require.Equal(t, DefaultHealthCheckInterval, h.checkInterval())
+
+	h.setHealthy("p1", false)
+	h.setHealthy("p2", false)
+	h.setHealthy("p2", true)
+	require.Equal(t, []peer.ID{"p1"}, h.unhealthyPeers())
+
+	h.forget("p1")
+	require.Empty(t, h.unhealthyPeers())
+}
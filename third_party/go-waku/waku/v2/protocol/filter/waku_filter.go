@@ -0,0 +1,646 @@
+package filter
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	libp2pProtocol "github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-msgio/protoio"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+var log = logging.Logger("wakufilter")
+
+var (
+	ErrNoPeersAvailable     = errors.New("no suitable remote peers")
+	ErrSubscriptionRejected = errors.New("subscription rejected")
+	ErrNotFullNode          = errors.New("not a filter full node")
+)
+
+// Time to wait for the response of a full node to a subscription request
+const responseTimeout = 10 * time.Second
+
+type (
+	Filter struct {
+		PeerID peer.ID
+		// Full nodes serving the filter. PeerID is the first of them
+		Peers          []peer.ID
+		Topic          string
+		ContentFilters []string
+		Chan           chan *protocol.Envelope
+	}
+
+	ContentFilter struct {
+		Topic         string
+		ContentTopics []string
+	}
+
+	FilterSubscription struct {
+		RequestID string
+		Peer      peer.ID
+		Peers     []peer.ID
+	}
+
+	WakuFilter struct {
+		ctx        context.Context
+		cancel     context.CancelFunc
+		h          host.Host
+		isFullNode bool
+		MsgC       chan *protocol.Envelope
+		wg         *sync.WaitGroup
+
+		// Guards isFullNode, so no subscription is accepted once the node
+		// stops being a full node
+		modeMutex sync.RWMutex
+
+		filters     *FilterMap
+		subscribers *Subscribers
+
+		// Client subscriptions whose full node disconnected
+		degraded     *degradedFilters
+		stateEmitter event.Emitter
+		health       *peersHealth
+
+		// Messages pushed by the full nodes, to drop duplicates
+		seen *seenMessages
+
+		selector *utils.PeerSelector
+	}
+)
+
+// HasPeer returns whether a full node serves the filter
+func (f Filter) HasPeer(peerID peer.ID) bool {
+	for _, p := range f.Peers {
+		if p == peerID {
+			return true
+		}
+	}
+	return false
+}
+
+// NOTE This is just a start, the design of this protocol isn't done yet. It
+// should be direct payload exchange (a la req-resp), not be coupled with the
+// relay protocol.
+const FilterID_v20beta1 = libp2pProtocol.ID("/vac/waku/filter/2.0.0-beta1")
+
+func NewWakuFilter(ctx context.Context, host host.Host, isFullNode bool) *WakuFilter {
+	ctx, err := tag.New(ctx, tag.Insert(metrics.KeyType, "filter"))
+	if err != nil {
+		log.Error(err)
+	}
+
+	wf := new(WakuFilter)
+	wf.ctx, wf.cancel = context.WithCancel(ctx)
+	wf.wg = &sync.WaitGroup{}
+	wf.MsgC = make(chan *protocol.Envelope, 1024)
+	wf.h = host
+	wf.isFullNode = isFullNode
+	wf.filters = NewFilterMap()
+	wf.subscribers = NewSubscribers()
+	wf.degraded = newDegradedFilters()
+	wf.health = newPeersHealth()
+	wf.seen = newSeenMessages()
+
+	wf.stateEmitter, err = host.EventBus().Emitter(new(EvtSubscriptionStateChanged))
+	if err != nil {
+		log.Error("could not create filter subscription state emitter", err)
+	}
+
+	wf.h.SetStreamHandlerMatch(FilterID_v20beta1, protocol.PrefixTextMatch(string(FilterID_v20beta1)), wf.onRequest)
+
+	wf.wg.Add(2)
+	go wf.FilterListener()
+	go wf.checkPeersHealth()
+
+	if wf.isFullNode {
+		log.Info("Filter protocol started")
+	} else {
+		log.Info("Filter protocol started (only client mode)")
+	}
+
+	return wf
+}
+
+// SetPeerSelector sets how the full node is selected when none is specified
+func (wf *WakuFilter) SetPeerSelector(selector *utils.PeerSelector) {
+	wf.selector = selector
+}
+
+// SetLimits limits the subscriptions accepted by a full node. Requests above
+// the limits are answered with an error response
+func (wf *WakuFilter) SetLimits(limits Limits) {
+	wf.subscribers.SetLimits(limits)
+}
+
+// SubscriptionCounts returns the number of subscribers, subscriptions and
+// content filters served by a full node
+func (wf *WakuFilter) SubscriptionCounts() SubscriptionCounts {
+	return wf.subscribers.Counts()
+}
+
+func (wf *WakuFilter) onRequest(s network.Stream) {
+	defer s.Close()
+
+	filterRPCRequest := &pb.FilterRPC{}
+
+	reader := protoio.NewDelimitedReader(s, math.MaxInt32)
+
+	err := reader.ReadMsg(filterRPCRequest)
+	if err != nil {
+		log.Error("error reading request", err)
+		return
+	}
+
+	log.Info(fmt.Sprintf("%s: received request from %s", s.Conn().LocalPeer(), s.Conn().RemotePeer()))
+
+	if filterRPCRequest.Push != nil && len(filterRPCRequest.Push.Messages) > 0 {
+		// We're on a light node.
+		// This is a message push coming from a full node.
+		for _, message := range filterRPCRequest.Push.Messages {
+			// Subscriptions to several full nodes receive each message from all of them
+			if hash, err := message.Hash(); err == nil && !wf.seen.isNew(hash) {
+				continue
+			}
+			wf.filters.Notify(message, filterRPCRequest.RequestId) // Trigger filter handlers on a light node
+		}
+
+		log.Info("filter light node, received a message push. ", len(filterRPCRequest.Push.Messages), " messages")
+		stats.Record(wf.ctx, metrics.Messages.M(int64(len(filterRPCRequest.Push.Messages))))
+	} else if filterRPCRequest.Response != nil && !filterRPCRequest.Response.IsSuccess {
+		// We're on a light node.
+		// The full node dropped one of our subscriptions.
+		wf.subscriptionDropped(filterRPCRequest.RequestId, s.Conn().RemotePeer(), filterRPCRequest.Response.Info)
+	} else if filterRPCRequest.Request != nil {
+		// This is a filter request coming from a light node.
+		wf.modeMutex.RLock()
+		defer wf.modeMutex.RUnlock()
+
+		if !wf.isFullNode {
+			// We're on a light node. Without a response the light node would
+			// assume the subscription was accepted
+			if filterRPCRequest.Request.Subscribe {
+				writer := protoio.NewDelimitedWriter(s)
+				response := &pb.FilterResponse{IsSuccess: false, Info: ErrNotFullNode.Error()}
+				if err := writer.WriteMsg(&pb.FilterRPC{RequestId: filterRPCRequest.RequestId, Response: response}); err != nil {
+					log.Debug("could not send filter response ", err)
+				}
+			}
+			return
+		}
+
+		// We're on a full node.
+		if filterRPCRequest.Request.Subscribe {
+			peerId := s.Conn().RemotePeer()
+			len, err := wf.subscribers.Append(peerId, filterRPCRequest.RequestId, filterRPCRequest.Request)
+
+			response := &pb.FilterResponse{IsSuccess: true}
+			if err != nil {
+				log.Info(fmt.Sprintf("filter full node, rejected subscription of %s: %s", peerId, err))
+				response = &pb.FilterResponse{IsSuccess: false, Info: err.Error()}
+			} else {
+				log.Info("filter full node, add a filter subscriber: ", peerId)
+				stats.Record(wf.ctx, metrics.FilterSubscriptions.M(int64(len)))
+			}
+
+			// Clients not expecting a response may have closed the stream already
+			writer := protoio.NewDelimitedWriter(s)
+			err = writer.WriteMsg(&pb.FilterRPC{RequestId: filterRPCRequest.RequestId, Response: response})
+			if err != nil {
+				log.Debug("could not send filter response ", err)
+			}
+		} else {
+			peerId := s.Conn().RemotePeer()
+			wf.subscribers.RemoveContentFilters(peerId, filterRPCRequest.Request.Topic, filterRPCRequest.Request.ContentFilters)
+
+			log.Info("filter full node, remove a filter subscriber: ", peerId.Pretty())
+			stats.Record(wf.ctx, metrics.FilterSubscriptions.M(int64(wf.subscribers.Length())))
+		}
+	} else {
+		log.Error("can't serve request")
+		return
+	}
+}
+
+func (wf *WakuFilter) pushMessage(subscriber Subscriber, msg *pb.WakuMessage) error {
+	pushRPC := &pb.FilterRPC{RequestId: subscriber.requestId, Push: &pb.MessagePush{Messages: []*pb.WakuMessage{msg}}}
+
+	conn, err := wf.h.NewStream(wf.ctx, peer.ID(subscriber.peer), FilterID_v20beta1)
+	// TODO: keep track of errors to automatically unsubscribe a peer?
+	if err != nil {
+		// @TODO more sophisticated error handling here
+		log.Error("failed to open peer stream")
+		//waku_filter_errors.inc(labelValues = [dialFailure])
+		return err
+	}
+
+	defer conn.Close()
+	writer := protoio.NewDelimitedWriter(conn)
+	err = writer.WriteMsg(pushRPC)
+	if err != nil {
+		log.Error("failed to push messages to remote peer")
+		return nil
+	}
+
+	return nil
+}
+
+func (wf *WakuFilter) FilterListener() {
+	defer wf.wg.Done()
+
+	// This function is invoked for each message received
+	// on the full node in context of Waku2-Filter
+	handle := func(envelope *protocol.Envelope) error { // async
+		msg := envelope.Message()
+		topic := envelope.PubsubTopic()
+		// Each subscriber is a light node that earlier on invoked
+		// a FilterRequest on this node
+		for _, subscriber := range wf.subscribers.Match(topic, msg.ContentTopic) {
+			// Do a message push to light node
+			log.Info("pushing messages to light node: ", subscriber.peer)
+			if err := wf.pushMessage(subscriber, msg); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for m := range wf.MsgC {
+		if err := handle(m); err != nil {
+			log.Error("failed to handle message", err)
+		}
+	}
+}
+
+// Having a FilterRequest struct,
+// select a peer with filter support, dial it,
+// and submit FilterRequest wrapped in FilterRPC
+func (wf *WakuFilter) requestSubscription(ctx context.Context, filter ContentFilter, opts ...FilterSubscribeOption) (subscription *FilterSubscription, err error) {
+	params := new(FilterSubscribeParameters)
+	params.host = wf.h
+	params.selector = wf.selector
+
+	optList := DefaultOptions()
+	optList = append(optList, opts...)
+	for _, opt := range optList {
+		opt(params)
+	}
+
+	peers := params.selectedPeers
+	if len(peers) == 0 && params.selectedPeer != "" {
+		peers = []peer.ID{params.selectedPeer}
+	}
+
+	if len(peers) == 0 {
+		return nil, ErrNoPeersAvailable
+	}
+
+	// The same request id is used with all the peers, so the messages they
+	// push match the same filter
+	requestID := hex.EncodeToString(protocol.GenerateRequestId())
+	for _, p := range peers {
+		_, peerErr := wf.subscribe(ctx, requestID, filter, p)
+		if peerErr != nil {
+			log.Info(fmt.Sprintf("could not subscribe to %s: %s", p, peerErr))
+			if err == nil {
+				err = peerErr
+			}
+			continue
+		}
+
+		if subscription == nil {
+			subscription = &FilterSubscription{RequestID: requestID, Peer: p}
+		}
+		subscription.Peers = append(subscription.Peers, p)
+	}
+
+	if subscription != nil {
+		return subscription, nil
+	}
+
+	return nil, err
+}
+
+// subscribe submits a FilterRequest to a peer
+func (wf *WakuFilter) subscribe(ctx context.Context, requestID string, filter ContentFilter, peerID peer.ID) (subscription *FilterSubscription, err error) {
+	var contentFilters []*pb.FilterRequest_ContentFilter
+	for _, ct := range filter.ContentTopics {
+		contentFilters = append(contentFilters, &pb.FilterRequest_ContentFilter{ContentTopic: ct})
+	}
+
+	request := pb.FilterRequest{
+		Subscribe:      true,
+		Topic:          filter.Topic,
+		ContentFilters: contentFilters,
+	}
+
+	var conn network.Stream
+	conn, err = wf.h.NewStream(ctx, peerID, FilterID_v20beta1)
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	writer := protoio.NewDelimitedWriter(conn)
+	filterRPC := &pb.FilterRPC{RequestId: requestID, Request: &request}
+	log.Info("sending filterRPC: ", filterRPC)
+	err = writer.WriteMsg(filterRPC)
+	if err != nil {
+		log.Error("failed to write message", err)
+		return
+	}
+
+	err = wf.readResponse(conn)
+	if err != nil {
+		return
+	}
+
+	subscription = new(FilterSubscription)
+	subscription.Peer = peerID
+	subscription.Peers = []peer.ID{peerID}
+	subscription.RequestID = requestID
+
+	// Keep the connection to the peer while there are subscriptions
+	wf.h.ConnManager().Protect(peerID, string(FilterID_v20beta1))
+
+	return
+}
+
+// readResponse waits for the full node to accept a subscription. Full nodes
+// that don't send responses close the stream instead, which also means the
+// subscription was accepted
+func (wf *WakuFilter) readResponse(conn network.Stream) error {
+	err := conn.CloseWrite()
+	if err != nil {
+		return err
+	}
+
+	err = conn.SetReadDeadline(time.Now().Add(responseTimeout))
+	if err != nil {
+		return err
+	}
+
+	response := &pb.FilterRPC{}
+	reader := protoio.NewDelimitedReader(conn, math.MaxInt32)
+	err = reader.ReadMsg(response)
+	if err == io.EOF {
+		return nil
+	}
+
+	if err != nil {
+		log.Error("could not read filter response", err)
+		return err
+	}
+
+	if response.Response != nil && !response.Response.IsSuccess {
+		return fmt.Errorf("%w: %s", ErrSubscriptionRejected, response.Response.Info)
+	}
+
+	return nil
+}
+
+// unprotectPeer allows the connection to a peer to be pruned
+// once there are no more subscriptions using it
+func (wf *WakuFilter) unprotectPeer(peerID peer.ID) {
+	for filterMapItem := range wf.filters.Items() {
+		if filterMapItem.Value.HasPeer(peerID) {
+			return
+		}
+	}
+	wf.h.ConnManager().Unprotect(peerID, string(FilterID_v20beta1))
+}
+
+func (wf *WakuFilter) Unsubscribe(ctx context.Context, contentFilter ContentFilter, peer peer.ID) error {
+	conn, err := wf.h.NewStream(ctx, peer, FilterID_v20beta1)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	// This is the only successful path to subscription
+	id := protocol.GenerateRequestId()
+
+	var contentFilters []*pb.FilterRequest_ContentFilter
+	for _, ct := range contentFilter.ContentTopics {
+		contentFilters = append(contentFilters, &pb.FilterRequest_ContentFilter{ContentTopic: ct})
+	}
+
+	request := pb.FilterRequest{
+		Subscribe:      false,
+		Topic:          contentFilter.Topic,
+		ContentFilters: contentFilters,
+	}
+
+	writer := protoio.NewDelimitedWriter(conn)
+	filterRPC := &pb.FilterRPC{RequestId: hex.EncodeToString(id), Request: &request}
+	err = writer.WriteMsg(filterRPC)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (wf *WakuFilter) Stop() {
+	wf.stopResubscribing()
+	wf.cancel()
+	close(wf.MsgC)
+
+	wf.h.RemoveStreamHandler(FilterID_v20beta1)
+	wf.filters.RemoveAll()
+	wf.wg.Wait()
+
+	if wf.stateEmitter != nil {
+		if err := wf.stateEmitter.Close(); err != nil {
+			log.Error("could not close filter subscription state emitter", err)
+		}
+	}
+}
+
+func (wf *WakuFilter) Subscribe(ctx context.Context, f ContentFilter, opts ...FilterSubscribeOption) (filterID string, theFilter Filter, err error) {
+	// TODO: check if there's an existing pubsub topic that uses the same peer. If so, reuse filter, and return same channel and filterID
+
+	// Registers for messages that match a specific filter. Triggers the handler whenever a message is received.
+	// ContentFilterChan takes MessagePush structs
+	remoteSubs, err := wf.requestSubscription(ctx, f, opts...)
+	if err != nil || remoteSubs.RequestID == "" {
+		// Failed to subscribe
+		log.Error("remote subscription to filter failed", err)
+		return
+	}
+
+	// Register handler for filter, whether remote subscription succeeded or not
+
+	filterID = remoteSubs.RequestID
+	theFilter = Filter{
+		PeerID:         remoteSubs.Peer,
+		Peers:          remoteSubs.Peers,
+		Topic:          f.Topic,
+		ContentFilters: f.ContentTopics,
+		Chan:           make(chan *protocol.Envelope, 1024), // To avoid blocking
+	}
+
+	wf.filters.Set(filterID, theFilter)
+	for _, p := range theFilter.Peers {
+		wf.emitState(EvtSubscriptionStateChanged{FilterID: filterID, Peer: p, State: SubscriptionActive})
+	}
+
+	return
+}
+
+// UnsubscribeFilterByID removes a subscription to a filter node completely
+// using the filterID returned when the subscription was created
+func (wf *WakuFilter) UnsubscribeFilterByID(ctx context.Context, filterID string) error {
+
+	var f Filter
+	var ok bool
+
+	if f, ok = wf.filters.Get(filterID); !ok {
+		return errors.New("filter not found")
+	}
+
+	cf := ContentFilter{
+		Topic:         f.Topic,
+		ContentTopics: f.ContentFilters,
+	}
+
+	err := wf.unsubscribePeers(ctx, cf, f.Peers)
+	if err != nil {
+		return err
+	}
+
+	wf.filters.Delete(filterID)
+	wf.clearDegraded(filterID)
+	for _, p := range f.Peers {
+		wf.unprotectPeer(p)
+	}
+
+	return nil
+}
+
+// Unsubscribe filter removes content topics from a filter subscription. If all
+// the contentTopics are removed the subscription is dropped completely
+func (wf *WakuFilter) UnsubscribeFilter(ctx context.Context, cf ContentFilter) error {
+	return wf.UnsubscribeContentTopics(ctx, cf.Topic, cf.ContentTopics...)
+}
+
+// UnsubscribeContentTopics removes content topics from the subscriptions to a
+// pubsub topic, while the other content topics keep being pushed. Subscriptions
+// without content topics left are dropped completely
+func (wf *WakuFilter) UnsubscribeContentTopics(ctx context.Context, topic string, contentTopics ...string) error {
+	toRemove := make(map[string]struct{}, len(contentTopics))
+	for _, ct := range contentTopics {
+		toRemove[ct] = struct{}{}
+	}
+
+	var filters []FilterMapItem
+	for filterMapItem := range wf.filters.Items() {
+		if filterMapItem.Value.Topic == topic {
+			filters = append(filters, filterMapItem)
+		}
+	}
+
+	for _, filterMapItem := range filters {
+		id := filterMapItem.Key
+		f := filterMapItem.Value
+
+		var removed, remaining []string
+		for _, ct := range f.ContentFilters {
+			if _, ok := toRemove[ct]; ok {
+				removed = append(removed, ct)
+			} else {
+				remaining = append(remaining, ct)
+			}
+		}
+
+		if len(removed) == 0 {
+			continue
+		}
+
+		// Send message to full node in order to unsubscribe
+		err := wf.unsubscribePeers(ctx, ContentFilter{Topic: topic, ContentTopics: removed}, f.Peers)
+		if err != nil {
+			return err
+		}
+
+		if len(remaining) > 0 {
+			wf.filters.SetContentFilters(id, remaining)
+			continue
+		}
+
+		// make sure we delete the content filter
+		// if no more topics are left
+		wf.filters.Delete(id)
+		wf.clearDegraded(id)
+		for _, p := range f.Peers {
+			wf.unprotectPeer(p)
+		}
+	}
+
+	return nil
+}
+
+// unsubscribePeers sends an unsubscribe request to the full nodes of a
+// filter. It only fails if none of them could be reached
+func (wf *WakuFilter) unsubscribePeers(ctx context.Context, contentFilter ContentFilter, peers []peer.ID) error {
+	var err error
+	unsubscribed := false
+	for _, p := range peers {
+		peerErr := wf.Unsubscribe(ctx, contentFilter, p)
+		if peerErr == nil {
+			unsubscribed = true
+		} else if err == nil {
+			err = peerErr
+		}
+	}
+
+	if unsubscribed {
+		return nil
+	}
+	return err
+}
+
+// Topics returns the pubsub topics of the subscriptions to full nodes
+func (wf *WakuFilter) Topics() []string {
+	topics := make(map[string]struct{})
+	for filterMapItem := range wf.filters.Items() {
+		if filterMapItem.Value.Topic != "" {
+			topics[filterMapItem.Value.Topic] = struct{}{}
+		}
+	}
+
+	var result []string
+	for topic := range topics {
+		result = append(result, topic)
+	}
+	return result
+}
+
+// ServingPeers returns the full nodes currently serving a filter
+func (wf *WakuFilter) ServingPeers(filterID string) ([]peer.ID, error) {
+	f, ok := wf.filters.Get(filterID)
+	if !ok {
+		return nil, errors.New("filter not found")
+	}
+
+	return f.Peers, nil
+}
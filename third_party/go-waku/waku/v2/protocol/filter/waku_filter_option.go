@@ -0,0 +1,78 @@
+package filter
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+type (
+	FilterSubscribeParameters struct {
+		host          host.Host
+		selector      *utils.PeerSelector
+		selectedPeer  peer.ID
+		selectedPeers []peer.ID
+	}
+
+	FilterSubscribeOption func(*FilterSubscribeParameters)
+)
+
+func WithPeer(p peer.ID) FilterSubscribeOption {
+	return func(params *FilterSubscribeParameters) {
+		params.selectedPeer = p
+	}
+}
+
+// WithPeers subscribes to several full nodes, so messages keep being pushed
+// when one of them fails. Each message is only delivered once
+func WithPeers(peers ...peer.ID) FilterSubscribeOption {
+	return func(params *FilterSubscribeParameters) {
+		params.selectedPeers = peers
+	}
+}
+
+// WithAutomaticPeersSelection subscribes to up to n connected full nodes
+func WithAutomaticPeersSelection(n int) FilterSubscribeOption {
+	return func(params *FilterSubscribeParameters) {
+		peers := connectedFilterPeers(params.host)
+		if len(peers) > n {
+			peers = peers[:n]
+		}
+		if len(peers) == 0 {
+			log.Info("Error selecting peers: ", ErrNoPeersAvailable)
+		}
+		params.selectedPeers = peers
+	}
+}
+
+// WithAutomaticPeerSelection subscribes to a full node, selected randomly
+// unless the protocol has a PeerSelector
+func WithAutomaticPeerSelection() FilterSubscribeOption {
+	return func(params *FilterSubscribeParameters) {
+		p, err := params.selector.SelectPeer(params.host, string(FilterID_v20beta1))
+		if err == nil {
+			params.selectedPeer = *p
+		} else {
+			log.Info("Error selecting peer: ", err)
+		}
+	}
+}
+
+func WithFastestPeerSelection(ctx context.Context) FilterSubscribeOption {
+	return func(params *FilterSubscribeParameters) {
+		p, err := utils.SelectPeerWithLowestRTT(ctx, params.host, string(FilterID_v20beta1))
+		if err == nil {
+			params.selectedPeer = *p
+		} else {
+			log.Info("Error selecting peer: ", err)
+		}
+	}
+}
+
+func DefaultOptions() []FilterSubscribeOption {
+	return []FilterSubscribeOption{
+		WithAutomaticPeerSelection(),
+	}
+}
@@ -0,0 +1,112 @@
+package lightpush
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitExceeded is the info of the responses to requests rejected
+// because the peer sent too many of them
+const RateLimitExceeded = "Rate limit exceeded"
+
+// Time span in which the rejected requests of a peer are counted, and after
+// which idle peers are forgotten
+const violationsWindow = time.Minute
+
+// RateLimit limits the push requests accepted from each peer
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+	// Number of requests rejected within a minute after which a peer is
+	// reported to OnOffender. Zero never reports peers
+	MaxViolations int
+	OnOffender    func(peer.ID)
+}
+
+type peerRate struct {
+	limiter     *rate.Limiter
+	lastSeen    time.Time
+	violations  int
+	windowStart time.Time
+}
+
+type rateLimiter struct {
+	sync.Mutex
+	RateLimit
+	peers     map[peer.ID]*peerRate
+	lastPrune time.Time
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	return &rateLimiter{
+		RateLimit: limit,
+		peers:     make(map[peer.ID]*peerRate),
+		lastPrune: time.Now(),
+	}
+}
+
+// allow returns whether a request of a peer is accepted. A peer is reported
+// once it reaches the maximum number of violations, then again if it keeps
+// exceeding the limit
+func (r *rateLimiter) allow(ctx context.Context, p peer.ID) bool {
+	r.Lock()
+	now := time.Now()
+	r.prune(now)
+
+	pr, ok := r.peers[p]
+	if !ok {
+		pr = &peerRate{limiter: rate.NewLimiter(rate.Limit(r.RequestsPerSecond), r.Burst)}
+		r.peers[p] = pr
+	}
+	pr.lastSeen = now
+
+	if pr.limiter.AllowN(now, 1) {
+		r.Unlock()
+		return true
+	}
+
+	if now.Sub(pr.windowStart) > violationsWindow {
+		pr.windowStart = now
+		pr.violations = 0
+	}
+	pr.violations++
+
+	offender := r.MaxViolations > 0 && pr.violations >= r.MaxViolations
+	if offender {
+		pr.violations = 0
+	}
+	r.Unlock()
+
+	log.Debug("rate limit exceeded by ", p)
+	metrics.RecordLightpushError(ctx, "rateLimitExceeded")
+
+	if offender {
+		log.Info("rate limit repeatedly exceeded by ", p)
+		metrics.RecordLightpushError(ctx, "rateLimitOffender")
+		if r.OnOffender != nil {
+			r.OnOffender(p)
+		}
+	}
+
+	return false
+}
+
+// prune forgets the peers that sent no request during the violations
+// window. It must be called with the lock held
+func (r *rateLimiter) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < violationsWindow {
+		return
+	}
+	r.lastPrune = now
+
+	for p, pr := range r.peers {
+		if now.Sub(pr.lastSeen) > violationsWindow {
+			delete(r.peers, p)
+		}
+	}
+}
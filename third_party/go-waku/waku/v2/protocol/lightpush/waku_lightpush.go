@@ -0,0 +1,373 @@
+package lightpush
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	libp2pProtocol "github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-msgio/protoio"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+var log = logging.Logger("waku_lightpush")
+
+const LightPushID_v20beta1 = libp2pProtocol.ID("/vac/waku/lightpush/2.0.0-beta1")
+
+// DefaultAttemptTimeout bounds each attempt of a push request whose
+// context has no deadline
+const DefaultAttemptTimeout = 10 * time.Second
+
+var (
+	ErrNoPeersAvailable = errors.New("no suitable remote peers")
+	ErrInvalidId        = errors.New("invalid request id")
+	// ErrPeerNotConnected is returned when pushing to a peer that isn't connected
+	ErrPeerNotConnected = errors.New("lightpush peer is not connected")
+	// ErrProtocolNotSupported is returned when pushing to a peer that doesn't
+	// support lightpush
+	ErrProtocolNotSupported = errors.New("peer does not support lightpush")
+	// ErrRateLimitExceeded is returned when a peer rejects a request because
+	// too many were sent to it
+	ErrRateLimitExceeded = errors.New("lightpush rate limit exceeded")
+)
+
+type WakuLightPush struct {
+	h        host.Host
+	relay    *relay.WakuRelay
+	ctx      context.Context
+	limiter  *rateLimiter
+	selector *utils.PeerSelector
+}
+
+func NewWakuLightPush(ctx context.Context, h host.Host, relay *relay.WakuRelay) *WakuLightPush {
+	wakuLP := new(WakuLightPush)
+	wakuLP.relay = relay
+	wakuLP.ctx = ctx
+	wakuLP.h = h
+
+	return wakuLP
+}
+
+// SetRateLimit limits the requests accepted from each peer. Requests above
+// the limit are answered with a RateLimitExceeded response. It must be set
+// before starting the protocol
+func (wakuLP *WakuLightPush) SetRateLimit(limit RateLimit) {
+	wakuLP.limiter = newRateLimiter(limit)
+}
+
+// SetPeerSelector sets how the peers are selected when none is specified, and
+// lets the selector know about the requests that failed
+func (wakuLP *WakuLightPush) SetPeerSelector(selector *utils.PeerSelector) {
+	wakuLP.selector = selector
+}
+
+func (wakuLP *WakuLightPush) Start() error {
+	if wakuLP.IsClientOnly() {
+		return errors.New("relay is required, without it, it is only a client and cannot be started")
+	}
+
+	wakuLP.h.SetStreamHandlerMatch(LightPushID_v20beta1, protocol.PrefixTextMatch(string(LightPushID_v20beta1)), wakuLP.onRequest)
+	log.Info("Light Push protocol started")
+
+	return nil
+}
+
+func (wakuLp *WakuLightPush) IsClientOnly() bool {
+	return wakuLp.relay == nil
+}
+
+func (wakuLP *WakuLightPush) onRequest(s network.Stream) {
+	defer s.Close()
+
+	requestPushRPC := &pb.PushRPC{}
+
+	writer := protoio.NewDelimitedWriter(s)
+	reader := protoio.NewDelimitedReader(s, math.MaxInt32)
+
+	err := reader.ReadMsg(requestPushRPC)
+	if err != nil {
+		log.Error("error reading request", err)
+		metrics.RecordLightpushError(wakuLP.ctx, "decodeRpcFailure")
+		return
+	}
+
+	log.Info(fmt.Sprintf("%s: lightpush message received from %s", s.Conn().LocalPeer(), s.Conn().RemotePeer()))
+
+	if requestPushRPC.Query != nil {
+		log.Info("lightpush push request")
+		response := new(pb.PushResponse)
+		if wakuLP.limiter != nil && !wakuLP.limiter.allow(wakuLP.ctx, s.Conn().RemotePeer()) {
+			response.IsSuccess = false
+			response.Info = RateLimitExceeded
+		} else if !wakuLP.IsClientOnly() {
+			pubSubTopic := requestPushRPC.Query.PubsubTopic
+			message := requestPushRPC.Query.Message
+
+			// TODO: Assumes success, should probably be extended to check for network, peers, etc
+			// It might make sense to use WithReadiness option here?
+
+			_, err := wakuLP.relay.PublishToTopic(wakuLP.ctx, message, pubSubTopic)
+
+			if err != nil {
+				response.IsSuccess = false
+				response.Info = "Could not publish message"
+			} else {
+				response.IsSuccess = true
+				response.Info = "Totally" // TODO: ask about this
+			}
+		} else {
+			log.Debug("no relay protocol present, unsuccessful push")
+			response.IsSuccess = false
+			response.Info = "No relay protocol"
+		}
+
+		responsePushRPC := &pb.PushRPC{}
+		responsePushRPC.RequestId = requestPushRPC.RequestId
+		responsePushRPC.Response = response
+
+		err = writer.WriteMsg(responsePushRPC)
+		if err != nil {
+			log.Error("error writing response", err)
+			_ = s.Reset()
+		} else {
+			log.Info(fmt.Sprintf("%s: response sent  to %s", s.Conn().LocalPeer().String(), s.Conn().RemotePeer().String()))
+		}
+	}
+
+	if requestPushRPC.Response != nil {
+		if requestPushRPC.Response.IsSuccess {
+			log.Info("lightpush message success")
+		} else {
+			log.Info(fmt.Sprintf("lightpush message failure. info=%s", requestPushRPC.Response.Info))
+		}
+	}
+}
+
+// PushResult is the outcome of a push request accepted by a peer
+type PushResult struct {
+	// Hash of the message
+	Hash []byte
+	// Peer that accepted the request
+	Peer peer.ID
+	// Number of peers the request was sent to, including the one accepting it
+	Attempts int
+}
+
+// PeerError is the failure of a push request sent to a peer
+type PeerError struct {
+	Peer peer.ID
+	Err  error
+}
+
+func (e PeerError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Peer, e.Err)
+}
+
+func (e PeerError) Unwrap() error {
+	return e.Err
+}
+
+// PushError is returned when every peer a push request was sent to failed
+type PushError struct {
+	Errors []PeerError
+}
+
+func (e *PushError) Error() string {
+	var errs []string
+	for _, err := range e.Errors {
+		errs = append(errs, err.Error())
+	}
+	return fmt.Sprintf("push request failed after %d attempts: %s", len(e.Errors), strings.Join(errs, "; "))
+}
+
+// Unwrap returns the error of the last attempt
+func (e *PushError) Unwrap() error {
+	return e.Errors[len(e.Errors)-1]
+}
+
+// request sends a push request to the selected peer. When it fails, the
+// request is sent to the other connected lightpush peers, up to the maximum
+// number of retries
+func (wakuLP *WakuLightPush) request(ctx context.Context, req *pb.PushRequest, opts ...LightPushOption) (*PushResult, error) {
+	params := new(LightPushParameters)
+	params.host = wakuLP.h
+	params.selector = wakuLP.selector
+
+	optList := DefaultOptions(wakuLP.h)
+	optList = append(optList, opts...)
+	for _, opt := range optList {
+		opt(params)
+	}
+
+	if params.selectedPeer == "" {
+		metrics.RecordLightpushError(wakuLP.ctx, "dialError")
+		return nil, ErrNoPeersAvailable
+	}
+
+	if len(params.requestId) == 0 {
+		return nil, ErrInvalidId
+	}
+
+	tried := make(map[peer.ID]bool)
+	var errs []PeerError
+	p := params.selectedPeer
+	for attempt := 0; attempt <= params.maxRetries; attempt++ {
+		if attempt > 0 {
+			p = wakuLP.nextPeer(tried)
+			if p == "" {
+				break
+			}
+		}
+		tried[p] = true
+
+		attemptCtx, cancel := attemptContext(ctx, params.maxRetries-attempt+1)
+		err := wakuLP.requestFromPeer(attemptCtx, p, req, params.requestId)
+		cancel()
+		if err == nil {
+			wakuLP.selector.RecordSuccess(p)
+			return &PushResult{Peer: p, Attempts: attempt + 1}, nil
+		}
+		wakuLP.selector.RecordFailure(p)
+
+		log.Info(fmt.Sprintf("push request to %s failed: %s", p, err))
+		errs = append(errs, PeerError{Peer: p, Err: err})
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, &PushError{Errors: errs}
+}
+
+// attemptContext returns the context of one of the remaining attempts of a
+// request. The time left before the deadline of the request is split evenly
+// between them
+func attemptContext(ctx context.Context, attemptsLeft int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithTimeout(ctx, DefaultAttemptTimeout)
+	}
+
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(attemptsLeft))
+}
+
+// nextPeer returns a connected lightpush peer that wasn't tried yet
+func (wakuLP *WakuLightPush) nextPeer(tried map[peer.ID]bool) peer.ID {
+	for _, p := range wakuLP.h.Network().Peers() {
+		if tried[p] {
+			continue
+		}
+
+		protocols, err := wakuLP.h.Peerstore().SupportsProtocols(p, string(LightPushID_v20beta1))
+		if err == nil && len(protocols) > 0 {
+			return p
+		}
+	}
+	return ""
+}
+
+func (wakuLP *WakuLightPush) requestFromPeer(ctx context.Context, p peer.ID, req *pb.PushRequest, requestId []byte) error {
+	connOpt, err := wakuLP.h.NewStream(ctx, p, LightPushID_v20beta1)
+	if err != nil {
+		log.Info("failed to connect to remote peer", err)
+		metrics.RecordLightpushError(wakuLP.ctx, "dialError")
+		return err
+	}
+
+	defer connOpt.Close()
+	defer func() {
+		err := connOpt.Reset()
+		if err != nil {
+			metrics.RecordLightpushError(wakuLP.ctx, "dialError")
+			log.Error("failed to reset connection", err)
+		}
+	}()
+
+	// Streams don't follow the context, so reading a response from
+	// an unresponsive peer must be bounded by its deadline
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = connOpt.SetDeadline(deadline)
+	}
+
+	pushRequestRPC := &pb.PushRPC{RequestId: hex.EncodeToString(requestId), Query: req}
+
+	writer := protoio.NewDelimitedWriter(connOpt)
+	reader := protoio.NewDelimitedReader(connOpt, math.MaxInt32)
+
+	err = writer.WriteMsg(pushRequestRPC)
+	if err != nil {
+		log.Error("could not write request", err)
+		return err
+	}
+
+	pushResponseRPC := &pb.PushRPC{}
+	err = reader.ReadMsg(pushResponseRPC)
+	if err != nil {
+		log.Error("could not read response", err)
+		metrics.RecordLightpushError(wakuLP.ctx, "decodeRPCFailure")
+		return err
+	}
+
+	if pushResponseRPC.Response == nil {
+		return errors.New("no push response received")
+	}
+
+	if !pushResponseRPC.Response.IsSuccess {
+		if pushResponseRPC.Response.Info == RateLimitExceeded {
+			return ErrRateLimitExceeded
+		}
+		return errors.New(pushResponseRPC.Response.Info)
+	}
+
+	return nil
+}
+
+func (wakuLP *WakuLightPush) Stop() {
+	wakuLP.h.RemoveStreamHandler(LightPushID_v20beta1)
+}
+
+// Push sends a message to a lightpush peer to be relayed on a pubsub topic.
+// The result includes the peer that accepted it and the number of attempts
+func (wakuLP *WakuLightPush) Push(ctx context.Context, message *pb.WakuMessage, topic string, opts ...LightPushOption) (*PushResult, error) {
+	if message == nil {
+		return nil, errors.New("message can't be null")
+	}
+
+	req := new(pb.PushRequest)
+	req.Message = message
+	req.PubsubTopic = topic
+
+	result, err := wakuLP.request(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Hash, _ = message.Hash()
+	return result, nil
+}
+
+func (wakuLP *WakuLightPush) PublishToTopic(ctx context.Context, message *pb.WakuMessage, topic string, opts ...LightPushOption) ([]byte, error) {
+	result, err := wakuLP.Push(ctx, message, topic, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Hash, nil
+}
+
+func (wakuLP *WakuLightPush) Publish(ctx context.Context, message *pb.WakuMessage, opts ...LightPushOption) ([]byte, error) {
+	return wakuLP.PublishToTopic(ctx, message, relay.DefaultWakuTopic, opts...)
+}
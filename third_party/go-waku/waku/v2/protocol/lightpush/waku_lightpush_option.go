@@ -0,0 +1,77 @@
+package lightpush
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+type LightPushParameters struct {
+	host         host.Host
+	selector     *utils.PeerSelector
+	selectedPeer peer.ID
+	requestId    []byte
+	maxRetries   int
+}
+
+type LightPushOption func(*LightPushParameters)
+
+func WithPeer(p peer.ID) LightPushOption {
+	return func(params *LightPushParameters) {
+		params.selectedPeer = p
+	}
+}
+
+// WithAutomaticPeerSelection selects a lightpush peer, randomly unless the
+// protocol has a PeerSelector
+func WithAutomaticPeerSelection(host host.Host) LightPushOption {
+	return func(params *LightPushParameters) {
+		p, err := params.selector.SelectPeer(host, string(LightPushID_v20beta1))
+		if err == nil {
+			params.selectedPeer = *p
+		} else {
+			log.Info("Error selecting peer: ", err)
+		}
+	}
+}
+
+func WithFastestPeerSelection(ctx context.Context) LightPushOption {
+	return func(params *LightPushParameters) {
+		p, err := utils.SelectPeerWithLowestRTT(ctx, params.host, string(LightPushID_v20beta1))
+		if err == nil {
+			params.selectedPeer = *p
+		} else {
+			log.Info("Error selecting peer: ", err)
+		}
+	}
+}
+
+// WithMaxRetries sends the request to up to n other connected lightpush peers
+// when the selected one fails or rejects it
+func WithMaxRetries(n int) LightPushOption {
+	return func(params *LightPushParameters) {
+		params.maxRetries = n
+	}
+}
+
+func WithRequestId(requestId []byte) LightPushOption {
+	return func(params *LightPushParameters) {
+		params.requestId = requestId
+	}
+}
+
+func WithAutomaticRequestId() LightPushOption {
+	return func(params *LightPushParameters) {
+		params.requestId = protocol.GenerateRequestId()
+	}
+}
+
+func DefaultOptions(host host.Host) []LightPushOption {
+	return []LightPushOption{
+		WithAutomaticRequestId(),
+		WithAutomaticPeerSelection(host),
+	}
+}
@@ -0,0 +1,323 @@
+package peer_exchange
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	libp2pProtocol "github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-msgio/protoio"
+	"go.opencensus.io/stats"
+
+	"github.com/status-im/go-waku/waku/v2/discv5"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+var log = logging.Logger("waku_peer_exchange")
+
+const PeerExchangeID_v20alpha1 = libp2pProtocol.ID("/vac/waku/peer-exchange/2.0.0-alpha1")
+
+// MaxCacheSize is the number of discv5 records kept to answer requests
+const MaxCacheSize = 1000
+
+// MaxResponsePeers is the maximum number of peers sent in a response
+const MaxResponsePeers = 100
+
+var (
+	ErrNoPeersAvailable = errors.New("no suitable remote peers")
+	ErrNoDiscV5         = errors.New("discv5 is required to serve peer exchange requests")
+)
+
+type WakuPeerExchange struct {
+	h    host.Host
+	disc *discv5.DiscoveryV5
+	ctx  context.Context
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	enrCacheMutex sync.RWMutex
+	enrCache      map[enode.ID]*enode.Node
+	rng           *rand.Rand
+}
+
+// NewWakuPeerExchange returns a new instance of WakuPeerExchange. The discovery
+// instance is used to collect the records served to other peers. It can be nil
+// if the node only acts as a client
+func NewWakuPeerExchange(ctx context.Context, h host.Host, disc *discv5.DiscoveryV5) *WakuPeerExchange {
+	wakuPX := new(WakuPeerExchange)
+	wakuPX.ctx = ctx
+	wakuPX.h = h
+	wakuPX.disc = disc
+	wakuPX.enrCache = make(map[enode.ID]*enode.Node)
+	wakuPX.rng = rand.New(rand.NewSource(rand.Int63())) // nolint: gosec
+	return wakuPX
+}
+
+// Start collects the records found by discv5 and sets the handler
+// that answers the requests of other peers
+func (wakuPX *WakuPeerExchange) Start() error {
+	if wakuPX.disc == nil {
+		return ErrNoDiscV5
+	}
+
+	wakuPX.quit = make(chan struct{})
+
+	wakuPX.h.SetStreamHandler(PeerExchangeID_v20alpha1, wakuPX.onRequest)
+
+	wakuPX.wg.Add(1)
+	go wakuPX.runPeerExchangeDiscv5Loop()
+
+	log.Info("Peer exchange protocol started")
+	return nil
+}
+
+func (wakuPX *WakuPeerExchange) onRequest(s network.Stream) {
+	defer s.Close()
+
+	requestRPC := &pb.PeerExchangeRPC{}
+	reader := protoio.NewDelimitedReader(s, math.MaxInt32)
+	err := reader.ReadMsg(requestRPC)
+	if err != nil {
+		log.Error("error reading request", err)
+		return
+	}
+
+	if requestRPC.Query != nil {
+		log.Info(fmt.Sprintf("%s: peer exchange request received from %s", s.Conn().LocalPeer(), s.Conn().RemotePeer()))
+
+		records, err := wakuPX.getENRsFromCache(requestRPC.Query.NumPeers, discv5.WakuEnrBitfield(requestRPC.Query.WakuFlags))
+		if err != nil {
+			log.Error("error obtaining peers from cache", err)
+			_ = s.Reset()
+			return
+		}
+
+		responseRPC := &pb.PeerExchangeRPC{}
+		responseRPC.Response = new(pb.PeerExchangeResponse)
+		responseRPC.Response.PeerInfos = records
+
+		writer := protoio.NewDelimitedWriter(s)
+		err = writer.WriteMsg(responseRPC)
+		if err != nil {
+			log.Error("error writing response", err)
+			_ = s.Reset()
+			return
+		}
+
+		stats.Record(wakuPX.ctx, metrics.PeerExchangeServed.M(1))
+	}
+}
+
+// Request asks a peer for the records of up to numPeers waku nodes
+func (wakuPX *WakuPeerExchange) Request(ctx context.Context, numPeers int, opts ...PeerExchangeOption) ([]*enode.Node, error) {
+	params := new(PeerExchangeParameters)
+	params.host = wakuPX.h
+
+	optList := DefaultOptions(wakuPX.h)
+	optList = append(optList, opts...)
+	for _, opt := range optList {
+		opt(params)
+	}
+
+	if params.selectedPeer == "" {
+		return nil, ErrNoPeersAvailable
+	}
+
+	connOpt, err := wakuPX.h.NewStream(ctx, params.selectedPeer, PeerExchangeID_v20alpha1)
+	if err != nil {
+		log.Info("failed to connect to remote peer", err)
+		return nil, err
+	}
+	defer connOpt.Close()
+
+	requestRPC := &pb.PeerExchangeRPC{
+		Query: &pb.PeerExchangeQuery{
+			NumPeers:  uint64(numPeers),
+			WakuFlags: uint32(params.wakuFlags),
+		},
+	}
+
+	writer := protoio.NewDelimitedWriter(connOpt)
+	err = writer.WriteMsg(requestRPC)
+	if err != nil {
+		log.Error("could not write request", err)
+		_ = connOpt.Reset()
+		return nil, err
+	}
+
+	responseRPC := &pb.PeerExchangeRPC{}
+	reader := protoio.NewDelimitedReader(connOpt, math.MaxInt32)
+	err = reader.ReadMsg(responseRPC)
+	if err != nil {
+		log.Error("could not read response", err)
+		_ = connOpt.Reset()
+		return nil, err
+	}
+
+	if responseRPC.Response == nil {
+		return nil, errors.New("empty peer exchange response")
+	}
+
+	var nodes []*enode.Node
+	for _, p := range responseRPC.Response.PeerInfos {
+		var record enr.Record
+		if err := rlp.Decode(bytes.NewBuffer(p.Enr), &record); err != nil {
+			log.Debug("could not decode ENR", err)
+			continue
+		}
+
+		node, err := enode.New(enode.ValidSchemes, &record)
+		if err != nil {
+			log.Debug("invalid ENR", err)
+			continue
+		}
+
+		if !hasWakuFlags(node, params.wakuFlags) {
+			continue
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	stats.Record(wakuPX.ctx, metrics.PeerExchangePeers.M(int64(len(nodes))))
+
+	return nodes, nil
+}
+
+// Stop unmounts the peer exchange protocol
+func (wakuPX *WakuPeerExchange) Stop() {
+	if wakuPX.quit == nil {
+		return
+	}
+
+	wakuPX.h.RemoveStreamHandler(PeerExchangeID_v20alpha1)
+	close(wakuPX.quit)
+	wakuPX.wg.Wait()
+}
+
+func hasWakuFlags(node *enode.Node, flags discv5.WakuEnrBitfield) bool {
+	if flags == 0 {
+		return true
+	}
+
+	nodeFlags, err := discv5.WakuEnrBitfieldFromNode(node)
+	if err != nil {
+		return false
+	}
+
+	return nodeFlags&flags == flags
+}
+
+func (wakuPX *WakuPeerExchange) getENRsFromCache(numPeers uint64, flags discv5.WakuEnrBitfield) ([]*pb.PeerInfo, error) {
+	wakuPX.enrCacheMutex.RLock()
+	defer wakuPX.enrCacheMutex.RUnlock()
+
+	var candidates []*enode.Node
+	for _, node := range wakuPX.enrCache {
+		if hasWakuFlags(node, flags) {
+			candidates = append(candidates, node)
+		}
+	}
+
+	if numPeers > MaxResponsePeers {
+		numPeers = MaxResponsePeers
+	}
+
+	if uint64(len(candidates)) > numPeers {
+		wakuPX.rng.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+		candidates = candidates[:numPeers]
+	}
+
+	var result []*pb.PeerInfo
+	for _, node := range candidates {
+		var b bytes.Buffer
+		if err := node.Record().EncodeRLP(&b); err != nil {
+			return nil, err
+		}
+		result = append(result, &pb.PeerInfo{Enr: b.Bytes()})
+	}
+
+	return result, nil
+}
+
+func (wakuPX *WakuPeerExchange) cleanCache() {
+	wakuPX.enrCacheMutex.Lock()
+	defer wakuPX.enrCacheMutex.Unlock()
+
+	for id := range wakuPX.enrCache {
+		if len(wakuPX.enrCache) <= MaxCacheSize {
+			return
+		}
+		delete(wakuPX.enrCache, id)
+	}
+}
+
+func (wakuPX *WakuPeerExchange) iterate(iterator enode.Iterator) {
+	for iterator.Next() {
+		node := iterator.Node()
+
+		wakuPX.enrCacheMutex.Lock()
+		wakuPX.enrCache[node.ID()] = node
+		wakuPX.enrCacheMutex.Unlock()
+
+		select {
+		case <-wakuPX.quit:
+			return
+		default:
+		}
+	}
+}
+
+func (wakuPX *WakuPeerExchange) runPeerExchangeDiscv5Loop() {
+	defer wakuPX.wg.Done()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-wakuPX.quit:
+			return
+		case <-ticker.C:
+		}
+
+		// The discv5 listener is only available once discovery is started
+		iterator, err := wakuPX.disc.Iterator()
+		if err != nil {
+			continue
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			wakuPX.iterate(iterator)
+		}()
+
+		select {
+		case <-wakuPX.quit:
+			iterator.Close()
+			<-done
+			return
+		case <-time.After(time.Minute):
+			iterator.Close()
+			<-done
+		}
+
+		wakuPX.cleanCache()
+	}
+}
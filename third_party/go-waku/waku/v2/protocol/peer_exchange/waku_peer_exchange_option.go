@@ -0,0 +1,47 @@
+package peer_exchange
+
+import (
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/discv5"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+type PeerExchangeParameters struct {
+	host         host.Host
+	selectedPeer peer.ID
+	wakuFlags    discv5.WakuEnrBitfield
+}
+
+type PeerExchangeOption func(*PeerExchangeParameters)
+
+func WithPeer(p peer.ID) PeerExchangeOption {
+	return func(params *PeerExchangeParameters) {
+		params.selectedPeer = p
+	}
+}
+
+func WithAutomaticPeerSelection(host host.Host) PeerExchangeOption {
+	return func(params *PeerExchangeParameters) {
+		p, err := utils.SelectPeer(host, string(PeerExchangeID_v20alpha1))
+		if err == nil {
+			params.selectedPeer = *p
+		} else {
+			log.Info("Error selecting peer: ", err)
+		}
+	}
+}
+
+// WithWakuFlags is used to request only peers that support all the
+// capabilities in the bitfield (i.e. only store nodes)
+func WithWakuFlags(flags discv5.WakuEnrBitfield) PeerExchangeOption {
+	return func(params *PeerExchangeParameters) {
+		params.wakuFlags = flags
+	}
+}
+
+func DefaultOptions(host host.Host) []PeerExchangeOption {
+	return []PeerExchangeOption{
+		WithAutomaticPeerSelection(host),
+	}
+}
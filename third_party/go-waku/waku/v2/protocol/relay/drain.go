@@ -0,0 +1,133 @@
+package relay
+
+import (
+	"context"
+	"sync"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// localMessages are the messages published by the node on the topics it's
+// subscribed to, which pubsub didn't deliver back yet. Pubsub delivers them
+// asynchronously, so they would be lost if the subscriptions were cancelled
+// right after publishing. It traces the messages pubsub drops instead of
+// delivering back, so their delivery isn't awaited
+type localMessages struct {
+	noopTracer
+	sync.Mutex
+	// Message IDs by topic
+	pending map[string]map[string]struct{}
+	// Closed once no message is pending, replaced when one is added
+	empty chan struct{}
+}
+
+var _ pubsub.RawTracer = (*localMessages)(nil)
+
+func newLocalMessages() *localMessages {
+	empty := make(chan struct{})
+	close(empty)
+	return &localMessages{pending: make(map[string]map[string]struct{}), empty: empty}
+}
+
+func (l *localMessages) add(topic string, id string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if len(l.pending) == 0 {
+		l.empty = make(chan struct{})
+	}
+	if _, ok := l.pending[topic]; !ok {
+		l.pending[topic] = make(map[string]struct{})
+	}
+	l.pending[topic][id] = struct{}{}
+}
+
+func (l *localMessages) remove(topic string, id string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if _, ok := l.pending[topic][id]; !ok {
+		return
+	}
+
+	delete(l.pending[topic], id)
+	if len(l.pending[topic]) == 0 {
+		l.forgetTopic(topic)
+	}
+}
+
+// forget stops waiting for the messages of a topic that was left
+func (l *localMessages) forget(topic string) {
+	l.Lock()
+	defer l.Unlock()
+
+	if _, ok := l.pending[topic]; ok {
+		l.forgetTopic(topic)
+	}
+}
+
+func (l *localMessages) forgetTopic(topic string) {
+	delete(l.pending, topic)
+	if len(l.pending) == 0 {
+		close(l.empty)
+	}
+}
+
+// DuplicateMessage is traced when the message was already published
+func (l *localMessages) DuplicateMessage(msg *pubsub.Message) {
+	l.remove(msg.GetTopic(), string(pb.Hash(msg.Data)))
+}
+
+// UndeliverableMessage is traced when the subscription falls behind
+func (l *localMessages) UndeliverableMessage(msg *pubsub.Message) {
+	l.remove(msg.GetTopic(), string(pb.Hash(msg.Data)))
+}
+
+// wait returns a channel closed once no message is pending
+func (l *localMessages) wait() <-chan struct{} {
+	l.Lock()
+	defer l.Unlock()
+	return l.empty
+}
+
+// StopReceiving closes the subscriptions and leaves all the topics, so no more
+// envelopes are submitted to the broadcaster. It waits first for the messages
+// published by the node to be delivered back, and then for the envelopes
+// already received to be submitted, unless ctx is done before
+func (w *WakuRelay) StopReceiving(ctx context.Context) error {
+	select {
+	case <-w.local.wait():
+	case <-ctx.Done():
+		log.Warn("stopped waiting for the messages published by the node: ", ctx.Err())
+	}
+
+	w.closeSubscriptions()
+
+	done := make(chan struct{})
+	go func() {
+		w.receiving.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeSubscriptions closes all the subscriptions, which leaves the topics
+func (w *WakuRelay) closeSubscriptions() {
+	w.subscriptionsMutex.Lock()
+	var subscriptions []*Subscription
+	for _, subs := range w.subscriptions {
+		subscriptions = append(subscriptions, subs...)
+	}
+	w.subscriptionsMutex.Unlock()
+
+	for _, sub := range subscriptions {
+		sub.Unsubscribe()
+	}
+}
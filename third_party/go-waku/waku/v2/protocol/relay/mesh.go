@@ -0,0 +1,96 @@
+package relay
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// meshTracer keeps the gossipsub mesh of each topic, which pubsub doesn't
+// expose, from the grafts and prunes. Tracers are invoked synchronously by
+// pubsub, so it only updates a map
+type meshTracer struct {
+	noopTracer
+	sync.Mutex
+	mesh map[string]map[peer.ID]struct{}
+}
+
+var _ pubsub.RawTracer = (*meshTracer)(nil)
+
+func newMeshTracer() *meshTracer {
+	return &meshTracer{mesh: make(map[string]map[peer.ID]struct{})}
+}
+
+func (t *meshTracer) peers(topic string) []peer.ID {
+	t.Lock()
+	defer t.Unlock()
+
+	result := make(peer.IDSlice, 0, len(t.mesh[topic]))
+	for p := range t.mesh[topic] {
+		result = append(result, p)
+	}
+	sort.Sort(result)
+	return result
+}
+
+func (t *meshTracer) Graft(p peer.ID, topic string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.mesh[topic]; !ok {
+		t.mesh[topic] = make(map[peer.ID]struct{})
+	}
+	t.mesh[topic][p] = struct{}{}
+}
+
+func (t *meshTracer) Prune(p peer.ID, topic string) {
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.mesh[topic], p)
+}
+
+func (t *meshTracer) RemovePeer(p peer.ID) {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, peers := range t.mesh {
+		delete(peers, p)
+	}
+}
+
+func (t *meshTracer) Leave(topic string) {
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.mesh, topic)
+}
+
+// noopTracer implements the pubsub.RawTracer methods not used by a tracer
+type noopTracer struct{}
+
+func (noopTracer) AddPeer(p peer.ID, proto protocol.ID)             {}
+func (noopTracer) RemovePeer(p peer.ID)                             {}
+func (noopTracer) Join(topic string)                                {}
+func (noopTracer) Leave(topic string)                               {}
+func (noopTracer) Graft(p peer.ID, topic string)                    {}
+func (noopTracer) Prune(p peer.ID, topic string)                    {}
+func (noopTracer) ValidateMessage(msg *pubsub.Message)              {}
+func (noopTracer) DeliverMessage(msg *pubsub.Message)               {}
+func (noopTracer) RejectMessage(msg *pubsub.Message, reason string) {}
+func (noopTracer) DuplicateMessage(msg *pubsub.Message)             {}
+func (noopTracer) ThrottlePeer(p peer.ID)                           {}
+func (noopTracer) RecvRPC(rpc *pubsub.RPC)                          {}
+func (noopTracer) SendRPC(rpc *pubsub.RPC, p peer.ID)               {}
+func (noopTracer) DropRPC(rpc *pubsub.RPC, p peer.ID)               {}
+func (noopTracer) UndeliverableMessage(msg *pubsub.Message)         {}
+
+// MeshPeers returns the peers of the gossipsub mesh of a topic, the ones
+// messages are forwarded to. Other peers subscribed to the topic, returned
+// by PeersForTopic, only receive gossip about them
+func (w *WakuRelay) MeshPeers(topic string) []peer.ID {
+	return w.mesh.peers(topic)
+}
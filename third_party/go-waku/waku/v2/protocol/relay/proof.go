@@ -0,0 +1,47 @@
+package relay
+
+import (
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// ProofGenerator returns the proof of a message published by the node, i.e.
+// the proof of the topics protected from spam by a static group
+type ProofGenerator func(msg *pb.WakuMessage) ([]byte, error)
+
+// SetProofGenerator sets the proof field of the messages published on a topic
+// with the one returned by fn. Publishing fails if fn returns an error. The
+// messages which already have a proof, i.e. the ones pushed by light nodes,
+// are published as they are. The proof is generated before the message is
+// signed, if the topic is protected
+func (w *WakuRelay) SetProofGenerator(topic string, fn ProofGenerator) {
+	w.proofGeneratorsMutex.Lock()
+	defer w.proofGeneratorsMutex.Unlock()
+
+	if w.proofGenerators == nil {
+		w.proofGenerators = make(map[string]ProofGenerator)
+	}
+
+	if fn == nil {
+		delete(w.proofGenerators, topic)
+		return
+	}
+	w.proofGenerators[topic] = fn
+}
+
+func (w *WakuRelay) proofGenerator(topic string) ProofGenerator {
+	w.proofGeneratorsMutex.RLock()
+	defer w.proofGeneratorsMutex.RUnlock()
+	return w.proofGenerators[topic]
+}
+
+// addProof returns a copy of the message with the proof generated by fn
+func addProof(msg *pb.WakuMessage, fn ProofGenerator) (*pb.WakuMessage, error) {
+	proof, err := fn(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := *msg
+	result.Proof = proof
+	return &result, nil
+}
@@ -0,0 +1,103 @@
+package relay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// ErrNoTopicKey is returned when a protected topic is registered without key
+var ErrNoTopicKey = errors.New("a key is required to protect a topic")
+
+// AddProtectedTopic only accepts the messages of a topic signed by the
+// private key matching pubKey. The signature is carried in the meta field of
+// the messages
+func (w *WakuRelay) AddProtectedTopic(topic string, pubKey *ecdsa.PublicKey) error {
+	if pubKey == nil {
+		return ErrNoTopicKey
+	}
+
+	return w.AddValidator(topic, newSignatureValidator(topic, pubKey))
+}
+
+// AddSigningKey signs the messages published on a topic with privKey. The
+// topic is also protected, so only the messages signed with the same key
+// are accepted on it
+func (w *WakuRelay) AddSigningKey(topic string, privKey *ecdsa.PrivateKey) error {
+	if privKey == nil {
+		return ErrNoTopicKey
+	}
+
+	w.signingKeysMutex.Lock()
+	if w.signingKeys == nil {
+		w.signingKeys = make(map[string]*ecdsa.PrivateKey)
+	}
+	w.signingKeys[topic] = privKey
+	w.signingKeysMutex.Unlock()
+
+	return w.AddProtectedTopic(topic, &privKey.PublicKey)
+}
+
+func (w *WakuRelay) signingKey(topic string) *ecdsa.PrivateKey {
+	w.signingKeysMutex.RLock()
+	defer w.signingKeysMutex.RUnlock()
+	return w.signingKeys[topic]
+}
+
+// signMessage returns a copy of the message with its signature set in meta
+func signMessage(topic string, msg *pb.WakuMessage, privKey *ecdsa.PrivateKey) (*pb.WakuMessage, error) {
+	signature, err := crypto.Sign(signatureHash(topic, msg), privKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := *msg
+	signed.Meta = signature
+	return &signed, nil
+}
+
+func newSignatureValidator(topic string, pubKey *ecdsa.PublicKey) Validator {
+	publicKey := crypto.FromECDSAPub(pubKey)
+
+	return func(ctx context.Context, msg *pb.WakuMessage, peerID peer.ID) bool {
+		// The last byte of the signature is the recovery id, which isn't
+		// needed since the key is known
+		if len(msg.Meta) != crypto.SignatureLength {
+			log.Debug("rejecting unsigned message on protected topic ", topic, " from ", peerID)
+			return false
+		}
+
+		if !crypto.VerifySignature(publicKey, signatureHash(topic, msg), msg.Meta[:crypto.SignatureLength-1]) {
+			log.Debug("rejecting message with invalid signature on protected topic ", topic, " from ", peerID)
+			return false
+		}
+
+		return true
+	}
+}
+
+// signatureHash is the hash signed for protected topics. It covers the
+// topic, so a message can't be replayed on a topic with the same key, and
+// every field of the message but meta and proof. Variable length fields are
+// hashed first so they can't be shifted into each other
+func signatureHash(topic string, msg *pb.WakuMessage) []byte {
+	var version [4]byte
+	binary.BigEndian.PutUint32(version[:], msg.Version)
+
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], math.Float64bits(msg.Timestamp))
+
+	return crypto.Keccak256(
+		crypto.Keccak256([]byte(topic)),
+		crypto.Keccak256(msg.Payload),
+		crypto.Keccak256([]byte(msg.ContentTopic)),
+		version[:],
+		timestamp[:],
+	)
+}
@@ -0,0 +1,144 @@
+package relay
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultSeenMessagesMaxSize is the number of message IDs kept by the seen
+// messages cache unless specified otherwise
+const DefaultSeenMessagesMaxSize = 100000
+
+// SeenMessagesStats describes the seen messages cache
+type SeenMessagesStats struct {
+	// Zero if the cache is disabled
+	TTL     time.Duration
+	MaxSize int
+	// Number of message IDs in the cache
+	Size int
+	// Number of messages found in the cache, which were ignored, and not found
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRate returns the ratio of the messages found in the cache
+func (s SeenMessagesStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type seenMessage struct {
+	id      string
+	expires time.Time
+}
+
+// seenMessages keeps the IDs of the messages accepted by the relay during a
+// TTL, up to a maximum number of them. Since the TTL is the same for all the
+// messages, the oldest ones are at the front of the list
+type seenMessages struct {
+	sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	order   *list.List
+	ids     map[string]*list.Element
+
+	hits   uint64
+	misses uint64
+}
+
+func newSeenMessages(ttl time.Duration, maxSize int) *seenMessages {
+	return &seenMessages{
+		ttl:     ttl,
+		maxSize: maxSize,
+		order:   list.New(),
+		ids:     make(map[string]*list.Element),
+	}
+}
+
+// add returns false if the message was already seen, otherwise it's added
+func (s *seenMessages) add(id string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	s.expire(now)
+
+	if _, ok := s.ids[id]; ok {
+		s.hits++
+		return false
+	}
+	s.misses++
+
+	s.ids[id] = s.order.PushBack(&seenMessage{id: id, expires: now.Add(s.ttl)})
+	for s.order.Len() > s.maxSize {
+		s.remove(s.order.Front())
+	}
+
+	return true
+}
+
+func (s *seenMessages) expire(now time.Time) {
+	for e := s.order.Front(); e != nil && !now.Before(e.Value.(*seenMessage).expires); e = s.order.Front() {
+		s.remove(e)
+	}
+}
+
+func (s *seenMessages) remove(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.ids, e.Value.(*seenMessage).id)
+}
+
+func (s *seenMessages) stats() SeenMessagesStats {
+	s.Lock()
+	defer s.Unlock()
+
+	s.expire(time.Now())
+	return SeenMessagesStats{
+		TTL:     s.ttl,
+		MaxSize: s.maxSize,
+		Size:    s.order.Len(),
+		Hits:    s.hits,
+		Misses:  s.misses,
+	}
+}
+
+// SetSeenMessagesCache makes the relay ignore the messages received again
+// within ttl, instead of only during the pubsub.TimeCacheDuration of the
+// gossipsub cache, which still applies. Ignored messages are neither
+// delivered nor forwarded, and the peers sending them are not penalized. At
+// most maxSize message IDs are kept, the oldest ones being forgotten first.
+// The messages published by the node are always accepted
+func (w *WakuRelay) SetSeenMessagesCache(ttl time.Duration, maxSize int) error {
+	if ttl <= 0 || maxSize <= 0 {
+		return errors.New("the TTL and the maximum size of the seen messages cache must be positive")
+	}
+
+	w.validators.Lock()
+	w.validators.seen = newSeenMessages(ttl, maxSize)
+	w.validators.Unlock()
+
+	for _, topic := range w.joinedTopics() {
+		if err := w.registerValidator(topic); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SeenMessagesStats describes the seen messages cache. It's empty unless
+// it was set SetSeenMessagesCache
+func (w *WakuRelay) SeenMessagesStats() SeenMessagesStats {
+	w.validators.Lock()
+	seen := w.validators.seen
+	w.validators.Unlock()
+
+	if seen == nil {
+		return SeenMessagesStats{}
+	}
+	return seen.stats()
+}
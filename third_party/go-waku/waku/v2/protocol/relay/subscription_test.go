@@ -0,0 +1,21 @@
+package relay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscription(t *testing.T) {
+	unsubscribed := false
+	e := newSubscription(func() { unsubscribed = true })
+	e.Unsubscribe()
+	require.True(t, e.closed)
+	require.True(t, unsubscribed)
+
+	_, ok := <-e.C
+	require.False(t, ok)
+
+	// Unsubscribing again is a no-op
+	e.Unsubscribe()
+}
@@ -0,0 +1,163 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	proto "github.com/golang/protobuf/proto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+const (
+	DefaultMaxPayloadSize   = 1024 * 1024
+	DefaultMaxTimestampSkew = 20 * time.Second
+)
+
+// Validator decides whether a message received from a peer (or published by
+// this node) is accepted. Rejected messages are neither delivered nor forwarded
+type Validator func(ctx context.Context, msg *pb.WakuMessage, peerID peer.ID) bool
+
+// NewDefaultValidator returns a validator rejecting the messages without a
+// content topic, with a payload bigger than maxPayloadSize, or with a timestamp
+// deviating more than maxTimestampSkew from the local clock. Messages without
+// timestamp are accepted. Zero values use DefaultMaxPayloadSize and
+// DefaultMaxTimestampSkew
+func NewDefaultValidator(maxPayloadSize int, maxTimestampSkew time.Duration) Validator {
+	if maxPayloadSize == 0 {
+		maxPayloadSize = DefaultMaxPayloadSize
+	}
+
+	if maxTimestampSkew == 0 {
+		maxTimestampSkew = DefaultMaxTimestampSkew
+	}
+
+	return func(ctx context.Context, msg *pb.WakuMessage, peerID peer.ID) bool {
+		if msg.ContentTopic == "" {
+			log.Debug("rejecting message without content topic from ", peerID)
+			return false
+		}
+
+		if len(msg.Payload) > maxPayloadSize {
+			log.Debug(fmt.Sprintf("rejecting message with a payload of %d bytes from %s", len(msg.Payload), peerID))
+			return false
+		}
+
+		if msg.Timestamp != 0 && math.Abs(utils.GetUnixEpoch()-msg.Timestamp) > maxTimestampSkew.Seconds() {
+			log.Debug(fmt.Sprintf("rejecting message with timestamp %f from %s", msg.Timestamp, peerID))
+			return false
+		}
+
+		return true
+	}
+}
+
+type validators struct {
+	sync.Mutex
+	defaults []Validator
+	byTopic  map[string][]Validator
+	// Topics whose gossipsub validator was registered
+	registered map[string]bool
+	// Messages accepted recently, nil unless SetSeenMessagesCache
+	seen *seenMessages
+}
+
+func (v *validators) forTopic(topic string) ([]Validator, *seenMessages) {
+	v.Lock()
+	defer v.Unlock()
+
+	result := append([]Validator(nil), v.defaults...)
+	return append(result, v.byTopic[topic]...), v.seen
+}
+
+// AddValidator registers a validator for the messages of a topic. The
+// validators run in the order they were added, after the default ones
+func (w *WakuRelay) AddValidator(topic string, fn Validator) error {
+	w.validators.Lock()
+	if w.validators.byTopic == nil {
+		w.validators.byTopic = make(map[string][]Validator)
+	}
+	w.validators.byTopic[topic] = append(w.validators.byTopic[topic], fn)
+	w.validators.Unlock()
+
+	return w.registerValidator(topic)
+}
+
+// AddDefaultValidator registers a validator for the messages of every topic,
+// the ones already joined and the ones joined later
+func (w *WakuRelay) AddDefaultValidator(fn Validator) error {
+	w.validators.Lock()
+	w.validators.defaults = append(w.validators.defaults, fn)
+	w.validators.Unlock()
+
+	for _, topic := range w.joinedTopics() {
+		if err := w.registerValidator(topic); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *WakuRelay) hasValidators(topic string) bool {
+	w.validators.Lock()
+	defer w.validators.Unlock()
+	return len(w.validators.defaults) != 0 || len(w.validators.byTopic[topic]) != 0 || w.validators.seen != nil
+}
+
+// registerValidator registers the gossipsub validator of a topic, which
+// runs all the validators for it. Gossipsub only allows one for each topic
+func (w *WakuRelay) registerValidator(topic string) error {
+	w.validators.Lock()
+	defer w.validators.Unlock()
+
+	if w.validators.registered[topic] {
+		return nil
+	}
+
+	if err := w.pubsub.RegisterTopicValidator(topic, w.validate(topic)); err != nil {
+		return err
+	}
+
+	if w.validators.registered == nil {
+		w.validators.registered = make(map[string]bool)
+	}
+	w.validators.registered[topic] = true
+
+	return nil
+}
+
+// validate returns the gossipsub validator of a topic. Messages already seen
+// are ignored rather than rejected, since forwarding them is not the fault
+// of the peer
+func (w *WakuRelay) validate(topic string) pubsub.ValidatorEx {
+	return func(ctx context.Context, peerID peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		wakuMessage := &pb.WakuMessage{}
+		if err := proto.Unmarshal(msg.Data, wakuMessage); err != nil {
+			log.Debug("rejecting message that could not be decoded from ", peerID)
+			metrics.RecordRejectedMessage(ctx, topic)
+			return pubsub.ValidationReject
+		}
+
+		validators, seen := w.validators.forTopic(topic)
+		for _, fn := range validators {
+			if !fn(ctx, wakuMessage, peerID) {
+				metrics.RecordRejectedMessage(ctx, topic)
+				return pubsub.ValidationReject
+			}
+		}
+
+		if seen != nil && !seen.add(msgIdFn(msg.Message)) && peerID != w.host.ID() {
+			log.Debug("ignoring message already seen from ", peerID)
+			return pubsub.ValidationIgnore
+		}
+
+		return pubsub.ValidationAccept
+	}
+}
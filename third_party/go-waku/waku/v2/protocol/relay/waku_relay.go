@@ -0,0 +1,513 @@
+package relay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	proto "github.com/golang/protobuf/proto"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsub_pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	v2 "github.com/status-im/go-waku/waku/v2"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	waku_proto "github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+var log = logging.Logger("wakurelay")
+
+// Number of messages pubsub buffers for each topic. Once full, pubsub drops
+// the messages of the topic
+const subscriptionBufferSize = 1024
+
+const WakuRelayID_v200 = protocol.ID("/vac/waku/relay/2.0.0")
+
+var DefaultWakuTopic string = waku_proto.DefaultPubsubTopic().String()
+
+// ErrNotEnoughPeers is returned when publishing on a topic with fewer peers
+// than required SetMinPeersToPublish
+var ErrNotEnoughPeers = errors.New("not enough relay peers to publish")
+
+// EvtRelayPeersChanged is emitted on the host event bus when a peer joins or
+// leaves a topic joined by the node
+type EvtRelayPeersChanged struct {
+	Topic  string
+	Peer   peer.ID
+	Joined bool
+}
+
+type topicEvents struct {
+	handler *pubsub.TopicEventHandler
+	cancel  context.CancelFunc
+}
+
+type WakuRelay struct {
+	host   host.Host
+	pubsub *pubsub.PubSub
+
+	bcaster v2.Broadcaster
+	// Whether the broadcaster was created by the relay, since none was received
+	ownBcaster bool
+
+	// TODO: convert to concurrent maps
+	topicsMutex     sync.Mutex
+	wakuRelayTopics map[string]*pubsub.Topic
+	relaySubs       map[string]*pubsub.Subscription
+	topicEvents     map[string]topicEvents
+
+	peersEmitter event.Emitter
+
+	// TODO: convert to concurrent maps
+	subscriptions      map[string][]*Subscription
+	subscriptionsMutex sync.Mutex
+
+	validators validators
+
+	// Keys used to sign the messages published on protected topics
+	signingKeys      map[string]*ecdsa.PrivateKey
+	signingKeysMutex sync.RWMutex
+
+	// Generate the proofs of the messages published on some topics
+	proofGenerators      map[string]ProofGenerator
+	proofGeneratorsMutex sync.RWMutex
+
+	minPeersToPublish int
+
+	mesh *meshTracer
+
+	local *localMessages
+	// Counts the goroutines submitting the envelopes of each topic
+	receiving sync.WaitGroup
+}
+
+// Once https://github.com/status-im/nim-waku/issues/420 is fixed, implement a custom messageIdFn
+func msgIdFn(pmsg *pubsub_pb.Message) string {
+	hash := sha256.Sum256(pmsg.Data)
+	return string(hash[:])
+}
+
+func NewWakuRelay(ctx context.Context, h host.Host, bcaster v2.Broadcaster, opts ...pubsub.Option) (*WakuRelay, error) {
+	w := new(WakuRelay)
+	w.host = h
+	w.wakuRelayTopics = make(map[string]*pubsub.Topic)
+	w.relaySubs = make(map[string]*pubsub.Subscription)
+	w.topicEvents = make(map[string]topicEvents)
+	w.subscriptions = make(map[string][]*Subscription)
+	w.mesh = newMeshTracer()
+	w.local = newLocalMessages()
+	w.bcaster = bcaster
+	if w.bcaster == nil {
+		w.bcaster = v2.NewBroadcaster(1024)
+		w.ownBcaster = true
+	}
+
+	// default options required by WakuRelay
+	opts = append(opts, pubsub.WithMessageSignaturePolicy(pubsub.StrictNoSign))
+	opts = append(opts, pubsub.WithNoAuthor())
+	opts = append(opts, pubsub.WithMessageIdFn(msgIdFn))
+	opts = append(opts, pubsub.WithRawTracer(w.mesh))
+	opts = append(opts, pubsub.WithRawTracer(w.local))
+
+	opts = append(opts, pubsub.WithGossipSubProtocols(
+		[]protocol.ID{pubsub.GossipSubID_v11, pubsub.GossipSubID_v10, pubsub.FloodSubID, WakuRelayID_v200},
+		func(feat pubsub.GossipSubFeature, proto protocol.ID) bool {
+			switch feat {
+			case pubsub.GossipSubFeatureMesh:
+				return proto == pubsub.GossipSubID_v11 || proto == pubsub.GossipSubID_v10
+			case pubsub.GossipSubFeaturePX:
+				return proto == pubsub.GossipSubID_v11
+			default:
+				return false
+			}
+		},
+	))
+
+	ps, err := pubsub.NewGossipSub(ctx, h, opts...)
+	if err != nil {
+		return nil, err
+	}
+	w.pubsub = ps
+
+	w.peersEmitter, err = h.EventBus().Emitter(new(EvtRelayPeersChanged))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Relay protocol started")
+
+	return w, nil
+}
+
+func (w *WakuRelay) PubSub() *pubsub.PubSub {
+	return w.pubsub
+}
+
+func (w *WakuRelay) Topics() []string {
+	defer w.topicsMutex.Unlock()
+	w.topicsMutex.Lock()
+
+	var result []string
+	for topic := range w.relaySubs {
+		result = append(result, topic)
+	}
+	return result
+}
+
+// joinedTopics returns the topics the node joined to publish or subscribe
+func (w *WakuRelay) joinedTopics() []string {
+	defer w.topicsMutex.Unlock()
+	w.topicsMutex.Lock()
+
+	var result []string
+	for topic := range w.wakuRelayTopics {
+		result = append(result, topic)
+	}
+	return result
+}
+
+func (w *WakuRelay) SetPubSub(pubSub *pubsub.PubSub) {
+	w.pubsub = pubSub
+}
+
+// SetMinPeersToPublish makes publishing fail with ErrNotEnoughPeers when a
+// topic has fewer than n peers, instead of the message being silently lost.
+// It must be set before publishing
+func (w *WakuRelay) SetMinPeersToPublish(n int) {
+	w.minPeersToPublish = n
+}
+
+// PeersForTopic returns the relay peers known to be subscribed to a topic
+func (w *WakuRelay) PeersForTopic(topic string) []peer.ID {
+	return w.pubsub.ListPeers(topic)
+}
+
+func (w *WakuRelay) upsertTopic(topic string) (*pubsub.Topic, error) {
+	defer w.topicsMutex.Unlock()
+	w.topicsMutex.Lock()
+
+	return w.joinTopic(topic)
+}
+
+// joinTopic joins a topic if the node hasn't joined yet. It must be
+// called with the topics mutex held
+func (w *WakuRelay) joinTopic(topic string) (*pubsub.Topic, error) {
+	pubSubTopic, ok := w.wakuRelayTopics[topic]
+	if !ok {
+		if w.hasValidators(topic) {
+			if err := w.registerValidator(topic); err != nil {
+				return nil, err
+			}
+		}
+
+		newTopic, err := w.pubsub.Join(string(topic))
+		if err != nil {
+			return nil, err
+		}
+
+		handler, err := newTopic.EventHandler()
+		if err != nil {
+			_ = newTopic.Close()
+			return nil, err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w.topicEvents[topic] = topicEvents{handler: handler, cancel: cancel}
+		go w.emitPeerEvents(ctx, topic, handler)
+
+		w.wakuRelayTopics[topic] = newTopic
+		pubSubTopic = newTopic
+	}
+	return pubSubTopic, nil
+}
+
+// emitPeerEvents emits the peers joining and leaving a topic on the event
+// bus until the topic is left
+func (w *WakuRelay) emitPeerEvents(ctx context.Context, topic string, handler *pubsub.TopicEventHandler) {
+	for {
+		evt, err := handler.NextPeerEvent(ctx)
+		if err != nil {
+			return
+		}
+
+		err = w.peersEmitter.Emit(EvtRelayPeersChanged{Topic: topic, Peer: evt.Peer, Joined: evt.Type == pubsub.PeerJoin})
+		if err != nil {
+			log.Debug("could not emit relay peer event", err)
+		}
+	}
+}
+
+// stopPeerEvents stops emitting the peer events of a topic. It must be
+// called with the topics mutex held
+func (w *WakuRelay) stopPeerEvents(topic string) {
+	if evts, ok := w.topicEvents[topic]; ok {
+		evts.handler.Cancel()
+		evts.cancel()
+		delete(w.topicEvents, topic)
+	}
+}
+
+// subscribe creates the pubsub subscription of a topic, if it doesn't exist
+// yet. There's a single pubsub subscription for each topic, whose messages
+// are submitted to the broadcaster
+func (w *WakuRelay) subscribe(topic string) error {
+	defer w.topicsMutex.Unlock()
+	w.topicsMutex.Lock()
+
+	if _, ok := w.relaySubs[topic]; ok {
+		return nil
+	}
+
+	pubSubTopic, err := w.joinTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	sub, err := pubSubTopic.Subscribe(pubsub.WithBufferSize(subscriptionBufferSize))
+	if err != nil {
+		return err
+	}
+	w.relaySubs[topic] = sub
+
+	log.Info("Subscribing to topic ", topic)
+
+	w.receiving.Add(1)
+	go w.subscribeToTopic(topic, sub)
+
+	return nil
+}
+
+func (w *WakuRelay) PublishToTopic(ctx context.Context, message *pb.WakuMessage, topic string) ([]byte, error) {
+	// Publish a `WakuMessage` to a PubSub topic.
+	if w.pubsub == nil {
+		return nil, errors.New("PubSub hasn't been set")
+	}
+
+	if message == nil {
+		return nil, errors.New("message can't be null")
+	}
+
+	if w.minPeersToPublish > 0 {
+		if peers := len(w.PeersForTopic(topic)); peers < w.minPeersToPublish {
+			return nil, fmt.Errorf("%w: %s has %d peers, %d are required", ErrNotEnoughPeers, topic, peers, w.minPeersToPublish)
+		}
+	}
+
+	pubSubTopic, err := w.upsertTopic(topic)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fn := w.proofGenerator(topic); fn != nil && len(message.Proof) == 0 {
+		message, err = addProof(message, fn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if privKey := w.signingKey(topic); privKey != nil {
+		message, err = signMessage(topic, message, privKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := pb.Hash(out)
+
+	// Pubsub delivers the message back if the node is subscribed to the topic
+	w.topicsMutex.Lock()
+	if _, ok := w.relaySubs[topic]; ok {
+		w.local.add(topic, string(hash))
+	}
+	w.topicsMutex.Unlock()
+
+	err = pubSubTopic.Publish(ctx, out)
+	if err != nil {
+		w.local.remove(topic, string(hash))
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+func (w *WakuRelay) Publish(ctx context.Context, message *pb.WakuMessage) ([]byte, error) {
+	return w.PublishToTopic(ctx, message, DefaultWakuTopic)
+}
+
+func (w *WakuRelay) Stop() {
+	w.host.RemoveStreamHandler(WakuRelayID_v200)
+
+	w.closeSubscriptions()
+
+	w.topicsMutex.Lock()
+	for topic := range w.topicEvents {
+		w.stopPeerEvents(topic)
+	}
+	w.topicsMutex.Unlock()
+
+	if err := w.peersEmitter.Close(); err != nil {
+		log.Error("could not close relay peer events emitter", err)
+	}
+
+	if w.ownBcaster {
+		w.bcaster.Close()
+	}
+}
+
+// SubscribeToTopic subscribes to a pubsub topic. Each subscription to a topic
+// receives a copy of its messages, and only the messages of that topic
+func (w *WakuRelay) SubscribeToTopic(ctx context.Context, topic string) (*Subscription, error) {
+	w.subscriptionsMutex.Lock()
+	defer w.subscriptionsMutex.Unlock()
+
+	// NOTE The data field SHOULD be decoded as a WakuMessage.
+	if err := w.subscribe(topic); err != nil {
+		return nil, err
+	}
+
+	// Create client subscription
+	var subscription *Subscription
+	subscription = newSubscription(func() {
+		w.bcaster.Unregister(subscription.ch) // Remove from broadcast list
+		w.removeSubscription(topic, subscription)
+	})
+
+	w.subscriptions[topic] = append(w.subscriptions[topic], subscription)
+	// A subscriber not keeping up loses its oldest envelopes instead of
+	// delaying the other subscribers
+	w.bcaster.RegisterForTopic(topic, subscription.ch, v2.WithName("relay_subscription"), v2.WithOverflowPolicy(v2.DropOldest))
+
+	return subscription, nil
+}
+
+// removeSubscription forgets a subscription to a topic.
+// The topic is left once the last one is removed
+func (w *WakuRelay) removeSubscription(topic string, subscription *Subscription) {
+	w.subscriptionsMutex.Lock()
+	defer w.subscriptionsMutex.Unlock()
+
+	subs := w.subscriptions[topic]
+	for i, s := range subs {
+		if s == subscription {
+			w.subscriptions[topic] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+
+	if len(w.subscriptions[topic]) != 0 {
+		return
+	}
+
+	delete(w.subscriptions, topic)
+
+	if err := w.leaveTopic(topic); err != nil {
+		log.Error(fmt.Sprintf("could not leave topic %s: %s", topic, err.Error()))
+	}
+}
+
+// leaveTopic cancels the pubsub subscription of a topic and leaves it,
+// so the node stops being part of its mesh
+func (w *WakuRelay) leaveTopic(topic string) error {
+	defer w.topicsMutex.Unlock()
+	w.topicsMutex.Lock()
+
+	if sub, ok := w.relaySubs[topic]; ok {
+		log.Info("Unsubscribing from topic ", topic)
+		sub.Cancel()
+		delete(w.relaySubs, topic)
+		w.local.forget(topic)
+	}
+
+	pubSubTopic, ok := w.wakuRelayTopics[topic]
+	if !ok {
+		return nil
+	}
+
+	// The topic can't be closed while it has event handlers
+	w.stopPeerEvents(topic)
+
+	if err := pubSubTopic.Close(); err != nil {
+		return err
+	}
+	delete(w.wakuRelayTopics, topic)
+
+	return nil
+}
+
+func (w *WakuRelay) Subscribe(ctx context.Context) (*Subscription, error) {
+	return w.SubscribeToTopic(ctx, DefaultWakuTopic)
+}
+
+// Unsubscribe closes all the subscriptions to a topic and leaves it
+func (w *WakuRelay) Unsubscribe(ctx context.Context, topic string) error {
+	w.topicsMutex.Lock()
+	_, ok := w.relaySubs[topic]
+	w.topicsMutex.Unlock()
+	if !ok {
+		return fmt.Errorf("topics %s is not subscribed", (string)(topic))
+	}
+
+	w.subscriptionsMutex.Lock()
+	subscriptions := append([]*Subscription(nil), w.subscriptions[topic]...)
+	w.subscriptionsMutex.Unlock()
+
+	// The topic is left when the last subscription is closed
+	for _, subscription := range subscriptions {
+		subscription.Unsubscribe()
+	}
+
+	return w.leaveTopic(topic)
+}
+
+// subscribeToTopic submits the messages of a pubsub subscription to the
+// broadcaster until the subscription is cancelled
+func (w *WakuRelay) subscribeToTopic(t string, sub *pubsub.Subscription) {
+	defer w.receiving.Done()
+
+	ctx, err := tag.New(context.Background(), tag.Insert(metrics.KeyType, "relay"))
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	for {
+		msg, err := sub.Next(ctx)
+		if err != nil {
+			if err != pubsub.ErrSubscriptionCancelled {
+				log.Error(fmt.Errorf("subscription failed: %w", err))
+			}
+			return
+		}
+
+		stats.Record(ctx, metrics.Messages.M(1))
+		wakuMessage := &pb.WakuMessage{}
+		if err := proto.Unmarshal(msg.Data, wakuMessage); err != nil {
+			log.Error("could not decode message", err)
+			continue
+		}
+
+		envelope := waku_proto.NewEnvelopeFromPeer(wakuMessage, string(t), msg.ReceivedFrom)
+
+		w.bcaster.Submit(envelope)
+
+		if msg.ReceivedFrom == w.host.ID() {
+			w.local.remove(t, string(pb.Hash(msg.Data)))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package relay
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWakuRelay(t *testing.T) {
+	testTopic := "/waku/2/go/relay/test"
+
+	port, err := tests.FindFreePort(t, "", 5)
+	require.NoError(t, err)
+
+	host, err := tests.MakeHost(context.Background(), port, rand.Reader)
+	require.NoError(t, err)
+
+	relay, err := NewWakuRelay(context.Background(), host, nil)
+	defer relay.Stop()
+	require.NoError(t, err)
+
+	sub, err := relay.SubscribeToTopic(context.Background(), testTopic)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	topics := relay.Topics()
+	require.Equal(t, 1, len(topics))
+	require.Equal(t, testTopic, topics[0])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+
+		_, ok := <-sub.C
+		require.True(t, ok)
+	}()
+
+	msg := &pb.WakuMessage{
+		Payload:      []byte{1},
+		Version:      0,
+		ContentTopic: "test",
+		Timestamp:    0,
+	}
+	_, err = relay.PublishToTopic(context.Background(), msg, testTopic)
+	require.NoError(t, err)
+
+	<-ctx.Done()
+}
+
+func TestProofGenerator(t *testing.T) {
+	testTopic := "/waku/2/go/relay/test"
+
+	port, err := tests.FindFreePort(t, "", 5)
+	require.NoError(t, err)
+
+	host, err := tests.MakeHost(context.Background(), port, rand.Reader)
+	require.NoError(t, err)
+
+	relay, err := NewWakuRelay(context.Background(), host, nil)
+	require.NoError(t, err)
+	defer relay.Stop()
+
+	sub, err := relay.SubscribeToTopic(context.Background(), testTopic)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	relay.SetProofGenerator(testTopic, func(msg *pb.WakuMessage) ([]byte, error) {
+		return append([]byte("proof of "), msg.Payload...), nil
+	})
+
+	msg := &pb.WakuMessage{Payload: []byte{1}, ContentTopic: "test"}
+	_, err = relay.PublishToTopic(context.Background(), msg, testTopic)
+	require.NoError(t, err)
+	require.Empty(t, msg.Proof, "the message published is not modified")
+
+	envelope := <-sub.C
+	require.Equal(t, append([]byte("proof of "), 1), envelope.Message().Proof)
+
+	// The proof of the messages pushed by light nodes is kept
+	pushed := &pb.WakuMessage{Payload: []byte{2}, ContentTopic: "test", Proof: []byte("pushed")}
+	_, err = relay.PublishToTopic(context.Background(), pushed, testTopic)
+	require.NoError(t, err)
+	envelope = <-sub.C
+	require.Equal(t, []byte("pushed"), envelope.Message().Proof)
+
+	// Publishing fails without proof
+	proofErr := errors.New("no proof")
+	relay.SetProofGenerator(testTopic, func(msg *pb.WakuMessage) ([]byte, error) {
+		return nil, proofErr
+	})
+	_, err = relay.PublishToTopic(context.Background(), msg, testTopic)
+	require.ErrorIs(t, err, proofErr)
+
+	relay.SetProofGenerator(testTopic, nil)
+	_, err = relay.PublishToTopic(context.Background(), msg, testTopic)
+	require.NoError(t, err)
+	envelope = <-sub.C
+	require.Empty(t, envelope.Message().Proof)
+}
@@ -0,0 +1,212 @@
+package staticgroup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/protocol/relay"
+)
+
+var log = logging.Logger("wakustaticgroup")
+
+// Number of double signals buffered until they're read. Once full, the new
+// ones are only logged
+const doubleSignalsBufferSize = 100
+
+var (
+	// ErrNoMembershipKey is returned when publishing without a membership key
+	ErrNoMembershipKey = errors.New("a membership key is required to publish on a topic protected by a static group")
+	// ErrKeyNotMember is returned when the membership key is not the one of
+	// the member with the given index
+	ErrKeyNotMember = errors.New("the membership key is not the one of the member with this index")
+	// ErrRateLimitExceeded is returned when publishing more than one message in
+	// an epoch
+	ErrRateLimitExceeded = errors.New("a message was already published in this epoch")
+)
+
+// DoubleSignal is reported when a member publishes more than one message in
+// an epoch. Both messages are signed by the member, so they prove it exceeded
+// the rate limit
+type DoubleSignal struct {
+	Epoch        Epoch
+	Index        uint64
+	IDCommitment IDCommitment
+	// The peer which relayed the second message, which isn't necessarily the
+	// one of the member
+	PeerID peer.ID
+}
+
+// GroupRelay protects a relay topic from spam with a static group of signing
+// members: each member can only publish one message per epoch on it. It's not
+// RLN, the members are identified by their signature rather than a nullifier
+type GroupRelay struct {
+	topic string
+	tree  *MembershipTree
+	key   *MembershipKeyPair
+	index uint64
+	now   func() time.Time
+
+	publishedMutex sync.Mutex
+	// Hash of the message published by each member in the recent epochs
+	published     map[Epoch]map[uint64][]byte
+	doubleSignals chan DoubleSignal
+	stopped       bool
+}
+
+// NewGroupRelay protects topic with the membership tree of a group. The node
+// publishes as the member with the given index, whose key is key. Without
+// key, the messages received are verified, but no message can be published
+func NewGroupRelay(topic string, tree *MembershipTree, key *MembershipKeyPair, index uint64) (*GroupRelay, error) {
+	if key != nil {
+		commitment, err := tree.Member(index)
+		if err != nil {
+			return nil, err
+		}
+		if commitment != key.IDCommitment {
+			return nil, ErrKeyNotMember
+		}
+	}
+
+	return &GroupRelay{
+		topic:         topic,
+		tree:          tree,
+		key:           key,
+		index:         index,
+		now:           time.Now,
+		published:     make(map[Epoch]map[uint64][]byte),
+		doubleSignals: make(chan DoubleSignal, doubleSignalsBufferSize),
+	}, nil
+}
+
+// Topic returns the relay topic protected
+func (r *GroupRelay) Topic() string {
+	return r.topic
+}
+
+// Tree returns the membership tree of the group
+func (r *GroupRelay) Tree() *MembershipTree {
+	return r.tree
+}
+
+// DoubleSignals returns the channel receiving the members which published
+// more than one message in an epoch. It's closed by Stop
+func (r *GroupRelay) DoubleSignals() <-chan DoubleSignal {
+	return r.doubleSignals
+}
+
+// Stop closes the double signals channel
+func (r *GroupRelay) Stop() {
+	r.publishedMutex.Lock()
+	defer r.publishedMutex.Unlock()
+
+	if !r.stopped {
+		r.stopped = true
+		close(r.doubleSignals)
+	}
+}
+
+// GenerateProof returns the encoded rate limit proof of a message published
+// by the node in the current epoch
+func (r *GroupRelay) GenerateProof(msg *pb.WakuMessage) ([]byte, error) {
+	if r.key == nil {
+		return nil, ErrNoMembershipKey
+	}
+
+	epoch := CalcEpoch(r.now())
+
+	r.publishedMutex.Lock()
+	_, published := r.published[epoch][r.index]
+	r.publishedMutex.Unlock()
+	if published {
+		return nil, ErrRateLimitExceeded
+	}
+
+	path, err := r.tree.Path(r.index)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := GenerateProof(r.topic, msg, r.key, r.index, path, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return proof.Bytes(), nil
+}
+
+// Validator returns the relay validator of the topic. It rejects the messages
+// without a valid proof, the ones of an epoch too far from the current one,
+// and the second message of a member in an epoch, which is reported as a
+// double signal
+func (r *GroupRelay) Validator() relay.Validator {
+	return func(ctx context.Context, msg *pb.WakuMessage, peerID peer.ID) bool {
+		proof, err := ParseRateLimitProof(msg.Proof)
+		if err != nil {
+			log.Debug("rejecting message without valid proof from ", peerID)
+			return false
+		}
+
+		epoch := CalcEpoch(r.now())
+		if proof.Epoch.gap(epoch) > MaxEpochGap {
+			log.Debug(fmt.Sprintf("rejecting message of epoch %d from %s, the current epoch is %d", proof.Epoch, peerID, epoch))
+			return false
+		}
+
+		root, commitment, err := proof.root(r.topic, msg)
+		if err != nil || root != r.tree.Root() {
+			log.Debug("rejecting message of a non member from ", peerID)
+			return false
+		}
+
+		return r.record(epoch, proof, commitment, proofHash(r.topic, msg, proof.Epoch), peerID)
+	}
+}
+
+// record stores the hash of the message published by a member in an epoch,
+// and reports it if another one was. The same message can be received again,
+// i.e. after it was published by the node
+func (r *GroupRelay) record(current Epoch, proof *RateLimitProof, commitment IDCommitment, hash []byte, peerID peer.ID) bool {
+	r.publishedMutex.Lock()
+	defer r.publishedMutex.Unlock()
+
+	for epoch := range r.published {
+		if epoch.gap(current) > MaxEpochGap {
+			delete(r.published, epoch)
+		}
+	}
+
+	messages, ok := r.published[proof.Epoch]
+	if !ok {
+		messages = make(map[uint64][]byte)
+		r.published[proof.Epoch] = messages
+	}
+
+	previous, ok := messages[proof.Index]
+	if !ok {
+		messages[proof.Index] = hash
+		return true
+	}
+
+	if bytes.Equal(previous, hash) {
+		return true
+	}
+
+	log.Warn(fmt.Sprintf("rejecting message of member %d, which already published in epoch %d, from %s", proof.Index, proof.Epoch, peerID))
+
+	if !r.stopped {
+		select {
+		case r.doubleSignals <- DoubleSignal{Epoch: proof.Epoch, Index: proof.Index, IDCommitment: commitment, PeerID: peerID}:
+		default:
+			log.Warn("double signals buffer full, dropping the one of member ", proof.Index)
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,190 @@
+package staticgroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/stretchr/testify/require"
+)
+
+const testTopic = "/waku/2/static-group/proto"
+
+func testMessage(payload string) *pb.WakuMessage {
+	return &pb.WakuMessage{Payload: []byte(payload), ContentTopic: "test", Timestamp: 1}
+}
+
+// makeGroup returns the keys of a group and a group relay for each member
+func makeGroup(t *testing.T, n int) ([]*MembershipKeyPair, []*GroupRelay) {
+	var keys []*MembershipKeyPair
+	var commitments []IDCommitment
+	for i := 0; i < n; i++ {
+		key, err := NewMembershipKeyPair()
+		require.NoError(t, err)
+		keys = append(keys, key)
+		commitments = append(commitments, key.IDCommitment)
+	}
+
+	tree, err := NewMembershipTree(commitments...)
+	require.NoError(t, err)
+
+	var relays []*GroupRelay
+	for i, key := range keys {
+		r, err := NewGroupRelay(testTopic, tree, key, uint64(i))
+		require.NoError(t, err)
+		relays = append(relays, r)
+	}
+
+	return keys, relays
+}
+
+// publish returns the message with the proof of the member
+func publish(t *testing.T, r *GroupRelay, msg *pb.WakuMessage) *pb.WakuMessage {
+	proof, err := r.GenerateProof(msg)
+	require.NoError(t, err)
+	msg.Proof = proof
+	return msg
+}
+
+func TestRateLimitProofEncoding(t *testing.T) {
+	keys, relays := makeGroup(t, 2)
+	path, err := relays[1].Tree().Path(1)
+	require.NoError(t, err)
+
+	proof, err := GenerateProof(testTopic, testMessage("1"), keys[1], 1, path, 42)
+	require.NoError(t, err)
+
+	b := proof.Bytes()
+	require.Len(t, b, proofSize)
+
+	decoded, err := ParseRateLimitProof(b)
+	require.NoError(t, err)
+	require.Equal(t, proof, decoded)
+
+	_, err = ParseRateLimitProof(b[1:])
+	require.ErrorIs(t, err, ErrInvalidProof)
+	_, err = ParseRateLimitProof(nil)
+	require.ErrorIs(t, err, ErrInvalidProof)
+
+	_, err = GenerateProof(testTopic, testMessage("1"), keys[1], 1, path[1:], 42)
+	require.ErrorIs(t, err, ErrInvalidProof)
+}
+
+func TestNewGroupRelay(t *testing.T) {
+	keys, relays := makeGroup(t, 2)
+
+	_, err := NewGroupRelay(testTopic, relays[0].Tree(), keys[0], 1)
+	require.ErrorIs(t, err, ErrKeyNotMember)
+	_, err = NewGroupRelay(testTopic, relays[0].Tree(), keys[0], 2)
+	require.ErrorIs(t, err, ErrInvalidIndex)
+
+	// Without key, the messages are only verified
+	r, err := NewGroupRelay(testTopic, relays[0].Tree(), nil, 0)
+	require.NoError(t, err)
+	_, err = r.GenerateProof(testMessage("1"))
+	require.ErrorIs(t, err, ErrNoMembershipKey)
+	require.True(t, r.Validator()(context.Background(), publish(t, relays[1], testMessage("1")), ""))
+}
+
+func TestGroupValidator(t *testing.T) {
+	keys, relays := makeGroup(t, 3)
+	validate := relays[0].Validator()
+	ctx := context.Background()
+
+	require.True(t, validate(ctx, publish(t, relays[1], testMessage("1")), ""))
+
+	require.False(t, validate(ctx, testMessage("without proof"), ""), "messages without proof are rejected")
+
+	tampered := publish(t, relays[2], testMessage("2"))
+	tampered.Payload = []byte("3")
+	require.False(t, validate(ctx, tampered, ""), "the proof covers the payload")
+
+	// A proof for another topic isn't valid, even if the member is in the group
+	path, err := relays[2].Tree().Path(2)
+	require.NoError(t, err)
+	msg := testMessage("4")
+	proof, err := GenerateProof("/waku/2/other/proto", msg, keys[2], 2, path, CalcEpoch(time.Now()))
+	require.NoError(t, err)
+	msg.Proof = proof.Bytes()
+	require.False(t, validate(ctx, msg, ""))
+
+	// A key outside of the group can't use the path of a member
+	outsider, err := NewMembershipKeyPair()
+	require.NoError(t, err)
+	msg = testMessage("5")
+	proof, err = GenerateProof(testTopic, msg, outsider, 2, path, CalcEpoch(time.Now()))
+	require.NoError(t, err)
+	msg.Proof = proof.Bytes()
+	require.False(t, validate(ctx, msg, ""))
+
+	// A group with other members has another root
+	_, others := makeGroup(t, 3)
+	require.False(t, validate(ctx, publish(t, others[2], testMessage("6")), ""))
+}
+
+func TestGroupValidatorEpochGap(t *testing.T) {
+	_, relays := makeGroup(t, 2)
+	now := time.Now()
+	relays[0].now = func() time.Time { return now }
+
+	relays[1].now = func() time.Time { return now.Add(MaxEpochGap * EpochUnitSeconds * time.Second) }
+	require.True(t, relays[0].Validator()(context.Background(), publish(t, relays[1], testMessage("1")), ""))
+
+	relays[1].now = func() time.Time { return now.Add(-(MaxEpochGap + 1) * EpochUnitSeconds * time.Second) }
+	require.False(t, relays[0].Validator()(context.Background(), publish(t, relays[1], testMessage("2")), ""))
+}
+
+func TestGroupDoubleSignal(t *testing.T) {
+	keys, relays := makeGroup(t, 2)
+	now := time.Now()
+	relays[0].now = func() time.Time { return now }
+	relays[1].now = relays[0].now
+	validate := relays[0].Validator()
+	ctx := context.Background()
+
+	first := publish(t, relays[1], testMessage("1"))
+	require.True(t, validate(ctx, first, "peer1"))
+	// The same message may be received again
+	require.True(t, validate(ctx, first, "peer2"))
+
+	// The member already published in this epoch, but it didn't receive its
+	// message, so it can generate another proof
+	second := publish(t, relays[1], testMessage("2"))
+	require.False(t, validate(ctx, second, "peer2"))
+
+	select {
+	case signal := <-relays[0].DoubleSignals():
+		require.Equal(t, DoubleSignal{Epoch: CalcEpoch(now), Index: 1, IDCommitment: keys[1].IDCommitment, PeerID: "peer2"}, signal)
+	default:
+		require.Fail(t, "no double signal reported")
+	}
+
+	// The member can publish again in the next epoch
+	now = now.Add(EpochUnitSeconds * time.Second)
+	require.True(t, validate(ctx, publish(t, relays[1], testMessage("3")), "peer1"))
+
+	relays[0].Stop()
+	relays[0].Stop()
+	_, ok := <-relays[0].DoubleSignals()
+	require.False(t, ok)
+
+	// Double signals are still rejected after stopping
+	require.False(t, validate(ctx, publish(t, relays[1], testMessage("4")), "peer1"))
+}
+
+func TestGroupRateLimit(t *testing.T) {
+	_, relays := makeGroup(t, 1)
+	now := time.Now()
+	relays[0].now = func() time.Time { return now }
+
+	// The messages published by the node are validated as well, which records
+	// them, so a second one can't be published in the same epoch
+	require.True(t, relays[0].Validator()(context.Background(), publish(t, relays[0], testMessage("1")), ""))
+	_, err := relays[0].GenerateProof(testMessage("2"))
+	require.ErrorIs(t, err, ErrRateLimitExceeded)
+
+	now = now.Add(EpochUnitSeconds * time.Second)
+	_, err = relays[0].GenerateProof(testMessage("2"))
+	require.NoError(t, err)
+}
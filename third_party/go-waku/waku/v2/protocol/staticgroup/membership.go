@@ -0,0 +1,48 @@
+package staticgroup
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// IDCommitment identifies a member of the group without revealing its key:
+// it's the keccak256 hash of the public key of the member
+type IDCommitment [32]byte
+
+func (c IDCommitment) String() string {
+	return hex.EncodeToString(c[:])
+}
+
+// MembershipKeyPair is the key of a member, used to prove the messages it
+// publishes were published by a member of the group
+type MembershipKeyPair struct {
+	IDKey        *ecdsa.PrivateKey
+	IDCommitment IDCommitment
+}
+
+// NewMembershipKeyPair generates the key of a new member
+func NewMembershipKeyPair() (*MembershipKeyPair, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return ToMembershipKeyPair(key), nil
+}
+
+// ToMembershipKeyPair returns the membership key matching a private key
+func ToMembershipKeyPair(key *ecdsa.PrivateKey) *MembershipKeyPair {
+	return &MembershipKeyPair{
+		IDKey:        key,
+		IDCommitment: ToIDCommitment(&key.PublicKey),
+	}
+}
+
+// ToIDCommitment returns the identity commitment of a public key
+func ToIDCommitment(pubKey *ecdsa.PublicKey) IDCommitment {
+	var result IDCommitment
+	// The first byte of the uncompressed key is the 0x04 prefix
+	copy(result[:], crypto.Keccak256(crypto.FromECDSAPub(pubKey)[1:]))
+	return result
+}
@@ -0,0 +1,144 @@
+package staticgroup
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// EpochUnitSeconds is the length of an epoch. Each member can publish one
+// message per epoch
+const EpochUnitSeconds = 10
+
+// MaxEpochGap is the number of epochs the epoch of a message may differ from
+// the current one, to allow for clock drift and propagation delays
+const MaxEpochGap = 2
+
+var ErrInvalidProof = errors.New("invalid rate limit proof")
+
+// Epoch is the period of time a rate limit proof is valid for
+type Epoch uint64
+
+// CalcEpoch returns the epoch of a time
+func CalcEpoch(t time.Time) Epoch {
+	return Epoch(t.Unix() / EpochUnitSeconds)
+}
+
+// gap returns the number of epochs between two epochs
+func (e Epoch) gap(other Epoch) uint64 {
+	if e > other {
+		return uint64(e - other)
+	}
+	return uint64(other - e)
+}
+
+// RateLimitProof proves that a message was published by a member of the
+// group during an epoch. The member signs the message and the epoch, and the
+// merkle path of its identity commitment proves it's in the group. It's not a
+// zero-knowledge proof, so it reveals which member published the message
+type RateLimitProof struct {
+	Epoch     Epoch
+	Index     uint64
+	Path      []MerkleNode
+	Signature []byte
+}
+
+// Size of an encoded proof: the epoch and index, the path, and the signature
+// including its recovery id
+const proofSize = 8 + 8 + MerkleTreeDepth*32 + crypto.SignatureLength
+
+// GenerateProof returns the proof of a message published on a topic by the
+// member with the given key and index, whose merkle path is path
+func GenerateProof(topic string, msg *pb.WakuMessage, key *MembershipKeyPair, index uint64, path []MerkleNode, epoch Epoch) (*RateLimitProof, error) {
+	if len(path) != MerkleTreeDepth {
+		return nil, ErrInvalidProof
+	}
+
+	signature, err := crypto.Sign(proofHash(topic, msg, epoch), key.IDKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitProof{
+		Epoch:     epoch,
+		Index:     index,
+		Path:      path,
+		Signature: signature,
+	}, nil
+}
+
+// Bytes returns the proof encoded to be set in the proof field of a message
+func (p *RateLimitProof) Bytes() []byte {
+	result := make([]byte, 16, proofSize)
+	binary.BigEndian.PutUint64(result[0:8], uint64(p.Epoch))
+	binary.BigEndian.PutUint64(result[8:16], p.Index)
+	for _, node := range p.Path {
+		result = append(result, node[:]...)
+	}
+	return append(result, p.Signature...)
+}
+
+// ParseRateLimitProof decodes the proof of a message
+func ParseRateLimitProof(b []byte) (*RateLimitProof, error) {
+	if len(b) != proofSize {
+		return nil, ErrInvalidProof
+	}
+
+	p := &RateLimitProof{
+		Epoch: Epoch(binary.BigEndian.Uint64(b[0:8])),
+		Index: binary.BigEndian.Uint64(b[8:16]),
+		Path:  make([]MerkleNode, MerkleTreeDepth),
+	}
+
+	b = b[16:]
+	for i := range p.Path {
+		copy(p.Path[i][:], b[i*32:(i+1)*32])
+	}
+	p.Signature = append([]byte(nil), b[MerkleTreeDepth*32:]...)
+
+	return p, nil
+}
+
+// root returns the root of the tree the message signer belongs to according
+// to the proof, which is a member of the group if it's the root of the group
+func (p *RateLimitProof) root(topic string, msg *pb.WakuMessage) (MerkleNode, IDCommitment, error) {
+	if p.Index >= 1<<MerkleTreeDepth {
+		return MerkleNode{}, IDCommitment{}, ErrInvalidProof
+	}
+
+	pubKey, err := crypto.SigToPub(proofHash(topic, msg, p.Epoch), p.Signature)
+	if err != nil {
+		return MerkleNode{}, IDCommitment{}, ErrInvalidProof
+	}
+
+	commitment := ToIDCommitment(pubKey)
+	return computeRoot(commitment, p.Index, p.Path), commitment, nil
+}
+
+// proofHash is the hash signed by the proofs. It covers the topic and the
+// epoch, so a proof can't be reused on another topic or epoch, and every
+// field of the message but meta and proof. Variable length fields are hashed
+// first so they can't be shifted into each other
+func proofHash(topic string, msg *pb.WakuMessage, epoch Epoch) []byte {
+	var version [4]byte
+	binary.BigEndian.PutUint32(version[:], msg.Version)
+
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], math.Float64bits(msg.Timestamp))
+
+	var epochBytes [8]byte
+	binary.BigEndian.PutUint64(epochBytes[:], uint64(epoch))
+
+	return crypto.Keccak256(
+		crypto.Keccak256([]byte(topic)),
+		epochBytes[:],
+		crypto.Keccak256(msg.Payload),
+		crypto.Keccak256([]byte(msg.ContentTopic)),
+		version[:],
+		timestamp[:],
+	)
+}
@@ -0,0 +1,159 @@
+package staticgroup
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MerkleTreeDepth is the depth of the membership tree, so a group has at most
+// 2^MerkleTreeDepth members
+const MerkleTreeDepth = 20
+
+var (
+	ErrTreeFull     = errors.New("the membership tree is full")
+	ErrInvalidIndex = errors.New("no member with this index")
+)
+
+// MerkleNode is a node of the membership tree. The leaves are the identity
+// commitments of the members
+type MerkleNode [32]byte
+
+// zeroNodes are the roots of the empty subtrees of each height
+var zeroNodes = func() [MerkleTreeDepth + 1]MerkleNode {
+	var result [MerkleTreeDepth + 1]MerkleNode
+	for i := 1; i <= MerkleTreeDepth; i++ {
+		result[i] = hashNodes(result[i-1], result[i-1])
+	}
+	return result
+}()
+
+func hashNodes(left MerkleNode, right MerkleNode) MerkleNode {
+	var result MerkleNode
+	copy(result[:], crypto.Keccak256(left[:], right[:]))
+	return result
+}
+
+// MembershipTree is the merkle tree of the identity commitments of a group,
+// in the order the members joined. Only the subtrees containing members are
+// stored, the empty ones are known in advance
+type MembershipTree struct {
+	sync.RWMutex
+	// levels[0] are the leaves and levels[MerkleTreeDepth] the root
+	levels [MerkleTreeDepth + 1][]MerkleNode
+}
+
+// NewMembershipTree returns the tree of a group with the given members
+func NewMembershipTree(commitments ...IDCommitment) (*MembershipTree, error) {
+	t := &MembershipTree{}
+	for _, c := range commitments {
+		if _, err := t.Insert(c); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Insert adds a member to the tree, and returns its index
+func (t *MembershipTree) Insert(commitment IDCommitment) (uint64, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	index := uint64(len(t.levels[0]))
+	if index >= 1<<MerkleTreeDepth {
+		return 0, ErrTreeFull
+	}
+
+	t.levels[0] = append(t.levels[0], MerkleNode(commitment))
+
+	i := index
+	for height := 0; height < MerkleTreeDepth; height++ {
+		sibling := zeroNodes[height]
+		if i^1 < uint64(len(t.levels[height])) {
+			sibling = t.levels[height][i^1]
+		}
+
+		var parent MerkleNode
+		if i&1 == 0 {
+			parent = hashNodes(t.levels[height][i], sibling)
+		} else {
+			parent = hashNodes(sibling, t.levels[height][i])
+		}
+
+		i >>= 1
+		if i < uint64(len(t.levels[height+1])) {
+			t.levels[height+1][i] = parent
+		} else {
+			t.levels[height+1] = append(t.levels[height+1], parent)
+		}
+	}
+
+	return index, nil
+}
+
+// Size returns the number of members of the tree
+func (t *MembershipTree) Size() uint64 {
+	t.RLock()
+	defer t.RUnlock()
+	return uint64(len(t.levels[0]))
+}
+
+// Root returns the root of the tree, which changes whenever a member joins
+func (t *MembershipTree) Root() MerkleNode {
+	t.RLock()
+	defer t.RUnlock()
+
+	if len(t.levels[MerkleTreeDepth]) == 0 {
+		return zeroNodes[MerkleTreeDepth]
+	}
+	return t.levels[MerkleTreeDepth][0]
+}
+
+// Member returns the identity commitment of the member with the given index
+func (t *MembershipTree) Member(index uint64) (IDCommitment, error) {
+	t.RLock()
+	defer t.RUnlock()
+
+	if index >= uint64(len(t.levels[0])) {
+		return IDCommitment{}, ErrInvalidIndex
+	}
+	return IDCommitment(t.levels[0][index]), nil
+}
+
+// Path returns the siblings of the nodes between a member and the root,
+// starting with the sibling of the member, which prove its membership
+func (t *MembershipTree) Path(index uint64) ([]MerkleNode, error) {
+	t.RLock()
+	defer t.RUnlock()
+
+	if index >= uint64(len(t.levels[0])) {
+		return nil, ErrInvalidIndex
+	}
+
+	result := make([]MerkleNode, MerkleTreeDepth)
+	for height := 0; height < MerkleTreeDepth; height++ {
+		sibling := (index >> height) ^ 1
+		if sibling < uint64(len(t.levels[height])) {
+			result[height] = t.levels[height][sibling]
+		} else {
+			result[height] = zeroNodes[height]
+		}
+	}
+
+	return result, nil
+}
+
+// computeRoot returns the root of the tree containing commitment at index,
+// given its path
+func computeRoot(commitment IDCommitment, index uint64, path []MerkleNode) MerkleNode {
+	node := MerkleNode(commitment)
+	for height, sibling := range path {
+		if (index>>height)&1 == 0 {
+			node = hashNodes(node, sibling)
+		} else {
+			node = hashNodes(sibling, node)
+		}
+	}
+	return node
+}
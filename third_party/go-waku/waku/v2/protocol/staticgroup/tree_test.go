@@ -0,0 +1,79 @@
+package staticgroup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func makeCommitments(t *testing.T, n int) []IDCommitment {
+	var result []IDCommitment
+	for i := 0; i < n; i++ {
+		key, err := NewMembershipKeyPair()
+		require.NoError(t, err)
+		result = append(result, key.IDCommitment)
+	}
+	return result
+}
+
+func TestMembershipTree(t *testing.T) {
+	tree, err := NewMembershipTree()
+	require.NoError(t, err)
+	require.Zero(t, tree.Size())
+	require.Equal(t, zeroNodes[MerkleTreeDepth], tree.Root())
+
+	_, err = tree.Path(0)
+	require.ErrorIs(t, err, ErrInvalidIndex)
+
+	commitments := makeCommitments(t, 5)
+	var roots []MerkleNode
+	for i, c := range commitments {
+		index, err := tree.Insert(c)
+		require.NoError(t, err)
+		require.Equal(t, uint64(i), index)
+		require.NotContains(t, roots, tree.Root())
+		roots = append(roots, tree.Root())
+
+		// The path of every member leads to the new root
+		for j := 0; j <= i; j++ {
+			path, err := tree.Path(uint64(j))
+			require.NoError(t, err)
+			require.Len(t, path, MerkleTreeDepth)
+			require.Equal(t, tree.Root(), computeRoot(commitments[j], uint64(j), path))
+		}
+	}
+
+	member, err := tree.Member(3)
+	require.NoError(t, err)
+	require.Equal(t, commitments[3], member)
+	_, err = tree.Member(5)
+	require.ErrorIs(t, err, ErrInvalidIndex)
+
+	// The path doesn't prove the membership at another index, nor of another member
+	path, err := tree.Path(2)
+	require.NoError(t, err)
+	require.NotEqual(t, tree.Root(), computeRoot(commitments[2], 3, path))
+	require.NotEqual(t, tree.Root(), computeRoot(commitments[3], 2, path))
+
+	// A tree created with the members has the same root
+	other, err := NewMembershipTree(commitments...)
+	require.NoError(t, err)
+	require.Equal(t, tree.Root(), other.Root())
+	require.Equal(t, uint64(5), other.Size())
+}
+
+func TestMembershipTreeRoot(t *testing.T) {
+	commitments := makeCommitments(t, 3)
+	tree, err := NewMembershipTree(commitments...)
+	require.NoError(t, err)
+
+	// The root of a tree of 3 members, with the empty subtrees above them
+	node := hashNodes(
+		hashNodes(MerkleNode(commitments[0]), MerkleNode(commitments[1])),
+		hashNodes(MerkleNode(commitments[2]), zeroNodes[0]),
+	)
+	for height := 2; height < MerkleTreeDepth; height++ {
+		node = hashNodes(node, zeroNodes[height])
+	}
+	require.Equal(t, node, tree.Root())
+}
@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+)
+
+// queryCandidates returns the peers a query is sent to, in order: the selected
+// peer, if any, followed by the fallback peers. Duplicates are removed
+func queryCandidates(selectedPeer peer.ID, fallbackPeers []peer.ID) []peer.ID {
+	var result []peer.ID
+	seen := make(map[peer.ID]bool)
+	for _, p := range append([]peer.ID{selectedPeer}, fallbackPeers...) {
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		result = append(result, p)
+	}
+	return result
+}
+
+// Attempt is a query sent to a peer. Err is nil for the peer which answered
+type Attempt struct {
+	Peer     peer.ID
+	Duration time.Duration
+	Err      error
+}
+
+// PeerError is the failure of a query sent to a peer
+type PeerError struct {
+	Peer     peer.ID
+	Duration time.Duration
+	Err      error
+}
+
+func (e PeerError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Peer, e.Err)
+}
+
+func (e PeerError) Unwrap() error {
+	return e.Err
+}
+
+// QueryError is returned by Query when every peer the query was sent to
+// failed. It matches ErrFailedQuery
+type QueryError struct {
+	Errors []PeerError
+}
+
+func (e *QueryError) Error() string {
+	var errs []string
+	for _, err := range e.Errors {
+		errs = append(errs, err.Error())
+	}
+	return fmt.Sprintf("%s after %d attempts: %s", ErrFailedQuery, len(e.Errors), strings.Join(errs, "; "))
+}
+
+// Unwrap returns the error of the last attempt
+func (e *QueryError) Unwrap() error {
+	return e.Errors[len(e.Errors)-1]
+}
+
+func (e *QueryError) Is(target error) bool {
+	return target == ErrFailedQuery
+}
+
+// queryWithFailover sends a query to the candidates in order, until one of
+// them answers without error. queryFor returns the query sent to each of them.
+// It returns the response, the peer which sent it and the attempts made. When
+// there are several candidates, the time left before the deadline of the
+// query is split evenly between the remaining ones
+func (store *WakuStore) queryWithFailover(ctx context.Context, queryFor func(peer.ID) *pb.HistoryQuery, candidates []peer.ID, requestId []byte) (*pb.HistoryResponse, peer.ID, []Attempt, error) {
+	var attempts []Attempt
+	var errs []PeerError
+	for i, p := range candidates {
+		start := time.Now()
+		attemptCtx, cancel := attemptContext(ctx, len(candidates)-i)
+		response, err := store.queryFrom(attemptCtx, queryFor(p), p, requestId)
+		cancel()
+		if err == nil && response.Error == pb.HistoryResponse_INVALID_CURSOR {
+			err = ErrInvalidCursor
+		}
+
+		duration := time.Since(start)
+		attempts = append(attempts, Attempt{Peer: p, Duration: duration, Err: err})
+		if err == nil {
+			return response, p, attempts, nil
+		}
+
+		log.Info(fmt.Sprintf("query to %s failed: %s", p, err))
+		errs = append(errs, PeerError{Peer: p, Duration: duration, Err: err})
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, "", attempts, &QueryError{Errors: errs}
+}
+
+// attemptContext returns the context of one of the remaining attempts of a
+// query. The last attempt uses the context of the query as it is
+func attemptContext(ctx context.Context, attemptsLeft int) (context.Context, context.CancelFunc) {
+	if attemptsLeft <= 1 {
+		return context.WithCancel(ctx)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithTimeout(ctx, DefaultAttemptTimeout)
+	}
+
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(attemptsLeft))
+}
+
+// newResult returns the result of a query answered by p. The other candidates
+// are kept to request the next page from them if p fails, starting with the
+// ones after p, so the peers which already failed are tried last
+func newResult(q *pb.HistoryQuery, response *pb.HistoryResponse, p peer.ID, attempts []Attempt, candidates []peer.ID) *Result {
+	var fallbackPeers []peer.ID
+	for i, c := range candidates {
+		if c == p {
+			fallbackPeers = append(fallbackPeers, candidates[i+1:]...)
+			fallbackPeers = append(fallbackPeers, candidates[:i]...)
+			break
+		}
+	}
+
+	return &Result{
+		Messages:      response.Messages,
+		cursor:        response.PagingInfo.Cursor,
+		query:         q,
+		peerId:        p,
+		received:      len(response.Messages),
+		attempts:      attempts,
+		fallbackPeers: fallbackPeers,
+	}
+}
+
+// resumeQuery returns the query sent to another peer for the page after r.
+// The cursor of a peer is meaningless to the others, so the time range is
+// restricted instead to start at the timestamp of the last message received.
+// The messages received with that timestamp are sent again, see dropSeen
+func (r *Result) resumeQuery() *pb.HistoryQuery {
+	q := &pb.HistoryQuery{
+		PubsubTopic:    r.query.PubsubTopic,
+		ContentFilters: r.query.ContentFilters,
+		StartTime:      r.query.StartTime,
+		EndTime:        r.query.EndTime,
+		PagingInfo: &pb.PagingInfo{
+			PageSize:  r.query.PagingInfo.PageSize,
+			Direction: r.query.PagingInfo.Direction,
+		},
+	}
+
+	if len(r.boundarySeen) != 0 {
+		if r.query.PagingInfo.Direction == pb.PagingInfo_FORWARD {
+			q.StartTime = r.boundary
+		} else {
+			q.EndTime = r.boundary
+		}
+	}
+
+	return q
+}
+
+// dropSeen removes from the messages of r the ones already received with the
+// timestamp of the last message of the previous page
+func (r *Result) dropSeen(previous *Result) {
+	if len(previous.boundarySeen) == 0 {
+		return
+	}
+
+	var messages []*pb.WakuMessage
+	for _, msg := range r.Messages {
+		if msg.Timestamp == previous.boundary {
+			if _, ok := previous.boundarySeen[messageKey(msg)]; ok {
+				continue
+			}
+		}
+		messages = append(messages, msg)
+	}
+	r.Messages = messages
+}
+
+// trackBoundary records the timestamp of the last message received, in the
+// order of the query, and the messages received with it, including the ones of
+// the previous pages
+func (r *Result) trackBoundary(previous *Result) {
+	r.boundarySeen = make(map[string]struct{})
+	if previous != nil {
+		r.boundary = previous.boundary
+		for k := range previous.boundarySeen {
+			r.boundarySeen[k] = struct{}{}
+		}
+	}
+
+	forward := r.query.PagingInfo.Direction == pb.PagingInfo_FORWARD
+	for _, msg := range r.Messages {
+		beyond := msg.Timestamp < r.boundary
+		if forward {
+			beyond = msg.Timestamp > r.boundary
+		}
+
+		if len(r.boundarySeen) == 0 || beyond {
+			r.boundary = msg.Timestamp
+			r.boundarySeen = make(map[string]struct{})
+		} else if msg.Timestamp != r.boundary {
+			continue
+		}
+		r.boundarySeen[messageKey(msg)] = struct{}{}
+	}
+}
+
+func messageKey(msg *pb.WakuMessage) string {
+	hash, _ := msg.Hash()
+	return string(hash)
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/status-im/go-waku/tests"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+const failoverTestTopic = "test"
+
+func makeStoreNode(t *testing.T, msgs ...*pb.WakuMessage) (*WakuStore, host.Host) {
+	h, err := libp2p.New(context.Background(), libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+
+	s := NewWakuStore(h, nil, 0, 0)
+	s.Start(context.Background())
+	t.Cleanup(func() {
+		s.Stop()
+		h.Close()
+	})
+
+	for _, msg := range msgs {
+		s.storeMessage(protocol.NewEnvelope(msg, failoverTestTopic))
+	}
+
+	return s, h
+}
+
+func addStorePeer(t *testing.T, h host.Host, storeNode host.Host) {
+	h.Peerstore().AddAddr(storeNode.ID(), tests.GetHostAddress(storeNode), peerstore.PermanentAddrTTL)
+	require.NoError(t, h.Peerstore().AddProtocols(storeNode.ID(), string(StoreID_v20beta3)))
+}
+
+// unreachablePeer returns the id of a store peer which is not listening anymore
+func unreachablePeer(t *testing.T, h host.Host) peer.ID {
+	_, storeNode := makeStoreNode(t)
+	addStorePeer(t, h, storeNode)
+	require.NoError(t, storeNode.Close())
+	return storeNode.ID()
+}
+
+func failoverTestMessages(n int) []*pb.WakuMessage {
+	now := utils.GetUnixEpoch()
+	var msgs []*pb.WakuMessage
+	for i := 0; i < n; i++ {
+		msg := tests.CreateWakuMessage("ct", now-float64(n-i))
+		msg.Payload = []byte{byte(i)}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestQueryCandidates(t *testing.T) {
+	require.Equal(t, []peer.ID{"p1", "p2", "p3"}, queryCandidates("p1", []peer.ID{"p2", "p1", "", "p3", "p2"}))
+	require.Equal(t, []peer.ID{"p2"}, queryCandidates("", []peer.ID{"p2"}))
+	require.Empty(t, queryCandidates("", nil))
+}
+
+func TestQueryFailover(t *testing.T) {
+	msgs := failoverTestMessages(3)
+	_, storeHost := makeStoreNode(t, msgs...)
+	client, clientHost := makeStoreNode(t)
+	addStorePeer(t, clientHost, storeHost)
+	unreachable := unreachablePeer(t, clientHost)
+
+	q := Query{Topic: failoverTestTopic, ContentTopics: []string{"ct"}}
+	result, err := client.Query(context.Background(), q, WithPeer(unreachable), WithFallbackPeers(storeHost.ID()))
+	require.NoError(t, err)
+	require.Equal(t, storeHost.ID(), result.PeerID())
+	require.Len(t, result.Messages, 3)
+
+	attempts := result.Attempts()
+	require.Len(t, attempts, 2)
+	require.Equal(t, unreachable, attempts[0].Peer)
+	require.Error(t, attempts[0].Err)
+	require.Equal(t, storeHost.ID(), attempts[1].Peer)
+	require.NoError(t, attempts[1].Err)
+}
+
+func TestQueryFailoverAllPeersFail(t *testing.T) {
+	client, clientHost := makeStoreNode(t)
+	unreachable1 := unreachablePeer(t, clientHost)
+	unreachable2 := unreachablePeer(t, clientHost)
+
+	q := Query{Topic: failoverTestTopic, ContentTopics: []string{"ct"}}
+	_, err := client.Query(context.Background(), q, WithPeer(unreachable1), WithFallbackPeers(unreachable2))
+	require.ErrorIs(t, err, ErrFailedQuery)
+
+	var queryErr *QueryError
+	require.True(t, errors.As(err, &queryErr))
+	require.Len(t, queryErr.Errors, 2)
+	require.Equal(t, unreachable1, queryErr.Errors[0].Peer)
+	require.Equal(t, unreachable2, queryErr.Errors[1].Peer)
+}
+
+func TestNextFailover(t *testing.T) {
+	msgs := failoverTestMessages(5)
+	// Two messages share a timestamp, and are split between pages
+	msgs[2].Timestamp = msgs[1].Timestamp
+
+	_, storeHost1 := makeStoreNode(t, msgs...)
+	_, storeHost2 := makeStoreNode(t, msgs...)
+	client, clientHost := makeStoreNode(t)
+	addStorePeer(t, clientHost, storeHost1)
+	addStorePeer(t, clientHost, storeHost2)
+
+	q := Query{Topic: failoverTestTopic, ContentTopics: []string{"ct"}}
+	result, err := client.Query(context.Background(), q, WithPeer(storeHost1.ID()), WithFallbackPeers(storeHost2.ID()), WithPaging(true, 2))
+	require.NoError(t, err)
+	require.Equal(t, storeHost1.ID(), result.PeerID())
+	received := result.Messages
+
+	// The next pages are requested from the other peer, without duplicates
+	require.NoError(t, storeHost1.Close())
+	for !result.IsComplete() {
+		result, err = client.Next(context.Background(), result)
+		require.NoError(t, err)
+		require.Equal(t, storeHost2.ID(), result.PeerID())
+		received = append(received, result.Messages...)
+	}
+
+	require.ElementsMatch(t, msgs, received)
+}
+
+func TestAttemptContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+
+	// The time left is split between the remaining attempts
+	attemptCtx, attemptCancel := attemptContext(ctx, 4)
+	defer attemptCancel()
+	deadline, ok := attemptCtx.Deadline()
+	require.True(t, ok)
+	require.InDelta(t, float64(time.Second), float64(time.Until(deadline)), float64(100*time.Millisecond))
+
+	// and the last one uses the deadline of the query
+	lastCtx, lastCancel := attemptContext(ctx, 1)
+	defer lastCancel()
+	lastDeadline, _ := lastCtx.Deadline()
+	queryDeadline, _ := ctx.Deadline()
+	require.Equal(t, queryDeadline, lastDeadline)
+
+	// Queries without deadline give each attempt the default timeout
+	noDeadlineCtx, noDeadlineCancel := attemptContext(context.Background(), 2)
+	defer noDeadlineCancel()
+	deadline, ok = noDeadlineCtx.Deadline()
+	require.True(t, ok)
+	require.InDelta(t, float64(DefaultAttemptTimeout), float64(time.Until(deadline)), float64(100*time.Millisecond))
+}
@@ -0,0 +1,250 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/status-im/go-waku/waku/persistence"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+type MessageQueue struct {
+	sync.RWMutex
+
+	seen        map[[32]byte]struct{}
+	messages    []IndexedWakuMessage
+	maxMessages int
+	maxDuration time.Duration
+	maxBytes    int64
+	// Approximate size of the messages
+	size int64
+
+	// Number of messages of each pubsub topic, including the topics without
+	// messages anymore
+	topicMessages map[string]int
+	// Timestamps of the oldest and newest messages, recomputed when the
+	// message with one of them is removed
+	oldest      float64
+	newest      float64
+	boundsStale bool
+
+	quit chan struct{}
+	wg   *sync.WaitGroup
+}
+
+// Push adds a message, unless it was already pushed as identified by
+// persistence.MessageHash, and returns whether it was added
+func (self *MessageQueue) Push(msg IndexedWakuMessage) bool {
+	var k [32]byte
+	copy(k[:], persistence.MessageHash(msg.pubsubTopic, msg.msg))
+
+	self.Lock()
+	defer self.Unlock()
+
+	if _, ok := self.seen[k]; ok {
+		return false
+	}
+
+	self.seen[k] = struct{}{}
+	self.messages = append(self.messages, msg)
+	self.size += messageSize(msg)
+	self.topicMessages[msg.pubsubTopic]++
+
+	timestamp := msg.msg.Timestamp
+	if len(self.messages) == 1 {
+		self.oldest, self.newest, self.boundsStale = timestamp, timestamp, false
+	} else if !self.boundsStale {
+		if timestamp < self.oldest {
+			self.oldest = timestamp
+		}
+		if timestamp > self.newest {
+			self.newest = timestamp
+		}
+	}
+
+	if self.maxMessages != 0 && len(self.messages) > self.maxMessages {
+		numToPop := len(self.messages) - self.maxMessages
+		self.pop(numToPop)
+	}
+
+	self.pruneBySize()
+
+	return true
+}
+
+func messageSize(msg IndexedWakuMessage) int64 {
+	return persistence.MessageSize(msg.pubsubTopic, msg.msg)
+}
+
+// pop removes the n oldest messages
+func (self *MessageQueue) pop(n int) {
+	for _, msg := range self.messages[:n] {
+		self.size -= messageSize(msg)
+		self.topicMessages[msg.pubsubTopic]--
+
+		timestamp := msg.msg.Timestamp
+		if timestamp == self.oldest || timestamp == self.newest {
+			self.boundsStale = true
+		}
+	}
+	self.messages = self.messages[n:]
+}
+
+type queueStats struct {
+	messages      int
+	topicMessages map[string]int
+	oldest        float64
+	newest        float64
+	size          int64
+}
+
+func (self *MessageQueue) stats() queueStats {
+	self.Lock()
+	defer self.Unlock()
+
+	if self.boundsStale {
+		self.oldest, self.newest = 0, 0
+		for i, msg := range self.messages {
+			timestamp := msg.msg.Timestamp
+			if i == 0 || timestamp < self.oldest {
+				self.oldest = timestamp
+			}
+			if i == 0 || timestamp > self.newest {
+				self.newest = timestamp
+			}
+		}
+		self.boundsStale = false
+	}
+
+	if len(self.messages) == 0 {
+		self.oldest, self.newest = 0, 0
+	}
+
+	topicMessages := make(map[string]int, len(self.topicMessages))
+	for topic, count := range self.topicMessages {
+		topicMessages[topic] = count
+	}
+
+	return queueStats{
+		messages:      len(self.messages),
+		topicMessages: topicMessages,
+		oldest:        self.oldest,
+		newest:        self.newest,
+		size:          self.size,
+	}
+}
+
+// pruneBySize removes the oldest messages once their size exceeds maxBytes,
+// until it's back to persistence.PruneTarget of maxBytes, so messages aren't
+// removed one by one at each push
+func (self *MessageQueue) pruneBySize() {
+	if self.maxBytes == 0 || self.size <= self.maxBytes {
+		return
+	}
+
+	target := int64(float64(self.maxBytes) * persistence.PruneTarget)
+	size := self.size
+	n := 0
+	for n < len(self.messages) && size > target {
+		size -= messageSize(self.messages[n])
+		n++
+	}
+	self.pop(n)
+}
+
+// SetMaxSize sets the maximum size of the messages, pruning the oldest
+// messages if it's exceeded. A size of 0 means unlimited
+func (self *MessageQueue) SetMaxSize(maxBytes int64) {
+	self.Lock()
+	defer self.Unlock()
+
+	self.maxBytes = maxBytes
+	self.pruneBySize()
+}
+
+// Size returns the approximate size of the messages
+func (self *MessageQueue) Size() int64 {
+	self.RLock()
+	defer self.RUnlock()
+	return self.size
+}
+
+func (self *MessageQueue) Messages() <-chan IndexedWakuMessage {
+	c := make(chan IndexedWakuMessage)
+
+	f := func() {
+		self.RLock()
+		defer self.RUnlock()
+		for _, value := range self.messages {
+			c <- value
+		}
+		close(c)
+	}
+	go f()
+
+	return c
+}
+
+func (self *MessageQueue) cleanOlderRecords() {
+	self.Lock()
+	defer self.Unlock()
+
+	// TODO: check if retention days was set
+
+	t := utils.GetUnixEpochFrom(time.Now().Add(-self.maxDuration))
+
+	var idx int
+	for i := 0; i < len(self.messages); i++ {
+		if self.messages[i].index.ReceiverTime >= t {
+			idx = i
+			break
+		}
+	}
+
+	self.pop(idx)
+}
+
+func (self *MessageQueue) checkForOlderRecords(d time.Duration) {
+	defer self.wg.Done()
+
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-self.quit:
+			return
+		case <-ticker.C:
+			self.cleanOlderRecords()
+		}
+	}
+}
+
+func (self *MessageQueue) Length() int {
+	self.RLock()
+	defer self.RUnlock()
+	return len(self.messages)
+}
+
+func NewMessageQueue(maxMessages int, maxDuration time.Duration) *MessageQueue {
+	result := &MessageQueue{
+		maxMessages:   maxMessages,
+		maxDuration:   maxDuration,
+		seen:          make(map[[32]byte]struct{}),
+		topicMessages: make(map[string]int),
+		quit:          make(chan struct{}),
+		wg:            &sync.WaitGroup{},
+	}
+
+	if maxDuration != 0 {
+		result.wg.Add(1)
+		go result.checkForOlderRecords(10 * time.Second) // is 10s okay?
+	}
+
+	return result
+}
+
+func (self *MessageQueue) Stop() {
+	close(self.quit)
+	self.wg.Wait()
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Weight of the last query in the error rate and duration of a peer
+const rankingWeight = 0.3
+
+// Latency assumed for the peers which were never queried nor pinged
+const unknownLatency = time.Second
+
+type peerRecord struct {
+	errorRate float64
+	duration  time.Duration
+}
+
+// peerRanking keeps the recent error rate and query duration of the store
+// peers, as exponentially weighted moving averages
+type peerRanking struct {
+	sync.Mutex
+	peers map[peer.ID]*peerRecord
+}
+
+func newPeerRanking() *peerRanking {
+	return &peerRanking{peers: make(map[peer.ID]*peerRecord)}
+}
+
+// record updates the ranking of a peer after a query. An invalid cursor is the
+// fault of the client, so it's not counted as an error of the peer
+func (r *peerRanking) record(p peer.ID, d time.Duration, err error) {
+	if errors.Is(err, ErrInvalidCursor) {
+		return
+	}
+
+	failure := 0.0
+	if err != nil {
+		failure = 1
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	rec, ok := r.peers[p]
+	if !ok {
+		r.peers[p] = &peerRecord{errorRate: failure, duration: d}
+		return
+	}
+
+	rec.errorRate = rankingWeight*failure + (1-rankingWeight)*rec.errorRate
+	rec.duration = time.Duration(rankingWeight*float64(d) + (1-rankingWeight)*float64(rec.duration))
+}
+
+// score is the expected cost of querying a peer: its latency, plus the time
+// lost waiting for an attempt to time out weighted by its error rate. The
+// latency measured by libp2p is used when known, the duration of the last
+// queries otherwise
+func (r *peerRanking) score(p peer.ID, latency time.Duration) time.Duration {
+	r.Lock()
+	rec, ok := r.peers[p]
+	r.Unlock()
+
+	if latency == 0 {
+		latency = unknownLatency
+		if ok {
+			latency = rec.duration
+		}
+	}
+
+	if !ok {
+		return latency
+	}
+	return latency + time.Duration(rec.errorRate*float64(DefaultAttemptTimeout))
+}
+
+// rankedPeers returns the store peers of the peerstore, best first, at most
+// max of them unless max is 0. The connected peers are ranked before the
+// others, which have to be dialed, i.e. the ones known from a previous run
+func (store *WakuStore) rankedPeers(max int) []peer.ID {
+	var peers []peer.ID
+	scores := make(map[peer.ID]time.Duration)
+	connected := make(map[peer.ID]bool)
+	for _, p := range store.h.Peerstore().Peers() {
+		if p == store.h.ID() {
+			continue
+		}
+
+		protocols, err := store.h.Peerstore().SupportsProtocols(p, string(StoreID_v20beta3))
+		if err != nil || len(protocols) == 0 {
+			continue
+		}
+
+		peers = append(peers, p)
+		scores[p] = store.ranking.score(p, store.h.Peerstore().LatencyEWMA(p))
+		connected[p] = store.h.Network().Connectedness(p) == network.Connected
+	}
+
+	sort.SliceStable(peers, func(i, j int) bool {
+		if connected[peers[i]] != connected[peers[j]] {
+			return connected[peers[i]]
+		}
+		return scores[peers[i]] < scores[peers[j]]
+	})
+
+	if max > 0 && len(peers) > max {
+		peers = peers[:max]
+	}
+	return peers
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerRankingScore(t *testing.T) {
+	r := newPeerRanking()
+
+	// Peers never queried are assumed to have the unknown latency, unless
+	// libp2p measured it
+	require.Equal(t, unknownLatency, r.score("p1", 0))
+	require.Equal(t, 10*time.Millisecond, r.score("p1", 10*time.Millisecond))
+
+	r.record("p1", 100*time.Millisecond, nil)
+	require.Equal(t, 100*time.Millisecond, r.score("p1", 0))
+	require.Equal(t, 10*time.Millisecond, r.score("p1", 10*time.Millisecond))
+
+	// Failures add part of the attempt timeout to the score
+	r.record("p1", 100*time.Millisecond, errors.New("failed"))
+	require.Equal(t, 100*time.Millisecond+time.Duration(rankingWeight*float64(DefaultAttemptTimeout)), r.score("p1", 0))
+
+	// and successes lower the error rate again
+	before := r.score("p1", 0)
+	r.record("p1", 100*time.Millisecond, nil)
+	require.Less(t, int64(r.score("p1", 0)), int64(before))
+}
+
+func TestPeerRankingInvalidCursor(t *testing.T) {
+	r := newPeerRanking()
+	r.record("p1", 100*time.Millisecond, nil)
+
+	// An invalid cursor is the fault of the client
+	r.record("p1", time.Second, ErrInvalidCursor)
+	require.Equal(t, 100*time.Millisecond, r.score("p1", 0))
+}
+
+func TestRankedPeers(t *testing.T) {
+	client, clientHost := makeStoreNode(t)
+	_, storeHost1 := makeStoreNode(t)
+	_, storeHost2 := makeStoreNode(t)
+	_, storeHost3 := makeStoreNode(t)
+	addStorePeer(t, clientHost, storeHost1)
+	addStorePeer(t, clientHost, storeHost2)
+	addStorePeer(t, clientHost, storeHost3)
+
+	client.ranking.record(storeHost1.ID(), 300*time.Millisecond, nil)
+	client.ranking.record(storeHost2.ID(), 100*time.Millisecond, nil)
+	client.ranking.record(storeHost3.ID(), 10*time.Millisecond, errors.New("failed"))
+
+	require.Equal(t, []peer.ID{storeHost2.ID(), storeHost1.ID(), storeHost3.ID()}, client.rankedPeers(0))
+	require.Equal(t, []peer.ID{storeHost2.ID()}, client.rankedPeers(1))
+
+	// Connected peers are ranked first, since the others have to be dialed
+	require.NoError(t, clientHost.Connect(context.Background(), clientHost.Peerstore().PeerInfo(storeHost3.ID())))
+	require.Equal(t, storeHost3.ID(), client.rankedPeers(0)[0])
+}
@@ -0,0 +1,1121 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	libp2pProtocol "github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-msgio/protoio"
+
+	"github.com/status-im/go-waku/waku/persistence"
+	"github.com/status-im/go-waku/waku/v2/metrics"
+	"github.com/status-im/go-waku/waku/v2/protocol"
+	"github.com/status-im/go-waku/waku/v2/protocol/pb"
+	"github.com/status-im/go-waku/waku/v2/utils"
+)
+
+var log = logging.Logger("wakustore")
+
+// StoreID_v20beta3 is the current Waku Store protocol identifier
+const StoreID_v20beta3 = libp2pProtocol.ID("/vac/waku/store/2.0.0-beta3")
+
+// MaxPageSize is the maximum number of waku messages to return per page
+const MaxPageSize = 100
+
+// DefaultPageSize is the number of waku messages requested per page unless
+// specified WithPaging
+const DefaultPageSize = 20
+
+var (
+	ErrNoPeersAvailable      = errors.New("no suitable remote peers")
+	ErrInvalidId             = errors.New("invalid request id")
+	ErrFailedToResumeHistory = errors.New("failed to resume the history")
+	ErrFailedQuery           = errors.New("failed to resolve the query")
+	ErrInvalidTimeRange      = errors.New("invalid time range")
+	ErrInvalidCursor         = errors.New("invalid cursor")
+)
+
+// DefaultAttemptTimeout bounds each attempt of a query sent to several peers,
+// when its context has no deadline
+const DefaultAttemptTimeout = 10 * time.Second
+
+// DefaultMaxAttempts is the number of store peers a query is sent to
+// WithAutomaticPeerSelection unless specified WithMaxAttempts
+const DefaultMaxAttempts = 3
+
+// Clock difference tolerated with the store nodes when validating the time
+// range of a query
+const maxClockSkew = 20 * time.Second
+
+func minOf(vars ...int) int {
+	min := vars[0]
+
+	for _, i := range vars {
+		if min > i {
+			min = i
+		}
+	}
+
+	return min
+}
+
+func paginateWithIndex(list []IndexedWakuMessage, pinfo *pb.PagingInfo) (resMessages []IndexedWakuMessage, resPagingInfo *pb.PagingInfo) {
+	if pinfo == nil {
+		pinfo = new(pb.PagingInfo)
+	}
+
+	// takes list, and performs paging based on pinfo
+	// returns the page i.e, a sequence of IndexedWakuMessage and the new paging info to be used for the next paging request
+	cursor := pinfo.Cursor
+	pageSize := pinfo.PageSize
+	dir := pinfo.Direction
+
+	if pageSize == 0 { // pageSize being zero indicates that no pagination is required
+		return list, pinfo
+	}
+
+	if len(list) == 0 { // no pagination is needed for an empty list
+		return list, &pb.PagingInfo{PageSize: 0, Cursor: pinfo.Cursor, Direction: pinfo.Direction}
+	}
+
+	msgList := make([]IndexedWakuMessage, len(list))
+	_ = copy(msgList, list) // makes a copy of the list
+
+	sort.Slice(msgList, func(i, j int) bool { // sorts msgList based on the custom comparison proc indexedWakuMessageComparison
+		return indexedWakuMessageComparison(msgList[i], msgList[j]) == -1
+	})
+
+	initQuery := false
+	if cursor == nil {
+		initQuery = true // an empty cursor means it is an initial query
+		switch dir {
+		case pb.PagingInfo_FORWARD:
+			cursor = msgList[0].index // perform paging from the beginning of the list
+		case pb.PagingInfo_BACKWARD:
+			cursor = msgList[len(msgList)-1].index // perform paging from the end of the list
+		}
+	}
+
+	foundIndex := findIndex(msgList, cursor)
+	if foundIndex == -1 { // the cursor is not valid
+		return nil, &pb.PagingInfo{PageSize: 0, Cursor: pinfo.Cursor, Direction: pinfo.Direction}
+	}
+
+	var retrievedPageSize, s, e int
+	var newCursor *pb.Index // to be returned as part of the new paging info
+	switch dir {
+	case pb.PagingInfo_FORWARD: // forward pagination
+		remainingMessages := len(msgList) - foundIndex - 1
+		if initQuery {
+			remainingMessages = remainingMessages + 1
+			foundIndex = foundIndex - 1
+		}
+		// the number of queried messages cannot exceed the MaxPageSize and the total remaining messages i.e., msgList.len-foundIndex
+		retrievedPageSize = minOf(int(pageSize), MaxPageSize, remainingMessages)
+		s = foundIndex + 1 // non inclusive
+		e = foundIndex + retrievedPageSize
+		newCursor = msgList[e].index // the new cursor points to the end of the page
+	case pb.PagingInfo_BACKWARD: // backward pagination
+		remainingMessages := foundIndex
+		if initQuery {
+			remainingMessages = remainingMessages + 1
+			foundIndex = foundIndex + 1
+		}
+		// the number of queried messages cannot exceed the MaxPageSize and the total remaining messages i.e., foundIndex-0
+		retrievedPageSize = minOf(int(pageSize), MaxPageSize, remainingMessages)
+		s = foundIndex - retrievedPageSize
+		e = foundIndex - 1
+		newCursor = msgList[s].index // the new cursor points to the beginning of the page
+	}
+
+	// retrieve the messages
+	for i := s; i <= e; i++ {
+		resMessages = append(resMessages, msgList[i])
+	}
+
+	// there is no cursor after the last page, so clients know they are done
+	// without requesting another page
+	if (dir == pb.PagingInfo_FORWARD && e == len(msgList)-1) || (dir == pb.PagingInfo_BACKWARD && s == 0) {
+		newCursor = nil
+	}
+
+	resPagingInfo = &pb.PagingInfo{PageSize: uint64(retrievedPageSize), Cursor: newCursor, Direction: pinfo.Direction}
+
+	return
+}
+
+func paginateWithoutIndex(list []IndexedWakuMessage, pinfo *pb.PagingInfo) (resMessages []*pb.WakuMessage, resPinfo *pb.PagingInfo) {
+	// takes list, and performs paging based on pinfo
+	// returns the page i.e, a sequence of WakuMessage and the new paging info to be used for the next paging request
+	indexedData, updatedPagingInfo := paginateWithIndex(list, pinfo)
+	for _, indexedMsg := range indexedData {
+		resMessages = append(resMessages, indexedMsg.msg)
+	}
+	resPinfo = updatedPagingInfo
+	return
+}
+
+func (store *WakuStore) FindMessages(query *pb.HistoryQuery) *pb.HistoryResponse {
+	result := new(pb.HistoryResponse)
+	// data holds IndexedWakuMessage whose topics match the query
+	var data []IndexedWakuMessage
+	for indexedMsg := range store.messageQueue.Messages() {
+		// temporal filtering
+		// a time of 0 means the range is not bounded on that side, and the
+		// messages on the bounds are included
+		if query.StartTime != 0 && indexedMsg.msg.Timestamp < query.StartTime {
+			continue
+		}
+		if query.EndTime != 0 && indexedMsg.msg.Timestamp > query.EndTime {
+			continue
+		}
+
+		// filter based on content filters
+		// an empty list of contentFilters means no content filter is requested
+		if len(query.ContentFilters) != 0 {
+			match := false
+			for _, cf := range query.ContentFilters {
+				if cf.ContentTopic == indexedMsg.msg.ContentTopic {
+					match = true
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+		}
+
+		// filter based on pubsub topic
+		// an empty pubsub topic means no pubsub topic filter is requested
+		if query.PubsubTopic != "" {
+			if indexedMsg.pubsubTopic != query.PubsubTopic {
+				continue
+			}
+		}
+
+		// Some criteria matched
+		data = append(data, indexedMsg)
+	}
+
+	result.Messages, result.PagingInfo = paginateWithoutIndex(data, query.PagingInfo)
+	return result
+}
+
+type MessageProvider interface {
+	GetAll() ([]persistence.StoredMessage, error)
+	Put(cursor *pb.Index, pubsubTopic string, message *pb.WakuMessage) error
+	Stop()
+}
+
+type Query struct {
+	Topic         string
+	ContentTopics []string
+	StartTime     float64
+	EndTime       float64
+}
+
+// Result represents a valid response from a store node
+type Result struct {
+	Messages []*pb.WakuMessage
+
+	query  *pb.HistoryQuery
+	cursor *pb.Index
+	peerId peer.ID
+	// Number of messages of the response, including the ones already
+	// received from another peer
+	received int
+	attempts []Attempt
+	// Peers the next page is requested from if peerId fails
+	fallbackPeers []peer.ID
+	// Timestamp of the last message received, in the order of the query, and
+	// the messages received with that timestamp, to drop them when the next
+	// page is requested from another peer
+	boundary     float64
+	boundarySeen map[string]struct{}
+}
+
+func (r *Result) Cursor() *pb.Index {
+	return r.cursor
+}
+
+// PeerID returns the peer that answered the query, which is one of the
+// fallback peers if the selected one failed
+func (r *Result) PeerID() peer.ID {
+	return r.peerId
+}
+
+func (r *Result) Query() *pb.HistoryQuery {
+	return r.query
+}
+
+// Attempts returns the peers the query, or the page for the results returned
+// by Next, was sent to until one of them answered, and how long each took
+func (r *Result) Attempts() []Attempt {
+	return r.attempts
+}
+
+// IsComplete returns whether the result is the last page of the query, so
+// there is no need to call Next. Store nodes not signaling the last page
+// need one more query, which returns no messages
+func (r *Result) IsComplete() bool {
+	if r.cursor == nil || r.received == 0 {
+		return true
+	}
+
+	pageSize := r.query.PagingInfo.PageSize
+	if pageSize == 0 {
+		// The query was not paginated
+		return true
+	}
+
+	return uint64(r.received) < uint64(minOf(int(pageSize), MaxPageSize))
+}
+
+type IndexedWakuMessage struct {
+	msg         *pb.WakuMessage
+	index       *pb.Index
+	pubsubTopic string
+}
+
+type WakuStore struct {
+	ctx  context.Context
+	MsgC chan *protocol.Envelope
+	wg   *sync.WaitGroup
+
+	started bool
+
+	messageQueue *MessageQueue
+	msgProvider  MessageProvider
+	h            host.Host
+	selector     *utils.PeerSelector
+	ranking      *peerRanking
+	// Called with the messages returned by each query
+	observer func(peer.ID, []*pb.WakuMessage)
+}
+
+// NewWakuStore creates a WakuStore using an specific MessageProvider for storing the messages
+func NewWakuStore(host host.Host, p MessageProvider, maxNumberOfMessages int, maxRetentionDuration time.Duration) *WakuStore {
+	wakuStore := new(WakuStore)
+	wakuStore.msgProvider = p
+	wakuStore.h = host
+	wakuStore.wg = &sync.WaitGroup{}
+	wakuStore.ranking = newPeerRanking()
+	wakuStore.messageQueue = NewMessageQueue(maxNumberOfMessages, maxRetentionDuration)
+	return wakuStore
+}
+
+// SetMessageProvider allows switching the message provider used with a WakuStore
+func (store *WakuStore) SetMessageProvider(p MessageProvider) {
+	store.msgProvider = p
+}
+
+// SetPeerSelector sets how the peers are selected when none is specified, and
+// lets the selector know about the queries that failed
+func (store *WakuStore) SetPeerSelector(selector *utils.PeerSelector) {
+	store.selector = selector
+}
+
+// SetQueryObserver sets a function called with the messages returned by the
+// queries sent to other peers, including the ones used to resume the history
+func (store *WakuStore) SetQueryObserver(observer func(peer.ID, []*pb.WakuMessage)) {
+	store.observer = observer
+}
+
+// SetMaxSize sets the maximum size of the archived messages. Once it's
+// exceeded, the oldest messages are removed until the archive is back to 90%
+// of it. A size of 0 means unlimited. The size of a message is approximated
+// by persistence.MessageSize
+func (store *WakuStore) SetMaxSize(maxBytes int64) {
+	store.messageQueue.SetMaxSize(maxBytes)
+}
+
+// ArchiveSize returns the approximate size of the archived messages
+func (store *WakuStore) ArchiveSize() int64 {
+	return store.messageQueue.Size()
+}
+
+// StoreStats describes the messages archived by a WakuStore
+type StoreStats struct {
+	Messages int
+	// Number of messages of each pubsub topic
+	TopicMessages map[string]int
+	// Timestamps of the oldest and newest messages, 0 without messages
+	OldestTimestamp float64
+	NewestTimestamp float64
+	// Approximate size of the messages, as computed by
+	// persistence.MessageSize
+	Size int64
+	// Size of the messages persisted by the message provider, or 0 if it
+	// can't report it
+	ProviderSize int64
+}
+
+// Stats returns statistics about the archived messages. They are kept up to
+// date as messages are archived and pruned, so it's cheap to call
+func (store *WakuStore) Stats() StoreStats {
+	qStats := store.messageQueue.stats()
+
+	result := StoreStats{
+		Messages:        qStats.messages,
+		TopicMessages:   make(map[string]int),
+		OldestTimestamp: qStats.oldest,
+		NewestTimestamp: qStats.newest,
+		Size:            qStats.size,
+	}
+
+	for topic, count := range qStats.topicMessages {
+		if count > 0 {
+			result.TopicMessages[topic] = count
+		}
+	}
+
+	if p, ok := store.msgProvider.(interface{ Size() int64 }); ok {
+		result.ProviderSize = p.Size()
+	}
+
+	return result
+}
+
+func (store *WakuStore) recordArchive() {
+	qStats := store.messageQueue.stats()
+	metrics.RecordArchive(store.ctx, qStats.topicMessages, qStats.size, qStats.oldest, qStats.newest)
+}
+
+// Start initializes the WakuStore by enabling the protocol and fetching records from a message provider
+func (store *WakuStore) Start(ctx context.Context) {
+	if store.started {
+		return
+	}
+
+	store.started = true
+	store.ctx = ctx
+	store.MsgC = make(chan *protocol.Envelope, 1024)
+
+	store.h.SetStreamHandlerMatch(StoreID_v20beta3, protocol.PrefixTextMatch(string(StoreID_v20beta3)), store.onRequest)
+
+	store.wg.Add(1)
+	go store.storeIncomingMessages(ctx)
+
+	if store.msgProvider == nil {
+		log.Info("Store protocol started (no message provider)")
+		return
+	}
+
+	store.fetchDBRecords(ctx)
+
+	log.Info("Store protocol started")
+}
+
+func (store *WakuStore) fetchDBRecords(ctx context.Context) {
+	if store.msgProvider == nil {
+		return
+	}
+
+	storedMessages, err := (store.msgProvider).GetAll()
+	if err != nil {
+		log.Error("could not load DBProvider messages", err)
+		metrics.RecordStoreError(ctx, "store_load_failure")
+		return
+	}
+
+	for _, storedMessage := range storedMessages {
+		idx := &pb.Index{
+			Digest:       storedMessage.ID,
+			ReceiverTime: float64(storedMessage.ReceiverTime),
+		}
+
+		store.storeMessageWithIndex(storedMessage.PubsubTopic, idx, storedMessage.Message)
+
+		metrics.RecordMessage(ctx, "stored", store.messageQueue.Length())
+	}
+
+	store.recordArchive()
+}
+
+func (store *WakuStore) storeMessageWithIndex(pubsubTopic string, idx *pb.Index, msg *pb.WakuMessage) bool {
+	return store.messageQueue.Push(IndexedWakuMessage{msg: msg, index: idx, pubsubTopic: pubsubTopic})
+}
+
+// storeMessage archives a message, and returns false if it was already
+// archived, such as a message received both with relay and when resuming the
+// history
+func (store *WakuStore) storeMessage(env *protocol.Envelope) bool {
+	index, err := computeIndex(env)
+	if err != nil {
+		log.Error("could not calculate message index", err)
+		return false
+	}
+
+	if !store.storeMessageWithIndex(env.PubsubTopic(), index, env.Message()) {
+		return false
+	}
+
+	store.recordArchive()
+
+	if store.msgProvider == nil {
+		metrics.RecordMessage(store.ctx, "stored", store.messageQueue.Length())
+		return true
+	}
+
+	// TODO: Move this to a separate go routine if DB writes becomes a bottleneck
+	err = store.msgProvider.Put(index, env.PubsubTopic(), env.Message()) // Should the index be stored?
+	if err != nil {
+		log.Error("could not store message", err)
+		metrics.RecordStoreError(store.ctx, "store_failure")
+		return true
+	}
+
+	metrics.RecordMessage(store.ctx, "stored", store.messageQueue.Length())
+	return true
+}
+
+func (store *WakuStore) storeIncomingMessages(ctx context.Context) {
+	defer store.wg.Done()
+	for envelope := range store.MsgC {
+		store.storeMessage(envelope)
+	}
+}
+
+func (store *WakuStore) onRequest(s network.Stream) {
+	defer s.Close()
+
+	historyRPCRequest := &pb.HistoryRPC{}
+
+	writer := protoio.NewDelimitedWriter(s)
+	reader := protoio.NewDelimitedReader(s, math.MaxInt32)
+
+	err := reader.ReadMsg(historyRPCRequest)
+	if err != nil {
+		log.Error("error reading request", err)
+		metrics.RecordStoreError(store.ctx, "decodeRPCFailure")
+		return
+	}
+
+	log.Info(fmt.Sprintf("%s: Received query from %s", s.Conn().LocalPeer(), s.Conn().RemotePeer()))
+
+	historyResponseRPC := &pb.HistoryRPC{}
+	historyResponseRPC.RequestId = historyRPCRequest.RequestId
+	historyResponseRPC.Response = store.FindMessages(historyRPCRequest.Query)
+
+	err = writer.WriteMsg(historyResponseRPC)
+	if err != nil {
+		log.Error("error writing response", err)
+		_ = s.Reset()
+	} else {
+		log.Info(fmt.Sprintf("%s: Response sent  to %s", s.Conn().LocalPeer().String(), s.Conn().RemotePeer().String()))
+	}
+}
+
+func computeIndex(env *protocol.Envelope) (*pb.Index, error) {
+	return &pb.Index{
+		Digest:       env.Hash(),
+		ReceiverTime: utils.GetUnixEpoch(),
+		SenderTime:   env.Message().Timestamp,
+	}, nil
+}
+
+func indexComparison(x, y *pb.Index) int {
+	// compares x and y
+	// returns 0 if they are equal
+	// returns -1 if x < y
+	// returns 1 if x > y
+
+	var timecmp int = 0
+	if x.SenderTime > y.SenderTime {
+		timecmp = 1
+	} else if x.SenderTime < y.SenderTime {
+		timecmp = -1
+	}
+
+	digestcm := bytes.Compare(x.Digest, y.Digest)
+	if timecmp != 0 {
+		return timecmp // timestamp has a higher priority for comparison
+	}
+
+	return digestcm
+}
+
+func indexedWakuMessageComparison(x, y IndexedWakuMessage) int {
+	// compares x and y
+	// returns 0 if they are equal
+	// returns -1 if x < y
+	// returns 1 if x > y
+	return indexComparison(x.index, y.index)
+}
+
+func findIndex(msgList []IndexedWakuMessage, index *pb.Index) int {
+	// returns the position of an IndexedWakuMessage in msgList whose index value matches the given index
+	// returns -1 if no match is found
+	for i, indexedWakuMessage := range msgList {
+		if bytes.Equal(indexedWakuMessage.index.Digest, index.Digest) && indexedWakuMessage.index.ReceiverTime == index.ReceiverTime {
+			return i
+		}
+	}
+	return -1
+}
+
+type HistoryRequestParameters struct {
+	selectedPeer   peer.ID
+	fallbackPeers  []peer.ID
+	automaticPeers bool
+	maxAttempts    int
+	requestId      []byte
+	cursor         *pb.Index
+	pageSize       uint64
+	asc            bool
+	startTime      *time.Time
+	endTime        *time.Time
+
+	s *WakuStore
+}
+
+type HistoryRequestOption func(*HistoryRequestParameters)
+
+// WithPeer is an option used to specify the peerID to request the message history
+func WithPeer(p peer.ID) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.selectedPeer = p
+		params.automaticPeers = false
+	}
+}
+
+// WithFallbackPeers is an option used to specify the peers the query is sent
+// to, in order, when the selected peer fails, doesn't answer in time or
+// answers with an error
+func WithFallbackPeers(ids ...peer.ID) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.fallbackPeers = append(params.fallbackPeers, ids...)
+	}
+}
+
+// WithAutomaticPeerSelection is an option used to send the query to the best
+// connected store peer, ranked by latency and recent error rate. When it fails,
+// the query is sent to the next ones, up to the number of attempts set
+// WithMaxAttempts
+func WithAutomaticPeerSelection() HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.selectedPeer = ""
+		params.automaticPeers = true
+	}
+}
+
+// WithMaxAttempts is an option used to set the number of store peers a query
+// is sent to WithAutomaticPeerSelection, DefaultMaxAttempts by default. The
+// peers set WithFallbackPeers are tried whatever the number of attempts
+func WithMaxAttempts(n int) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.maxAttempts = n
+	}
+}
+
+func WithFastestPeerSelection(ctx context.Context) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		p, err := utils.SelectPeerWithLowestRTT(ctx, params.s.h, string(StoreID_v20beta3))
+		if err == nil {
+			params.selectedPeer = *p
+			params.automaticPeers = false
+		} else {
+			log.Info("Error selecting peer: ", err)
+		}
+	}
+}
+
+func WithRequestId(requestId []byte) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.requestId = requestId
+	}
+}
+
+func WithAutomaticRequestId() HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.requestId = protocol.GenerateRequestId()
+	}
+}
+
+func WithCursor(c *pb.Index) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.cursor = c
+	}
+}
+
+// WithPaging is an option used to specify the order and maximum number of records to return
+func WithPaging(asc bool, pageSize uint64) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.asc = asc
+		params.pageSize = pageSize
+	}
+}
+
+// WithTimeRange is an option used to only retrieve the messages with a
+// timestamp between start and end, both included. It overrides the times of
+// the query. A zero time leaves the range unbounded on that side
+func WithTimeRange(start time.Time, end time.Time) HistoryRequestOption {
+	return func(params *HistoryRequestParameters) {
+		params.startTime = &start
+		params.endTime = &end
+	}
+}
+
+func timestamp(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return utils.GetUnixEpochFrom(t)
+}
+
+// validateTimeRange rejects the time ranges which can't match any message:
+// negative times, times in the future and ranges ending before they start
+func validateTimeRange(startTime float64, endTime float64) error {
+	if startTime < 0 || endTime < 0 {
+		return fmt.Errorf("%w: negative time", ErrInvalidTimeRange)
+	}
+
+	if startTime != 0 && endTime != 0 && endTime < startTime {
+		return fmt.Errorf("%w: ends before it starts", ErrInvalidTimeRange)
+	}
+
+	maxTime := utils.GetUnixEpochFrom(time.Now().Add(maxClockSkew))
+	if startTime > maxTime || endTime > maxTime {
+		return fmt.Errorf("%w: time in the future", ErrInvalidTimeRange)
+	}
+
+	return nil
+}
+
+// Default options to be used when querying a store node for results
+func DefaultOptions() []HistoryRequestOption {
+	return []HistoryRequestOption{
+		WithAutomaticRequestId(),
+		WithAutomaticPeerSelection(),
+		WithMaxAttempts(DefaultMaxAttempts),
+		WithPaging(true, DefaultPageSize),
+	}
+}
+
+func (store *WakuStore) queryFrom(ctx context.Context, q *pb.HistoryQuery, selectedPeer peer.ID, requestId []byte) (*pb.HistoryResponse, error) {
+	log.Info(fmt.Sprintf("Querying message history with peer %s", selectedPeer))
+
+	start := time.Now()
+	response, err := store.requestFrom(ctx, q, selectedPeer, requestId)
+	store.ranking.record(selectedPeer, time.Since(start), err)
+	if err != nil {
+		store.selector.RecordFailure(selectedPeer)
+		return nil, err
+	}
+	store.selector.RecordSuccess(selectedPeer)
+
+	if store.observer != nil && response != nil {
+		store.observer(selectedPeer, response.Messages)
+	}
+
+	return response, nil
+}
+
+func (store *WakuStore) requestFrom(ctx context.Context, q *pb.HistoryQuery, selectedPeer peer.ID, requestId []byte) (*pb.HistoryResponse, error) {
+	connOpt, err := store.h.NewStream(ctx, selectedPeer, StoreID_v20beta3)
+	if err != nil {
+		log.Error("Failed to connect to remote peer", err)
+		return nil, err
+	}
+
+	defer connOpt.Close()
+	defer func() {
+		_ = connOpt.Reset()
+	}()
+
+	// Peers that don't answer in time are given up on, so the query can be
+	// sent to another one
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = connOpt.SetDeadline(deadline)
+	}
+
+	historyRequest := &pb.HistoryRPC{Query: q, RequestId: hex.EncodeToString(requestId)}
+
+	writer := protoio.NewDelimitedWriter(connOpt)
+	reader := protoio.NewDelimitedReader(connOpt, math.MaxInt32)
+
+	err = writer.WriteMsg(historyRequest)
+	if err != nil {
+		log.Error("could not write request", err)
+		return nil, err
+	}
+
+	historyResponseRPC := &pb.HistoryRPC{}
+	err = reader.ReadMsg(historyResponseRPC)
+	if err != nil {
+		log.Error("could not read response", err)
+		metrics.RecordStoreError(store.ctx, "decodeRPCFailure")
+		return nil, err
+	}
+
+	metrics.RecordMessage(ctx, "retrieved", store.messageQueue.Length())
+
+	// Avoid pruning the connection to a peer used to retrieve history
+	store.h.ConnManager().Protect(selectedPeer, string(StoreID_v20beta3))
+
+	return historyResponseRPC.Response, nil
+}
+
+func (store *WakuStore) Query(ctx context.Context, query Query, opts ...HistoryRequestOption) (*Result, error) {
+	q := &pb.HistoryQuery{
+		PubsubTopic:    query.Topic,
+		ContentFilters: []*pb.ContentFilter{},
+		StartTime:      query.StartTime,
+		EndTime:        query.EndTime,
+		PagingInfo:     &pb.PagingInfo{},
+	}
+
+	for _, cf := range query.ContentTopics {
+		q.ContentFilters = append(q.ContentFilters, &pb.ContentFilter{ContentTopic: cf})
+	}
+
+	params := new(HistoryRequestParameters)
+	params.s = store
+
+	optList := DefaultOptions()
+	optList = append(optList, opts...)
+	for _, opt := range optList {
+		opt(params)
+	}
+
+	var fallbackPeers []peer.ID
+	if params.automaticPeers {
+		fallbackPeers = store.rankedPeers(params.maxAttempts)
+	}
+	fallbackPeers = append(fallbackPeers, params.fallbackPeers...)
+
+	candidates := queryCandidates(params.selectedPeer, fallbackPeers)
+	if len(candidates) == 0 {
+		return nil, ErrNoPeersAvailable
+	}
+
+	if len(params.requestId) == 0 {
+		return nil, ErrInvalidId
+	}
+
+	if params.startTime != nil {
+		q.StartTime = timestamp(*params.startTime)
+	}
+
+	if params.endTime != nil {
+		q.EndTime = timestamp(*params.endTime)
+	}
+
+	if err := validateTimeRange(q.StartTime, q.EndTime); err != nil {
+		return nil, err
+	}
+
+	if params.cursor != nil {
+		q.PagingInfo.Cursor = params.cursor
+	}
+
+	if params.asc {
+		q.PagingInfo.Direction = pb.PagingInfo_FORWARD
+	} else {
+		q.PagingInfo.Direction = pb.PagingInfo_BACKWARD
+	}
+
+	q.PagingInfo.PageSize = params.pageSize
+
+	queryFor := func(peer.ID) *pb.HistoryQuery { return q }
+	response, p, attempts, err := store.queryWithFailover(ctx, queryFor, candidates, params.requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newResult(q, response, p, attempts, candidates)
+	result.trackBoundary(nil)
+	return result, nil
+}
+
+// Next is used with to retrieve the next page of rows from a query response.
+// If no more records are found, the result will not contain any messages.
+// This function is useful for iterating over results without having to manually
+// specify the cursor and pagination order and max number of results. If the
+// peer that answered the query fails, the next page is requested from the
+// other peers the query could be sent to, see resumeQuery
+func (store *WakuStore) Next(ctx context.Context, r *Result) (*Result, error) {
+	if r.IsComplete() {
+		return &Result{
+			Messages: []*pb.WakuMessage{},
+			query:    r.query,
+			peerId:   r.peerId,
+		}, nil
+	}
+
+	q := &pb.HistoryQuery{
+		PubsubTopic:    r.query.PubsubTopic,
+		ContentFilters: r.query.ContentFilters,
+		StartTime:      r.query.StartTime,
+		EndTime:        r.query.EndTime,
+		PagingInfo: &pb.PagingInfo{
+			PageSize:  r.query.PagingInfo.PageSize,
+			Direction: r.query.PagingInfo.Direction,
+			Cursor: &pb.Index{
+				Digest:       r.cursor.Digest,
+				ReceiverTime: r.cursor.ReceiverTime,
+				SenderTime:   r.cursor.SenderTime,
+			},
+		},
+	}
+
+	queryFor := func(p peer.ID) *pb.HistoryQuery {
+		if p == r.peerId {
+			return q
+		}
+		return r.resumeQuery()
+	}
+
+	candidates := append([]peer.ID{r.peerId}, r.fallbackPeers...)
+	response, p, attempts, err := store.queryWithFailover(ctx, queryFor, candidates, protocol.GenerateRequestId())
+	if err != nil {
+		return nil, err
+	}
+
+	result := newResult(queryFor(p), response, p, attempts, candidates)
+	if p != r.peerId {
+		result.dropSeen(r)
+	}
+	result.trackBoundary(r)
+	return result, nil
+}
+
+func (store *WakuStore) queryLoop(ctx context.Context, query *pb.HistoryQuery, candidateList []peer.ID) (*pb.HistoryResponse, peer.ID, error) {
+	// loops through the candidateList in order and sends the query to each until one of the query gets resolved successfully
+	// returns the response and the peer which sent it, or error if all the requests fail
+	for _, peer := range candidateList {
+		result, err := store.queryFrom(ctx, query, peer, protocol.GenerateRequestId())
+		if err == nil {
+			return result, peer, nil
+		}
+		log.Error(fmt.Errorf("resume history with peer %s failed: %w", peer, err))
+	}
+
+	return nil, "", ErrFailedQuery
+}
+
+// findLastSeen returns the timestamp of the most recent message stored for a
+// pubsub topic
+func (store *WakuStore) findLastSeen(pubsubTopic string) float64 {
+	var lastSeenTime float64 = 0
+	for imsg := range store.messageQueue.Messages() {
+		if imsg.pubsubTopic == pubsubTopic && imsg.msg.Timestamp > lastSeenTime {
+			lastSeenTime = imsg.msg.Timestamp
+		}
+	}
+	return lastSeenTime
+}
+
+// Resume retrieves the history of waku messages published on the default waku pubsub topic since the last time the waku store node has been online
+// messages are stored in the store node's messages field and in the message db
+// the offline time window is measured as the difference between the current time and the timestamp of the most recent persisted waku message
+// an offset of 20 second is added to the time window to count for nodes asynchrony
+// the history is fetched from one of the peers persisted in the waku store node's peer manager unit
+// peerList indicates the list of peers to query from. The history is fetched from the first available peer in this list. Such candidates should be found through a discovery method (to be developed).
+// if no peerList is passed, one of the peers in the underlying peer manager unit of the store protocol is picked randomly to fetch the history from. The history gets fetched successfully if the dialed peer has been online during the queried time window.
+// the resume proc returns the number of retrieved messages if no error occurs, otherwise returns the error string
+func (store *WakuStore) Resume(ctx context.Context, pubsubTopic string, peerList []peer.ID) (int, error) {
+	result, err := store.ResumeWithOptions(ctx, pubsubTopic, peerList)
+	if err != nil {
+		return -1, err
+	}
+
+	return result.Count, nil
+}
+
+// Time to wait for each page of messages when resuming the history
+const resumePageTimeout = 20 * time.Second
+
+type resumeParameters struct {
+	progress    func(fetched int, total int)
+	maxMessages int
+}
+
+type ResumeOption func(*resumeParameters)
+
+// WithProgress is an option used to be notified after each page of messages
+// retrieved when resuming the history, with the number of messages of the
+// page and the total number of messages retrieved so far
+func WithProgress(progress func(fetched int, total int)) ResumeOption {
+	return func(params *resumeParameters) {
+		params.progress = progress
+	}
+}
+
+// WithMaxMessages is an option used to stop resuming the history once n
+// messages have been retrieved. The most recent messages are retrieved first
+func WithMaxMessages(n int) ResumeOption {
+	return func(params *resumeParameters) {
+		params.maxMessages = n
+	}
+}
+
+// ResumeWithOptions resumes the history of a pubsub topic as Resume does, one
+// page of MaxPageSize messages at a time. Each page must be received within
+// 20s, so a long history doesn't fail as long as the store node keeps
+// sending it. The messages retrieved before an error are kept and counted
+// in the result
+func (store *WakuStore) ResumeWithOptions(ctx context.Context, pubsubTopic string, peerList []peer.ID, opts ...ResumeOption) (ResumeResult, error) {
+	result := ResumeResult{Topic: pubsubTopic}
+	if !store.started {
+		return result, errors.New("can't resume: store has not started")
+	}
+
+	params := new(resumeParameters)
+	for _, opt := range opts {
+		opt(params)
+	}
+
+	if len(peerList) == 0 {
+		p, err := store.selector.SelectPeer(store.h, string(StoreID_v20beta3))
+		if err != nil {
+			log.Info("Error selecting peer: ", err)
+			return result, ErrNoPeersAvailable
+		}
+		peerList = []peer.ID{*p}
+	}
+
+	currentTime := utils.GetUnixEpoch()
+	lastSeenTime := store.findLastSeen(pubsubTopic)
+
+	var offset float64 = 20
+	currentTime = currentTime + offset
+	lastSeenTime = math.Max(lastSeenTime-offset, 0)
+
+	rpc := &pb.HistoryQuery{
+		PubsubTopic: pubsubTopic,
+		StartTime:   lastSeenTime,
+		EndTime:     currentTime,
+		PagingInfo: &pb.PagingInfo{
+			PageSize:  MaxPageSize,
+			Direction: pb.PagingInfo_BACKWARD,
+		},
+	}
+
+	var selectedPeer peer.ID
+	for {
+		pageCtx, cancel := context.WithTimeout(ctx, resumePageTimeout)
+		var response *pb.HistoryResponse
+		var err error
+		if selectedPeer == "" {
+			response, selectedPeer, err = store.queryLoop(pageCtx, rpc, peerList)
+		} else {
+			// The following pages are requested from the peer which has the cursor
+			response, err = store.queryFrom(pageCtx, rpc, selectedPeer, protocol.GenerateRequestId())
+		}
+		cancel()
+
+		if err == nil && response.Error == pb.HistoryResponse_INVALID_CURSOR {
+			err = ErrInvalidCursor
+		}
+		if err != nil {
+			log.Error("failed to resume history", err)
+			return result, ErrFailedToResumeHistory
+		}
+
+		messages := response.Messages
+		if params.maxMessages > 0 && result.Count+len(messages) > params.maxMessages {
+			// Keep the most recent messages of the page, which are at its end
+			result.Skipped = result.Count + len(messages) - params.maxMessages
+			messages = messages[result.Skipped:]
+		}
+
+		for _, msg := range messages {
+			if !store.storeMessage(protocol.NewEnvelope(msg, pubsubTopic)) {
+				result.Duplicates++
+			}
+		}
+		result.Count += len(messages)
+
+		if params.progress != nil {
+			params.progress(len(messages), result.Count)
+		}
+
+		if result.Skipped > 0 || (params.maxMessages > 0 && result.Count == params.maxMessages) {
+			log.Info(fmt.Sprintf("Retrieved %d messages since the last online time, stopped at the maximum number of messages", result.Count))
+			return result, nil
+		}
+
+		if len(response.Messages) == 0 || response.PagingInfo == nil || response.PagingInfo.Cursor == nil {
+			break
+		}
+
+		rpc.PagingInfo.Cursor = response.PagingInfo.Cursor
+	}
+
+	log.Info(fmt.Sprintf("Retrieved %d messages since the last online time, %d already archived", result.Count, result.Duplicates))
+
+	return result, nil
+}
+
+// ResumeResult is the result of resuming the history of a pubsub topic
+type ResumeResult struct {
+	Topic string
+	// Number of retrieved messages
+	Count int
+	// Number of retrieved messages which were already archived, such as
+	// messages also received with relay while resuming
+	Duplicates int
+	// Number of messages skipped because of WithMaxMessages in the last
+	// retrieved page. The pages following it aren't requested
+	Skipped int
+	Err     error
+}
+
+// ResumeAll resumes the history of several pubsub topics, as Resume does for
+// each of them. Failing to resume a topic doesn't prevent resuming the
+// others. The result of each topic is returned, and ErrFailedToResumeHistory
+// if any of them failed. The options apply to each topic
+func (store *WakuStore) ResumeAll(ctx context.Context, pubsubTopics []string, peerList []peer.ID, opts ...ResumeOption) ([]ResumeResult, error) {
+	var results []ResumeResult
+	var failedTopics []string
+	for _, topic := range pubsubTopics {
+		result, err := store.ResumeWithOptions(ctx, topic, peerList, opts...)
+		if err != nil {
+			log.Info(fmt.Sprintf("could not resume history of %s: %s", topic, err))
+			failedTopics = append(failedTopics, topic)
+		}
+
+		result.Err = err
+		results = append(results, result)
+	}
+
+	if len(failedTopics) > 0 {
+		return results, fmt.Errorf("%w: %v", ErrFailedToResumeHistory, failedTopics)
+	}
+
+	return results, nil
+}
+
+// TODO: queryWithAccounting
+
+// Stop closes the store message channel and removes the protocol stream
+// handler. It returns once the messages of the channel are stored. Stopping a
+// stopped store does nothing
+func (store *WakuStore) Stop() {
+	if !store.started {
+		return
+	}
+	store.started = false
+
+	if store.MsgC != nil {
+		close(store.MsgC)
+	}
+
+	if store.h != nil {
+		store.h.RemoveStreamHandler(StoreID_v20beta3)
+	}
+
+	store.wg.Wait()
+}
@@ -0,0 +1,321 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+)
+
+var log = logging.Logger("utils")
+
+// MultiaddrENRField is the ENR key containing the multiaddresses
+// of a node that can't be expressed with the ip and tcp fields
+const MultiaddrENRField = "multiaddrs"
+
+var ErrNoPeersAvailable = errors.New("no suitable peers found")
+var PingServiceNotAvailable = errors.New("ping service not available")
+
+// SelectPeer is used to return a random peer that supports a given protocol.
+func SelectPeer(host host.Host, protocolId string) (*peer.ID, error) {
+	// @TODO We need to be more strategic about which peers we dial. Right now we just set one on the service.
+	// Ideally depending on the query and our set  of peers we take a subset of ideal peers.
+	// This will require us to check for various factors such as:
+	//  - which topics they track
+	//  - latency?
+	//  - default store peer?
+	peers, err := supportingPeers(host, protocolId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(peers) >= 1 {
+		// TODO: proper heuristic here that compares peer scores and selects "best" one. For now a random peer for the given protocol is returned
+		return &peers[rand.Intn(len(peers))], nil // nolint: gosec
+	}
+
+	return nil, ErrNoPeersAvailable
+}
+
+// supportingPeers returns the peers in the peerstore that support a protocol
+func supportingPeers(host host.Host, protocolId string) (peer.IDSlice, error) {
+	var peers peer.IDSlice
+	for _, peer := range host.Peerstore().Peers() {
+		protocols, err := host.Peerstore().SupportsProtocols(peer, protocolId)
+		if err != nil {
+			log.Error("error obtaining the protocols supported by peers", err)
+			return nil, err
+		}
+
+		if len(protocols) > 0 {
+			peers = append(peers, peer)
+		}
+	}
+	return peers, nil
+}
+
+type pingResult struct {
+	p   peer.ID
+	rtt time.Duration
+}
+
+func SelectPeerWithLowestRTT(ctx context.Context, host host.Host, protocolId string) (*peer.ID, error) {
+	peers, err := supportingPeers(host, protocolId)
+	if err != nil {
+		return nil, err
+	}
+
+	wg := sync.WaitGroup{}
+	waitCh := make(chan struct{})
+	pingCh := make(chan pingResult, 1000)
+
+	wg.Add(len(peers))
+
+	go func() {
+		for _, p := range peers {
+			go func(p peer.ID) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+				defer cancel()
+				result := <-ping.Ping(ctx, host, p)
+				if result.Error == nil {
+					pingCh <- pingResult{
+						p:   p,
+						rtt: result.RTT,
+					}
+				}
+			}(p)
+		}
+		wg.Wait()
+		close(waitCh)
+		close(pingCh)
+	}()
+
+	select {
+	case <-waitCh:
+		var min *pingResult
+		for p := range pingCh {
+			if min == nil {
+				min = &p
+			} else {
+				if p.rtt < min.rtt {
+					min = &p
+				}
+			}
+		}
+		if min == nil {
+			return nil, ErrNoPeersAvailable
+		} else {
+			return &min.p, nil
+		}
+	case <-ctx.Done():
+		return nil, ErrNoPeersAvailable
+	}
+}
+
+// EnodeToPeerID returns the peer ID derived from the public key of a node record
+func EnodeToPeerID(node *enode.Node) (peer.ID, error) {
+	return peer.IDFromPublicKey(&ECDSAPublicKey{node.Pubkey()})
+}
+
+func EnodeToMultiAddr(node *enode.Node) (ma.Multiaddr, error) {
+	peerID, err := EnodeToPeerID(node)
+	if err != nil {
+		return nil, err
+	}
+
+	ipProtocol := "ip4"
+	if node.IP().To4() == nil {
+		ipProtocol = "ip6"
+	}
+
+	return ma.NewMultiaddr(fmt.Sprintf("/%s/%s/tcp/%d/p2p/%s", ipProtocol, node.IP(), node.TCP(), peerID))
+}
+
+func EnodeToPeerInfo(node *enode.Node) (*peer.AddrInfo, error) {
+	address, err := EnodeToMultiAddr(node)
+	if err != nil {
+		return nil, err
+	}
+
+	return peer.AddrInfoFromP2pAddr(address)
+}
+
+// EnrToAddrInfo returns the peer info of a node record, with the address
+// from its ip and tcp fields and the ones included in its multiaddrs field
+func EnrToAddrInfo(node *enode.Node) (*peer.AddrInfo, error) {
+	peerID, err := EnodeToPeerID(node)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &peer.AddrInfo{ID: peerID}
+
+	if node.IP() != nil && node.TCP() != 0 {
+		ipProtocol := "ip4"
+		if node.IP().To4() == nil {
+			ipProtocol = "ip6"
+		}
+
+		addr, err := ma.NewMultiaddr(fmt.Sprintf("/%s/%s/tcp/%d", ipProtocol, node.IP(), node.TCP()))
+		if err != nil {
+			return nil, err
+		}
+		info.Addrs = append(info.Addrs, addr)
+	}
+
+	var multiaddrs []byte
+	if err := node.Load(enr.WithEntry(MultiaddrENRField, &multiaddrs)); err == nil {
+		addrs, err := decodeMultiaddrs(multiaddrs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s field in record of %s: %w", MultiaddrENRField, peerID, err)
+		}
+		info.Addrs = append(info.Addrs, addrs...)
+	} else if !enr.IsNotFound(err) {
+		return nil, err
+	}
+
+	if len(info.Addrs) == 0 {
+		return nil, fmt.Errorf("record of %s has no tcp endpoint", peerID)
+	}
+
+	return info, nil
+}
+
+// ExtractIP returns the IPv4 or IPv6 address of a multiaddress
+func ExtractIP(addr ma.Multiaddr) (net.IP, error) {
+	ipStr, err := addr.ValueForProtocol(ma.P_IP4)
+	if err != nil {
+		ipStr, err = addr.ValueForProtocol(ma.P_IP6)
+		if err != nil {
+			return nil, fmt.Errorf("could not extract ip from ma %s", addr)
+		}
+	}
+	return net.ParseIP(ipStr), nil
+}
+
+func GetENRandIP(addr ma.Multiaddr, privK *ecdsa.PrivateKey) (*enode.Node, *net.TCPAddr, error) {
+	ip, err := ExtractIP(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	portStr, err := addr.ValueForProtocol(ma.P_TCP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tcpAddr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(ip.String(), portStr))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := &enr.Record{}
+
+	if port > 0 && port <= math.MaxUint16 {
+		r.Set(enr.TCP(uint16(port))) // lgtm [go/incorrect-integer-conversion]
+	} else {
+		return nil, nil, fmt.Errorf("could not set port %d", port)
+	}
+
+	r.Set(enr.IP(ip))
+
+	// Websocket and relayed addresses can't be derived from the ip and tcp
+	// fields, so they're included in the record to allow peers to use them
+	if IsWebsocketAddress(addr) || IsCircuitAddress(addr) {
+		r.Set(MultiaddrsENREntry(addr))
+	}
+
+	err = enode.SignV4(r, privK)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	node, err := enode.New(enode.ValidSchemes, r)
+
+	return node, tcpAddr, err
+}
+
+// IsWebsocketAddress returns true for websocket and secure websocket addresses
+func IsWebsocketAddress(addr ma.Multiaddr) bool {
+	for _, p := range addr.Protocols() {
+		if p.Code == ma.P_WS || p.Code == ma.P_WSS {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCircuitAddress returns true for addresses reached through a relay
+func IsCircuitAddress(addr ma.Multiaddr) bool {
+	for _, p := range addr.Protocols() {
+		if p.Code == ma.P_CIRCUIT {
+			return true
+		}
+	}
+	return false
+}
+
+// MultiaddrsENREntry returns the ENR entry with the multiaddresses of a node
+func MultiaddrsENREntry(addrs ...ma.Multiaddr) enr.Entry {
+	return enr.WithEntry(MultiaddrENRField, encodeMultiaddrs(addrs...))
+}
+
+// encodeMultiaddrs encodes multiaddresses as described by RFC31: each one
+// is prefixed by its length as a 2 bytes big endian integer. The trailing /p2p
+// component is removed since the peer ID is already part of the record
+func encodeMultiaddrs(addrs ...ma.Multiaddr) []byte {
+	var result []byte
+	for _, addr := range addrs {
+		if rest, last := ma.SplitLast(addr); last != nil && last.Protocol().Code == ma.P_P2P {
+			addr = rest
+		}
+		b := addr.Bytes()
+		result = append(result, byte(len(b)>>8), byte(len(b)))
+		result = append(result, b...)
+	}
+	return result
+}
+
+func decodeMultiaddrs(b []byte) ([]ma.Multiaddr, error) {
+	var result []ma.Multiaddr
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, errors.New("truncated multiaddress length")
+		}
+
+		size := int(b[0])<<8 | int(b[1])
+		b = b[2:]
+		if len(b) < size {
+			return nil, errors.New("truncated multiaddress")
+		}
+
+		addr, err := ma.NewMultiaddrBytes(b[:size])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, addr)
+		b = b[size:]
+	}
+	return result, nil
+}
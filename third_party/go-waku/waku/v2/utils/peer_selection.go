@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// PeerSelection is the strategy used to choose among the peers that support a protocol
+type PeerSelection int
+
+const (
+	// RandomPeerSelection chooses any peer
+	RandomPeerSelection PeerSelection = iota
+	// LowestLatencyPeerSelection chooses the peer with the lowest latency
+	// recorded in the peerstore
+	LowestLatencyPeerSelection
+	// RoundRobinPeerSelection rotates through the peers, so repeated requests
+	// are spread across them
+	RoundRobinPeerSelection
+)
+
+func (s PeerSelection) String() string {
+	switch s {
+	case RandomPeerSelection:
+		return "random"
+	case LowestLatencyPeerSelection:
+		return "lowest_latency"
+	case RoundRobinPeerSelection:
+		return "round_robin"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultPeerFailureCooldown is the time during which a peer is avoided after
+// a failed request
+const DefaultPeerFailureCooldown = 30 * time.Second
+
+// SelectPeerWithLowestLatency returns the peer supporting a protocol with the
+// lowest latency recorded in the peerstore, usually measured by pings. Peers
+// with an unknown latency are only returned if no latency is known at all
+func SelectPeerWithLowestLatency(host host.Host, protocolId string) (*peer.ID, error) {
+	peers, err := supportingPeers(host, protocolId)
+	if err != nil {
+		return nil, err
+	}
+
+	return lowestLatencyPeer(host, peers)
+}
+
+func lowestLatencyPeer(host host.Host, peers peer.IDSlice) (*peer.ID, error) {
+	if len(peers) == 0 {
+		return nil, ErrNoPeersAvailable
+	}
+
+	var result *peer.ID
+	var min time.Duration
+	for i, p := range peers {
+		latency := host.Peerstore().LatencyEWMA(p)
+		if latency != 0 && (result == nil || latency < min) {
+			result = &peers[i]
+			min = latency
+		}
+	}
+
+	if result == nil {
+		return randomPeer(peers)
+	}
+	return result, nil
+}
+
+func randomPeer(peers peer.IDSlice) (*peer.ID, error) {
+	if len(peers) == 0 {
+		return nil, ErrNoPeersAvailable
+	}
+	return &peers[rand.Intn(len(peers))], nil // nolint: gosec
+}
+
+// PeerRotation is the state of the round robin selection of the peers of each protocol
+type PeerRotation struct {
+	sync.Mutex
+	last map[string]peer.ID
+}
+
+// NewPeerRotation creates the state of a round robin selection
+func NewPeerRotation() *PeerRotation {
+	return &PeerRotation{last: make(map[string]peer.ID)}
+}
+
+// SelectPeersRoundRobin returns the peers supporting a protocol one after the
+// other, in the order of their IDs. Peers added meanwhile join the rotation
+func SelectPeersRoundRobin(host host.Host, protocolId string, rotation *PeerRotation) (*peer.ID, error) {
+	peers, err := supportingPeers(host, protocolId)
+	if err != nil {
+		return nil, err
+	}
+
+	return rotation.next(protocolId, peers)
+}
+
+func (r *PeerRotation) next(protocolId string, peers peer.IDSlice) (*peer.ID, error) {
+	if len(peers) == 0 {
+		return nil, ErrNoPeersAvailable
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	sort.Sort(peers)
+
+	// The first peer after the last one selected, which may be gone
+	last := r.last[protocolId]
+	result := peers[0]
+	for _, p := range peers {
+		if p > last {
+			result = p
+			break
+		}
+	}
+
+	r.last[protocolId] = result
+	return &result, nil
+}
+
+// PeerSelector selects peers with one of the strategies. The peers a request
+// recently failed with are avoided during a cooldown, unless there are no
+// other peers. A nil PeerSelector selects random peers
+type PeerSelector struct {
+	strategy PeerSelection
+	cooldown time.Duration
+	rotation *PeerRotation
+
+	sync.Mutex
+	failures map[peer.ID]time.Time
+}
+
+// NewPeerSelector creates a PeerSelector that avoids the peers a request
+// failed with during the cooldown
+func NewPeerSelector(strategy PeerSelection, cooldown time.Duration) *PeerSelector {
+	return &PeerSelector{
+		strategy: strategy,
+		cooldown: cooldown,
+		rotation: NewPeerRotation(),
+		failures: make(map[peer.ID]time.Time),
+	}
+}
+
+// Strategy returns the strategy used to select the peers
+func (s *PeerSelector) Strategy() PeerSelection {
+	if s == nil {
+		return RandomPeerSelection
+	}
+	return s.strategy
+}
+
+// SelectPeer returns a peer supporting a protocol
+func (s *PeerSelector) SelectPeer(host host.Host, protocolId string) (*peer.ID, error) {
+	if s == nil {
+		return SelectPeer(host, protocolId)
+	}
+
+	peers, err := supportingPeers(host, protocolId)
+	if err != nil {
+		return nil, err
+	}
+
+	if available := s.available(peers); len(available) > 0 {
+		peers = available
+	}
+
+	switch s.strategy {
+	case LowestLatencyPeerSelection:
+		return lowestLatencyPeer(host, peers)
+	case RoundRobinPeerSelection:
+		return s.rotation.next(protocolId, peers)
+	default:
+		return randomPeer(peers)
+	}
+}
+
+// available returns the peers which are not cooling down after a failure
+func (s *PeerSelector) available(peers peer.IDSlice) peer.IDSlice {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	var result peer.IDSlice
+	for _, p := range peers {
+		if failedAt, ok := s.failures[p]; ok {
+			if now.Sub(failedAt) < s.cooldown {
+				continue
+			}
+			delete(s.failures, p)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// RecordFailure avoids selecting a peer during the cooldown
+func (s *PeerSelector) RecordFailure(id peer.ID) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.failures[id] = time.Now()
+}
+
+// RecordSuccess ends the cooldown of a peer
+func (s *PeerSelector) RecordSuccess(id peer.ID) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	delete(s.failures, id)
+}
@@ -915,10 +915,15 @@ func (w *Waku) Start() error {
 // Stop implements node.Service, stopping the background data propagation thread
 // of the Waku protocol.
 func (w *Waku) Stop() error {
-	w.node.Stop()
+	err := w.node.Stop()
+	// Close releases the host, so its listeners, discv5 socket and
+	// peerstore are not leaked
+	if closeErr := w.node.Close(); err == nil {
+		err = closeErr
+	}
 	close(w.quit)
 	close(w.filterMsgChannel)
-	return nil
+	return err
 }
 
 func (w *Waku) OnNewEnvelopes(envelope *wakuprotocol.Envelope, msgType common.MessageType) ([]common.EnvelopeError, error) {
@@ -1068,30 +1073,35 @@ func (w *Waku) StopDiscV5() error {
 	return nil
 }
 
-func (w *Waku) AddStorePeer(address string) (string, error) {
-	addr, err := multiaddr.NewMultiaddr(address)
+// addPeer adds a peer to the waku node peerstore and returns its peer ID
+func (w *Waku) addPeer(addr multiaddr.Multiaddr, protocol libp2pproto.ID) (string, error) {
+	info, err := peer.AddrInfoFromP2pAddr(addr)
 	if err != nil {
 		return "", err
 	}
 
-	peerID, err := w.node.AddPeer(addr, store.StoreID_v20beta3)
-	if err != nil {
+	if _, err := w.node.AddPeer(addr, protocol); err != nil {
 		return "", err
 	}
-	return string(*peerID), nil
+	return string(info.ID), nil
 }
 
-func (w *Waku) AddRelayPeer(address string) (string, error) {
+func (w *Waku) AddStorePeer(address string) (string, error) {
 	addr, err := multiaddr.NewMultiaddr(address)
 	if err != nil {
 		return "", err
 	}
 
-	peerID, err := w.node.AddPeer(addr, relay.WakuRelayID_v200)
+	return w.addPeer(addr, store.StoreID_v20beta3)
+}
+
+func (w *Waku) AddRelayPeer(address string) (string, error) {
+	addr, err := multiaddr.NewMultiaddr(address)
 	if err != nil {
 		return "", err
 	}
-	return string(*peerID), nil
+
+	return w.addPeer(addr, relay.WakuRelayID_v200)
 }
 
 func (w *Waku) DialPeer(address string) error {
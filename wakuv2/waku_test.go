@@ -0,0 +1,53 @@
+// Copyright 2019 The Waku Library Authors.
+//
+// The Waku library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Waku library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty off
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Waku library. If not, see <http://www.gnu.org/licenses/>.
+//
+// This software uses the go-ethereum library, which is licensed
+// under the GNU Lesser General Public Library, version 3 or any later.
+
+package wakuv2
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	return port
+}
+
+func TestRestartOnSamePort(t *testing.T) {
+	cfg := &Config{Host: "127.0.0.1", Port: freePort(t)}
+	addr := fmt.Sprint(cfg.Host, ":", cfg.Port)
+
+	for i := 0; i < 2; i++ {
+		w, err := New("", cfg, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, w.Start())
+		require.NoError(t, w.Stop())
+
+		// libp2p binds with SO_REUSEPORT, so only a listener without it tells
+		// whether Stop closed the host
+		l, err := net.Listen("tcp", addr)
+		require.NoError(t, err)
+		require.NoError(t, l.Close())
+	}
+}